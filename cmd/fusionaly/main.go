@@ -2,67 +2,505 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/term"
 
 	"fusionaly-installer/internal/admin"
+	"fusionaly-installer/internal/audit"
+	"fusionaly-installer/internal/bundle"
 	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/crash"
+	"fusionaly-installer/internal/cron"
+	"fusionaly-installer/internal/database"
+	"fusionaly-installer/internal/diagnostics"
+	"fusionaly-installer/internal/docker"
 	"fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/exitcode"
 	"fusionaly-installer/internal/installer"
+	"fusionaly-installer/internal/instance"
+	"fusionaly-installer/internal/lock"
 	"fusionaly-installer/internal/logging"
+	"fusionaly-installer/internal/mail"
+	"fusionaly-installer/internal/offsite"
+	"fusionaly-installer/internal/signals"
+	"fusionaly-installer/internal/systemd"
 	"fusionaly-installer/internal/updater"
 	"fusionaly-installer/internal/validation"
 )
 
 var currentInstallerVersion string = "dev"
 
+// mutatingCommands change containers, configuration or data and must not run
+// concurrently with each other.
+var mutatingCommands = map[string]bool{
+	"install":               true,
+	"update":                true,
+	"self-update":           true,
+	"tls":                   true,
+	"rollback":              true,
+	"reload":                true,
+	"backup":                true,
+	"restore":               true,
+	"restore-db":            true,
+	"schedule-backups":      true,
+	"uninstall":             true,
+	"start":                 true,
+	"stop":                  true,
+	"restart":               true,
+	"migrate":               true,
+	"create-admin-user":     true,
+	"import-admin-users":    true,
+	"change-admin-password": true,
+	"delete-admin-user":     true,
+	"reset-admin-token":     true,
+	"reset-admin-password":  true,
+	"update-license-key":    true,
+	"rotate-secret":         true,
+	"set-domain":            true,
+	"set-ports":             true,
+	"export-bundle":         true,
+	"import-bundle":         true,
+	"prune":                 true,
+	"regenerate-proxy":      true,
+	"maintenance":           true,
+	"reset-db":              true,
+	"import-data":           true,
+	"apply-config":          true,
+	"fnctl":                 true,
+}
+
+// instanceCommands accept --instance; the rest only manage the default
+// installation.
+var instanceCommands = map[string]bool{
+	"install":               true,
+	"start":                 true,
+	"stop":                  true,
+	"restart":               true,
+	"status":                true,
+	"logs":                  true,
+	"search-logs":           true,
+	"db-shell":              true,
+	"uninstall":             true,
+	"installed-version":     true,
+	"migrate":               true,
+	"create-admin-user":     true,
+	"import-admin-users":    true,
+	"change-admin-password": true,
+	"list-admin-users":      true,
+	"export-admin-users":    true,
+	"delete-admin-user":     true,
+	"reset-admin-token":     true,
+	"reset-admin-password":  true,
+	"rotate-secret":         true,
+	"set-domain":            true,
+	"set-ports":             true,
+	"prune":                 true,
+	"regenerate-proxy":      true,
+	"maintenance":           true,
+	"disk-usage":            true,
+	"verify-public":         true,
+	"reset-db":              true,
+	"show-config":           true,
+	"import-data":           true,
+	"fnctl":                 true,
+}
+
+// auditRun is the audited run of the current command, nil until it starts
+// or when the command is not audited.
+var auditRun *audit.Run
+
+// rootCtx bounds the whole command: it ends on SIGINT/SIGTERM or once the
+// --timeout limit passes. Commands pass it to every call that takes a
+// context; host commands are bound to it through the default executor.
+var rootCtx = context.Background()
+
+// exit ends the installer with code, recording the outcome of the running
+// command in the audit trail first. A failure after the --timeout limit
+// passed exits with exitcode.Timeout whatever error it surfaced as.
+func exit(code int) {
+	if code != exitcode.OK && signals.TimedOut(rootCtx) {
+		fmt.Printf("Error: %v\n", context.Cause(rootCtx))
+		code = exitcode.Timeout
+	}
+	auditRun.Finish(code)
+	os.Exit(code)
+}
+
+// selected is the installation chosen with --instance.
+var selected = instance.Default()
+
+// secrets hides secret command arguments from whatever records commands,
+// nil when nothing does.
+var secrets executor.SecretRegistry
+
 func main() {
 	// Detect the current working directory
 	workingDirectory, err := os.Getwd()
 	if err != nil {
 		fmt.Printf("Error: Failed to determine working directory: %v\n", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
+	}
+
+	useSudo := removeFlag("--sudo")
+	verbose := removeFlag("--verbose")
+	quiet := removeFlag("--quiet")
+	dryRun := removeFlag("--dry-run")
+	instanceName, hasInstance := mustRemoveFlagValue("--instance")
+	scriptPath, recordScript := mustRemoveFlagValue("--record-script")
+	// stop has its own --timeout, the grace period before killing.
+	var timeout time.Duration
+	if len(os.Args) < 2 || os.Args[1] != "stop" {
+		value, ok := mustRemoveFlagValue("--timeout")
+		if ok {
+			if timeout, err = time.ParseDuration(value); err != nil || timeout < 0 {
+				err := usageErrorf("--timeout must be a duration such as 30s or 10m, got %q", value)
+				fmt.Printf("Error: %v\n", err)
+				exit(exitcode.ExitCode(err))
+			}
+		}
+	}
+	if dryRun {
+		// Set before selecting the instance, so registering a new one is
+		// only reported too.
+		dry := executor.NewDryRunExecutor(os.Stdout)
+		executor.SetDefault(dry)
+		secrets = dry
+	}
+	if hasInstance {
+		if len(os.Args) < 2 || !instanceCommands[os.Args[1]] {
+			err := usageErrorf("--instance is not supported by this command")
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+		var err error
+		if selected, err = selectInstance(instanceName, os.Args[1] == "install"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	}
+	if !dryRun {
+		execConfig := executor.Config{UseSudo: useSudo}
+		// Run host commands from the install directory once it exists, so
+		// they don't depend on where the binary was invoked.
+		if info, err := os.Stat(selected.DataDir()); err == nil && info.IsDir() {
+			execConfig.WorkDir = selected.DataDir()
+		}
+		executor.SetDefault(executor.NewCommandExecutorWithConfig(execConfig))
+	}
+	if recordScript {
+		// The recorder passes secrets on to a dry run it wraps.
+		recorder := executor.NewScriptRecordingExecutor(executor.Default(), scriptPath)
+		executor.SetDefault(recorder)
+		secrets = recorder
+	}
+	if secrets != nil {
+		// Secrets from the .env file are shown as variables, not values.
+		if env, err := config.LoadEnvFile(selected.Paths().EnvFile); err == nil {
+			for _, key := range env.Keys() {
+				if value, _ := env.Get(key); executor.IsSecretVar(key) {
+					secrets.AddSecret(key, value)
+				}
+			}
+		}
 	}
 
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
+		exit(exitcode.Invalid)
 	}
 
 	// Initialize logging
 	startTime := time.Now()
-	logger := initLogging()
+	logger := initLogging(verbose, quiet)
 	logger.Debug("Installer version: %s", currentInstallerVersion)
 	logger.Debug("Working directory: %s", workingDirectory)
 
+	// A panic is turned into a crash report in the install directory; the
+	// recent host commands it lists are recorded by the executor below.
+	history := executor.NewHistory(50)
+	defer (&crash.Reporter{
+		Logger:  logger,
+		Dir:     selected.DataDir(),
+		Version: currentInstallerVersion,
+		Command: os.Args[1],
+		EnvFile: selected.Paths().EnvFile,
+		History: history,
+		Exit:    exit,
+	}).Recover()
+
+	// SIGINT/SIGTERM cancels whatever command is running through the default
+	// executor, so components built below stop at the next step.
+	sigCtx, stopSignals := signals.NotifyContext(context.Background(), func(sig os.Signal) {
+		if mutatingCommands[os.Args[1]] {
+			logger.Warn("Interrupted by %s; the installation may be left partially configured. Re-run 'fusionaly %s' to finish it.", sig, os.Args[1])
+		}
+	})
+	defer stopSignals()
+	// --timeout bounds everything run from here; 0 leaves it unbounded.
+	var cancelTimeout context.CancelFunc
+	rootCtx, cancelTimeout = signals.WithTimeout(sigCtx, timeout)
+	defer cancelTimeout()
+	// At debug level every host command is traced with its duration and exit code.
+	if logger.IsLevelEnabled(logrus.DebugLevel) {
+		executor.SetDefault(executor.WithTracing(executor.Default(), logger))
+	}
+	executor.SetDefault(executor.WithHistory(executor.Default(), history))
+	executor.SetDefault(executor.WithBaseContext(executor.Default(), rootCtx))
+
 	inst := installer.NewInstaller(logger)
+	if !selected.IsDefault() {
+		inst.SetInstance(selected)
+	}
+	inst.SetSecretRegistry(secrets)
 
 	// Update environment variables with current version
 	os.Setenv("FUSIONALY_VERSION", currentInstallerVersion)
 
+	// Commands that change the installation are recorded in its audit
+	// trail, and hold the lock for their whole run; a dry run changes
+	// nothing and skips both.
+	if !dryRun {
+		auditRun = audit.New(filepath.Join(selected.DataDir(), audit.FileName), mutatingCommands).Begin(os.Args[1], selected.Name)
+	}
+	if mutatingCommands[os.Args[1]] && !dryRun {
+		release, err := lock.AcquireLock(selected.DataDir())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+		defer release()
+	}
+
 	switch os.Args[1] {
 	case "install":
 		runInstall(inst, logger, startTime)
 	case "update":
 		runUpdate(inst, logger, startTime)
+	case "self-update":
+		if err := updater.NewSelfUpdater(logger, currentInstallerVersion).SelfUpdate(rootCtx); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "status":
+		if err := runStatus(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "logs":
+		if err := runLogs(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "validate-config":
+		if err := runValidateConfig(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "show-config":
+		if err := runShowConfig(verbose, quiet); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "doctor":
+		if err := runDoctor(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "tls":
+		if err := runTLS(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "set-domain":
+		if err := runSetDomain(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "set-ports":
+		if err := runSetPorts(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "apply-config":
+		if err := runApplyConfig(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "test-email":
+		if err := runTestEmail(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "rollback":
+		runRollback(logger, startTime)
 	case "reload":
 		runReload(logger, startTime)
+	case "backup":
+		if err := runBackup(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "restore":
+		if err := runRestore(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "verify-backup":
+		if err := runVerifyBackup(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "export-bundle", "import-bundle":
+		if err := runBundle(logger, os.Args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "schedule-backups":
+		if err := runScheduleBackups(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "uninstall":
+		if err := runUninstall(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "db-shell":
+		if err := runDBShell(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "search-logs":
+		if err := runSearchLogs(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "prune":
+		if err := runPrune(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "regenerate-proxy":
+		if err := newStack(logger).RegenerateProxyConfig(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "maintenance":
+		if err := runMaintenance(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "disk-usage":
+		usage, err := newStack(logger).DiskUsage(rootCtx)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+		fmt.Print(usage)
+	case "verify-public":
+		if err := runVerifyPublic(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
 	case "restore-db":
 		runRestoreDB(inst, logger, startTime)
+	case "start", "stop", "restart":
+		if err := runStack(logger, os.Args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "reset-db":
+		if err := runResetDB(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "import-data":
+		if err := runImportData(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "migrate":
+		if err := admin.NewManager(logger, adminConfig()).Migrate(rootCtx); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "fnctl":
+		out, err := admin.NewManager(logger, adminConfig()).RunFnctl(rootCtx, os.Args[2:]...)
+		fmt.Print(out)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "create-admin-user":
+		if err := runCreateAdminUser(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "import-admin-users":
+		if err := runImportAdminUsers(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
 	case "change-admin-password":
 		if err := runAdminPasswordChange(logger); err != nil {
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(exitcode.ExitCode(err))
+		}
+	case "list-admin-users":
+		if err := runListAdminUsers(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "export-admin-users":
+		if err := runExportAdminUsers(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "delete-admin-user":
+		if err := runDeleteAdminUser(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "reset-admin-token":
+		if err := runResetAdminToken(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "reset-admin-password":
+		if err := runResetAdminPassword(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
 		}
 	case "update-license-key":
 		if err := runUpdateLicenseKey(logger, startTime); err != nil {
 			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+			exit(exitcode.ExitCode(err))
+		}
+	case "rotate-secret":
+		if err := runRotateSecret(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "check-update":
+		if err := runCheckUpdate(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
+		}
+	case "installed-version":
+		if err := runInstalledVersion(logger); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(exitcode.ExitCode(err))
 		}
 	case "version", "--version", "-v":
 		printVersion()
@@ -71,29 +509,22 @@ func main() {
 	default:
 		fmt.Printf("Unknown command: %s\n", os.Args[1])
 		printUsage()
-		os.Exit(1)
+		exit(exitcode.Invalid)
 	}
-}
 
-func initLogging() *logging.Logger {
-	logLevel := "info"
-	if envLevel := os.Getenv("LOG_LEVEL"); envLevel != "" {
-		logLevel = envLevel
-	}
-
-	verbose := false
-	if os.Getenv("VERBOSE") == "true" {
-		verbose = true
+	// Failures exit through exit(), which records them; reaching here is success.
+	if err := auditRun.Finish(exitcode.OK); err != nil {
+		logger.Warn("%v", err)
 	}
+}
 
-	quiet := false
-	if os.Getenv("QUIET") == "true" {
-		quiet = true
-	}
+func initLogging(verboseFlag, quietFlag bool) *logging.Logger {
+	verbose := verboseFlag || os.Getenv("VERBOSE") == "true"
+	quiet := (quietFlag || os.Getenv("QUIET") == "true") && !verbose
 
 	// Configure the main logger to log to stdout
 	logger := logging.NewLogger(logging.Config{
-		Level:   logLevel,
+		Level:   logging.ResolveLevel(os.Getenv("LOG_LEVEL"), verbose, quiet),
 		Verbose: verbose,
 		Quiet:   quiet,
 	})
@@ -104,10 +535,51 @@ func initLogging() *logging.Logger {
 func runInstall(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
 	logger.Debug("Initializing installation environment")
 
+	var opts installer.InstallOptions
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] == "--config" && i+1 < len(os.Args) {
+			fromFile, warnings, err := installer.InstallOptionsFromFile(os.Args[i+1])
+			for _, w := range warnings {
+				logger.Warn("%s: %s", os.Args[i+1], w)
+			}
+			if err != nil {
+				logger.Error("%v", err)
+				if code := exitcode.ExitCode(err); code != exitcode.Generic {
+					exit(code)
+				}
+				exit(exitcode.Invalid)
+			}
+			fromFile.SkipFirewall = opts.SkipFirewall
+			fromFile.SkipNetwork = opts.SkipNetwork
+			fromFile.StrictClock = opts.StrictClock
+			fromFile.ImageTarball = opts.ImageTarball
+			fromFile.Metrics = opts.Metrics
+			if opts.Version != "" {
+				fromFile.Version = opts.Version
+			}
+			opts = fromFile
+			i++
+		} else if os.Args[i] == "--version" && i+1 < len(os.Args) {
+			opts.Version = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--skip-firewall" {
+			opts.SkipFirewall = true
+		} else if os.Args[i] == "--skip-network-check" {
+			opts.SkipNetwork = true
+		} else if os.Args[i] == "--strict-clock" {
+			opts.StrictClock = true
+		} else if os.Args[i] == "--image-tarball" && i+1 < len(os.Args) {
+			opts.ImageTarball = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--metrics" {
+			opts.Metrics = installer.StdoutMetrics{}
+		}
+	}
+
 	// Run the complete installation process
-	if err := inst.RunCompleteInstallation(); err != nil {
+	if err := inst.RunCompleteInstallationContext(rootCtx, opts); err != nil {
 		logger.Error("Installation failed: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	// Calculate and display completion time
@@ -124,17 +596,44 @@ func runUpdate(inst *installer.Installer, logger *logging.Logger, startTime time
 	logger.Debug("Initializing update environment")
 
 	updater := updater.NewUpdater(logger)
-	logger.Info("Running update...")
-	err := updater.Run(currentInstallerVersion)
+
+	var targetVersion string
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] == "--version" && i+1 < len(os.Args) {
+			targetVersion = os.Args[i+1]
+			i++
+		}
+	}
+
+	var err error
+	if targetVersion != "" {
+		logger.Info("Updating to version %s...", targetVersion)
+		err = updater.Update(rootCtx, targetVersion)
+	} else {
+		logger.Info("Running update...")
+		err = updater.Run(rootCtx, currentInstallerVersion)
+	}
 	if err != nil {
 		logger.Error("Update failed: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	elapsedTime := time.Since(startTime).Round(time.Second)
 	logger.Success("Update completed in %s", elapsedTime)
 }
 
+func runRollback(logger *logging.Logger, startTime time.Time) {
+	u := updater.NewUpdater(logger)
+	logger.Info("Rolling back to the previous version...")
+	if err := u.Rollback(rootCtx); err != nil {
+		logger.Error("Rollback failed: %v", err)
+		exit(exitcode.ExitCode(err))
+	}
+
+	elapsedTime := time.Since(startTime).Round(time.Second)
+	logger.Success("Rollback completed in %s", elapsedTime)
+}
+
 func runRestoreDB(inst *installer.Installer, logger *logging.Logger, startTime time.Time) {
 	logger.Info("Starting database restore...")
 
@@ -145,25 +644,25 @@ func runRestoreDB(inst *installer.Installer, logger *logging.Logger, startTime t
 	backups, err := inst.ListBackups()
 	if err != nil {
 		logger.Error("Failed to list backups: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	if len(backups) == 0 {
 		logger.Error("No backups found in %s", backupDir)
-		os.Exit(1)
+		exit(exitcode.NotFound)
 	}
 
 	// Let user select a backup
 	selectedBackup, err := inst.PromptBackupSelection(backups)
 	if err != nil {
 		logger.Error("Backup selection failed: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	// Validate the selected backup
 	if err := inst.ValidateBackup(selectedBackup); err != nil {
 		logger.Error("Backup validation failed: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	// Confirmation prompt
@@ -176,20 +675,20 @@ func runRestoreDB(inst *installer.Installer, logger *logging.Logger, startTime t
 	confirmation, err := reader.ReadString('\n')
 	if err != nil {
 		logger.Error("Failed to read confirmation: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
 	if confirmation != "yes" && confirmation != "y" {
 		logger.Info("Restore cancelled by user")
-		os.Exit(0)
+		exit(0)
 	}
 
 	// Perform the restore
 	err = inst.RestoreFromBackup(selectedBackup)
 	if err != nil {
 		logger.Error("Restore failed: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	elapsedTime := time.Since(startTime).Round(time.Second)
@@ -206,16 +705,629 @@ func runReload(logger *logging.Logger, startTime time.Time) {
 	err := reloader.Run()
 	if err != nil {
 		logger.Error("Reload failed: %v", err)
-		os.Exit(1)
+		exit(exitcode.ExitCode(err))
 	}
 
 	elapsedTime := time.Since(startTime).Round(time.Second)
 	logger.Success("Reload completed in %s", elapsedTime)
 }
 
+func runBackup(logger *logging.Logger) error {
+	var destDir string
+	if len(os.Args) >= 3 {
+		destDir = os.Args[2]
+	} else {
+		cfg := config.NewConfig(logger)
+		if err := cfg.LoadFromFile(selected.Paths().EnvFile); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		destDir = cfg.GetData().BackupPath
+	}
+
+	env, err := config.LoadEnvFile(selected.Paths().EnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	ctx := rootCtx
+	db := database.NewDatabase(logger)
+	path, err := db.Backup(ctx, destDir)
+	if err != nil {
+		return err
+	}
+
+	if passphrase, _ := env.Get("BACKUP_PASSPHRASE"); passphrase != "" {
+		encrypted := path + database.EncryptedSuffix
+		if err := database.EncryptBackup(path, encrypted, passphrase); err != nil {
+			return fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		if err := os.Remove(path); err != nil {
+			logger.Warn("Failed to remove unencrypted backup %s: %v", path, err)
+		}
+		path = encrypted
+	}
+	fmt.Println(path)
+
+	return uploadBackup(ctx, logger, env, path)
+}
+
+// uploadBackup copies a backup to the S3 bucket configured in the .env file,
+// if any.
+func uploadBackup(ctx context.Context, logger *logging.Logger, env *config.EnvFile, path string) error {
+	s3cfg := offsite.S3ConfigFromEnv(func(key string) string {
+		value, _ := env.Get(key)
+		return value
+	})
+	if !s3cfg.Enabled() {
+		return nil
+	}
+
+	uploader, err := offsite.NewS3Uploader(s3cfg, nil)
+	if err != nil {
+		return err
+	}
+	key, err := offsite.NewStore(uploader, s3cfg.Prefix).UploadBackup(ctx, path)
+	if err != nil {
+		return err
+	}
+	logger.Success("Backup uploaded to s3://%s/%s", s3cfg.Bucket, key)
+	return nil
+}
+
+func runScheduleBackups(logger *logging.Logger) error {
+	manager := cron.NewManager(logger)
+	expr := cron.DefaultBackupSchedule
+	if len(os.Args) >= 3 {
+		expr = strings.Join(os.Args[2:], " ")
+	}
+	if expr == "off" {
+		return manager.RemoveBackupSchedule()
+	}
+	return manager.InstallBackupSchedule(expr)
+}
+
+func runUninstall(logger *logging.Logger) error {
+	opts := docker.UninstallOptions{InstallDir: selected.DataDir()}
+	for _, arg := range os.Args[2:] {
+		if arg == "--remove-data" {
+			opts.RemoveData = true
+		}
+	}
+	if env, err := config.LoadEnvFile(selected.Paths().EnvFile); err == nil {
+		if dir, ok := env.Get("INSTALL_DIR"); ok && dir != "" {
+			opts.InstallDir = dir
+		}
+	}
+
+	if opts.RemoveData {
+		fmt.Printf("⚠️  This will permanently delete %s, including the database and backups stored there.\n", opts.InstallDir)
+	} else {
+		fmt.Printf("This will remove the Fusionaly containers. Data in %s will be kept.\n", opts.InstallDir)
+	}
+	fmt.Print("Are you sure you want to continue? (yes/no): ")
+	confirmation, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "yes" && confirmation != "y" {
+		logger.Info("Uninstall cancelled by user")
+		return nil
+	}
+
+	ctx := rootCtx
+	// Every instance has its own boot unit; the cron jobs belong to the
+	// default installation.
+	units := systemd.NewManager(logger, executor.Default())
+	units.SetInstance(selected.Name)
+	if err := units.RemoveSystemdUnit(ctx); err != nil {
+		logger.Warn("%v", err)
+	}
+	if selected.IsDefault() {
+		cronManager := cron.NewManager(logger)
+		for _, remove := range []func() error{cronManager.RemoveCronJob, cronManager.RemoveBackupSchedule} {
+			if err := remove(); err != nil {
+				logger.Warn("%v", err)
+			}
+		}
+	}
+	return newStack(logger).Uninstall(ctx, opts)
+}
+
+func runMaintenance(logger *logging.Logger) error {
+	if len(os.Args) < 3 || (os.Args[2] != "on" && os.Args[2] != "off") {
+		return usageErrorf("usage: fusionaly maintenance on|off")
+	}
+	return newStack(logger).SetMaintenanceMode(rootCtx, os.Args[2] == "on")
+}
+
+func runVerifyPublic(logger *logging.Logger) error {
+	domain := ""
+	if len(os.Args) >= 3 {
+		domain = os.Args[2]
+	} else if env, err := config.LoadEnvFile(selected.Paths().EnvFile); err == nil {
+		domain, _ = env.Get("FUSIONALY_DOMAIN")
+	}
+	if domain == "" {
+		return usageErrorf("usage: fusionaly verify-public [domain]; FUSIONALY_DOMAIN is not set in %s", selected.Paths().EnvFile)
+	}
+
+	logger.Info("Checking https://%s from this host...", domain)
+	if err := diagnostics.VerifyPublicAccess(rootCtx, domain); err != nil {
+		return err
+	}
+	logger.Success("https://%s is reachable with a valid certificate", domain)
+	return nil
+}
+
+func runPrune(logger *logging.Logger) error {
+	var opts docker.PruneOptions
+	for _, arg := range os.Args[2:] {
+		if arg == "--volumes" {
+			opts.Volumes = true
+		} else {
+			return usageErrorf("unknown prune option %q", arg)
+		}
+	}
+	_, err := newStack(logger).Prune(rootCtx, opts)
+	return err
+}
+
+func runRestore(logger *logging.Logger) error {
+	var backupPath string
+	force := false
+	for _, arg := range os.Args[2:] {
+		if arg == "--force" {
+			force = true
+		} else if backupPath == "" {
+			backupPath = arg
+		}
+	}
+
+	db := database.NewDatabase(logger)
+	db.SetPassphraseFunc(backupPassphrase)
+	if backupPath == "" {
+		// Without a file, pick one from the configured backup directory.
+		cfg := config.NewConfig(logger)
+		if err := cfg.LoadFromFile(selected.Paths().EnvFile); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		return db.RestoreInteractive(rootCtx, cfg.GetData().BackupPath, force)
+	}
+	return db.Restore(rootCtx, backupPath, force)
+}
+
+// backupPassphrase returns BACKUP_PASSPHRASE from .env, prompting for it
+// when it is not configured.
+func backupPassphrase() (string, error) {
+	env, err := config.LoadEnvFile(selected.Paths().EnvFile)
+	if err == nil {
+		if passphrase, _ := env.Get("BACKUP_PASSPHRASE"); passphrase != "" {
+			return passphrase, nil
+		}
+	}
+	fmt.Print("Backup passphrase: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	return string(passphrase), err
+}
+
+func runDBShell(logger *logging.Logger) error {
+	conf := config.NewConfig(logger)
+	data := conf.GetData()
+	data.InstallDir = selected.DataDir()
+	if env, err := config.LoadEnvFile(selected.Paths().EnvFile); err == nil {
+		if dir, ok := env.Get("INSTALL_DIR"); ok && dir != "" {
+			data.InstallDir = dir
+		}
+	}
+	conf.SetData(data)
+	return database.NewDatabase(logger).DBShell(rootCtx, conf.GetMainDBPath())
+}
+
+func runVerifyBackup(logger *logging.Logger) error {
+	var backupPath string
+	dryRestore := false
+	for _, arg := range os.Args[2:] {
+		if arg == "--dry-restore" {
+			dryRestore = true
+		} else if backupPath == "" {
+			backupPath = arg
+		}
+	}
+	if backupPath == "" {
+		return usageErrorf("usage: fusionaly verify-backup <backup-file> [--dry-restore]")
+	}
+
+	var opts database.VerifyBackupOptions
+	if dryRestore {
+		env, err := config.LoadEnvFile(selected.Paths().EnvFile)
+		if err != nil {
+			return fmt.Errorf("--dry-restore needs APP_IMAGE from .env: %w", err)
+		}
+		if opts.DryRestoreImage, _ = env.Get("APP_IMAGE"); opts.DryRestoreImage == "" {
+			return fmt.Errorf("--dry-restore needs APP_IMAGE in .env")
+		}
+	}
+
+	db := database.NewDatabase(logger)
+	db.SetPassphraseFunc(backupPassphrase)
+	return db.VerifyBackup(rootCtx, backupPath, opts)
+}
+
+func runBundle(logger *logging.Logger, command string) error {
+	var path string
+	encrypt, force := false, false
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--encrypt" && command == "export-bundle":
+			encrypt = true
+		case arg == "--force" && command == "import-bundle":
+			force = true
+		case path == "":
+			path = arg
+		}
+	}
+	if path == "" {
+		if command == "export-bundle" {
+			return usageErrorf("usage: fusionaly export-bundle <file> [--encrypt]")
+		}
+		return usageErrorf("usage: fusionaly import-bundle <file> [--force]")
+	}
+
+	stack := newStack(logger)
+	b := bundle.NewBundler(logger, selected.Paths(), database.NewDatabase(logger), stack.InstalledVersion)
+	if command == "export-bundle" {
+		if encrypt {
+			b.SetPassphraseFunc(backupPassphrase)
+		}
+		return b.ExportBundle(path)
+	}
+
+	b.SetPassphraseFunc(backupPassphrase)
+	if err := b.ImportBundle(path, force); err != nil {
+		return err
+	}
+	logger.Info("Run 'fusionaly reload' so the containers pick up the imported configuration")
+	return nil
+}
+
+func runStatus(logger *logging.Logger) error {
+	st, err := newStack(logger).Status(rootCtx)
+	if err != nil {
+		return err
+	}
+	if len(os.Args) >= 3 && os.Args[2] == "--json" {
+		return st.WriteJSON(os.Stdout)
+	}
+	return st.WriteTable(os.Stdout)
+}
+
+func runCheckUpdate(logger *logging.Logger) error {
+	info, err := updater.NewUpdateChecker(logger).CheckForUpdate(rootCtx)
+	if err != nil {
+		return err
+	}
+	if info.Available {
+		fmt.Printf("Update available: %s -> %s (run 'fusionaly update --version %s')\n", info.Current, info.Latest, info.Latest)
+	} else {
+		fmt.Printf("Fusionaly %s is up to date\n", info.Current)
+	}
+	return nil
+}
+
+func runInstalledVersion(logger *logging.Logger) error {
+	version, err := newStack(logger).InstalledVersion(rootCtx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(version)
+	return nil
+}
+
+func runValidateConfig() error {
+	path := selected.Paths().EnvFile
+	if len(os.Args) >= 3 {
+		path = os.Args[2]
+	}
+	problems, err := config.ValidateConfig(path)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Printf("✅ %s is valid\n", path)
+		return nil
+	}
+	fmt.Printf("Found %d problem(s) in %s:\n", len(problems), path)
+	for _, p := range problems {
+		fmt.Printf("  • %s\n", p)
+	}
+	return fmt.Errorf("%w: configuration is invalid", errors.ErrInvalidInput)
+}
+
+// runShowConfig prints the selected instance's effective configuration.
+// --verbose and --quiet count as flags setting LOG_LEVEL.
+func runShowConfig(verbose, quiet bool) error {
+	format := config.FormatText
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] == "--format" && i+1 < len(os.Args) {
+			format = os.Args[i+1]
+			i++
+		} else if os.Args[i] == "--json" {
+			format = config.FormatJSON
+		}
+	}
+
+	var flags map[string]string
+	if verbose || quiet {
+		flags = map[string]string{"LOG_LEVEL": logging.ResolveLevel("", verbose, quiet)}
+	}
+	effective, err := config.ResolveEffectiveConfig(selected.Paths().EnvFile, os.LookupEnv, flags)
+	if err != nil {
+		return err
+	}
+	return effective.PrintEffectiveConfig(os.Stdout, format)
+}
+
+func runTLS(logger *logging.Logger) error {
+	var positional []string
+	staging := false
+	for _, arg := range os.Args[2:] {
+		if arg == "--staging" {
+			staging = true
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	if len(positional) != 2 {
+		return usageErrorf("usage: fusionaly tls <domain> <email> [--staging]")
+	}
+
+	stack := docker.NewStack(logger, executor.Default())
+	stack.UseStagingCA(staging)
+	return stack.ConfigureTLS(positional[0], positional[1])
+}
+
+func runSetDomain(logger *logging.Logger) error {
+	if len(os.Args) != 3 {
+		return usageErrorf("usage: fusionaly set-domain <domain>")
+	}
+	return newStack(logger).SetDomain(rootCtx, os.Args[2])
+}
+
+func runSetPorts(logger *logging.Logger) error {
+	if len(os.Args) != 4 {
+		return usageErrorf("usage: fusionaly set-ports <http-port> <https-port>")
+	}
+	http, err := strconv.Atoi(os.Args[2])
+	if err != nil {
+		return usageErrorf("invalid HTTP port %q", os.Args[2])
+	}
+	https, err := strconv.Atoi(os.Args[3])
+	if err != nil {
+		return usageErrorf("invalid HTTPS port %q", os.Args[3])
+	}
+	return newStack(logger).SetPorts(rootCtx, http, https)
+}
+
+// runApplyConfig applies KEY=VALUE changes to the installer-managed .env
+// settings together, reverting them if the app is unhealthy afterwards.
+func runApplyConfig(logger *logging.Logger) error {
+	const usage = "usage: fusionaly apply-config KEY=VALUE..."
+	if len(os.Args) < 3 {
+		return usageErrorf(usage)
+	}
+	current, err := os.ReadFile(selected.Paths().EnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	var changes strings.Builder
+	for _, arg := range os.Args[2:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return usageErrorf(usage)
+		}
+		if !config.IsManagedKey(key) {
+			return usageErrorf("%s is not a setting apply-config manages; edit .env and run 'fusionaly reload' instead", key)
+		}
+		fmt.Fprintf(&changes, "%s=%s\n", key, value)
+	}
+
+	// Append the changes to a copy so the .env parser, where the last value
+	// of a key wins, turns them into the candidate configuration; the real
+	// file is only written by ApplyConfig. The copy is scratch, so it is
+	// written even during a dry run.
+	tmp, err := os.CreateTemp("", "fusionaly-apply-*.env")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if len(current) > 0 && current[len(current)-1] != '\n' {
+		current = append(current, '\n')
+	}
+	if _, err := tmp.Write(append(current, changes.String()...)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	candidate := config.NewConfig(logger)
+	if err := candidate.LoadFromFile(tmp.Name()); err != nil {
+		return err
+	}
+	return updater.NewUpdater(logger).ApplyConfig(rootCtx, candidate.GetData())
+}
+
+func runTestEmail() error {
+	if len(os.Args) < 3 {
+		return usageErrorf("usage: fusionaly test-email <to>")
+	}
+	env, err := config.LoadEnvFile(selected.Paths().EnvFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	mailer, err := mail.NewMailer(mail.SMTPConfigFromEnv(func(key string) string {
+		value, _ := env.Get(key)
+		return value
+	}))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(rootCtx, time.Minute)
+	defer cancel()
+	if err := mailer.SendTestEmail(ctx, os.Args[2]); err != nil {
+		return err
+	}
+	fmt.Printf("Test email sent to %s\n", os.Args[2])
+	return nil
+}
+
+func runLogs(logger *logging.Logger) error {
+	service := docker.ServiceApp
+	follow := false
+	tail := 0
+	for i := 2; i < len(os.Args); i++ {
+		switch arg := os.Args[i]; arg {
+		case "-f", "--follow":
+			follow = true
+		case "--tail":
+			if i+1 >= len(os.Args) {
+				return usageErrorf("--tail needs a number of lines")
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n < 0 {
+				return usageErrorf("invalid --tail value %q", os.Args[i+1])
+			}
+			tail = n
+			i++
+		default:
+			service = arg
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(rootCtx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return newStack(logger).Logs(ctx, service, follow, tail)
+}
+
+func runSearchLogs(logger *logging.Logger) error {
+	var pattern string
+	since := time.Hour
+	for i := 2; i < len(os.Args); i++ {
+		switch arg := os.Args[i]; arg {
+		case "--since":
+			if i+1 >= len(os.Args) {
+				return usageErrorf("--since needs a duration such as 30m")
+			}
+			d, err := time.ParseDuration(os.Args[i+1])
+			if err != nil {
+				return usageErrorf("invalid --since value %q", os.Args[i+1])
+			}
+			since = d
+			i++
+		default:
+			pattern = arg
+		}
+	}
+	if pattern == "" {
+		return usageErrorf("usage: fusionaly search-logs <pattern> [--since 1h]")
+	}
+
+	lines, err := newStack(logger).LogsSearch(rootCtx, pattern, since)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		fmt.Printf("[%s] %s\n", line.Service, line.Text)
+	}
+	return nil
+}
+
+func runDoctor() error {
+	d := diagnostics.New(executor.Default(), selected.DataDir(), currentInstallerVersion)
+	report, err := d.Doctor(rootCtx)
+	if err != nil {
+		return err
+	}
+
+	asJSON := len(os.Args) >= 3 && os.Args[2] == "--json"
+	if asJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if !report.Healthy() {
+		return fmt.Errorf("one or more checks failed")
+	}
+	return nil
+}
+
+func runStack(logger *logging.Logger, action string) error {
+	stack := newStack(logger)
+	ctx := rootCtx
+
+	switch action {
+	case "start":
+		if err := verifyImageDigest(ctx, logger, stack); err != nil {
+			return err
+		}
+		return stack.Up(ctx)
+	case "stop":
+		timeout := docker.DefaultStopTimeout
+		for i := 2; i < len(os.Args); i++ {
+			if os.Args[i] != "--timeout" || i+1 >= len(os.Args) {
+				return usageErrorf("usage: fusionaly stop [--timeout 30s]")
+			}
+			d, err := time.ParseDuration(os.Args[i+1])
+			if err != nil || d <= 0 {
+				return usageErrorf("invalid --timeout value %q", os.Args[i+1])
+			}
+			timeout = d
+			i++
+		}
+		return stack.Stop(ctx, timeout)
+	}
+
+	services := []string{docker.ServiceApp, docker.ServiceProxy}
+	if len(os.Args) >= 3 {
+		services = os.Args[2:]
+	}
+	for _, service := range services {
+		if err := stack.RestartContext(ctx, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyImageDigest checks the app container still runs the image recorded
+// at deploy time. Drift is a warning unless --strict-digest is given.
+func verifyImageDigest(ctx context.Context, logger *logging.Logger, stack *docker.Stack) error {
+	strict := false
+	for _, arg := range os.Args[2:] {
+		if arg != "--strict-digest" {
+			return usageErrorf("usage: fusionaly start [--strict-digest]")
+		}
+		strict = true
+	}
+	container, err := stack.AppContainer(ctx)
+	if err != nil {
+		logger.Debug("Skipping image digest verification: %v", err)
+		return nil
+	}
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	d.SetNames(selected.Names())
+	return updater.VerifyImageDigest(logger, selected.DataDir(), container, d, strict)
+}
+
 func runAdminPasswordChange(logger *logging.Logger) error {
 	startTime := time.Now()
-	adminMgr := admin.NewManager(logger)
+	adminMgr := admin.NewManager(logger, adminConfig())
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Print("Enter admin email: ")
@@ -232,7 +1344,7 @@ func runAdminPasswordChange(logger *logging.Logger) error {
 
 	var password string
 	for {
-		fmt.Print("Enter new admin password (minimum 8 characters): ")
+		fmt.Printf("Enter new admin password (minimum %d characters): ", adminMgr.PasswordPolicy().MinLength)
 		passBytes, err := term.ReadPassword(int(syscall.Stdin))
 		if err != nil {
 			logger.Error("Failed to read password: %v", err)
@@ -245,6 +1357,10 @@ func runAdminPasswordChange(logger *logging.Logger) error {
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
+		if err := adminMgr.PasswordPolicy().Check(password); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
 
 		fmt.Print("Confirm new admin password: ")
 		confirmBytes, err := term.ReadPassword(int(syscall.Stdin))
@@ -272,6 +1388,213 @@ func runAdminPasswordChange(logger *logging.Logger) error {
 	return nil
 }
 
+// usageError reports bad command-line arguments; it matches
+// errors.ErrInvalidInput so the CLI exits with exitcode.Invalid.
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func (e *usageError) Is(target error) bool { return target == errors.ErrInvalidInput }
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func runCreateAdminUser(logger *logging.Logger) error {
+	if len(os.Args) < 3 {
+		return usageErrorf("usage: fusionaly create-admin-user <email>")
+	}
+	email := strings.TrimSpace(os.Args[2])
+
+	adminMgr := admin.NewManager(logger, adminConfig())
+	exists, err := adminMgr.AdminExists(email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		logger.Info("Admin user %s already exists; nothing to do", email)
+		return nil
+	}
+	fmt.Printf("Password must be at least %d characters\n", adminMgr.PasswordPolicy().MinLength)
+	if err := adminMgr.CreateAdminUserInteractive(email, os.Stdin, os.Stdout); err != nil {
+		logger.Error("Failed to create admin user: %v", err)
+		return err
+	}
+	logger.Success("Admin user %s created", email)
+	return nil
+}
+
+func runImportAdminUsers(logger *logging.Logger) error {
+	if len(os.Args) < 3 {
+		return usageErrorf("usage: fusionaly import-admin-users <file.csv|file.json>")
+	}
+	created, errs := admin.NewManager(logger, adminConfig()).CreateAdminUsersFromFile(os.Args[2])
+	for _, email := range created {
+		logger.Success("Admin user %s created", email)
+	}
+	for _, err := range errs {
+		logger.Error("%v", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d admin users could not be created", len(errs), len(created)+len(errs))
+	}
+	return nil
+}
+
+func runListAdminUsers(logger *logging.Logger) error {
+	adminMgr := admin.NewManager(logger, adminConfig())
+	users, err := adminMgr.ListAdminUsers()
+	if err != nil {
+		logger.Error("Failed to list admin users: %v", err)
+		return err
+	}
+
+	if len(users) == 0 {
+		fmt.Println("No admin users found")
+		return nil
+	}
+	for _, user := range users {
+		fmt.Printf("%s\t%s\n", user.Email, user.CreatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func runExportAdminUsers(logger *logging.Logger) error {
+	format := admin.FormatCSV
+	var path string
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] == "--format" && i+1 < len(os.Args) {
+			format = os.Args[i+1]
+			i++
+		} else if path == "" {
+			path = os.Args[i]
+		}
+	}
+
+	out := os.Stdout
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := admin.NewManager(logger, adminConfig()).ExportAdminUsers(out, format); err != nil {
+		return err
+	}
+	if path != "" {
+		logger.Success("Admin users exported to %s", path)
+	}
+	return nil
+}
+
+func runResetAdminToken(logger *logging.Logger) error {
+	if len(os.Args) < 3 {
+		return usageErrorf("usage: fusionaly reset-admin-token <email>")
+	}
+	email := strings.TrimSpace(os.Args[2])
+	token, err := admin.NewManager(logger, adminConfig()).GenerateAdminResetToken(email)
+	if err != nil {
+		return err
+	}
+	// Printed rather than logged so the token never lands in the log files.
+	fmt.Printf("Reset token for %s: %s\n", email, token)
+	fmt.Println("Run 'fusionaly reset-admin-password <token>' on this server to set a new password.")
+	return nil
+}
+
+func runResetAdminPassword(logger *logging.Logger) error {
+	if len(os.Args) < 3 {
+		return usageErrorf("usage: fusionaly reset-admin-password <token>")
+	}
+	adminMgr := admin.NewManager(logger, adminConfig())
+	fmt.Printf("Password must be at least %d characters\n", adminMgr.PasswordPolicy().MinLength)
+	password, err := admin.PromptAdminPassword(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	return adminMgr.ResetAdminPasswordWithToken(os.Args[2], password)
+}
+
+func runImportData(logger *logging.Logger) error {
+	if len(os.Args) != 3 {
+		return usageErrorf("usage: fusionaly import-data <bundle>")
+	}
+	mgr := admin.NewManager(logger, adminConfig())
+	return mgr.ImportData(rootCtx, os.Args[2])
+}
+
+func runResetDB(logger *logging.Logger) error {
+	force := false
+	for _, arg := range os.Args[2:] {
+		if arg != "--force" {
+			return usageErrorf("usage: fusionaly reset-db --force")
+		}
+		force = true
+	}
+
+	mgr := admin.NewManager(logger, adminConfig())
+	// The extra confirmation for production needs someone at a terminal.
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		mgr.SetConfirmFunc(func(prompt string) (bool, error) {
+			fmt.Printf("⚠️  %s\n", prompt)
+			fmt.Print("Type 'reset production' to continue: ")
+			answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			return strings.TrimSpace(answer) == "reset production", err
+		})
+	}
+	return mgr.ResetDatabase(rootCtx, force)
+}
+
+func runDeleteAdminUser(logger *logging.Logger) error {
+	if len(os.Args) < 3 {
+		return usageErrorf("usage: fusionaly delete-admin-user <email> [--force]")
+	}
+	email := strings.TrimSpace(os.Args[2])
+	force := len(os.Args) >= 4 && os.Args[3] == "--force"
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("⚠️  This will permanently delete the admin user %s.\n", email)
+	fmt.Print("Are you sure you want to continue? (yes/no): ")
+	confirmation, err := reader.ReadString('\n')
+	if err != nil {
+		logger.Error("Failed to read confirmation: %v", err)
+		return err
+	}
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "yes" && confirmation != "y" {
+		logger.Info("Deletion cancelled by user")
+		return nil
+	}
+
+	adminMgr := admin.NewManager(logger, adminConfig())
+	if err := adminMgr.DeleteAdminUser(email, force); err != nil {
+		logger.Error("Failed to delete admin user: %v", err)
+		return err
+	}
+	return nil
+}
+
+func runRotateSecret(logger *logging.Logger) error {
+	fmt.Println("⚠️  Rotating the secret key signs every user out and restarts Fusionaly.")
+	fmt.Print("Are you sure you want to continue? (yes/no): ")
+	confirmation, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "yes" && confirmation != "y" {
+		logger.Info("Secret rotation cancelled by user")
+		return nil
+	}
+
+	d := docker.NewDocker(logger, database.NewDatabase(logger))
+	d.SetNames(selected.Names())
+	d.SetPorts(selected.Ports)
+	return updater.NewSecretRotator(logger, selected.Paths().EnvFile, d).RotateSecret(rootCtx)
+}
+
 func runUpdateLicenseKey(logger *logging.Logger, startTime time.Time) error {
 	envFile := "/opt/fusionaly/.env"
 
@@ -343,15 +1666,164 @@ func printVersion() {
 	fmt.Println(currentInstallerVersion)
 }
 
+// removeFlagValue strips "flag value" or "flag=value" from os.Args and
+// returns the value. A flag given last, with no value after it, is a usage
+// error.
+func removeFlagValue(flag string) (value string, found bool, err error) {
+	args := os.Args[:1]
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == flag && i+1 < len(os.Args):
+			value, found = os.Args[i+1], true
+			i++
+		case arg == flag:
+			return "", false, usageErrorf("flag needs a value: %s", flag)
+		case strings.HasPrefix(arg, flag+"="):
+			value, found = strings.TrimPrefix(arg, flag+"="), true
+		default:
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+	return value, found, nil
+}
+
+// mustRemoveFlagValue is removeFlagValue for main, exiting on a usage error.
+func mustRemoveFlagValue(flag string) (string, bool) {
+	value, found, err := removeFlagValue(flag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		exit(exitcode.ExitCode(err))
+	}
+	return value, found
+}
+
+// selectInstance resolves --instance, registering the instance when it is
+// being installed.
+func selectInstance(name string, create bool) (instance.Instance, error) {
+	if err := instance.ValidateName(name); err != nil {
+		return instance.Instance{}, usageErrorf("%v", err)
+	}
+	registry := instance.NewRegistry(instance.DefaultRegistry)
+	if create {
+		return registry.Create(name)
+	}
+	return registry.Lookup(name)
+}
+
+// newStack returns a Stack for the selected instance.
+func newStack(logger *logging.Logger) *docker.Stack {
+	stack := docker.NewStack(logger, executor.Default())
+	stack.SetNames(selected.Names())
+	stack.SetInstancePorts(selected.Ports)
+	stack.SetEnvFile(selected.Paths().EnvFile)
+	return stack
+}
+
+// adminConfig returns the admin Manager configuration for the selected
+// instance.
+func adminConfig() admin.Config {
+	cfg := admin.DefaultConfig()
+	cfg.Paths = selected.Paths()
+	cfg.Names = selected.Names()
+	cfg.Secrets = secrets
+	// FUSIONALY_FNCTL_ALLOW extends the subcommands `fusionaly fnctl` forwards.
+	for _, name := range strings.Split(os.Getenv("FUSIONALY_FNCTL_ALLOW"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.FnctlCommands = append(cfg.FnctlCommands, name)
+		}
+	}
+	return cfg
+}
+
+// removeFlag deletes every occurrence of a boolean flag from os.Args so
+// command dispatch and positional arguments are unaffected, and reports
+// whether it was present.
+func removeFlag(flag string) bool {
+	found := false
+	args := os.Args[:1]
+	for _, arg := range os.Args[1:] {
+		if arg == flag {
+			found = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	return found
+}
+
 func printUsage() {
 	fmt.Println("Usage: fusionaly [command] [options]")
 	fmt.Println("\nCommands:")
-	fmt.Println("  install                     Install Fusionaly")
-	fmt.Println("  update                      Update an existing installation")
+	fmt.Println("  install [--version <tag>]   Install Fusionaly, optionally pinned to an app image tag (--skip-firewall leaves ufw/firewalld alone, --skip-network-check skips waiting for the image registry on air-gapped hosts, --strict-clock fails instead of warning when the host clock is out of sync, --image-tarball <file> loads the images from a docker save bundle instead of pulling, --metrics prints step timings)")
+	fmt.Println("  install --config <file>     Install unattended from a YAML file declaring domain, admin, version and backups")
+	fmt.Println("  update [--version <tag>]    Update an existing installation (a version backs up and rolls back on failure)")
+	fmt.Println("  self-update                 Replace this binary with the latest verified release")
+	fmt.Println("  status [--json]             Show each service's state, image tag and uptime")
+	fmt.Println("  logs [app|caddy] [-f]       Show container logs (-f follows, --tail N limits lines)")
+	fmt.Println("  search-logs <regex> [--since 1h] Search the app and proxy logs, tagging lines with their service")
+	fmt.Println("  validate-config [path]      Report every problem in the .env file (default /opt/fusionaly/.env)")
+	fmt.Println("  show-config [--format json] Show the effective configuration and where each value comes from, secrets redacted")
+	fmt.Println("  doctor [--json]             Check docker, containers, ports, disk and versions")
+	fmt.Println("  verify-public [domain]      Check the site answers over HTTPS with a valid certificate")
+	fmt.Println("  tls <domain> <email>        Serve domain with a Let's Encrypt certificate (--staging uses the staging CA)")
+	fmt.Println("  set-domain <domain>         Move the site to a new domain and reload the proxy")
+	fmt.Println("  set-ports <http> <https>    Publish the proxy on other host ports, e.g. behind another web server")
+	fmt.Println("  apply-config KEY=VALUE...   Change settings together, reverting them if the app is unhealthy afterwards")
+	fmt.Println("  test-email <to>             Send a test message with the SMTP_* settings from .env")
+	fmt.Println("  rollback                    Redeploy the previously installed app version")
 	fmt.Println("  reload                      Reload containers with latest .env config without backup")
+	fmt.Println("  backup [dir]                Dump the database (encrypted with BACKUP_PASSPHRASE and uploaded to S3_BUCKET if set)")
+	fmt.Println("  restore [file] [--force]    Restore a dump written by backup, choosing from a list without a file (--force replaces existing data)")
+	fmt.Println("  verify-backup <file> [--dry-restore] Check a backup's integrity (--dry-restore loads it into a throwaway container)")
+	fmt.Println("  export-bundle <file> [--encrypt] Archive .env, Caddyfile and a database dump for another host")
+	fmt.Println("  import-bundle <file> [--force]   Restore an exported bundle into this installation")
+	fmt.Println("  db-shell                    Open an interactive sqlite3 prompt on the installation's database")
+	fmt.Println("  schedule-backups [cron|off] Run backup on a cron schedule (default \"0 2 * * *\"; off removes it)")
 	fmt.Println("  restore-db                  Interactively restore database from a backup")
+	fmt.Println("  uninstall [--remove-data]   Remove containers, cron jobs and boot unit (--remove-data also deletes the install dir)")
+	fmt.Println("  regenerate-proxy            Rebuild the Caddyfile from the current settings and restart the proxy")
+	fmt.Println("  maintenance on|off          Serve a maintenance page instead of the app, or switch back")
+	fmt.Println("  disk-usage                  Show the space used by this install's data, volumes and images")
+	fmt.Println("  prune [--volumes]           Remove stopped containers and dangling images of this install (--volumes also its unused volumes)")
+	fmt.Println("  start [--strict-digest]     Start the Fusionaly containers, verifying the image digest")
+	fmt.Println("  stop [--timeout 30s]        Stop the Fusionaly containers, killing them only after the timeout")
+	fmt.Println("  restart [app|caddy]         Restart all containers or a single service")
+	fmt.Println("  migrate                     Apply pending database migrations in the app container")
+	fmt.Println("  reset-db --force            Back up, then wipe the database to an empty schema (FUSIONALY_ENV=production asks again)")
+	fmt.Println("  import-data <bundle>        Back up, then load analytics data exported from another instance (fnctl export-data)")
+	fmt.Println("  fnctl <subcommand> [args]   Run an allowed fnctl subcommand in the app container (FUSIONALY_FNCTL_ALLOW adds more)")
+	fmt.Println("  create-admin-user <email>   Create an admin user, prompting for the password")
+	fmt.Println("  import-admin-users <file>   Create admin users from a CSV (email,password) or JSON file")
 	fmt.Println("  change-admin-password       Change the admin user password")
+	fmt.Println("  list-admin-users            List existing admin users")
+	fmt.Println("  export-admin-users [file]   Write admin emails and creation dates as CSV (--format json for JSON)")
+	fmt.Println("  delete-admin-user <email>   Delete an admin user (--force allows removing the last one)")
+	fmt.Println("  reset-admin-token <email>   Print a one-time token for resetting a forgotten admin password")
+	fmt.Println("  reset-admin-password <tok>  Set a new admin password using a reset token")
 	fmt.Println("  update-license-key [key]    Update the license key and restart containers")
+	fmt.Println("  rotate-secret               Replace FUSIONALY_PRIVATE_KEY and restart (signs every user out)")
+	fmt.Println("  check-update                Report whether a newer Fusionaly release is available")
+	fmt.Println("  installed-version           Show the Fusionaly app version currently deployed")
 	fmt.Println("  version                     Show version information")
 	fmt.Println("  help                        Show this help message")
+	fmt.Println("\nOptions:")
+	fmt.Println("  --dry-run                   Print the external commands a command would run instead of running them")
+	fmt.Println("  --record-script <file>      Append every external command to a shell script for review or replay, secrets as variables")
+	fmt.Println("  --sudo                      Run docker and other host commands through passwordless sudo")
+	fmt.Println("  --verbose                   Log debug output (wins over --quiet)")
+	fmt.Println("  --quiet                     Only log errors")
+	fmt.Println("  --instance <name>           Manage a named instance with its own containers, data dir and ports")
+	fmt.Println("  --timeout <duration>        Abort the command once it has run this long, e.g. 30m (exit code 124; not for stop)")
+	fmt.Println("\nExit codes:")
+	fmt.Println("  1                           Unclassified failure")
+	fmt.Println("  2                           Invalid usage, input or configuration")
+	fmt.Println("  3                           User, backup, version or file not found")
+	fmt.Println("  4                           A docker or fnctl command failed")
+	fmt.Println("  5                           Another fusionaly command is already running")
+	fmt.Println("  6                           Root privileges or file permissions missing")
+	fmt.Println("  124                         Timeout (--timeout reached)")
+	fmt.Println("  130                         Interrupted")
 }
@@ -1,54 +1,352 @@
 package admin
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"fusionaly-installer/internal/config"
 	"fusionaly-installer/internal/database"
 	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
+	"fusionaly-installer/internal/validation"
 )
 
+// DefaultCommandTimeout bounds how long a single fnctl invocation may run
+// before it is killed.
+const DefaultCommandTimeout = 30 * time.Second
+
+// DefaultMigrateTimeout bounds a `fnctl migrate` run, which can take far
+// longer than the other commands on a large database.
+const DefaultMigrateTimeout = 10 * time.Minute
+
+// DefaultHealthTimeout bounds how long the Manager waits for the app container
+// to become healthy before running fnctl.
+const DefaultHealthTimeout = 60 * time.Second
+
+// ErrInvalidEmail is returned before any fnctl command runs when the supplied
+// admin email is not a well-formed address.
+var ErrInvalidEmail = errors.New("invalid admin email")
+
+// ErrRefusedLastAdmin is returned by a non-forced delete that would leave the
+// installation without any admin account.
+var ErrRefusedLastAdmin = errors.New("refusing to delete the last remaining admin user")
+
 // Manager handles administrative user operations inside the running container.
 // It is decoupled from the Installer/Updater flows so it can be invoked
 // separately (e.g. via `fusionaly change-admin-password`).
 
 type dockerExecutor interface {
 	ExecuteCommand(args ...string) error
+	ExecuteCommandOutput(args ...string) (stdout string, stderr string, err error)
+	ExecuteCommandContext(ctx context.Context, args ...string) error
+	ExecuteCommandOutputContext(ctx context.Context, args ...string) (stdout string, stderr string, err error)
+	RunningAppContainer() (string, error)
+	WaitForHealthy(ctx context.Context, container string, timeout time.Duration) error
+}
+
+// Config holds the tunables for a Manager.
+type Config struct {
+	CommandTimeout time.Duration           // Per-command deadline; zero disables it
+	PasswordPolicy PasswordPolicy          // Enforced before creating users or changing passwords
+	Retry          RetryConfig             // Retries for transient executor failures
+	HealthTimeout  time.Duration           // Wait for the app container to be healthy first; zero skips the wait
+	MigrateTimeout time.Duration           // Deadline for fnctl migrate; zero disables it
+	Paths          config.InstallPaths     // Locates fnctl and the installation's files
+	Names          docker.Names            // Containers fnctl runs in; zero uses docker.DefaultNames
+	FnctlCommands  []string                // Subcommands RunFnctl may forward
+	Secrets        executor.SecretRegistry // Told about secret fnctl arguments before they run; nil for none
+}
+
+// DefaultConfig returns the Manager configuration used by the CLI.
+func DefaultConfig() Config {
+	return Config{
+		CommandTimeout: DefaultCommandTimeout,
+		PasswordPolicy: DefaultPasswordPolicy(),
+		Retry:          DefaultRetryConfig(),
+		HealthTimeout:  DefaultHealthTimeout,
+		MigrateTimeout: DefaultMigrateTimeout,
+		Paths:          config.DefaultInstallPaths(),
+		FnctlCommands:  DefaultFnctlCommands(),
+	}
 }
 
 type Manager struct {
-	docker dockerExecutor
-	logger *logging.Logger
+	docker  dockerExecutor
+	logger  *logging.Logger
+	config  Config
+	confirm ConfirmFunc                               // Extra confirmation for ResetDatabase in production
+	backup  func(ctx context.Context) (string, error) // Safety backup before a reset or import; nil uses safetyBackup
+	version func(ctx context.Context) (string, error) // Installed app version for ImportData; nil asks docker
 }
 
 // NewManager creates a Manager with default docker executor.
-func NewManager(logger *logging.Logger) *Manager {
+func NewManager(logger *logging.Logger, config Config) *Manager {
 	db := database.NewDatabase(logger)
 	d := docker.NewDocker(logger, db)
-	return &Manager{docker: d, logger: logger}
+	if config.Names.Project != "" {
+		d.SetNames(config.Names)
+	}
+	return &Manager{docker: d, logger: logger, config: config}
 }
 
-// withExecutor is used in tests to inject a fake executor.
-func newManagerWithExecutor(logger *logging.Logger, exec dockerExecutor) *Manager {
-	return &Manager{docker: exec, logger: logger}
+// withExecutor is used in tests to inject a fake executor and install paths.
+func newManagerWithExecutor(logger *logging.Logger, exec dockerExecutor, paths config.InstallPaths) *Manager {
+	cfg := DefaultConfig()
+	cfg.Paths = paths
+	return &Manager{docker: exec, logger: logger, config: cfg}
+}
+
+// SetPasswordPolicy overrides the policy enforced on admin passwords.
+func (m *Manager) SetPasswordPolicy(policy PasswordPolicy) {
+	m.config.PasswordPolicy = policy
+}
+
+// PasswordPolicy returns the policy enforced on admin passwords.
+func (m *Manager) PasswordPolicy() PasswordPolicy {
+	return m.config.PasswordPolicy
 }
 
 // CreateAdminUser creates the initial admin user inside the container.
 func (m *Manager) CreateAdminUser(email, password string) error {
-	err := m.docker.ExecuteCommand("/app/fnctl", "create-admin-user", email, password)
+	return m.CreateAdminUserContext(context.Background(), email, password)
+}
+
+// CreateAdminUserContext is like CreateAdminUser but aborts when ctx is done.
+func (m *Manager) CreateAdminUserContext(ctx context.Context, email, password string) error {
+	if err := checkEmail(email); err != nil {
+		return err
+	}
+	if err := m.config.PasswordPolicy.Check(password); err != nil {
+		return err
+	}
+	_, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "create-admin-user", email, password)
 	if err != nil {
-		return fmt.Errorf("failed to create admin user: %w", err)
+		return fnctlError("failed to create admin user", stderr, err)
 	}
 	return nil
 }
 
+// AdminExists reports whether an admin account with email exists. Emails are
+// compared case-insensitively.
+func (m *Manager) AdminExists(email string) (bool, error) {
+	return m.AdminExistsContext(context.Background(), email)
+}
+
+// AdminExistsContext is like AdminExists but aborts when ctx is done.
+func (m *Manager) AdminExistsContext(ctx context.Context, email string) (bool, error) {
+	if err := checkEmail(email); err != nil {
+		return false, err
+	}
+	users, err := m.ListAdminUsersContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	return containsEmail(users, email), nil
+}
+
+// containsEmail reports whether users includes email, ignoring case.
+func containsEmail(users []AdminUser, email string) bool {
+	for _, user := range users {
+		if strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAdminUserIfNotExists creates the admin user unless an account with
+// that email already exists, so re-running an install is safe. created
+// reports whether a new account was made.
+func (m *Manager) CreateAdminUserIfNotExists(email, password string) (created bool, err error) {
+	return m.CreateAdminUserIfNotExistsContext(context.Background(), email, password)
+}
+
+// CreateAdminUserIfNotExistsContext is like CreateAdminUserIfNotExists but aborts when ctx is done.
+func (m *Manager) CreateAdminUserIfNotExistsContext(ctx context.Context, email, password string) (created bool, err error) {
+	exists, err := m.AdminExistsContext(ctx, email)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing admin users: %w", err)
+	}
+	if exists {
+		m.logger.Info("Admin user %s already exists, skipping creation", email)
+		return false, nil
+	}
+	if err := m.CreateAdminUserContext(ctx, email, password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // ChangeAdminPassword changes the password of an existing admin user.
 func (m *Manager) ChangeAdminPassword(email, newPassword string) error {
+	return m.ChangeAdminPasswordContext(context.Background(), email, newPassword)
+}
+
+// ChangeAdminPasswordContext is like ChangeAdminPassword but aborts when ctx is done.
+func (m *Manager) ChangeAdminPasswordContext(ctx context.Context, email, newPassword string) error {
+	if err := checkEmail(email); err != nil {
+		return err
+	}
+	if err := m.config.PasswordPolicy.Check(newPassword); err != nil {
+		return err
+	}
 	m.logger.InfoWithTime("Changing admin password for %s", email)
-	err := m.docker.ExecuteCommand("/app/fnctl", "change-admin-password", email, newPassword)
+	_, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "change-admin-password", email, newPassword)
 	if err != nil {
-		return fmt.Errorf("failed to change admin password: %w", err)
+		return fnctlError("failed to change admin password", stderr, err)
 	}
 	m.logger.Success("Password changed for %s", email)
 	return nil
 }
+
+// DeleteAdminUser removes an admin account. Unless force is set, it first
+// lists the existing admins and refuses to remove the only one left.
+func (m *Manager) DeleteAdminUser(email string, force bool) error {
+	return m.DeleteAdminUserContext(context.Background(), email, force)
+}
+
+// DeleteAdminUserContext is like DeleteAdminUser but aborts when ctx is done.
+func (m *Manager) DeleteAdminUserContext(ctx context.Context, email string, force bool) error {
+	if err := checkEmail(email); err != nil {
+		return err
+	}
+
+	if !force {
+		users, err := m.ListAdminUsersContext(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check remaining admin users: %w", err)
+		}
+		if !containsEmail(users, email) {
+			return fmt.Errorf("%w: %s", ErrAdminNotFound, email)
+		}
+		if len(users) == 1 {
+			return ErrRefusedLastAdmin
+		}
+	}
+
+	m.logger.InfoWithTime("Deleting admin user %s", email)
+	_, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "delete-admin-user", email)
+	if err != nil {
+		return fnctlError("failed to delete admin user", stderr, err)
+	}
+	m.logger.Success("Admin user %s deleted", email)
+	return nil
+}
+
+// AdminUser is an admin account as reported by fnctl.
+type AdminUser struct {
+	Email     string
+	CreatedAt time.Time
+}
+
+// ListAdminUsers returns the admin accounts that currently exist.
+func (m *Manager) ListAdminUsers() ([]AdminUser, error) {
+	return m.ListAdminUsersContext(context.Background())
+}
+
+// ListAdminUsersContext is like ListAdminUsers but aborts when ctx is done.
+func (m *Manager) ListAdminUsersContext(ctx context.Context) ([]AdminUser, error) {
+	stdout, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "list-admin-users")
+	if err != nil {
+		return nil, fnctlError("failed to list admin users", stderr, err)
+	}
+	users, err := parseAdminUsers(stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse admin users: %w", err)
+	}
+	return users, nil
+}
+
+// adminTimeLayouts are the timestamp formats accepted from fnctl output.
+var adminTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// parseAdminUsers parses `fnctl list-admin-users` output, one user per line
+// in the form "<email> <created_at>". Blank lines are ignored.
+func parseAdminUsers(output string) ([]AdminUser, error) {
+	users := []AdminUser{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("missing created date in line %q", strings.TrimSpace(line))
+		}
+
+		raw := strings.Join(fields[1:], " ")
+		var createdAt time.Time
+		var err error
+		for _, layout := range adminTimeLayouts {
+			if createdAt, err = time.Parse(layout, raw); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid created date %q for %s", raw, fields[0])
+		}
+
+		users = append(users, AdminUser{Email: fields[0], CreatedAt: createdAt})
+	}
+	return users, nil
+}
+
+// waitHealthy blocks until the running app container reports healthy, so
+// fnctl is never invoked while the app is still starting.
+func (m *Manager) waitHealthy(ctx context.Context) error {
+	if m.config.HealthTimeout <= 0 {
+		return nil
+	}
+	container, err := m.docker.RunningAppContainer()
+	if err != nil {
+		return err
+	}
+	if err := m.docker.WaitForHealthy(ctx, container, m.config.HealthTimeout); err != nil {
+		return fmt.Errorf("app container is not ready: %w", err)
+	}
+	return nil
+}
+
+// run executes a command in the app container, retrying transient failures
+// according to the configured RetryConfig.
+func (m *Manager) run(ctx context.Context, args ...string) (stdout, stderr string, err error) {
+	return m.runWithTimeout(ctx, m.config.CommandTimeout, args...)
+}
+
+// runWithTimeout is like run but each attempt gets timeout instead of the
+// configured CommandTimeout.
+func (m *Manager) runWithTimeout(ctx context.Context, timeout time.Duration, args ...string) (stdout, stderr string, err error) {
+	if err := m.waitHealthy(ctx); err != nil {
+		return "", "", err
+	}
+	err = m.retry(ctx, func() error {
+		var runErr error
+		stdout, stderr, runErr = m.runOnce(ctx, timeout, args...)
+		return transportError(runErr, stderr)
+	})
+	return stdout, stderr, err
+}
+
+// runOnce executes a single attempt, applying timeout on top of whatever
+// deadline ctx already carries.
+func (m *Manager) runOnce(ctx context.Context, timeout time.Duration, args ...string) (string, string, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	m.logger.Debug("Running %s", redactCommand(args))
+	m.registerSecrets(args)
+	return m.docker.ExecuteCommandOutputContext(ctx, args...)
+}
+
+// checkEmail validates an admin email so fnctl never sees a malformed address.
+func checkEmail(email string) error {
+	if err := validation.ValidateEmail(email); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+	return nil
+}
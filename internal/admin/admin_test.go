@@ -1,40 +1,108 @@
 package admin
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"fusionaly-installer/internal/config"
 	"fusionaly-installer/internal/logging"
 )
 
 type fakeExecutor struct {
 	cmds      [][]string
 	failAfter int // fail after N commands; 0 means no fail unless failAfter==1 etc.
+	stdout    string
+	stderr    string
+	delay     time.Duration // simulate a slow command; honors ctx cancellation
+	failFirst int           // fail the first N commands, then succeed
+	err       error         // error returned on failure; defaults to a generic executor failure
+
+	healthChecks []string // containers passed to WaitForHealthy
+	healthErr    error    // returned by WaitForHealthy
+}
+
+func (f *fakeExecutor) RunningAppContainer() (string, error) {
+	return "fusionaly-app-1", nil
+}
+
+func (f *fakeExecutor) WaitForHealthy(ctx context.Context, container string, timeout time.Duration) error {
+	f.healthChecks = append(f.healthChecks, container)
+	return f.healthErr
 }
 
 func (f *fakeExecutor) ExecuteCommand(args ...string) error {
+	return f.ExecuteCommandContext(context.Background(), args...)
+}
+
+func (f *fakeExecutor) ExecuteCommandOutput(args ...string) (string, string, error) {
+	return f.ExecuteCommandOutputContext(context.Background(), args...)
+}
+
+func (f *fakeExecutor) ExecuteCommandContext(ctx context.Context, args ...string) error {
+	_, _, err := f.ExecuteCommandOutputContext(ctx, args...)
+	return err
+}
+
+func (f *fakeExecutor) ExecuteCommandOutputContext(ctx context.Context, args ...string) (string, string, error) {
 	copyArgs := make([]string, len(args))
 	copy(copyArgs, args)
 	f.cmds = append(f.cmds, copyArgs)
-	if f.failAfter != 0 && len(f.cmds) >= f.failAfter {
-		return fmt.Errorf("executor failure")
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		}
+	}
+	if (f.failAfter != 0 && len(f.cmds) >= f.failAfter) || len(f.cmds) <= f.failFirst {
+		if f.err != nil {
+			return f.stdout, f.stderr, f.err
+		}
+		return f.stdout, f.stderr, fmt.Errorf("executor failure")
 	}
-	return nil
+	return f.stdout, f.stderr, nil
 }
 
 // makeFakeManager returns a Manager wired with a fake executor for testing.
 func makeFakeManager() (*Manager, *fakeExecutor) {
 	logger := logging.NewLogger(logging.Config{Level: "debug"})
 	fe := &fakeExecutor{}
-	mgr := newManagerWithExecutor(logger, fe)
+	mgr := newManagerWithExecutor(logger, fe, config.DefaultInstallPaths())
+	mgr.config.Retry.BaseDelay = time.Millisecond
 	return mgr, fe
 }
 
+func TestCustomBinaryPath(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+	fe := &fakeExecutor{stdout: "admin@company.com\n"}
+	paths := config.PathsForDir(t.TempDir())
+	paths.BinaryPath = "/srv/fusionaly/bin/fnctl"
+	mgr := newManagerWithExecutor(logger, fe, paths)
+
+	if err := mgr.CreateAdminUser("ops@company.com", "SecurePassword123"); err != nil {
+		t.Fatalf("CreateAdminUser: %v", err)
+	}
+	if err := mgr.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	want := [][]string{
+		{"/srv/fusionaly/bin/fnctl", "create-admin-user", "ops@company.com", "SecurePassword123"},
+		{"/srv/fusionaly/bin/fnctl", "migrate"},
+	}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fe.cmds)
+	}
+}
+
 func TestCreateAdminUser(t *testing.T) {
 	mgr, fe := makeFakeManager()
 	email := "test@example.com"
-	pass := "password123"
+	pass := "password123456"
 	if err := mgr.CreateAdminUser(email, pass); err != nil {
 		t.Fatalf("CreateAdminUser returned error: %v", err)
 	}
@@ -47,7 +115,7 @@ func TestCreateAdminUser(t *testing.T) {
 func TestChangeAdminPassword(t *testing.T) {
 	mgr, fe := makeFakeManager()
 	email := "test@example.com"
-	pass := "newpass123"
+	pass := "newpass123456"
 	if err := mgr.ChangeAdminPassword(email, pass); err != nil {
 		t.Fatalf("ChangeAdminPassword returned error: %v", err)
 	}
@@ -60,30 +128,30 @@ func TestChangeAdminPassword(t *testing.T) {
 func TestCreateAdminUser_Error(t *testing.T) {
 	mgr, fe := makeFakeManager()
 	fe.failAfter = 1
-	if err := mgr.CreateAdminUser("x@y.com", "passw0rd"); err == nil {
-		t.Fatal("expected error but got nil")
+	if err := mgr.CreateAdminUser("x@y.com", "passw0rd-long"); !errors.Is(err, ErrExecutor) {
+		t.Fatalf("expected ErrExecutor, got: %v", err)
 	}
 }
 
 func TestChangeAdminPassword_Error(t *testing.T) {
 	mgr, fe := makeFakeManager()
 	fe.failAfter = 1
-	if err := mgr.ChangeAdminPassword("x@y.com", "pass123"); err == nil {
-		t.Fatal("expected error but got nil")
+	if err := mgr.ChangeAdminPassword("x@y.com", "pass123-long"); !errors.Is(err, ErrExecutor) {
+		t.Fatalf("expected ErrExecutor, got: %v", err)
 	}
 }
 
 func TestSequenceCommands(t *testing.T) {
 	mgr, fe := makeFakeManager()
-	if err := mgr.CreateAdminUser("a@b.com", "pass1234"); err != nil {
+	if err := mgr.CreateAdminUser("a@b.com", "pass1234-long"); err != nil {
 		t.Fatal(err)
 	}
-	if err := mgr.ChangeAdminPassword("a@b.com", "pass4321"); err != nil {
+	if err := mgr.ChangeAdminPassword("a@b.com", "pass4321-long"); err != nil {
 		t.Fatal(err)
 	}
 	want := [][]string{
-		{"/app/fnctl", "create-admin-user", "a@b.com", "pass1234"},
-		{"/app/fnctl", "change-admin-password", "a@b.com", "pass4321"},
+		{"/app/fnctl", "create-admin-user", "a@b.com", "pass1234-long"},
+		{"/app/fnctl", "change-admin-password", "a@b.com", "pass4321-long"},
 	}
 	if !reflect.DeepEqual(fe.cmds, want) {
 		t.Errorf("sequence commands mismatch\nwant %#v\ngot  %#v", want, fe.cmds)
@@ -93,11 +161,12 @@ func TestSequenceCommands(t *testing.T) {
 func TestChangeAdminPassword_FailsExecutor(t *testing.T) {
 	logger := logging.NewLogger(logging.Config{Level: "error"})
 	fe := &fakeExecutor{failAfter: 1}
-	mgr := newManagerWithExecutor(logger, fe)
-	// Expect failure on first call
-	err := mgr.ChangeAdminPassword("x@y.com", "pass")
-	if err == nil {
-		t.Fatalf("expected error but got nil")
+	mgr := newManagerWithExecutor(logger, fe, config.DefaultInstallPaths())
+	mgr.config.Retry.BaseDelay = time.Millisecond
+	// fnctl ran and failed, so the command is not retried
+	err := mgr.ChangeAdminPassword("x@y.com", "pass-long-enough")
+	if !errors.Is(err, ErrExecutor) {
+		t.Fatalf("expected ErrExecutor, got: %v", err)
 	}
 	if len(fe.cmds) != 1 {
 		t.Fatalf("expected 1 command recorded, got %d", len(fe.cmds))
@@ -126,10 +195,10 @@ func TestAdminUserCreation(t *testing.T) {
 		mgr, fe := makeFakeManager()
 		fe.failAfter = 1
 		
-		err := mgr.CreateAdminUser("admin@test.com", "password123")
+		err := mgr.CreateAdminUser("admin@test.com", "password123456")
 		
-		if err == nil {
-			t.Error("Expected admin user creation to fail when system fails")
+		if !errors.Is(err, ErrExecutor) {
+			t.Errorf("Expected ErrExecutor when system fails, got: %v", err)
 		}
 	})
 }
@@ -156,10 +225,10 @@ func TestAdminPasswordManagement(t *testing.T) {
 		mgr, fe := makeFakeManager()
 		fe.failAfter = 1
 		
-		err := mgr.ChangeAdminPassword("admin@test.com", "newpassword")
+		err := mgr.ChangeAdminPassword("admin@test.com", "newpassword-long")
 		
-		if err == nil {
-			t.Error("Expected password change to fail when system fails")
+		if !errors.Is(err, ErrExecutor) {
+			t.Errorf("Expected ErrExecutor when system fails, got: %v", err)
 		}
 	})
 }
@@ -193,3 +262,301 @@ func TestAdminWorkflow(t *testing.T) {
 		}
 	})
 }
+
+func TestCreateAdminUser_SurfacesFnctlError(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.failAfter = 1
+	fe.stderr = "user already exists: admin@company.com\n"
+
+	err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123")
+	if !errors.Is(err, ErrExecutor) {
+		t.Fatalf("expected ErrExecutor, got: %v", err)
+	}
+	if errors.Is(err, ErrAdminNotFound) {
+		t.Errorf("an existing user must not match ErrAdminNotFound: %v", err)
+	}
+	if !strings.Contains(err.Error(), "user already exists: admin@company.com") {
+		t.Errorf("expected fnctl stderr in error, got: %v", err)
+	}
+}
+
+func TestChangeAdminPassword_SurfacesFnctlError(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.failAfter = 1
+	fe.stderr = "user not found"
+
+	err := mgr.ChangeAdminPassword("missing@company.com", "SecurePassword123")
+	if !errors.Is(err, ErrAdminNotFound) {
+		t.Fatalf("expected ErrAdminNotFound, got: %v", err)
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Stderr != "user not found" {
+		t.Errorf("expected a *CommandError carrying stderr, got: %#v", err)
+	}
+	if !strings.Contains(err.Error(), "user not found") {
+		t.Errorf("expected fnctl stderr in error, got: %v", err)
+	}
+}
+
+func TestFnctlError_WithoutStderr(t *testing.T) {
+	err := fnctlError("failed to create admin user", "  \n", fmt.Errorf("exit status 1"))
+	if err.Error() != "failed to create admin user: exit status 1" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+	if !errors.Is(err, ErrExecutor) {
+		t.Errorf("expected ErrExecutor, got: %v", err)
+	}
+}
+
+func TestCreateAdminUser_TimesOutSlowCommand(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.config.CommandTimeout = 20 * time.Millisecond
+	fe.delay = time.Second
+
+	err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestChangeAdminPasswordContext_HonorsCancellation(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.delay = time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := mgr.ChangeAdminPasswordContext(ctx, "admin@company.com", "SecurePassword123")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	if got := DefaultConfig().CommandTimeout; got != DefaultCommandTimeout {
+		t.Errorf("CommandTimeout = %v, want %v", got, DefaultCommandTimeout)
+	}
+}
+
+func TestInvalidEmailNeverReachesExecutor(t *testing.T) {
+	invalid := []string{"", "not-an-email", "admin@", "@company.com", "admin@@company.com", "admin@company"}
+
+	for _, email := range invalid {
+		t.Run("create/"+email, func(t *testing.T) {
+			mgr, fe := makeFakeManager()
+			err := mgr.CreateAdminUser(email, "SecurePassword123")
+			if !errors.Is(err, ErrInvalidEmail) {
+				t.Fatalf("expected ErrInvalidEmail for %q, got: %v", email, err)
+			}
+			if len(fe.cmds) != 0 {
+				t.Errorf("executor should not be invoked, got: %v", fe.cmds)
+			}
+		})
+		t.Run("change/"+email, func(t *testing.T) {
+			mgr, fe := makeFakeManager()
+			err := mgr.ChangeAdminPassword(email, "SecurePassword123")
+			if !errors.Is(err, ErrInvalidEmail) {
+				t.Fatalf("expected ErrInvalidEmail for %q, got: %v", email, err)
+			}
+			if len(fe.cmds) != 0 {
+				t.Errorf("executor should not be invoked, got: %v", fe.cmds)
+			}
+		})
+	}
+}
+
+func TestListAdminUsers(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\nops@company.com   2024-03-04 08:00:00  \n\n"
+
+	users, err := mgr.ListAdminUsers()
+	if err != nil {
+		t.Fatalf("ListAdminUsers returned error: %v", err)
+	}
+
+	want := []AdminUser{
+		{Email: "admin@company.com", CreatedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{Email: "ops@company.com", CreatedAt: time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("users mismatch\nwant %#v\ngot  %#v", want, users)
+	}
+	if !reflect.DeepEqual(fe.cmds, [][]string{{"/app/fnctl", "list-admin-users"}}) {
+		t.Errorf("unexpected commands: %v", fe.cmds)
+	}
+}
+
+func TestListAdminUsers_Empty(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "  \n\t\n"
+
+	users, err := mgr.ListAdminUsers()
+	if err != nil {
+		t.Fatalf("ListAdminUsers returned error: %v", err)
+	}
+	if len(users) != 0 {
+		t.Errorf("expected no users, got: %v", users)
+	}
+}
+
+func TestListAdminUsers_MalformedOutput(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com yesterday\n"
+
+	_, err := mgr.ListAdminUsers()
+	if err == nil || !strings.Contains(err.Error(), "failed to parse admin users") {
+		t.Fatalf("expected parse error, got: %v", err)
+	}
+	if errors.Is(err, ErrExecutor) {
+		t.Errorf("a parse failure is not an executor failure: %v", err)
+	}
+}
+
+func TestListAdminUsers_ExecutorError(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.failAfter = 1
+	fe.stderr = "database locked"
+
+	_, err := mgr.ListAdminUsers()
+	if !errors.Is(err, ErrExecutor) || !strings.Contains(err.Error(), "database locked") {
+		t.Fatalf("expected fnctl error, got: %v", err)
+	}
+}
+
+func TestDeleteAdminUser_RefusesLastAdmin(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\n"
+
+	err := mgr.DeleteAdminUser("admin@company.com", false)
+	if !errors.Is(err, ErrRefusedLastAdmin) {
+		t.Fatalf("expected ErrRefusedLastAdmin, got: %v", err)
+	}
+
+	want := [][]string{{"/app/fnctl", "list-admin-users"}}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("delete must not run for the last admin\nwant %#v\ngot  %#v", want, fe.cmds)
+	}
+}
+
+func TestDeleteAdminUser_WithOtherAdmins(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\nops@company.com 2024-02-02T15:04:05Z\n"
+
+	if err := mgr.DeleteAdminUser("ops@company.com", false); err != nil {
+		t.Fatalf("DeleteAdminUser returned error: %v", err)
+	}
+
+	want := [][]string{
+		{"/app/fnctl", "list-admin-users"},
+		{"/app/fnctl", "delete-admin-user", "ops@company.com"},
+	}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %#v\ngot  %#v", want, fe.cmds)
+	}
+}
+
+func TestDeleteAdminUser_ForceSkipsGuard(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\n"
+
+	if err := mgr.DeleteAdminUser("admin@company.com", true); err != nil {
+		t.Fatalf("forced DeleteAdminUser returned error: %v", err)
+	}
+
+	want := [][]string{{"/app/fnctl", "delete-admin-user", "admin@company.com"}}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %#v\ngot  %#v", want, fe.cmds)
+	}
+}
+
+func TestDeleteAdminUser_ExecutorError(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.failAfter = 1
+
+	if err := mgr.DeleteAdminUser("admin@company.com", true); !errors.Is(err, ErrExecutor) {
+		t.Fatalf("expected ErrExecutor, got: %v", err)
+	}
+}
+
+func TestDeleteAdminUser_UnknownUser(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\nops@company.com 2024-02-02T15:04:05Z\n"
+
+	err := mgr.DeleteAdminUser("ghost@company.com", false)
+	if !errors.Is(err, ErrAdminNotFound) {
+		t.Fatalf("expected ErrAdminNotFound, got: %v", err)
+	}
+	if len(fe.cmds) != 1 {
+		t.Errorf("delete must not run for an unknown user, got %v", fe.cmds)
+	}
+}
+
+func TestManagerWaitsForHealthyContainer(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	if err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123"); err != nil {
+		t.Fatalf("CreateAdminUser returned error: %v", err)
+	}
+	if !reflect.DeepEqual(fe.healthChecks, []string{"fusionaly-app-1"}) {
+		t.Errorf("expected a health wait on fusionaly-app-1, got %v", fe.healthChecks)
+	}
+}
+
+func TestManagerSkipsFnctlWhenUnhealthy(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.healthErr = fmt.Errorf("not healthy within 60s")
+
+	err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123")
+	if !errors.Is(err, ErrExecutor) || !strings.Contains(err.Error(), "not ready") {
+		t.Fatalf("expected readiness error, got %v", err)
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("fnctl should not run before the container is healthy, got %v", fe.cmds)
+	}
+}
+
+func TestAdminExists(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\nops@company.com 2024-03-04\n"
+
+	exists, err := mgr.AdminExists("OPS@company.com")
+	if err != nil || !exists {
+		t.Errorf("AdminExists(listed) = %v, %v; want true", exists, err)
+	}
+	exists, err = mgr.AdminExists("new@company.com")
+	if err != nil || exists {
+		t.Errorf("AdminExists(unlisted) = %v, %v; want false", exists, err)
+	}
+}
+
+func TestCreateAdminUserIfNotExists_SkipsExisting(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "admin@company.com 2024-01-02T15:04:05Z\n"
+
+	created, err := mgr.CreateAdminUserIfNotExists("admin@company.com", "SecurePassword123")
+	if err != nil {
+		t.Fatalf("CreateAdminUserIfNotExists returned error: %v", err)
+	}
+	if created {
+		t.Error("created = true for an existing admin")
+	}
+	if !reflect.DeepEqual(fe.cmds, [][]string{{"/app/fnctl", "list-admin-users"}}) {
+		t.Errorf("only the list call should run, got %v", fe.cmds)
+	}
+}
+
+func TestCreateAdminUserIfNotExists_CreatesMissing(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "ops@company.com 2024-03-04\n"
+
+	created, err := mgr.CreateAdminUserIfNotExists("admin@company.com", "SecurePassword123")
+	if err != nil || !created {
+		t.Fatalf("CreateAdminUserIfNotExists = %v, %v; want created", created, err)
+	}
+	want := [][]string{
+		{"/app/fnctl", "list-admin-users"},
+		{"/app/fnctl", "create-admin-user", "admin@company.com", "SecurePassword123"},
+	}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fe.cmds)
+	}
+}
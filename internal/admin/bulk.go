@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrDuplicateEmail is reported for every repeat of an email already listed
+// earlier in a bulk import file.
+var ErrDuplicateEmail = errors.New("duplicate email in file")
+
+// adminEntry is one account to create in a bulk import.
+type adminEntry struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	line     int    // CSV line or JSON array index (1-based)
+}
+
+// RowError ties a bulk import failure to the row that caused it. The
+// password is never included.
+type RowError struct {
+	Row   int
+	Email string
+	Err   error
+}
+
+func (e *RowError) Error() string {
+	if e.Email == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d (%s): %v", e.Row, e.Email, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// CreateAdminUsersFromFile creates one admin per entry in path, which is
+// either a JSON array of {"email", "password"} objects (.json) or a CSV file
+// of email,password rows with an optional header. Each entry goes through
+// CreateAdminUser; a failing entry is recorded and the import carries on.
+// created lists the emails that succeeded, in file order.
+func (m *Manager) CreateAdminUsersFromFile(path string) (created []string, errs []error) {
+	return m.CreateAdminUsersFromFileContext(context.Background(), path)
+}
+
+// CreateAdminUsersFromFileContext is like CreateAdminUsersFromFile but aborts when ctx is done.
+func (m *Manager) CreateAdminUsersFromFileContext(ctx context.Context, path string) (created []string, errs []error) {
+	entries, err := readAdminEntries(path)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	seen := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		email := strings.TrimSpace(entry.Email)
+		key := strings.ToLower(email)
+		if first, ok := seen[key]; ok {
+			errs = append(errs, &RowError{Row: entry.line, Email: email, Err: fmt.Errorf("%w (first listed in row %d)", ErrDuplicateEmail, first)})
+			continue
+		}
+		seen[key] = entry.line
+
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, &RowError{Row: entry.line, Email: email, Err: err})
+			continue
+		}
+		if err := m.CreateAdminUserContext(ctx, email, entry.Password); err != nil {
+			errs = append(errs, &RowError{Row: entry.line, Email: email, Err: err})
+			continue
+		}
+		created = append(created, email)
+	}
+	return created, errs
+}
+
+// readAdminEntries parses a bulk import file, choosing the format by
+// extension.
+func readAdminEntries(path string) ([]adminEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return parseAdminJSON(f)
+	}
+	return parseAdminCSV(f)
+}
+
+func parseAdminJSON(r io.Reader) ([]adminEntry, error) {
+	var entries []adminEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid JSON: expected an array of {\"email\", \"password\"} objects: %w", err)
+	}
+	for i := range entries {
+		entries[i].line = i + 1
+	}
+	return entries, nil
+}
+
+func parseAdminCSV(r io.Reader) ([]adminEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []adminEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "email") {
+			continue
+		}
+		entry := adminEntry{Email: record[0], line: line}
+		if len(record) > 1 {
+			entry.Password = record[1]
+		}
+		entries = append(entries, entry)
+	}
+}
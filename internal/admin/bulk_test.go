@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeImportFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCreateAdminUsersFromFile_CSV(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	path := writeImportFile(t, "admins.csv", `email,password
+alice@company.com,SecurePassword123
+not-an-email,SecurePassword123
+bob@company.com,short
+Alice@company.com,AnotherPassword456
+carol@company.com,SecurePassword789
+`)
+
+	created, errs := mgr.CreateAdminUsersFromFile(path)
+
+	if want := []string{"alice@company.com", "carol@company.com"}; !reflect.DeepEqual(created, want) {
+		t.Errorf("created = %v, want %v", created, want)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	for i, want := range []error{ErrInvalidEmail, ErrWeakPassword, ErrDuplicateEmail} {
+		if !errors.Is(errs[i], want) {
+			t.Errorf("errs[%d] = %v, want %v", i, errs[i], want)
+		}
+	}
+	var rowErr *RowError
+	if !errors.As(errs[2], &rowErr) || rowErr.Row != 5 {
+		t.Errorf("duplicate should be reported for row 5, got %v", errs[2])
+	}
+	for _, err := range errs {
+		if strings.Contains(err.Error(), "Password") {
+			t.Errorf("error %q leaks a password", err)
+		}
+	}
+
+	if len(fe.cmds) != 2 {
+		t.Errorf("expected fnctl to run only for valid rows, got %v", fe.cmds)
+	}
+}
+
+func TestCreateAdminUsersFromFile_JSON(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	path := writeImportFile(t, "admins.json", `[
+  {"email": "alice@company.com", "password": "SecurePassword123"},
+  {"email": "alice@company.com", "password": "SecurePassword123"},
+  {"email": "bob@company.com", "password": "SecurePassword456"}
+]`)
+
+	created, errs := mgr.CreateAdminUsersFromFile(path)
+
+	if want := []string{"alice@company.com", "bob@company.com"}; !reflect.DeepEqual(created, want) {
+		t.Errorf("created = %v, want %v", created, want)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrDuplicateEmail) {
+		t.Errorf("expected a single duplicate error, got %v", errs)
+	}
+	want := [][]string{
+		{"/app/fnctl", "create-admin-user", "alice@company.com", "SecurePassword123"},
+		{"/app/fnctl", "create-admin-user", "bob@company.com", "SecurePassword456"},
+	}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fe.cmds)
+	}
+}
+
+func TestCreateAdminUsersFromFile_ContinuesPastExecutorFailure(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.config.Retry.MaxAttempts = 1
+	fe.failFirst = 1
+	path := writeImportFile(t, "admins.csv", "alice@company.com,SecurePassword123\nbob@company.com,SecurePassword456\n")
+
+	created, errs := mgr.CreateAdminUsersFromFile(path)
+	if !reflect.DeepEqual(created, []string{"bob@company.com"}) {
+		t.Errorf("created = %v", created)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "alice@company.com") {
+		t.Errorf("expected the failure for alice to be reported, got %v", errs)
+	}
+}
+
+func TestCreateAdminUsersFromFile_BadFile(t *testing.T) {
+	mgr, fe := makeFakeManager()
+
+	if _, errs := mgr.CreateAdminUsersFromFile(filepath.Join(t.TempDir(), "missing.csv")); len(errs) != 1 {
+		t.Errorf("expected one error for a missing file, got %v", errs)
+	}
+	if _, errs := mgr.CreateAdminUsersFromFile(writeImportFile(t, "admins.json", `{"email": "a@b.com"}`)); len(errs) != 1 {
+		t.Errorf("expected one error for a non-array JSON file, got %v", errs)
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("fnctl should not run, got %v", fe.cmds)
+	}
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrExecutor is matched (via errors.Is) by every failure to run fnctl in
+// the app container, whether the command exited non-zero, timed out or the
+// container was not ready. The underlying cause stays reachable through
+// errors.Unwrap.
+var ErrExecutor = errors.New("fnctl command failed")
+
+// ErrAdminNotFound is returned when the admin account an operation targets
+// does not exist.
+var ErrAdminNotFound = errors.New("admin user not found")
+
+// CommandError describes a failed fnctl invocation. It matches ErrExecutor,
+// and also ErrAdminNotFound when fnctl's stderr says the user is missing.
+type CommandError struct {
+	Action string // What the Manager was doing, e.g. "failed to create admin user"
+	Stderr string // fnctl's trimmed stderr, if any
+	Err    error  // The executor error
+}
+
+func (e *CommandError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("%s: %s: %v", e.Action, e.Stderr, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Action, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CommandError) Is(target error) bool {
+	switch target {
+	case ErrExecutor:
+		return true
+	case ErrAdminNotFound:
+		return reportsMissingUser(e.Stderr)
+	}
+	return false
+}
+
+// reportsMissingUser recognises fnctl's messages for an unknown account.
+func reportsMissingUser(stderr string) bool {
+	msg := strings.ToLower(stderr)
+	return strings.Contains(msg, "user not found") || strings.Contains(msg, "no such user") || strings.Contains(msg, "does not exist")
+}
+
+// fnctlError wraps an executor failure with whatever fnctl printed on stderr,
+// which is usually far more useful to the operator than the exit status.
+func fnctlError(action, stderr string, err error) error {
+	return &CommandError{Action: action, Stderr: strings.TrimSpace(stderr), Err: err}
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const listedAdmins = "admin@company.com 2024-01-02T15:04:05Z\nops@company.com 2024-03-04 08:00:00\n"
+
+func TestExportAdminUsers_CSV(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = listedAdmins
+
+	var buf bytes.Buffer
+	if err := mgr.ExportAdminUsers(&buf, FormatCSV); err != nil {
+		t.Fatalf("ExportAdminUsers returned error: %v", err)
+	}
+	want := "email,created_at\nadmin@company.com,2024-01-02T15:04:05Z\nops@company.com,2024-03-04T08:00:00Z\n"
+	if buf.String() != want {
+		t.Errorf("CSV export mismatch\nwant %q\ngot  %q", want, buf.String())
+	}
+}
+
+func TestExportAdminUsers_JSON(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = listedAdmins
+
+	var buf bytes.Buffer
+	if err := mgr.ExportAdminUsers(&buf, FormatJSON); err != nil {
+		t.Fatalf("ExportAdminUsers returned error: %v", err)
+	}
+	want := `[
+  {"email":"admin@company.com","created_at":"2024-01-02T15:04:05Z"},
+  {"email":"ops@company.com","created_at":"2024-03-04T08:00:00Z"}
+]
+`
+	if buf.String() != want {
+		t.Errorf("JSON export mismatch\nwant %q\ngot  %q", want, buf.String())
+	}
+	var decoded []exportedAdmin
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 2 {
+		t.Errorf("export is not a valid JSON array: %v", err)
+	}
+}
+
+func TestExportAdminUsers_EmptyJSON(t *testing.T) {
+	mgr, _ := makeFakeManager()
+
+	var buf bytes.Buffer
+	if err := mgr.ExportAdminUsers(&buf, FormatJSON); err != nil {
+		t.Fatalf("ExportAdminUsers returned error: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("empty export = %q, want %q", buf.String(), "[]\n")
+	}
+}
+
+func TestExportAdminUsers_UnsupportedFormat(t *testing.T) {
+	mgr, fe := makeFakeManager()
+
+	err := mgr.ExportAdminUsers(&bytes.Buffer{}, "xml")
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("no fnctl command should run for an unsupported format, got %v", fe.cmds)
+	}
+}
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrDisallowedCommand is returned by RunFnctl, before anything runs, for a
+// subcommand missing from Config.FnctlCommands.
+var ErrDisallowedCommand = errors.New("fnctl subcommand is not allowed")
+
+// DefaultFnctlCommands returns the fnctl subcommands RunFnctl forwards by
+// default: ones that only read state or are safe to repeat. Destructive
+// operations stay behind the dedicated commands and their safety checks,
+// e.g. delete-admin-user refusing to remove the last admin.
+func DefaultFnctlCommands() []string {
+	return []string{"help", "version", "list-admin-users", "migrate", "admin-reset-token"}
+}
+
+// RunFnctl runs `fnctl args...` in the app container and returns its
+// stdout. args[0] must be one of Config.FnctlCommands.
+func (m *Manager) RunFnctl(ctx context.Context, args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%w: no subcommand given", ErrDisallowedCommand)
+	}
+	if !slices.Contains(m.config.FnctlCommands, args[0]) {
+		return "", fmt.Errorf("%w: %q (allowed: %v)", ErrDisallowedCommand, args[0], m.config.FnctlCommands)
+	}
+	stdout, stderr, err := m.run(ctx, append([]string{m.config.Paths.BinaryPath}, args...)...)
+	if err != nil {
+		return stdout, fnctlError("fnctl "+args[0]+" failed", stderr, err)
+	}
+	return stdout, nil
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunFnctl_Allowed(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "fnctl 1.4.2\n"
+
+	out, err := mgr.RunFnctl(context.Background(), "version")
+	if err != nil {
+		t.Fatalf("RunFnctl returned error: %v", err)
+	}
+	if out != "fnctl 1.4.2\n" {
+		t.Errorf("output = %q", out)
+	}
+	if !reflect.DeepEqual(fe.cmds, [][]string{{"/app/fnctl", "version"}}) {
+		t.Errorf("unexpected commands: %v", fe.cmds)
+	}
+}
+
+func TestRunFnctl_BlocksDisallowed(t *testing.T) {
+	mgr, fe := makeFakeManager()
+
+	for _, args := range [][]string{{"drop-database"}, {"delete-admin-user", "admin@company.com"}, {}} {
+		if _, err := mgr.RunFnctl(context.Background(), args...); !errors.Is(err, ErrDisallowedCommand) {
+			t.Errorf("RunFnctl(%v) error = %v, want ErrDisallowedCommand", args, err)
+		}
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("disallowed commands must not reach the executor, got %v", fe.cmds)
+	}
+}
+
+func TestRunFnctl_ExtendedAllowlist(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.config.FnctlCommands = append(mgr.config.FnctlCommands, "reindex")
+
+	if _, err := mgr.RunFnctl(context.Background(), "reindex", "--all"); err != nil {
+		t.Fatalf("RunFnctl returned error: %v", err)
+	}
+	if !reflect.DeepEqual(fe.cmds, [][]string{{"/app/fnctl", "reindex", "--all"}}) {
+		t.Errorf("unexpected commands: %v", fe.cmds)
+	}
+}
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+)
+
+// DataManifestName is the first entry of a data bundle written by
+// `fnctl export-data`.
+const DataManifestName = "manifest.json"
+
+// containerImportDir is where ImportData stages a bundle for fnctl: the
+// imports directory under storage, which the app container mounts at
+// /app/storage.
+const containerImportDir = "/app/storage/imports"
+
+var (
+	// ErrInvalidDataBundle is returned by ImportData for a file that is not a
+	// data bundle or whose manifest is unreadable.
+	ErrInvalidDataBundle = errors.New("invalid data bundle")
+
+	// ErrDataVersionMismatch is returned by ImportData, before anything is
+	// changed, when the bundle was exported by a newer app than the one
+	// installed or either version cannot be compared.
+	ErrDataVersionMismatch = errors.New("data bundle does not match the installed version")
+)
+
+// DataManifest describes a bundle of analytics data exported from another
+// instance.
+type DataManifest struct {
+	SchemaVersion string `json:"schema_version"` // App version whose schema the data uses, e.g. "1.4.0"
+}
+
+// SetVersionFunc sets how ImportData reads the installed app version; nil
+// uses docker.Stack.InstalledVersion.
+func (m *Manager) SetVersionFunc(fn func(ctx context.Context) (string, error)) {
+	m.version = fn
+}
+
+// ImportData loads the data bundle at sourcePath, exported from another
+// instance with `fnctl export-data`, into this one. The bundle's schema
+// version must match the installed app or be older, in which case the
+// imported data is migrated forward; a newer bundle aborts with
+// ErrDataVersionMismatch and asks for an update first. A safety backup of
+// this instance's database is written before `fnctl import-data` runs, as
+// for ResetDatabase.
+func (m *Manager) ImportData(ctx context.Context, sourcePath string) error {
+	manifest, err := readDataManifest(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	version := m.version
+	if version == nil {
+		stack := docker.NewStack(m.logger, executor.Default())
+		stack.SetNames(m.config.Names)
+		version = stack.InstalledVersion
+	}
+	installed, err := version(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read installed version: %w", err)
+	}
+	older, err := checkDataVersion(manifest.SchemaVersion, installed)
+	if err != nil {
+		return err
+	}
+
+	hostDir := filepath.Join(m.config.Paths.DataDir, "storage", "imports")
+	staged := filepath.Join(hostDir, filepath.Base(sourcePath))
+	if err := stageFile(sourcePath, staged); err != nil {
+		return err
+	}
+	defer os.Remove(staged)
+
+	backup := m.backup
+	if backup == nil {
+		backup = m.safetyBackup
+	}
+	path, err := backup(ctx)
+	if err != nil {
+		return fmt.Errorf("safety backup failed, nothing imported: %w", err)
+	}
+	m.logger.Info("Safety backup written to %s", path)
+
+	m.logger.InfoWithTime("Importing data exported by Fusionaly %s", manifest.SchemaVersion)
+	if _, stderr, err := m.runWithTimeout(ctx, m.config.MigrateTimeout, m.config.Paths.BinaryPath, "import-data", containerImportDir+"/"+filepath.Base(staged)); err != nil {
+		return fnctlError("failed to import data", stderr, fmt.Errorf("%w; restore %s to undo a partial import", err, path))
+	}
+	if older {
+		if err := m.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+	m.logger.Success("Imported data from %s", sourcePath)
+	return nil
+}
+
+// readDataManifest reads the manifest at the head of the gzipped tar
+// bundle at path.
+func readDataManifest(path string) (DataManifest, error) {
+	var manifest DataManifest
+	f, err := os.Open(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to open data bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return manifest, fmt.Errorf("%w %s: not a gzip archive", ErrInvalidDataBundle, path)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != DataManifestName {
+		return manifest, fmt.Errorf("%w %s: %s must be the first entry", ErrInvalidDataBundle, path, DataManifestName)
+	}
+	if err := json.NewDecoder(io.LimitReader(tr, 1<<20)).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("%w %s: unreadable manifest: %v", ErrInvalidDataBundle, path, err)
+	}
+	if manifest.SchemaVersion == "" {
+		return manifest, fmt.Errorf("%w %s: manifest has no schema_version", ErrInvalidDataBundle, path)
+	}
+	return manifest, nil
+}
+
+// releaseVersion matches the versions checkDataVersion can compare, e.g.
+// "1.4.0" or "v1.4".
+var releaseVersion = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?$`)
+
+// checkDataVersion accepts data exported by the installed version or an
+// older one, reporting which. Anything else fails with guidance.
+func checkDataVersion(bundle, installed string) (older bool, err error) {
+	b, ok := parseVersion(bundle)
+	if !ok {
+		return false, fmt.Errorf("%w: bundle schema version %q is not a release version", ErrDataVersionMismatch, bundle)
+	}
+	i, ok := parseVersion(installed)
+	if !ok {
+		return false, fmt.Errorf("%w: installed version %q cannot be compared; pin a release with 'fusionaly update --version %s' and retry", ErrDataVersionMismatch, installed, bundle)
+	}
+	for n := range b {
+		if b[n] > i[n] {
+			return false, fmt.Errorf("%w: the bundle was exported by Fusionaly %s but %s is installed; run 'fusionaly update --version %s' first, then retry the import", ErrDataVersionMismatch, bundle, installed, bundle)
+		}
+		if b[n] < i[n] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseVersion splits a release version into major, minor and patch.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	match := releaseVersion.FindStringSubmatch(v)
+	if match == nil {
+		return parts, false
+	}
+	for n, s := range match[1:] {
+		if s != "" {
+			parts[n], _ = strconv.Atoi(s)
+		}
+	}
+	return parts, true
+}
+
+// stageFile copies src to dst, creating dst's directory, so the app
+// container can read it.
+func stageFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return fmt.Errorf("failed to create import directory: %w", err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open data bundle: %w", err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to stage data bundle: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to stage data bundle: %w", err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+)
+
+// writeDataBundle writes a data bundle whose manifest is manifest.
+func writeDataBundle(t *testing.T, manifest string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "peer-export.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, entry := range []struct{ name, body string }{
+		{DataManifestName, manifest},
+		{"events.ndjson", `{"site":1,"path":"/"}` + "\n"},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o600, Size: int64(len(entry.body))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(entry.body))
+	}
+	tw.Close()
+	gz.Close()
+	return path
+}
+
+// newImportManager returns a reset-style Manager reporting installed as the
+// app version.
+func newImportManager(t *testing.T, installed string) (*Manager, *fakeExecutor, *[]string) {
+	t.Helper()
+	mgr, fe, backups := newResetManager(t, "")
+	mgr.SetVersionFunc(func(ctx context.Context) (string, error) { return installed, nil })
+	return mgr, fe, backups
+}
+
+func TestCheckDataVersion(t *testing.T) {
+	for _, tc := range []struct {
+		bundle, installed string
+		older, ok         bool
+	}{
+		{"1.4.0", "1.4.0", false, true},
+		{"v1.4", "1.4.0", false, true},
+		{"1.3.9", "1.4.0", true, true},
+		{"0.9.0", "1.0.0", true, true},
+		{"1.4.1", "1.4.0", false, false},
+		{"2.0.0", "1.9.9", false, false},
+		{"1.4.0", "latest", false, false},
+		{"nightly", "1.4.0", false, false},
+	} {
+		older, err := checkDataVersion(tc.bundle, tc.installed)
+		if tc.ok != (err == nil) || older != tc.older {
+			t.Errorf("checkDataVersion(%q, %q) = %v, %v; want older=%v ok=%v", tc.bundle, tc.installed, older, err, tc.older, tc.ok)
+		}
+		if err != nil && !errors.Is(err, ErrDataVersionMismatch) {
+			t.Errorf("checkDataVersion(%q, %q): %v does not match ErrDataVersionMismatch", tc.bundle, tc.installed, err)
+		}
+	}
+}
+
+func TestImportData_SameVersion(t *testing.T) {
+	mgr, fe, backups := newImportManager(t, "1.4.0")
+	source := writeDataBundle(t, `{"schema_version":"1.4.0"}`)
+
+	if err := mgr.ImportData(context.Background(), source); err != nil {
+		t.Fatalf("ImportData: %v", err)
+	}
+	want := [][]string{{"/app/fnctl", "import-data", "/app/storage/imports/peer-export.tar.gz"}}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands = %v, want %v", fe.cmds, want)
+	}
+	if len(*backups) != 1 {
+		t.Errorf("expected one safety backup, got %v", *backups)
+	}
+	if _, err := os.Stat(filepath.Join(mgr.config.Paths.DataDir, "storage", "imports", "peer-export.tar.gz")); !os.IsNotExist(err) {
+		t.Errorf("the staged bundle should be removed after the import, got %v", err)
+	}
+}
+
+func TestImportData_OlderBundleIsMigrated(t *testing.T) {
+	mgr, fe, _ := newImportManager(t, "1.4.0")
+	source := writeDataBundle(t, `{"schema_version":"1.2.3"}`)
+
+	if err := mgr.ImportData(context.Background(), source); err != nil {
+		t.Fatalf("ImportData: %v", err)
+	}
+	want := [][]string{
+		{"/app/fnctl", "import-data", "/app/storage/imports/peer-export.tar.gz"},
+		{"/app/fnctl", "migrate"},
+	}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands = %v, want %v", fe.cmds, want)
+	}
+}
+
+func TestImportData_NewerBundleAborts(t *testing.T) {
+	mgr, fe, backups := newImportManager(t, "1.4.0")
+	source := writeDataBundle(t, `{"schema_version":"1.5.0"}`)
+
+	err := mgr.ImportData(context.Background(), source)
+	if !errors.Is(err, ErrDataVersionMismatch) {
+		t.Fatalf("expected ErrDataVersionMismatch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "fusionaly update --version 1.5.0") {
+		t.Errorf("error should say how to fix it, got %q", err)
+	}
+	if len(fe.cmds) != 0 || len(*backups) != 0 {
+		t.Errorf("nothing should run on a mismatch, got commands %v and backups %v", fe.cmds, *backups)
+	}
+}
+
+func TestImportData_InvalidBundle(t *testing.T) {
+	mgr, fe, _ := newImportManager(t, "1.4.0")
+
+	notGzip := filepath.Join(t.TempDir(), "export.tar.gz")
+	os.WriteFile(notGzip, []byte("plain text"), 0o600)
+	for name, source := range map[string]string{
+		"not gzip":         notGzip,
+		"no version":       writeDataBundle(t, `{}`),
+		"garbled manifest": writeDataBundle(t, `{"schema_version":`),
+	} {
+		if err := mgr.ImportData(context.Background(), source); !errors.Is(err, ErrInvalidDataBundle) {
+			t.Errorf("%s: expected ErrInvalidDataBundle, got %v", name, err)
+		}
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("nothing should run for an invalid bundle, got %v", fe.cmds)
+	}
+}
+
+func TestImportData_BacksUpSelectedInstance(t *testing.T) {
+	mgr, fe, _ := newImportManager(t, "1.4.0")
+	mgr.backup = nil
+	mgr.config.Names = docker.NamesFor("fusionaly-staging")
+	de := &dumpExecutor{running: "fusionaly-app-2\nfusionaly-staging-app-2\n"}
+	orig := executor.Default()
+	t.Cleanup(func() { executor.SetDefault(orig) })
+	executor.SetDefault(de)
+
+	if err := mgr.ImportData(context.Background(), writeDataBundle(t, `{"schema_version":"1.4.0"}`)); err != nil {
+		t.Fatalf("ImportData: %v", err)
+	}
+	want := []string{"docker", "exec", "fusionaly-staging-app-2", "sqlite3", "/app/storage/fusionaly-production.db", ".dump"}
+	if len(de.cmds) != 2 || !reflect.DeepEqual(de.cmds[1], want) {
+		t.Errorf("safety backup commands = %v, want a dump of the selected instance %v", de.cmds, want)
+	}
+	if len(fe.cmds) != 1 {
+		t.Errorf("expected the import to run after the backup, got %v", fe.cmds)
+	}
+}
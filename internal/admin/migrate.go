@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// migrateSummary matches fnctl's summary line in either word order, e.g.
+	// "Applied 3 migrations" or "3 migrations applied".
+	migrateSummary = regexp.MustCompile(`(?i)\b(?:applied\s+(\d+)\s+migrations?|(\d+)\s+migrations?\s+applied)\b`)
+	// migrateUpToDate matches the messages printed when nothing was pending.
+	migrateUpToDate = regexp.MustCompile(`(?i)\b(?:no\s+pending\s+migrations|no\s+migrations\s+to\s+apply|already\s+up[\s-]to[\s-]date|nothing\s+to\s+migrate)\b`)
+	// migrateStep matches the per-migration lines printed when there is no
+	// summary, e.g. "Applied migration 0007_add_goals" or "-> applied 0007".
+	migrateStep = regexp.MustCompile(`(?i)^\W*applied\s+(?:migration\s+)?\S+`)
+)
+
+// Migrate runs the app's pending schema migrations with `fnctl migrate` and
+// logs how many were applied. Running it on an up-to-date database is a
+// no-op that reports there were no pending migrations.
+func (m *Manager) Migrate(ctx context.Context) error {
+	m.logger.InfoWithTime("Running database migrations")
+	stdout, stderr, err := m.runWithTimeout(ctx, m.config.MigrateTimeout, m.config.Paths.BinaryPath, "migrate")
+	if err != nil {
+		return fnctlError("failed to run migrations", stderr, err)
+	}
+
+	applied, ok := parseMigrateOutput(stdout)
+	switch {
+	case !ok:
+		m.logger.Success("Migrations finished")
+		m.logger.Debug("Unrecognised fnctl migrate output: %s", strings.TrimSpace(stdout))
+	case applied == 0:
+		m.logger.Success("No pending migrations")
+	default:
+		m.logger.Success("Applied %d migration(s)", applied)
+	}
+	return nil
+}
+
+// parseMigrateOutput returns the number of migrations `fnctl migrate`
+// reported applying. ok is false when the output matches none of the known
+// formats.
+func parseMigrateOutput(output string) (applied int, ok bool) {
+	if match := migrateSummary.FindStringSubmatch(output); match != nil {
+		n := match[1]
+		if n == "" {
+			n = match[2]
+		}
+		applied, err := strconv.Atoi(n)
+		return applied, err == nil
+	}
+	if migrateUpToDate.MatchString(output) {
+		return 0, true
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if migrateStep.MatchString(line) {
+			applied++
+		}
+	}
+	return applied, applied > 0
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMigrateOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		applied int
+		ok      bool
+	}{
+		{"summary", "Running migrations...\nApplied 3 migrations\n", 3, true},
+		{"summary reversed", "1 migration applied in 120ms\n", 1, true},
+		{"per-migration lines", "applied migration 0007_add_goals\napplied migration 0008_index_events\n", 2, true},
+		{"none pending", "No pending migrations\n", 0, true},
+		{"up to date", "Database is already up to date.\n", 0, true},
+		{"unknown", "done\n", 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied, ok := parseMigrateOutput(tt.output)
+			if applied != tt.applied || ok != tt.ok {
+				t.Errorf("parseMigrateOutput(%q) = %d, %v; want %d, %v", tt.output, applied, ok, tt.applied, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMigrate_Applied(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "Applied 2 migrations\n"
+
+	if err := mgr.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if !reflect.DeepEqual(fe.cmds, [][]string{{"/app/fnctl", "migrate"}}) {
+		t.Errorf("unexpected commands: %v", fe.cmds)
+	}
+}
+
+func TestMigrate_NonePending(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "No pending migrations\n"
+
+	if err := mgr.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate should succeed on an up-to-date database: %v", err)
+	}
+	if len(fe.cmds) != 1 {
+		t.Errorf("expected a single fnctl call, got %v", fe.cmds)
+	}
+}
+
+func TestMigrate_SurfacesFnctlError(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.config.Retry.MaxAttempts = 1
+	fe.failFirst = 1
+	fe.stderr = "migration 0009 failed: duplicate column"
+
+	err := mgr.Migrate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "duplicate column") {
+		t.Fatalf("expected fnctl stderr in error, got: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrWeakPassword is matched (via errors.Is) by every WeakPasswordError.
+var ErrWeakPassword = errors.New("password does not meet policy")
+
+// PasswordPolicy describes the minimum strength required for admin passwords.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireDigit  bool
+	RequireUpper  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy returns the policy applied by the CLI.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength: 12,
+	}
+}
+
+// WeakPasswordError lists every policy rule a password failed.
+type WeakPasswordError struct {
+	Failed []string
+}
+
+func (e *WeakPasswordError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(e.Failed, ", "))
+}
+
+func (e *WeakPasswordError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// Check returns a *WeakPasswordError describing all failed rules, or nil.
+func (p PasswordPolicy) Check(password string) error {
+	var hasDigit, hasUpper, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var failed []string
+	if len([]rune(password)) < p.MinLength {
+		failed = append(failed, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.RequireDigit && !hasDigit {
+		failed = append(failed, "must contain a digit")
+	}
+	if p.RequireUpper && !hasUpper {
+		failed = append(failed, "must contain an uppercase letter")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		failed = append(failed, "must contain a symbol")
+	}
+
+	if len(failed) > 0 {
+		return &WeakPasswordError{Failed: failed}
+	}
+	return nil
+}
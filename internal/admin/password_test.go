@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Rules(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 12, RequireDigit: true, RequireUpper: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		failed   []string
+	}{
+		{"default accepts long password", DefaultPasswordPolicy(), "correcthorsebattery", nil},
+		{"min length", PasswordPolicy{MinLength: 12}, "short", []string{"must be at least 12 characters"}},
+		{"require digit", PasswordPolicy{RequireDigit: true}, "NoDigitsHere!", []string{"must contain a digit"}},
+		{"require upper", PasswordPolicy{RequireUpper: true}, "lowercase123!", []string{"must contain an uppercase letter"}},
+		{"require symbol", PasswordPolicy{RequireSymbol: true}, "NoSymbols123", []string{"must contain a symbol"}},
+		{"strict accepts compliant password", strict, "Sup3r-Secret-Pass", nil},
+		{"strict lists every failure", strict, "weak", []string{
+			"must be at least 12 characters",
+			"must contain a digit",
+			"must contain an uppercase letter",
+			"must contain a symbol",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.password)
+			if tt.failed == nil {
+				if err != nil {
+					t.Fatalf("expected password to pass, got: %v", err)
+				}
+				return
+			}
+
+			var weak *WeakPasswordError
+			if !errors.As(err, &weak) {
+				t.Fatalf("expected *WeakPasswordError, got: %v", err)
+			}
+			if !errors.Is(err, ErrWeakPassword) {
+				t.Errorf("expected errors.Is(err, ErrWeakPassword)")
+			}
+			if strings.Join(weak.Failed, "|") != strings.Join(tt.failed, "|") {
+				t.Errorf("failed rules = %v, want %v", weak.Failed, tt.failed)
+			}
+			for _, rule := range tt.failed {
+				if !strings.Contains(err.Error(), rule) {
+					t.Errorf("error message %q missing rule %q", err.Error(), rule)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerRejectsWeakPassword(t *testing.T) {
+	mgr, fe := makeFakeManager()
+
+	if err := mgr.CreateAdminUser("admin@company.com", "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("CreateAdminUser: expected ErrWeakPassword, got: %v", err)
+	}
+	if err := mgr.ChangeAdminPassword("admin@company.com", "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("ChangeAdminPassword: expected ErrWeakPassword, got: %v", err)
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("executor should not be invoked for weak passwords, got: %v", fe.cmds)
+	}
+}
+
+func TestManagerSetPasswordPolicy(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.SetPasswordPolicy(PasswordPolicy{MinLength: 4})
+
+	if err := mgr.CreateAdminUser("admin@company.com", "abcd"); err != nil {
+		t.Fatalf("expected relaxed policy to accept password, got: %v", err)
+	}
+	if len(fe.cmds) != 1 {
+		t.Errorf("expected 1 command, got %d", len(fe.cmds))
+	}
+	if got := mgr.PasswordPolicy().MinLength; got != 4 {
+		t.Errorf("PasswordPolicy().MinLength = %d, want 4", got)
+	}
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrPasswordMismatch is returned when the confirmation does not match the
+// first password entered.
+var ErrPasswordMismatch = errors.New("passwords do not match")
+
+// PromptAdminPassword asks for a password twice and returns it once both
+// entries match. When in is a terminal the input is not echoed; otherwise each
+// entry is read as a line, which lets tests and scripts pipe input in.
+func PromptAdminPassword(in io.Reader, out io.Writer) (string, error) {
+	read := lineReader(in, out)
+
+	fmt.Fprint(out, "Enter admin password: ")
+	password, err := read()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	fmt.Fprint(out, "Confirm admin password: ")
+	confirm, err := read()
+	if err != nil {
+		return "", fmt.Errorf("failed to read confirmation password: %w", err)
+	}
+
+	if password != confirm {
+		return "", ErrPasswordMismatch
+	}
+	return password, nil
+}
+
+// CreateAdminUserInteractive prompts for the admin password on in/out and then
+// creates the user exactly like CreateAdminUser.
+func (m *Manager) CreateAdminUserInteractive(email string, in io.Reader, out io.Writer) error {
+	return m.CreateAdminUserInteractiveContext(context.Background(), email, in, out)
+}
+
+// CreateAdminUserInteractiveContext is like CreateAdminUserInteractive but aborts when ctx is done.
+func (m *Manager) CreateAdminUserInteractiveContext(ctx context.Context, email string, in io.Reader, out io.Writer) error {
+	if err := checkEmail(email); err != nil {
+		return err
+	}
+	password, err := PromptAdminPassword(in, out)
+	if err != nil {
+		return err
+	}
+	return m.CreateAdminUserContext(ctx, email, password)
+}
+
+// lineReader returns a function reading one secret per call from in. Terminal
+// input is read without echo; anything else is read line by line from a single
+// buffered reader so consecutive calls don't lose data.
+func lineReader(in io.Reader, out io.Writer) func() (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return func() (string, error) {
+			b, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(out)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(b)), nil
+		}
+	}
+
+	reader := bufio.NewReader(in)
+	return func() (string, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPromptAdminPasswordMatching(t *testing.T) {
+	var out bytes.Buffer
+	got, err := PromptAdminPassword(strings.NewReader("correct-horse-battery\ncorrect-horse-battery\n"), &out)
+	if err != nil {
+		t.Fatalf("PromptAdminPassword returned error: %v", err)
+	}
+	if got != "correct-horse-battery" {
+		t.Errorf("password = %q, want %q", got, "correct-horse-battery")
+	}
+	if !strings.Contains(out.String(), "Confirm admin password") {
+		t.Errorf("expected confirmation prompt, got %q", out.String())
+	}
+}
+
+func TestPromptAdminPasswordMismatch(t *testing.T) {
+	_, err := PromptAdminPassword(strings.NewReader("correct-horse-battery\nwrong-horse-battery\n"), &bytes.Buffer{})
+	if !errors.Is(err, ErrPasswordMismatch) {
+		t.Fatalf("expected ErrPasswordMismatch, got %v", err)
+	}
+}
+
+func TestPromptAdminPasswordMissingConfirmation(t *testing.T) {
+	_, err := PromptAdminPassword(strings.NewReader("correct-horse-battery\n"), &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected error when confirmation is missing")
+	}
+}
+
+func TestCreateAdminUserInteractive(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	in := strings.NewReader("correct-horse-battery\ncorrect-horse-battery\n")
+
+	if err := mgr.CreateAdminUserInteractive("admin@company.com", in, &bytes.Buffer{}); err != nil {
+		t.Fatalf("CreateAdminUserInteractive returned error: %v", err)
+	}
+	if len(fe.cmds) != 1 || fe.cmds[0][3] != "correct-horse-battery" {
+		t.Fatalf("unexpected commands: %v", fe.cmds)
+	}
+}
+
+func TestCreateAdminUserInteractiveMismatchSkipsExecutor(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	in := strings.NewReader("correct-horse-battery\nwrong-horse-battery\n")
+
+	err := mgr.CreateAdminUserInteractive("admin@company.com", in, &bytes.Buffer{})
+	if !errors.Is(err, ErrPasswordMismatch) {
+		t.Fatalf("expected ErrPasswordMismatch, got %v", err)
+	}
+	if len(fe.cmds) != 0 {
+		t.Fatalf("executor should not run on mismatch, got %v", fe.cmds)
+	}
+}
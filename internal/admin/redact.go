@@ -0,0 +1,52 @@
+package admin
+
+import "strings"
+
+// redactedValue replaces secret arguments in log output.
+const redactedValue = "****"
+
+// secretArg is a fnctl argument that carries a secret.
+type secretArg struct {
+	pos  int    // Position counted after the subcommand itself
+	name string // Variable it is written as by a Config.Secrets recorder
+}
+
+// secretArgs maps fnctl subcommands to their arguments that carry secrets.
+// Any new subcommand that accepts a password or token must be registered
+// here so it is never logged or recorded.
+var secretArgs = map[string][]secretArg{
+	"create-admin-user":     {{1, "ADMIN_PASSWORD"}},
+	"change-admin-password": {{1, "ADMIN_PASSWORD"}},
+	"admin-reset-password":  {{0, "ADMIN_RESET_TOKEN"}, {1, "ADMIN_PASSWORD"}},
+}
+
+// redactCommand renders a fnctl command line for logging with every
+// registered secret argument masked.
+func redactCommand(args []string) string {
+	if len(args) < 2 {
+		return strings.Join(args, " ")
+	}
+
+	masked := make([]string, len(args))
+	copy(masked, args)
+	for _, arg := range secretArgs[args[1]] {
+		if i := arg.pos + 2; i < len(masked) {
+			masked[i] = redactedValue
+		}
+	}
+	return strings.Join(masked, " ")
+}
+
+// registerSecrets hands the registered secret arguments of a fnctl command
+// line to Config.Secrets before it runs, so a script recorder or dry run
+// writes them as variables rather than in plain text.
+func (m *Manager) registerSecrets(args []string) {
+	if m.config.Secrets == nil || len(args) < 2 {
+		return
+	}
+	for _, arg := range secretArgs[args[1]] {
+		if i := arg.pos + 2; i < len(args) {
+			m.config.Secrets.AddSecret(arg.name, args[i])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+func TestRedactCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"/app/fnctl", "create-admin-user", "a@b.com", "s3cret"}, "/app/fnctl create-admin-user a@b.com ****"},
+		{[]string{"/app/fnctl", "change-admin-password", "a@b.com", "s3cret"}, "/app/fnctl change-admin-password a@b.com ****"},
+		{[]string{"/app/fnctl", "admin-reset-password", "tok3n", "s3cret"}, "/app/fnctl admin-reset-password **** ****"},
+		{[]string{"/app/fnctl", "list-admin-users"}, "/app/fnctl list-admin-users"},
+		{[]string{"/app/fnctl", "create-admin-user", "a@b.com"}, "/app/fnctl create-admin-user a@b.com"},
+		{[]string{"/app/fnctl"}, "/app/fnctl"},
+	}
+	for _, tt := range tests {
+		if got := redactCommand(tt.args); got != tt.want {
+			t.Errorf("redactCommand(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestManagerNeverLogsPlaintextPassword(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogger(logging.Config{Level: "debug"})
+	logger.SetOutput(&buf)
+
+	fe := &fakeExecutor{}
+	mgr := newManagerWithExecutor(logger, fe, config.DefaultInstallPaths())
+	password := "Plaintext-Secret-42"
+
+	if err := mgr.CreateAdminUser("admin@company.com", password); err != nil {
+		t.Fatalf("CreateAdminUser returned error: %v", err)
+	}
+	if err := mgr.ChangeAdminPassword("admin@company.com", password); err != nil {
+		t.Fatalf("ChangeAdminPassword returned error: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, password) {
+		t.Fatalf("log output leaked the password:\n%s", out)
+	}
+	if !strings.Contains(out, "create-admin-user admin@company.com ****") {
+		t.Errorf("expected redacted command in log output, got:\n%s", out)
+	}
+	if fe.cmds[0][3] != password {
+		t.Errorf("executor must receive the real password, got %q", fe.cmds[0][3])
+	}
+}
+
+// runningDocker answers every docker command as if the app were running.
+type runningDocker struct{}
+
+func (runningDocker) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	return executor.Result{Stdout: "fusionaly-app-1\n"}, nil
+}
+
+func TestManagerNeverRecordsPlaintextSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.sh")
+	recorder := executor.NewScriptRecordingExecutor(runningDocker{}, path)
+	orig := executor.Default()
+	t.Cleanup(func() { executor.SetDefault(orig) })
+	executor.SetDefault(recorder)
+
+	cfg := DefaultConfig()
+	cfg.HealthTimeout = 0
+	cfg.Secrets = recorder
+	mgr := NewManager(logging.NewLogger(logging.Config{Level: "error"}), cfg)
+	password, token := "SuperSecretPass123", "reset-tok3n-abc"
+
+	if err := mgr.CreateAdminUser("a@b.com", password); err != nil {
+		t.Fatalf("CreateAdminUser: %v", err)
+	}
+	if err := mgr.ChangeAdminPassword("a@b.com", password+"x"); err != nil {
+		t.Fatalf("ChangeAdminPassword: %v", err)
+	}
+	if err := mgr.ResetAdminPasswordWithToken(token, password); err != nil {
+		t.Fatalf("ResetAdminPasswordWithToken: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := string(data)
+	for _, secret := range []string{password, token} {
+		if strings.Contains(script, secret) {
+			t.Errorf("script leaks %q:\n%s", secret, script)
+		}
+	}
+	for _, want := range []string{
+		`/app/fnctl create-admin-user a@b.com "${ADMIN_PASSWORD}"`,
+		`/app/fnctl change-admin-password a@b.com "${ADMIN_PASSWORD_2}"`,
+		`/app/fnctl admin-reset-password "${ADMIN_RESET_TOKEN}" "${ADMIN_PASSWORD}"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script should contain %s, got:\n%s", want, script)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidResetToken is returned when fnctl printed no token, or before
+// any command runs when the token supplied for a reset is empty.
+var ErrInvalidResetToken = errors.New("invalid admin reset token")
+
+// GenerateAdminResetToken asks fnctl for a one-time token that lets an
+// operator who lost the admin password set a new one with
+// ResetAdminPasswordWithToken. The token is returned rather than mailed so
+// recovery works without SMTP; callers print it on the server console.
+func (m *Manager) GenerateAdminResetToken(email string) (string, error) {
+	return m.GenerateAdminResetTokenContext(context.Background(), email)
+}
+
+// GenerateAdminResetTokenContext is like GenerateAdminResetToken but aborts when ctx is done.
+func (m *Manager) GenerateAdminResetTokenContext(ctx context.Context, email string) (string, error) {
+	if err := checkEmail(email); err != nil {
+		return "", err
+	}
+	m.logger.InfoWithTime("Generating admin reset token for %s", email)
+	stdout, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "admin-reset-token", email)
+	if err != nil {
+		return "", fnctlError("failed to generate reset token", stderr, err)
+	}
+	token := parseResetToken(stdout)
+	if token == "" {
+		return "", ErrInvalidResetToken
+	}
+	return token, nil
+}
+
+// ResetAdminPasswordWithToken sets a new admin password using a token from
+// GenerateAdminResetToken. fnctl rejects unknown, used or expired tokens.
+func (m *Manager) ResetAdminPasswordWithToken(token, newPassword string) error {
+	return m.ResetAdminPasswordWithTokenContext(context.Background(), token, newPassword)
+}
+
+// ResetAdminPasswordWithTokenContext is like ResetAdminPasswordWithToken but aborts when ctx is done.
+func (m *Manager) ResetAdminPasswordWithTokenContext(ctx context.Context, token, newPassword string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if err := m.config.PasswordPolicy.Check(newPassword); err != nil {
+		return err
+	}
+	m.logger.InfoWithTime("Resetting admin password with token")
+	_, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "admin-reset-password", token, newPassword)
+	if err != nil {
+		return fnctlError("failed to reset admin password", stderr, err)
+	}
+	m.logger.Success("Admin password reset")
+	return nil
+}
+
+// parseResetToken extracts the token from `fnctl admin-reset-token` output:
+// the last non-empty line, with an optional "Token:" label removed.
+func parseResetToken(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if i := strings.Index(last, ":"); i >= 0 && strings.EqualFold(strings.TrimSpace(last[:i]), "token") {
+		last = strings.TrimSpace(last[i+1:])
+	}
+	if strings.ContainsAny(last, " \t") {
+		return ""
+	}
+	return last
+}
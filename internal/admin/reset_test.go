@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/logging"
+)
+
+func TestGenerateAdminResetToken(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "Reset token generated, valid for 1 hour\nToken: 4f9c2a7e1b\n"
+
+	token, err := mgr.GenerateAdminResetToken("admin@company.com")
+	if err != nil {
+		t.Fatalf("GenerateAdminResetToken returned error: %v", err)
+	}
+	if token != "4f9c2a7e1b" {
+		t.Errorf("token = %q, want 4f9c2a7e1b", token)
+	}
+	want := [][]string{{"/app/fnctl", "admin-reset-token", "admin@company.com"}}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fe.cmds)
+	}
+}
+
+func TestGenerateAdminResetToken_NoToken(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.stdout = "\n"
+
+	if _, err := mgr.GenerateAdminResetToken("admin@company.com"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("expected ErrInvalidResetToken, got %v", err)
+	}
+}
+
+func TestResetAdminPasswordWithToken(t *testing.T) {
+	mgr, fe := makeFakeManager()
+
+	if err := mgr.ResetAdminPasswordWithToken("4f9c2a7e1b", "NewSecurePassword1"); err != nil {
+		t.Fatalf("ResetAdminPasswordWithToken returned error: %v", err)
+	}
+	want := [][]string{{"/app/fnctl", "admin-reset-password", "4f9c2a7e1b", "NewSecurePassword1"}}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fe.cmds)
+	}
+}
+
+func TestResetAdminPasswordWithToken_ValidatesBeforeRunning(t *testing.T) {
+	mgr, fe := makeFakeManager()
+
+	if err := mgr.ResetAdminPasswordWithToken("  ", "NewSecurePassword1"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("expected ErrInvalidResetToken, got %v", err)
+	}
+	if err := mgr.ResetAdminPasswordWithToken("4f9c2a7e1b", "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("expected ErrWeakPassword, got %v", err)
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("fnctl should not run for invalid input, got %v", fe.cmds)
+	}
+}
+
+func TestResetAdminPasswordWithToken_NeverLogsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogger(logging.Config{Level: "debug"})
+	logger.SetOutput(&buf)
+	mgr := newManagerWithExecutor(logger, &fakeExecutor{}, config.DefaultInstallPaths())
+
+	if err := mgr.ResetAdminPasswordWithToken("4f9c2a7e1b", "NewSecurePassword1"); err != nil {
+		t.Fatalf("ResetAdminPasswordWithToken returned error: %v", err)
+	}
+	for _, secret := range []string{"4f9c2a7e1b", "NewSecurePassword1"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("log output contains %q:\n%s", secret, buf.String())
+		}
+	}
+}
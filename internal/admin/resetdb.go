@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/database"
+	"fusionaly-installer/internal/docker"
+)
+
+var (
+	// ErrResetNotForced is returned by ResetDatabase, before anything runs,
+	// when force is not set.
+	ErrResetNotForced = errors.New("resetting the database deletes all its data; pass --force to confirm")
+
+	// ErrProductionReset is returned by ResetDatabase for an installation
+	// marked production when the extra confirmation is missing or declined.
+	ErrProductionReset = errors.New("refusing to reset a production database")
+)
+
+// ConfirmFunc asks the operator a yes/no question.
+type ConfirmFunc func(prompt string) (bool, error)
+
+// SetConfirmFunc sets how ResetDatabase asks for the extra confirmation a
+// production installation needs; nil refuses such resets.
+func (m *Manager) SetConfirmFunc(fn ConfirmFunc) {
+	m.confirm = fn
+}
+
+// ResetDatabase wipes the app's database back to an empty schema, for test
+// and staging installations. Without force it refuses with
+// ErrResetNotForced. An installation marked production (FUSIONALY_ENV in its
+// .env) also needs the confirmation set with SetConfirmFunc, failing with
+// ErrProductionReset otherwise. A backup is written first so the data can be
+// restored; then `fnctl reset-db` drops every table and `fnctl migrate`
+// recreates the schema.
+func (m *Manager) ResetDatabase(ctx context.Context, force bool) error {
+	if !force {
+		return ErrResetNotForced
+	}
+	production, err := m.production()
+	if err != nil {
+		return err
+	}
+	if production {
+		if m.confirm == nil {
+			return fmt.Errorf("%w: no confirmation possible without a terminal", ErrProductionReset)
+		}
+		ok, err := m.confirm("This installation is marked production. Delete all its data anyway?")
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		if !ok {
+			return ErrProductionReset
+		}
+	}
+
+	backup := m.backup
+	if backup == nil {
+		backup = m.safetyBackup
+	}
+	path, err := backup(ctx)
+	if err != nil {
+		return fmt.Errorf("safety backup failed, database left untouched: %w", err)
+	}
+	m.logger.Info("Safety backup written to %s", path)
+
+	if _, stderr, err := m.run(ctx, m.config.Paths.BinaryPath, "reset-db"); err != nil {
+		return fnctlError("failed to reset database", stderr, err)
+	}
+	if err := m.Migrate(ctx); err != nil {
+		return err
+	}
+	m.logger.Success("Database reset; restore %s to get the previous data back", path)
+	return nil
+}
+
+// production reports whether the installation's .env marks it production.
+// A missing .env is not production.
+func (m *Manager) production() (bool, error) {
+	env, err := config.LoadEnvFile(m.config.Paths.EnvFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", m.config.Paths.EnvFile, err)
+	}
+	environment, _ := env.Get("FUSIONALY_ENV")
+	return config.ConfigData{Environment: environment}.IsProduction(), nil
+}
+
+// safetyBackup dumps the configured instance's database to its BACKUP_PATH,
+// or the install's backup directory when none is set.
+func (m *Manager) safetyBackup(ctx context.Context) (string, error) {
+	dir := filepath.Join(m.config.Paths.DataDir, "storage", "backups")
+	if env, err := config.LoadEnvFile(m.config.Paths.EnvFile); err == nil {
+		if path, _ := env.Get("BACKUP_PATH"); path != "" {
+			dir = path
+		}
+	}
+	names := m.config.Names
+	if names.Project == "" {
+		names = docker.DefaultNames()
+	}
+	db := database.NewDatabase(m.logger)
+	db.SetAppContainers(names.AppPrimary, names.AppSecondary)
+	return db.Backup(ctx, dir)
+}
@@ -0,0 +1,151 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+// newResetManager returns a Manager for an installation in a temp dir whose
+// .env holds env, with the safety backup stubbed out.
+func newResetManager(t *testing.T, env string) (*Manager, *fakeExecutor, *[]string) {
+	t.Helper()
+	paths := config.PathsForDir(t.TempDir())
+	if env != "" {
+		if err := os.WriteFile(paths.EnvFile, []byte(env), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fe := &fakeExecutor{stdout: "Applied 4 migrations\n"}
+	mgr := newManagerWithExecutor(logging.NewLogger(logging.Config{Level: "error"}), fe, paths)
+	var backups []string
+	mgr.backup = func(ctx context.Context) (string, error) {
+		path := filepath.Join(paths.DataDir, "fusionaly-backup-20240101-120000.sql.gz")
+		backups = append(backups, path)
+		return path, nil
+	}
+	return mgr, fe, &backups
+}
+
+func TestResetDatabase_RefusesWithoutForce(t *testing.T) {
+	mgr, fe, backups := newResetManager(t, "FUSIONALY_ENV=staging\n")
+
+	if err := mgr.ResetDatabase(context.Background(), false); !errors.Is(err, ErrResetNotForced) {
+		t.Fatalf("expected ErrResetNotForced, got %v", err)
+	}
+	if len(fe.cmds) != 0 || len(*backups) != 0 {
+		t.Errorf("nothing should run without force, got commands %v and backups %v", fe.cmds, *backups)
+	}
+}
+
+func TestResetDatabase_BacksUpThenResets(t *testing.T) {
+	mgr, fe, backups := newResetManager(t, "FUSIONALY_ENV=staging\n")
+
+	if err := mgr.ResetDatabase(context.Background(), true); err != nil {
+		t.Fatalf("ResetDatabase: %v", err)
+	}
+	if len(*backups) != 1 {
+		t.Errorf("expected one safety backup, got %v", *backups)
+	}
+	want := [][]string{{"/app/fnctl", "reset-db"}, {"/app/fnctl", "migrate"}}
+	if !reflect.DeepEqual(fe.cmds, want) {
+		t.Errorf("commands = %v, want %v", fe.cmds, want)
+	}
+}
+
+func TestResetDatabase_FailedBackupLeavesDatabase(t *testing.T) {
+	mgr, fe, _ := newResetManager(t, "")
+	mgr.backup = func(ctx context.Context) (string, error) { return "", errors.New("no running app container found") }
+
+	if err := mgr.ResetDatabase(context.Background(), true); err == nil {
+		t.Fatal("expected the backup failure")
+	}
+	if len(fe.cmds) != 0 {
+		t.Errorf("the database must not be reset without a backup, got %v", fe.cmds)
+	}
+}
+
+func TestResetDatabase_ProductionGuard(t *testing.T) {
+	const production = "FUSIONALY_DOMAIN=analytics.example.com\nFUSIONALY_ENV=production\n"
+
+	t.Run("no confirmation possible", func(t *testing.T) {
+		mgr, fe, backups := newResetManager(t, production)
+		if err := mgr.ResetDatabase(context.Background(), true); !errors.Is(err, ErrProductionReset) {
+			t.Fatalf("expected ErrProductionReset, got %v", err)
+		}
+		if len(fe.cmds) != 0 || len(*backups) != 0 {
+			t.Errorf("nothing should run, got commands %v and backups %v", fe.cmds, *backups)
+		}
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		mgr, fe, _ := newResetManager(t, production)
+		asked := 0
+		mgr.SetConfirmFunc(func(prompt string) (bool, error) { asked++; return false, nil })
+		if err := mgr.ResetDatabase(context.Background(), true); !errors.Is(err, ErrProductionReset) {
+			t.Fatalf("expected ErrProductionReset, got %v", err)
+		}
+		if asked != 1 || len(fe.cmds) != 0 {
+			t.Errorf("asked %d times, commands %v", asked, fe.cmds)
+		}
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		mgr, fe, _ := newResetManager(t, production)
+		mgr.SetConfirmFunc(func(prompt string) (bool, error) { return true, nil })
+		if err := mgr.ResetDatabase(context.Background(), true); err != nil {
+			t.Fatalf("ResetDatabase: %v", err)
+		}
+		if len(fe.cmds) != 2 {
+			t.Errorf("expected reset-db and migrate, got %v", fe.cmds)
+		}
+	})
+}
+
+// dumpExecutor answers `docker ps` with running and records every command.
+type dumpExecutor struct {
+	running string
+	cmds    [][]string
+}
+
+func (d *dumpExecutor) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	d.cmds = append(d.cmds, append([]string{name}, args...))
+	if len(args) > 0 && args[0] == "ps" {
+		return executor.Result{Stdout: d.running}, nil
+	}
+	return executor.Result{Stdout: "COMMIT;\n"}, nil
+}
+
+func TestSafetyBackup_DumpsSelectedInstance(t *testing.T) {
+	mgr, _, _ := newResetManager(t, "")
+	mgr.config.Names = docker.NamesFor("fusionaly-staging")
+	de := &dumpExecutor{running: "fusionaly-app-1\nfusionaly-staging-app-1\n"}
+	orig := executor.Default()
+	t.Cleanup(func() { executor.SetDefault(orig) })
+	executor.SetDefault(de)
+
+	path, err := mgr.safetyBackup(context.Background())
+	if err != nil {
+		t.Fatalf("safetyBackup: %v", err)
+	}
+	if dir := filepath.Join(mgr.config.Paths.DataDir, "storage", "backups"); filepath.Dir(path) != dir {
+		t.Errorf("backup written to %s, want the instance's %s", path, dir)
+	}
+	var dumped string
+	for _, cmd := range de.cmds {
+		if len(cmd) > 2 && cmd[1] == "exec" {
+			dumped = cmd[2]
+		}
+	}
+	if dumped != "fusionaly-staging-app-1" {
+		t.Errorf("dumped %q, want the selected instance's app container", dumped)
+	}
+}
@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/docker"
+	apperrors "fusionaly-installer/internal/errors"
+)
+
+// RetryConfig controls how transient fnctl failures are retried.
+type RetryConfig struct {
+	MaxAttempts int           // Total attempts including the first; values below 1 mean a single attempt
+	BaseDelay   time.Duration // Delay before the second attempt, doubled after each failure
+}
+
+// DefaultRetryConfig returns the retry policy used by the CLI.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+	}
+}
+
+// errExecTransport marks an attempt that docker could not deliver to the app
+// container, so fnctl never ran.
+var errExecTransport = errors.New("could not exec into the app container")
+
+// execTransportErrors are what docker prints when an exec fails before the
+// command starts: the daemon is unreachable or the container is stopped,
+// restarting or gone.
+var execTransportErrors = []string{
+	"Cannot connect to the Docker daemon",
+	"Error response from daemon",
+	"No such container",
+}
+
+// transportError marks err as errExecTransport when stderr shows that docker
+// exec failed before fnctl started.
+func transportError(err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	for _, marker := range execTransportErrors {
+		if strings.Contains(stderr, marker) {
+			return fmt.Errorf("%w: %w", errExecTransport, err)
+		}
+	}
+	return err
+}
+
+// isTransient reports whether an executor failure is worth retrying. Only
+// failures that happened before fnctl ran are: no app container was running
+// (typically one restarting) or docker could not exec into it. A command that
+// ran and failed is never retried, as some fnctl commands (creating or
+// deleting users) are not idempotent and may have taken effect.
+func isTransient(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrWeakPassword), errors.Is(err, ErrRefusedLastAdmin), errors.Is(err, ErrInvalidResetToken):
+		return false
+	case errors.Is(err, apperrors.ErrInvalidInput):
+		return false
+	}
+	return errors.Is(err, docker.ErrNoRunningApp) || errors.Is(err, errExecTransport)
+}
+
+// retry calls fn until it succeeds, returns a non-transient error, the
+// attempts are exhausted, or ctx is done. The last error is returned as-is
+// so callers can still match it with errors.Is.
+func (m *Manager) retry(ctx context.Context, fn func() error) error {
+	attempts := m.config.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := m.config.Retry.BaseDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !isTransient(err) || attempt == attempts {
+			return err
+		}
+
+		m.logger.Warn("Attempt %d/%d failed: %v (retrying in %s)", attempt, attempts, err, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/docker"
+	apperrors "fusionaly-installer/internal/errors"
+)
+
+func TestDefaultRetryConfig(t *testing.T) {
+	cfg := DefaultRetryConfig()
+	if cfg.MaxAttempts != 3 || cfg.BaseDelay != 500*time.Millisecond {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if DefaultConfig().Retry != cfg {
+		t.Errorf("DefaultConfig().Retry = %+v, want %+v", DefaultConfig().Retry, cfg)
+	}
+}
+
+// restarting is what docker exec prints for an app container that is
+// restarting.
+const restarting = "Error response from daemon: Container fusionaly-app-1 is restarting, wait until the container is running\n"
+
+func TestRetryEventuallySucceeds(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.failFirst = 2
+	fe.stderr = restarting
+
+	if err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123"); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if len(fe.cmds) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(fe.cmds))
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.config.Retry.MaxAttempts = 4
+	fe.failAfter = 1
+	fe.stderr = restarting
+
+	if err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123"); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if len(fe.cmds) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(fe.cmds))
+	}
+}
+
+func TestRetryDoesNotRepeatCommandsThatRan(t *testing.T) {
+	for name, run := range map[string]func(*Manager) error{
+		"create": func(m *Manager) error { return m.CreateAdminUser("admin@company.com", "SecurePassword123") },
+		"delete": func(m *Manager) error { return m.DeleteAdminUser("admin@company.com", true) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			mgr, fe := makeFakeManager()
+			fe.failFirst = 1
+			fe.stderr = "database is locked\n"
+
+			if err := run(mgr); err == nil {
+				t.Fatal("expected the fnctl failure to be returned")
+			}
+			if len(fe.cmds) != 1 {
+				t.Fatalf("expected a single attempt, got %v", fe.cmds)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnNonRetryableError(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	fe.failAfter = 1
+	fe.err = apperrors.NewValidationError("password", "", "rejected by fnctl")
+
+	err := mgr.ChangeAdminPassword("admin@company.com", "SecurePassword123")
+	if !errors.Is(err, apperrors.ErrInvalidInput) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if len(fe.cmds) != 1 {
+		t.Fatalf("expected a single attempt, got %d", len(fe.cmds))
+	}
+}
+
+func TestRetryStopsOnTimeout(t *testing.T) {
+	mgr, fe := makeFakeManager()
+	mgr.config.CommandTimeout = 10 * time.Millisecond
+	fe.delay = time.Second
+
+	err := mgr.CreateAdminUser("admin@company.com", "SecurePassword123")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(fe.cmds) != 1 {
+		t.Fatalf("expected a single attempt, got %d", len(fe.cmds))
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"fnctl failure", transportError(errors.New("exit status 1"), "database is locked"), false},
+		{"no running app", fmt.Errorf("exec: %w", docker.ErrNoRunningApp), true},
+		{"exec transport", transportError(errors.New("exit status 1"), restarting), true},
+		{"daemon down", transportError(errors.New("exit status 1"), "Cannot connect to the Docker daemon at unix:///var/run/docker.sock"), true},
+		{"canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"weak password", &WeakPasswordError{Failed: []string{"too short"}}, false},
+		{"invalid email", ErrInvalidEmail, false},
+		{"validation", apperrors.NewValidationError("email", "x", "bad"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package audit keeps an append-only trail of the commands that change an
+// installation: who ran what, when, and how it ended.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// FileName is the audit trail in the install directory. Unlike the
+// installer's own logs it is never rotated or truncated.
+const FileName = "audit.log"
+
+// Outcomes recorded in Entry.Outcome.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry is one line of the audit trail, written as JSON.
+type Entry struct {
+	Time       time.Time `json:"time"` // When the command started
+	User       string    `json:"user"` // Effective user running the installer
+	SudoUser   string    `json:"sudo_user,omitempty"`
+	Command    string    `json:"command"` // Subcommand only; its arguments may hold secrets
+	Instance   string    `json:"instance,omitempty"`
+	Outcome    string    `json:"outcome"`
+	ExitCode   int       `json:"exit_code"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// Trail appends entries for the commands it audits to a file.
+type Trail struct {
+	path    string
+	audited map[string]bool
+
+	now         func() time.Time       // nil means time.Now
+	currentUser func() (string, error) // nil looks up the effective user
+}
+
+// New returns a Trail writing to path that audits the commands in audited,
+// typically the installer's mutating commands.
+func New(path string, audited map[string]bool) *Trail {
+	return &Trail{path: path, audited: audited}
+}
+
+// Run is an audited command in progress. A nil *Run, returned for commands
+// that are not audited, records nothing.
+type Run struct {
+	trail *Trail
+	entry Entry
+	once  sync.Once
+}
+
+// Begin starts auditing command run against instance, returning nil when
+// the command is not audited.
+func (t *Trail) Begin(command, instance string) *Run {
+	if t == nil || !t.audited[command] {
+		return nil
+	}
+	return &Run{trail: t, entry: Entry{
+		Time:     t.clock(),
+		User:     t.user(),
+		SudoUser: os.Getenv("SUDO_USER"),
+		Command:  command,
+		Instance: instance,
+	}}
+}
+
+// Finish records the command's exit code. Only the first call writes an
+// entry, so it is safe on every exit path.
+func (r *Run) Finish(exitCode int) error {
+	if r == nil {
+		return nil
+	}
+	var err error
+	r.once.Do(func() {
+		e := r.entry
+		e.ExitCode = exitCode
+		e.Outcome = OutcomeSuccess
+		if exitCode != 0 {
+			e.Outcome = OutcomeFailure
+		}
+		e.DurationMS = r.trail.clock().Sub(e.Time).Milliseconds()
+		err = r.trail.append(e)
+	})
+	return err
+}
+
+// append writes e as one line. O_APPEND keeps concurrent writers from
+// interleaving within a line and never truncates what is already there.
+func (t *Trail) append(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+func (t *Trail) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func (t *Trail) user() string {
+	lookup := t.currentUser
+	if lookup == nil {
+		lookup = func() (string, error) {
+			u, err := user.Current()
+			if err != nil {
+				return "", err
+			}
+			return u.Username, nil
+		}
+	}
+	name, err := lookup()
+	if err != nil || name == "" {
+		return fmt.Sprintf("uid %d", os.Geteuid())
+	}
+	return name
+}
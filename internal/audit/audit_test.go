@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestTrail(t *testing.T) *Trail {
+	t.Helper()
+	trail := New(filepath.Join(t.TempDir(), FileName), map[string]bool{"install": true, "restore": true})
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	trail.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	trail.currentUser = func() (string, error) { return "root", nil }
+	return trail
+}
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestFinishRecordsMutatingCommand(t *testing.T) {
+	t.Setenv("SUDO_USER", "alice")
+	trail := newTestTrail(t)
+
+	run := trail.Begin("install", "staging")
+	if err := run.Finish(0); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if err := trail.Begin("restore", "").Finish(4); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	entries := readEntries(t, trail.path)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	want := Entry{
+		Time:       time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC),
+		User:       "root",
+		SudoUser:   "alice",
+		Command:    "install",
+		Instance:   "staging",
+		Outcome:    OutcomeSuccess,
+		DurationMS: 1000,
+	}
+	if entries[0] != want {
+		t.Errorf("entry = %+v\nwant    %+v", entries[0], want)
+	}
+	if entries[1].Command != "restore" || entries[1].Outcome != OutcomeFailure || entries[1].ExitCode != 4 {
+		t.Errorf("unexpected failure entry %+v", entries[1])
+	}
+
+	info, err := os.Stat(trail.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("audit log mode = %o, want 600", perm)
+	}
+}
+
+func TestReadOnlyCommandIsNotAudited(t *testing.T) {
+	trail := newTestTrail(t)
+
+	run := trail.Begin("status", "")
+	if run != nil {
+		t.Fatalf("status should not be audited, got %+v", run)
+	}
+	if err := run.Finish(0); err != nil {
+		t.Fatalf("Finish on an unaudited command: %v", err)
+	}
+	if entries := readEntries(t, trail.path); len(entries) != 0 {
+		t.Errorf("expected no entries, got %+v", entries)
+	}
+}
+
+func TestFinishAppendsOnce(t *testing.T) {
+	trail := newTestTrail(t)
+	if err := os.WriteFile(trail.path, []byte(`{"command":"update","outcome":"success"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := trail.Begin("install", "")
+	run.Finish(1)
+	run.Finish(0)
+
+	entries := readEntries(t, trail.path)
+	if len(entries) != 2 || entries[0].Command != "update" || entries[1].Outcome != OutcomeFailure {
+		t.Errorf("expected the earlier entry kept and one failure appended, got %+v", entries)
+	}
+}
@@ -0,0 +1,409 @@
+// Package bundle moves an installation between hosts as a single archive
+// holding its configuration and a database dump.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/database"
+	"fusionaly-installer/internal/logging"
+)
+
+const (
+	// ManifestName is the first entry of every bundle.
+	ManifestName = "manifest.json"
+	// FormatVersion is the bundle layout this installer writes and the
+	// newest it reads.
+	FormatVersion = 1
+
+	envName   = "fusionaly.env"
+	caddyName = "Caddyfile"
+	dumpName  = "database.sql.gz"
+)
+
+// ErrBundleExists is returned by a non-forced import when the target
+// installation already has a .env file.
+var ErrBundleExists = errors.New("installation already configured")
+
+// Manifest describes a bundle's contents.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	AppVersion    string    `json:"app_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Encrypted is set when the .env file and dump were encrypted with the
+	// export passphrase; their names then end in database.EncryptedSuffix.
+	Encrypted bool   `json:"encrypted"`
+	Files     []File `json:"files"`
+}
+
+// File is one archived file with the checksum import verifies.
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// databaseBackend is the part of *database.Database a bundle needs.
+type databaseBackend interface {
+	Backup(ctx context.Context, destDir string) (string, error)
+	Restore(ctx context.Context, backupPath string, force bool) error
+}
+
+// Bundler exports and imports one installation.
+type Bundler struct {
+	logger     *logging.Logger
+	paths      config.InstallPaths
+	db         databaseBackend
+	version    func(ctx context.Context) (string, error)
+	passphrase func() (string, error)
+	now        func() time.Time
+}
+
+// NewBundler creates a Bundler for the installation at paths. db dumps and
+// restores its database and version reports the deployed app version, e.g.
+// Stack.InstalledVersion.
+func NewBundler(logger *logging.Logger, paths config.InstallPaths, db databaseBackend, version func(ctx context.Context) (string, error)) *Bundler {
+	return &Bundler{
+		logger:  logger,
+		paths:   paths,
+		db:      db,
+		version: version,
+		now:     time.Now,
+	}
+}
+
+// SetPassphraseFunc sets how the bundle passphrase is obtained. With one
+// set, exports encrypt the .env file and database dump, which hold the app
+// secret and every user's data; without it they are stored in the clear.
+// Imports only call it for encrypted bundles.
+func (b *Bundler) SetPassphraseFunc(fn func() (string, error)) {
+	b.passphrase = fn
+}
+
+// readPassphrase calls the passphrase func, requiring a non-empty result.
+func (b *Bundler) readPassphrase() (string, error) {
+	if b.passphrase == nil {
+		return "", database.ErrPassphraseRequired
+	}
+	passphrase, err := b.passphrase()
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if passphrase == "" {
+		return "", database.ErrPassphraseRequired
+	}
+	return passphrase, nil
+}
+
+// ExportBundle writes the .env file, Caddyfile and a fresh database dump to
+// a gzipped tar at destPath, preceded by a Manifest.
+func (b *Bundler) ExportBundle(destPath string) error {
+	return b.ExportBundleContext(context.Background(), destPath)
+}
+
+// ExportBundleContext is ExportBundle with a context for the database dump.
+func (b *Bundler) ExportBundleContext(ctx context.Context, destPath string) error {
+	version, err := b.version(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read installed version: %w", err)
+	}
+	var passphrase string
+	if b.passphrase != nil {
+		if passphrase, err = b.readPassphrase(); err != nil {
+			return err
+		}
+	}
+
+	stage, err := os.MkdirTemp("", "fusionaly-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to stage bundle: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	dump, err := b.db.Backup(ctx, stage)
+	if err != nil {
+		return err
+	}
+	names := []string{envName, dumpName}
+	paths := map[string]string{envName: b.paths.EnvFile, dumpName: dump}
+	if _, err := os.Stat(b.paths.CaddyFile); err == nil {
+		names = append(names, caddyName)
+		paths[caddyName] = b.paths.CaddyFile
+	}
+
+	manifest := Manifest{
+		FormatVersion: FormatVersion,
+		AppVersion:    version,
+		CreatedAt:     b.now().UTC(),
+		Encrypted:     passphrase != "",
+	}
+	var sources []string
+	for _, name := range names {
+		src := paths[name]
+		if name != caddyName && manifest.Encrypted {
+			enc := filepath.Join(stage, name+database.EncryptedSuffix)
+			if err := database.EncryptBackup(src, enc, passphrase); err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", name, err)
+			}
+			name += database.EncryptedSuffix
+			src = enc
+		}
+		entry, err := describe(name, src)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, entry)
+		sources = append(sources, src)
+	}
+
+	if err := writeArchive(destPath, manifest, sources); err != nil {
+		return err
+	}
+	b.logger.Success("Bundle of Fusionaly %s written to %s", version, destPath)
+	if !manifest.Encrypted {
+		b.logger.Warn("The bundle holds FUSIONALY_PRIVATE_KEY and the database unencrypted; keep it private")
+	}
+	return nil
+}
+
+// ImportBundle restores a bundle written by ExportBundle into this
+// installation: the .env file and Caddyfile are written to their paths and
+// the dump is loaded with Database.Restore, so the app container must be
+// running, e.g. after installing on the new host. Unless force is set, an
+// existing .env file or a database with users is left alone.
+func (b *Bundler) ImportBundle(srcPath string, force bool) error {
+	return b.ImportBundleContext(context.Background(), srcPath, force)
+}
+
+// ImportBundleContext is ImportBundle with a context for the restore.
+func (b *Bundler) ImportBundleContext(ctx context.Context, srcPath string, force bool) error {
+	if _, err := os.Stat(b.paths.EnvFile); err == nil && !force {
+		return fmt.Errorf("%w: %s exists (use force to replace it)", ErrBundleExists, b.paths.EnvFile)
+	}
+
+	stage, err := os.MkdirTemp("", "fusionaly-bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to stage bundle: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	manifest, err := extractArchive(srcPath, stage)
+	if err != nil {
+		return err
+	}
+	var passphrase string
+	if manifest.Encrypted {
+		if passphrase, err = b.readPassphrase(); err != nil {
+			return err
+		}
+	}
+
+	files := map[string]string{}
+	for _, f := range manifest.Files {
+		name, path := f.Name, filepath.Join(stage, f.Name)
+		if manifest.Encrypted && name != caddyName {
+			name = strings.TrimSuffix(name, database.EncryptedSuffix)
+			plain := filepath.Join(stage, name)
+			if err := database.DecryptBackup(path, plain, passphrase); err != nil {
+				return err
+			}
+			path = plain
+		}
+		files[name] = path
+	}
+	if files[envName] == "" || files[dumpName] == "" {
+		return fmt.Errorf("invalid bundle %s: missing .env file or database dump", srcPath)
+	}
+
+	if err := os.MkdirAll(b.paths.DataDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", b.paths.DataDir, err)
+	}
+	if err := copyFile(files[envName], b.paths.EnvFile, 0o600); err != nil {
+		return err
+	}
+	if caddy := files[caddyName]; caddy != "" {
+		if err := copyFile(caddy, b.paths.CaddyFile, 0o644); err != nil {
+			return err
+		}
+	}
+	if err := b.db.Restore(ctx, files[dumpName], force); err != nil {
+		return err
+	}
+	b.logger.Success("Imported Fusionaly %s bundle from %s", manifest.AppVersion, srcPath)
+	return nil
+}
+
+// describe checksums the file at path for the manifest.
+func describe(name, path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return File{Name: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// writeArchive writes the manifest and then sources, in manifest order, to
+// dest through a temp file so a failed export leaves nothing behind.
+func writeArchive(dest string, manifest Manifest, sources []string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(zw)
+	err = writeEntry(tw, ManifestName, int64(len(data)), manifest.CreatedAt, bytes.NewReader(data))
+	for i := 0; err == nil && i < len(sources); i++ {
+		err = writeFileEntry(tw, manifest.Files[i], manifest.CreatedAt, sources[i])
+	}
+	for _, closer := range []io.Closer{tw, zw} {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil {
+		err = tmp.Chmod(0o600)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+func writeFileEntry(tw *tar.Writer, f File, modTime time.Time, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeEntry(tw, f.Name, f.Size, modTime, src)
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: size, ModTime: modTime, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// extractArchive reads the manifest and writes every file it lists into
+// dir, rejecting unlisted entries, missing files and checksum mismatches.
+func extractArchive(src, dir string) (Manifest, error) {
+	var manifest Manifest
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("invalid bundle %s: %s", src, fmt.Sprintf(format, args...))
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return manifest, fmt.Errorf("cannot read bundle: %w", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return manifest, invalid("%v", err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != ManifestName {
+		return manifest, invalid("%s must be the first entry", ManifestName)
+	}
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return manifest, invalid("unreadable manifest: %v", err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return manifest, invalid("format version %d is not supported (this installer reads up to %d)", manifest.FormatVersion, FormatVersion)
+	}
+
+	want := map[string]File{}
+	for _, file := range manifest.Files {
+		if file.Name != filepath.Base(file.Name) || file.Name == "." || file.Name == ".." {
+			return manifest, invalid("unsafe file name %q", file.Name)
+		}
+		want[file.Name] = file
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, invalid("%v", err)
+		}
+		file, ok := want[hdr.Name]
+		if !ok {
+			return manifest, invalid("unexpected entry %q", hdr.Name)
+		}
+		if err := extractEntry(tr, filepath.Join(dir, file.Name), file); err != nil {
+			return manifest, invalid("%v", err)
+		}
+		delete(want, hdr.Name)
+	}
+	for name := range want {
+		return manifest, invalid("missing %s", name)
+	}
+	return manifest, nil
+}
+
+func extractEntry(r io.Reader, dest string, file File) error {
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), r)
+	if err != nil {
+		return err
+	}
+	if n != file.Size || hex.EncodeToString(h.Sum(nil)) != file.SHA256 {
+		return fmt.Errorf("%s does not match its checksum", file.Name)
+	}
+	return nil
+}
+
+// copyFile replaces dest with the contents of src.
+func copyFile(src, dest string, perm os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return os.Chmod(dest, perm)
+}
@@ -0,0 +1,239 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/database"
+	"fusionaly-installer/internal/logging"
+)
+
+const (
+	testEnv   = "FUSIONALY_DOMAIN=analytics.example.com\nFUSIONALY_PRIVATE_KEY=0123456789abcdef0123456789abcdef\n"
+	testCaddy = "analytics.example.com {\n\treverse_proxy fusionaly-app-1:8080\n}\n"
+	testDump  = "BEGIN TRANSACTION;\nCREATE TABLE users(id INTEGER);\nCOMMIT;\n"
+)
+
+// fakeDB writes testDump on Backup and records what Restore loads.
+type fakeDB struct {
+	restored string
+	force    bool
+}
+
+func (f *fakeDB) Backup(ctx context.Context, destDir string) (string, error) {
+	path := filepath.Join(destDir, "fusionaly-backup-20240101-120000.sql.gz")
+	return path, os.WriteFile(path, []byte(testDump), 0o600)
+}
+
+func (f *fakeDB) Restore(ctx context.Context, backupPath string, force bool) error {
+	data, err := os.ReadFile(backupPath)
+	f.restored, f.force = string(data), force
+	return err
+}
+
+func newTestBundler(t *testing.T, dir string) (*Bundler, *fakeDB) {
+	t.Helper()
+	db := &fakeDB{}
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+	b := NewBundler(logger, config.PathsForDir(dir), db, func(context.Context) (string, error) { return "1.2.3", nil })
+	b.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return b, db
+}
+
+func writeInstall(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := config.PathsForDir(dir)
+	for path, content := range map[string]string{paths.EnvFile: testEnv, paths.CaddyFile: testCaddy} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// readArchive returns the entry names in order and their contents.
+func readArchive(t *testing.T, path string) ([]string, map[string][]byte) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	var names []string
+	contents := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names, contents
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		contents[hdr.Name] = data
+	}
+}
+
+func TestExportBundle(t *testing.T) {
+	b, _ := newTestBundler(t, writeInstall(t))
+	dest := filepath.Join(t.TempDir(), "fusionaly.bundle.tar.gz")
+
+	if err := b.ExportBundle(dest); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	if info, err := os.Stat(dest); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("bundle should be 0600, got %v %v", info, err)
+	}
+
+	names, contents := readArchive(t, dest)
+	wantNames := []string{ManifestName, "fusionaly.env", "database.sql.gz", "Caddyfile"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("entries = %v, want %v", names, wantNames)
+	}
+	for name, want := range map[string]string{"fusionaly.env": testEnv, "database.sql.gz": testDump, "Caddyfile": testCaddy} {
+		if string(contents[name]) != want {
+			t.Errorf("%s = %q, want %q", name, contents[name], want)
+		}
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(contents[ManifestName], &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if m.FormatVersion != FormatVersion || m.AppVersion != "1.2.3" || m.Encrypted {
+		t.Errorf("manifest = %+v", m)
+	}
+	if !m.CreatedAt.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("created_at = %v", m.CreatedAt)
+	}
+	if len(m.Files) != 3 || m.Files[0].Name != "fusionaly.env" || m.Files[0].Size != int64(len(testEnv)) || len(m.Files[0].SHA256) != 64 {
+		t.Errorf("files = %+v", m.Files)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src, _ := newTestBundler(t, writeInstall(t))
+	bundlePath := filepath.Join(t.TempDir(), "fusionaly.bundle.tar.gz")
+	if err := src.ExportBundle(bundlePath); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "fusionaly")
+	dst, db := newTestBundler(t, target)
+	if err := dst.ImportBundle(bundlePath, false); err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+
+	paths := config.PathsForDir(target)
+	for path, want := range map[string]string{paths.EnvFile: testEnv, paths.CaddyFile: testCaddy} {
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if info, _ := os.Stat(paths.EnvFile); info.Mode().Perm() != 0o600 {
+		t.Errorf(".env mode = %v, want 0600", info.Mode().Perm())
+	}
+	if db.restored != testDump || db.force {
+		t.Errorf("restored %q (force %v), want the exported dump", db.restored, db.force)
+	}
+
+	if err := dst.ImportBundle(bundlePath, false); !errors.Is(err, ErrBundleExists) {
+		t.Errorf("second import should refuse to replace .env, got %v", err)
+	}
+}
+
+func TestExportImportEncrypted(t *testing.T) {
+	src, _ := newTestBundler(t, writeInstall(t))
+	src.SetPassphraseFunc(func() (string, error) { return "correct horse battery", nil })
+	bundlePath := filepath.Join(t.TempDir(), "fusionaly.bundle.tar.gz")
+	if err := src.ExportBundle(bundlePath); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+
+	names, contents := readArchive(t, bundlePath)
+	wantNames := []string{ManifestName, "fusionaly.env.enc", "database.sql.gz.enc", "Caddyfile"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("entries = %v, want %v", names, wantNames)
+	}
+	if string(contents["fusionaly.env.enc"]) == testEnv {
+		t.Error(".env should be encrypted")
+	}
+
+	target := filepath.Join(t.TempDir(), "fusionaly")
+	dst, db := newTestBundler(t, target)
+	if err := dst.ImportBundle(bundlePath, false); !errors.Is(err, database.ErrPassphraseRequired) {
+		t.Fatalf("import without passphrase: got %v", err)
+	}
+	dst.SetPassphraseFunc(func() (string, error) { return "wrong passphrase", nil })
+	if err := dst.ImportBundle(bundlePath, false); !errors.Is(err, database.ErrWrongPassphrase) {
+		t.Fatalf("import with wrong passphrase: got %v", err)
+	}
+	dst.SetPassphraseFunc(func() (string, error) { return "correct horse battery", nil })
+	if err := dst.ImportBundle(bundlePath, false); err != nil {
+		t.Fatalf("ImportBundle: %v", err)
+	}
+	if got, _ := os.ReadFile(config.PathsForDir(target).EnvFile); string(got) != testEnv {
+		t.Errorf(".env = %q", got)
+	}
+	if db.restored != testDump {
+		t.Errorf("restored %q", db.restored)
+	}
+}
+
+func TestImportBundleRejectsTampering(t *testing.T) {
+	src, _ := newTestBundler(t, writeInstall(t))
+	bundlePath := filepath.Join(t.TempDir(), "fusionaly.bundle.tar.gz")
+	if err := src.ExportBundle(bundlePath); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+
+	// Rewrite the bundle with a modified .env but the original manifest.
+	names, contents := readArchive(t, bundlePath)
+	contents["fusionaly.env"] = []byte("FUSIONALY_DOMAIN=evil.example.com\n" + testEnv[len("FUSIONALY_DOMAIN=analytics.example.com\n"):])
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	for _, name := range names {
+		_ = tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(contents[name]))})
+		_, _ = tw.Write(contents[name])
+	}
+	tw.Close()
+	zw.Close()
+	f.Close()
+
+	target := filepath.Join(t.TempDir(), "fusionaly")
+	dst, db := newTestBundler(t, target)
+	if err := dst.ImportBundle(bundlePath, false); err == nil {
+		t.Fatal("expected a checksum error")
+	}
+	if _, err := os.Stat(config.PathsForDir(target).EnvFile); !os.IsNotExist(err) {
+		t.Error("a rejected bundle must not write .env")
+	}
+	if db.restored != "" {
+		t.Error("a rejected bundle must not be restored")
+	}
+}
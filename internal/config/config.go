@@ -2,8 +2,7 @@ package config
 
 import (
 	"bufio"
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,12 +11,15 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/term"
 
 	"fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
 	"fusionaly-installer/internal/validation"
 )
@@ -25,19 +27,44 @@ import (
 // GithubRepo is the centralized GitHub repository URL slug
 const GithubRepo = "karloscodes/fusionaly-installer"
 
+// DefaultRestartPolicy restarts crashed containers and brings them back
+// after a reboot unless they were stopped on purpose.
+const DefaultRestartPolicy = "unless-stopped"
+
+// DefaultAppMemory is the memory limit of the app container when
+// APP_MEMORY_LIMIT is not set.
+const DefaultAppMemory = "512m"
+
+// EnvironmentProduction is the FUSIONALY_ENV value that marks an
+// installation as production, guarding destructive commands like reset-db.
+const EnvironmentProduction = "production"
+
 // ConfigData holds the configuration
 type ConfigData struct {
-	Domain       string   // Local: User-provided
-	AppImage     string   // GitHub Release/Default: e.g., "karloscodes/fusionaly-beta:latest"
-	CaddyImage   string   // GitHub Release/Default: e.g., "caddy:2.7-alpine"
-	InstallDir   string   // Default: e.g., "/opt/fusionaly"
-	BackupPath   string   // Default: SQLite backup location
-	PrivateKey   string   // Generated: secure random key for FUSIONALY_PRIVATE_KEY
-	Version      string   // GitHub Release: Version of the fusionaly binary (optional)
-	InstallerURL string   // GitHub Release: URL to download new fusionaly binary
-	DNSWarnings  []string // DNS configuration warnings
-	User         string   // Database: Admin user email from users table
-	LicenseKey   string   // License key for the application
+	Domain        string   // Local: User-provided
+	AppImage      string   // GitHub Release/Default: e.g., "karloscodes/fusionaly-beta:latest"
+	CaddyImage    string   // GitHub Release/Default: e.g., "caddy:2.7-alpine"
+	InstallDir    string   // Default: e.g., "/opt/fusionaly"
+	BackupPath    string   // Default: SQLite backup location
+	PrivateKey    string   // Generated: secure random key for FUSIONALY_PRIVATE_KEY
+	Version       string   // GitHub Release: Version of the fusionaly binary (optional)
+	InstallerURL  string   // GitHub Release: URL to download new fusionaly binary
+	DNSWarnings   []string // DNS configuration warnings
+	User          string   // Database: Admin user email from users table
+	LicenseKey    string   // License key for the application
+	ACMEEmail     string   // Local: Let's Encrypt account email set by ConfigureTLS
+	ACMEStaging   bool     // Local: issue certificates from the Let's Encrypt staging CA
+	RestartPolicy string   // Local: docker restart policy of the app and proxy containers
+	HTTPPort      int      // Local: host port the proxy publishes for HTTP; 0 keeps the instance's
+	HTTPSPort     int      // Local: host port the proxy publishes for HTTPS; 0 keeps the instance's
+	AppMemory     string   // Local: docker memory limit of the app container, e.g. "1g"
+	AppCPUs       string   // Local: CPUs the app container may use, e.g. "1.5"; empty leaves it unlimited
+	Environment   string   // Local: FUSIONALY_ENV, e.g. "staging" or EnvironmentProduction
+}
+
+// IsProduction reports whether the installation is marked production.
+func (d ConfigData) IsProduction() bool {
+	return strings.EqualFold(d.Environment, EnvironmentProduction)
 }
 
 // Config manages configuration
@@ -51,20 +78,38 @@ func NewConfig(logger *logging.Logger) *Config {
 	return &Config{
 		logger: logger,
 		data: ConfigData{
-			Domain:       "", // Required from user
-			AppImage:     "karloscodes/fusionaly-beta:latest",
-			CaddyImage:   "caddy:2.7-alpine",
-			InstallDir:   "/opt/fusionaly",
-			BackupPath:   "/opt/fusionaly/storage/backups",
-			PrivateKey:   "",
-			Version:      "latest",
-			InstallerURL: fmt.Sprintf("https://github.com/%s/releases/latest", GithubRepo),
+			Domain:        "", // Required from user
+			AppImage:      "karloscodes/fusionaly-beta:latest",
+			CaddyImage:    "caddy:2.7-alpine",
+			InstallDir:    "/opt/fusionaly",
+			BackupPath:    "/opt/fusionaly/storage/backups",
+			PrivateKey:    "",
+			Version:       "latest",
+			InstallerURL:  fmt.Sprintf("https://github.com/%s/releases/latest", GithubRepo),
+			RestartPolicy: DefaultRestartPolicy,
+			AppMemory:     DefaultAppMemory,
 		},
 	}
 }
 
+// httpClient makes the configuration's HTTP requests. Its timeout bounds each
+// request even when the caller's context has no deadline.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// latestReleaseURL is where FetchFromServer looks up the latest release.
+var latestReleaseURL = fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", GithubRepo)
+
+// httpGet is http.Get through httpClient, aborted when ctx ends.
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
 // Helper function to get the current server's primary public IP address
-func getCurrentServerIP() (string, error) {
+func getCurrentServerIP(ctx context.Context) (string, error) {
 	// Try to get IPs from multiple external services for better reliability
 	externalServices := []string{
 		"https://api.ipify.org",
@@ -76,7 +121,7 @@ func getCurrentServerIP() (string, error) {
 
 	// Try external services first
 	for _, service := range externalServices {
-		resp, err := http.Get(service)
+		resp, err := httpGet(ctx, service)
 		if err == nil {
 			defer resp.Body.Close()
 			ip, err := io.ReadAll(resp.Body)
@@ -144,6 +189,16 @@ func getCurrentServerIP() (string, error) {
 	return "", fmt.Errorf("unable to determine server IP")
 }
 
+// ServerIPs returns the addresses this server is reachable on: its public IP
+// when an external lookup service answers, otherwise its local addresses.
+func ServerIPs(ctx context.Context) ([]string, error) {
+	ips, err := getCurrentServerIP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(ips, ","), nil
+}
+
 // Helper function to check domain against multiple IPs
 func checkDomainIPMatch(domain string, serverIPs string) (bool, string) {
 	ips, err := net.LookupIP(domain)
@@ -172,7 +227,7 @@ func checkDomainIPMatch(domain string, serverIPs string) (bool, string) {
 }
 
 // CollectFromUser gets required user input upfront
-func (c *Config) CollectFromUser(reader *bufio.Reader) error {
+func (c *Config) CollectFromUser(ctx context.Context, reader *bufio.Reader) error {
 	// Check if we're in non-interactive mode
 	if os.Getenv("NONINTERACTIVE") == "1" {
 		return c.collectFromEnvironment()
@@ -180,7 +235,7 @@ func (c *Config) CollectFromUser(reader *bufio.Reader) error {
 
 	// Initialize default values
 	c.data.Domain = ""
-	c.data.InstallDir = "/opt/fusionaly"
+	c.data.InstallDir = DefaultDataDir
 
 	// Collect domain
 	for {
@@ -204,7 +259,7 @@ func (c *Config) CollectFromUser(reader *bufio.Reader) error {
 	}
 
 	// Check DNS records and store warnings instead of blocking
-	c.CheckDNSAndStoreWarnings(c.data.Domain)
+	c.CheckDNSAndStoreWarnings(ctx, c.data.Domain)
 
 	c.data.BackupPath = filepath.Join(c.data.InstallDir, "storage", "backups")
 
@@ -234,7 +289,7 @@ func (c *Config) CollectFromUser(reader *bufio.Reader) error {
 		fmt.Println("Configuration declined. Let's start over.")
 		// Reset all values and start over
 		c.data.Domain = ""
-		return c.CollectFromUser(reader)
+		return c.CollectFromUser(ctx, reader)
 	}
 
 	c.logger.Success("Configuration collected from user")
@@ -256,7 +311,7 @@ func (c *Config) collectFromEnvironment() error {
 	c.logger.Info("  Domain: %s", c.data.Domain)
 
 	// Set default values for other fields
-	c.data.InstallDir = "/opt/fusionaly"
+	c.data.InstallDir = DefaultDataDir
 	c.data.BackupPath = filepath.Join(c.data.InstallDir, "backups")
 	c.data.AppImage = "karloscodes/fusionaly-beta:latest"
 	c.data.CaddyImage = "caddy:2.7-alpine"
@@ -314,6 +369,22 @@ func (c *Config) LoadFromFile(filename string) error {
 			c.data.User = value
 		case "FUSIONALY_LICENSE_KEY":
 			c.data.LicenseKey = value
+		case "ACME_EMAIL":
+			c.data.ACMEEmail = value
+		case "ACME_STAGING":
+			c.data.ACMEStaging = value == "true"
+		case "RESTART_POLICY":
+			c.data.RestartPolicy = value
+		case "HTTP_PORT":
+			c.data.HTTPPort = parsePort(key, value, c.logger)
+		case "HTTPS_PORT":
+			c.data.HTTPSPort = parsePort(key, value, c.logger)
+		case "APP_MEMORY_LIMIT":
+			c.data.AppMemory = value
+		case "APP_CPU_LIMIT":
+			c.data.AppCPUs = value
+		case "FUSIONALY_ENV":
+			c.data.Environment = value
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -327,9 +398,10 @@ func (c *Config) LoadFromFile(filename string) error {
 			return err
 		}
 		c.data.PrivateKey = pk
-		// Append to file
-		f, ferr := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
-		if ferr == nil {
+		// Append to file, unless this is a dry run
+		if dry, ok := executor.DryRunOf(executor.Default()); ok {
+			dry.SkipFile("write", filename)
+		} else if f, ferr := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0); ferr == nil {
 			fmt.Fprintf(f, "FUSIONALY_PRIVATE_KEY=%s\n", pk)
 			f.Close()
 			c.logger.Info("Added missing FUSIONALY_PRIVATE_KEY to %s", filename)
@@ -339,39 +411,88 @@ func (c *Config) LoadFromFile(filename string) error {
 	return nil
 }
 
-// SaveToFile saves local config to .env
+// parsePort reads a port setting from the .env file. A value that is not a
+// number is reported and ignored, so the instance's port stays in effect.
+func parsePort(key, value string, logger *logging.Logger) int {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Warn("Ignoring %s=%q: not a port number", key, value)
+		return 0
+	}
+	return port
+}
+
+// SaveToFile saves local config to .env. An existing file is updated in
+// place: comments and keys the installer doesn't manage are preserved, and an
+// existing FUSIONALY_PRIVATE_KEY is reused rather than regenerated.
 func (c *Config) SaveToFile(filename string) error {
 	c.logger.Info("Saving to %s", filename)
 
+	env, err := LoadEnvFile(filename)
+	if err != nil {
+		return err
+	}
+
 	// Ensure private key is set
 	if c.data.PrivateKey == "" {
-		pk, err := generatePrivateKey()
-		if err != nil {
-			return err
+		if existing, ok := env.Get("FUSIONALY_PRIVATE_KEY"); ok && existing != "" {
+			c.data.PrivateKey = existing
+		} else {
+			pk, err := generatePrivateKey()
+			if err != nil {
+				return err
+			}
+			c.data.PrivateKey = pk
+			c.logger.Info("Generated new FUSIONALY_PRIVATE_KEY")
 		}
-		c.data.PrivateKey = pk
-		c.logger.Info("Generated new FUSIONALY_PRIVATE_KEY")
-	}
-
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer file.Close()
 
-	fmt.Fprintf(file, "FUSIONALY_DOMAIN=%s\n", c.data.Domain)
-	fmt.Fprintf(file, "APP_IMAGE=%s\n", c.data.AppImage)
-	fmt.Fprintf(file, "CADDY_IMAGE=%s\n", c.data.CaddyImage)
-	fmt.Fprintf(file, "INSTALL_DIR=%s\n", c.data.InstallDir)
-	fmt.Fprintf(file, "BACKUP_PATH=%s\n", c.data.BackupPath)
-	fmt.Fprintf(file, "VERSION=%s\n", c.data.Version)
-	fmt.Fprintf(file, "INSTALLER_URL=%s\n", c.data.InstallerURL)
-	fmt.Fprintf(file, "FUSIONALY_PRIVATE_KEY=%s\n", c.data.PrivateKey)
+	env.Set("FUSIONALY_DOMAIN", c.data.Domain)
+	env.Set("APP_IMAGE", c.data.AppImage)
+	env.Set("CADDY_IMAGE", c.data.CaddyImage)
+	env.Set("INSTALL_DIR", c.data.InstallDir)
+	env.Set("BACKUP_PATH", c.data.BackupPath)
+	env.Set("VERSION", c.data.Version)
+	env.Set("INSTALLER_URL", c.data.InstallerURL)
+	env.Set("FUSIONALY_PRIVATE_KEY", c.data.PrivateKey)
 	if c.data.User != "" {
-		fmt.Fprintf(file, "FUSIONALY_USER=%s\n", c.data.User)
+		env.Set("FUSIONALY_USER", c.data.User)
 	}
 	if c.data.LicenseKey != "" {
-		fmt.Fprintf(file, "FUSIONALY_LICENSE_KEY=%s\n", c.data.LicenseKey)
+		env.Set("FUSIONALY_LICENSE_KEY", c.data.LicenseKey)
+	}
+	if c.data.ACMEEmail != "" {
+		env.Set("ACME_EMAIL", c.data.ACMEEmail)
+	}
+	if c.data.ACMEStaging {
+		env.Set("ACME_STAGING", "true")
+	} else if _, ok := env.Get("ACME_STAGING"); ok {
+		env.Set("ACME_STAGING", "false")
+	}
+
+	if _, ok := env.Get("RESTART_POLICY"); ok || (c.data.RestartPolicy != "" && c.data.RestartPolicy != DefaultRestartPolicy) {
+		env.Set("RESTART_POLICY", c.data.RestartPolicy)
+	}
+
+	if c.data.HTTPPort != 0 {
+		env.Set("HTTP_PORT", strconv.Itoa(c.data.HTTPPort))
+	}
+	if c.data.HTTPSPort != 0 {
+		env.Set("HTTPS_PORT", strconv.Itoa(c.data.HTTPSPort))
+	}
+
+	if _, ok := env.Get("APP_MEMORY_LIMIT"); ok || (c.data.AppMemory != "" && c.data.AppMemory != DefaultAppMemory) {
+		env.Set("APP_MEMORY_LIMIT", c.data.AppMemory)
+	}
+	if _, ok := env.Get("APP_CPU_LIMIT"); ok || c.data.AppCPUs != "" {
+		env.Set("APP_CPU_LIMIT", c.data.AppCPUs)
+	}
+	if _, ok := env.Get("FUSIONALY_ENV"); ok || c.data.Environment != "" {
+		env.Set("FUSIONALY_ENV", c.data.Environment)
+	}
+
+	if err := env.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
 	}
 
 	c.logger.Info("Configuration saved to %s", filename)
@@ -394,6 +515,29 @@ func (c *Config) SetCaddyImage(image string) {
 	c.logger.Info("CaddyImage updated to: %s", image)
 }
 
+// PinAppVersion points AppImage at the given tag (e.g. "1.2.3") so that exact
+// release is pulled and recorded in .env instead of the release default.
+func (c *Config) PinAppVersion(version string) error {
+	if err := validation.ValidateImageTag(version); err != nil {
+		return errors.WrapWithContext(err, "invalid app version")
+	}
+	c.data.AppImage = imageWithTag(c.data.AppImage, version)
+	c.logger.Info("AppImage pinned to: %s", c.data.AppImage)
+	return nil
+}
+
+// imageWithTag replaces any tag or digest on image with tag. A registry port
+// ("host:5000/repo") is not mistaken for a tag.
+func imageWithTag(image, tag string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image + ":" + tag
+}
+
 // DockerImages contains both app and caddy image information
 type DockerImages struct {
 	AppImage   string
@@ -465,6 +609,34 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.data.RestartPolicy != "" {
+		if err := validation.ValidateRestartPolicy(c.data.RestartPolicy); err != nil {
+			return errors.NewConfigError("restart_policy", c.data.RestartPolicy, err.Error())
+		}
+	}
+
+	if c.data.HTTPPort != 0 {
+		if err := validation.ValidatePort(strconv.Itoa(c.data.HTTPPort)); err != nil {
+			return errors.NewConfigError("http_port", strconv.Itoa(c.data.HTTPPort), err.Error())
+		}
+	}
+	if c.data.HTTPSPort != 0 {
+		if err := validation.ValidatePort(strconv.Itoa(c.data.HTTPSPort)); err != nil {
+			return errors.NewConfigError("https_port", strconv.Itoa(c.data.HTTPSPort), err.Error())
+		}
+	}
+
+	if c.data.AppMemory != "" {
+		if err := validation.ValidateMemoryLimit(c.data.AppMemory); err != nil {
+			return errors.NewConfigError("app_memory_limit", c.data.AppMemory, err.Error())
+		}
+	}
+	if c.data.AppCPUs != "" {
+		if err := validation.ValidateCPULimit(c.data.AppCPUs); err != nil {
+			return errors.NewConfigError("app_cpu_limit", c.data.AppCPUs, err.Error())
+		}
+	}
+
 	// Validate installer URL if provided
 	if c.data.InstallerURL != "" {
 		if err := validation.ValidateURL(c.data.InstallerURL); err != nil {
@@ -476,7 +648,7 @@ func (c *Config) Validate() error {
 }
 
 // CheckDNSAndStoreWarnings checks DNS configuration and stores warnings instead of blocking
-func (c *Config) CheckDNSAndStoreWarnings(domain string) {
+func (c *Config) CheckDNSAndStoreWarnings(ctx context.Context, domain string) {
 	// Skip DNS checks for localhost - no DNS resolution needed
 	if isLocalhostDomain(domain) {
 		fmt.Printf("🏠 Skipping DNS checks for localhost domain: %s\n", domain)
@@ -507,7 +679,7 @@ func (c *Config) CheckDNSAndStoreWarnings(domain string) {
 	}
 
 	// Check if domain resolves to server IP
-	serverIPs, err := getCurrentServerIP()
+	serverIPs, err := getCurrentServerIP(ctx)
 	if err != nil {
 		warning := fmt.Sprintf("Could not determine server IP addresses: %v", err)
 		c.data.DNSWarnings = append(c.data.DNSWarnings, warning)
@@ -555,14 +727,14 @@ func (c *Config) HasDNSWarnings() bool {
 	return len(c.data.DNSWarnings) > 0
 }
 
-// generatePrivateKey generates a secure random private key
+// generatePrivateKey generates a secure random private key. Callers only
+// invoke it when no key exists yet, so an installed key is never replaced.
 func generatePrivateKey() (string, error) {
-	key := make([]byte, 16)
-	_, err := rand.Read(key)
+	key, err := GenerateSecret(PrivateKeyLength)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate private key: %w", err)
 	}
-	return hex.EncodeToString(key), nil
+	return key, nil
 }
 
 // readPassword reads a password from either terminal or stdin based on environment
@@ -595,12 +767,13 @@ func (c *Config) readPassword(reader *bufio.Reader, prompt string) (string, erro
 	return strings.TrimSpace(string(passwordBytes)), nil
 }
 
-// FetchFromServer fetches config from the latest GitHub release
-func (c *Config) FetchFromServer(_ string) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", GithubRepo)
+// FetchFromServer fetches config from the latest GitHub release. The requests
+// end with ctx.
+func (c *Config) FetchFromServer(ctx context.Context, _ string) error {
+	url := latestReleaseURL
 	c.logger.Info("Fetching latest release from GitHub: %s", url)
 
-	resp, err := http.Get(url)
+	resp, err := httpGet(ctx, url)
 	if err != nil || resp.StatusCode != http.StatusOK {
 		c.logger.Warn("Failed to fetch latest release: %v", err)
 		if resp != nil {
@@ -656,7 +829,7 @@ func (c *Config) FetchFromServer(_ string) error {
 	}
 
 	if configURL != "" {
-		if err := c.fetchConfigJSON(configURL); err != nil {
+		if err := c.fetchConfigJSON(ctx, configURL); err != nil {
 			c.logger.Warn("Failed to fetch config.json from %s: %v", configURL, err)
 		}
 	} else {
@@ -678,9 +851,9 @@ func (c *Config) FetchFromServer(_ string) error {
 }
 
 // fetchConfigJSON fetches and applies config.json from a URL
-func (c *Config) fetchConfigJSON(url string) error {
+func (c *Config) fetchConfigJSON(ctx context.Context, url string) error {
 	c.logger.Info("Fetching config.json from %s", url)
-	resp, err := http.Get(url)
+	resp, err := httpGet(ctx, url)
 	if err != nil {
 		return fmt.Errorf("failed to fetch config.json: %w", err)
 	}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"fusionaly-installer/internal/logging"
 )
@@ -306,7 +311,7 @@ func TestCheckDNSAndStoreWarnings(t *testing.T) {
 	c := NewConfig(testLogger(t))
 
 	// Test with invalid domain (should generate warnings)
-	c.CheckDNSAndStoreWarnings("invalid-domain-that-does-not-exist.nonexistent")
+	c.CheckDNSAndStoreWarnings(context.Background(), "invalid-domain-that-does-not-exist.nonexistent")
 
 	if !c.HasDNSWarnings() {
 		t.Error("CheckDNSAndStoreWarnings() should generate warnings for invalid domain")
@@ -334,7 +339,7 @@ func TestCheckDNSAndStoreWarningsSkipsLocalhost(t *testing.T) {
 	c := NewConfig(testLogger(t))
 
 	// Test with localhost domain (should skip checks and have no warnings)
-	c.CheckDNSAndStoreWarnings("localhost")
+	c.CheckDNSAndStoreWarnings(context.Background(), "localhost")
 
 	if c.HasDNSWarnings() {
 		t.Error("CheckDNSAndStoreWarnings() should not generate warnings for localhost")
@@ -356,7 +361,7 @@ func TestCheckDNSAndStoreWarningsSkipsLocalhost(t *testing.T) {
 	}
 
 	for _, variant := range localhostVariants {
-		c.CheckDNSAndStoreWarnings(variant)
+		c.CheckDNSAndStoreWarnings(context.Background(), variant)
 		if c.HasDNSWarnings() {
 			t.Errorf("CheckDNSAndStoreWarnings() should not generate warnings for localhost variant: %s", variant)
 		}
@@ -412,18 +417,50 @@ func TestFetchFromServer(t *testing.T) {
 	c := NewConfig(testLogger(t))
 
 	// Test with invalid URL (should not fail, just warn and continue)
-	err := c.FetchFromServer("https://invalid-url-that-does-not-exist.com")
+	err := c.FetchFromServer(context.Background(), "https://invalid-url-that-does-not-exist.com")
 	if err != nil {
 		t.Errorf("FetchFromServer() should not fail on network errors, got: %v", err)
 	}
 
 	// Test with empty URL (uses default GitHub API)
-	err = c.FetchFromServer("")
+	err = c.FetchFromServer(context.Background(), "")
 	if err != nil {
 		t.Errorf("FetchFromServer() with empty URL should not fail, got: %v", err)
 	}
 }
 
+func TestFetchFromServerStopsAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stall until the client gives up or the test ends
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	orig := latestReleaseURL
+	latestReleaseURL = srv.URL
+	t.Cleanup(func() { latestReleaseURL = orig })
+
+	c := NewConfig(testLogger(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := c.FetchFromServer(ctx, ""); err != nil {
+		t.Errorf("FetchFromServer() should fall back to defaults, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("FetchFromServer() took %s, want it to stop at the deadline", elapsed)
+	}
+	if err := c.fetchConfigJSON(ctx, srv.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fetchConfigJSON() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
 func TestConfigurationValidation(t *testing.T) {
 	t.Run("ValidateCompleteConfiguration", func(t *testing.T) {
 		c := NewConfig(testLogger(t))
@@ -521,3 +558,129 @@ func TestEnvironmentConfigCollection(t *testing.T) {
 		}
 	})
 }
+
+func TestPinAppVersion(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	if err := c.PinAppVersion("1.2.3"); err != nil {
+		t.Fatalf("PinAppVersion error: %v", err)
+	}
+	if got := c.GetData().AppImage; got != "karloscodes/fusionaly-beta:1.2.3" {
+		t.Errorf("AppImage = %q, want karloscodes/fusionaly-beta:1.2.3", got)
+	}
+
+	if err := c.PinAppVersion("1.2:3"); err == nil {
+		t.Error("PinAppVersion should reject a malformed tag")
+	}
+	if got := c.GetData().AppImage; got != "karloscodes/fusionaly-beta:1.2.3" {
+		t.Errorf("AppImage changed on invalid pin: %q", got)
+	}
+}
+
+func TestImageWithTag(t *testing.T) {
+	tests := []struct {
+		image, tag, want string
+	}{
+		{"karloscodes/fusionaly-beta:latest", "1.2.3", "karloscodes/fusionaly-beta:1.2.3"},
+		{"karloscodes/fusionaly-beta", "1.2.3", "karloscodes/fusionaly-beta:1.2.3"},
+		{"registry.local:5000/fusionaly", "v2.0.0", "registry.local:5000/fusionaly:v2.0.0"},
+		{"karloscodes/fusionaly-beta@sha256:abc", "1.2.3", "karloscodes/fusionaly-beta:1.2.3"},
+	}
+	for _, tt := range tests {
+		if got := imageWithTag(tt.image, tt.tag); got != tt.want {
+			t.Errorf("imageWithTag(%q, %q) = %q, want %q", tt.image, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestRestartPolicy(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	if c.data.RestartPolicy != DefaultRestartPolicy {
+		t.Errorf("default RestartPolicy = %q, want %q", c.data.RestartPolicy, DefaultRestartPolicy)
+	}
+
+	tmpFile := t.TempDir() + "/test.env"
+	if err := os.WriteFile(tmpFile, []byte("FUSIONALY_DOMAIN=test.example.com\nRESTART_POLICY=on-failure\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.data.RestartPolicy != "on-failure" {
+		t.Errorf("RestartPolicy = %q, want on-failure", c.data.RestartPolicy)
+	}
+	c.data.RestartPolicy = "always"
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	content, _ := os.ReadFile(tmpFile)
+	if !strings.Contains(string(content), "RESTART_POLICY=always") {
+		t.Errorf("saved config missing RESTART_POLICY=always:\n%s", content)
+	}
+
+	c.data.RestartPolicy = "sometimes"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "restart policy must be one of") {
+		t.Errorf("Validate() error = %v, want invalid restart policy", err)
+	}
+}
+
+func TestProxyPorts(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	tmpFile := t.TempDir() + "/test.env"
+	if err := os.WriteFile(tmpFile, []byte("FUSIONALY_DOMAIN=test.example.com\nHTTP_PORT=8080\nHTTPS_PORT=not-a-port\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.data.HTTPPort != 8080 || c.data.HTTPSPort != 0 {
+		t.Errorf("ports = %d/%d, want 8080/0", c.data.HTTPPort, c.data.HTTPSPort)
+	}
+	c.data.HTTPSPort = 8443
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	content, _ := os.ReadFile(tmpFile)
+	if !strings.Contains(string(content), "HTTP_PORT=8080") || !strings.Contains(string(content), "HTTPS_PORT=8443") {
+		t.Errorf("saved config missing the ports:\n%s", content)
+	}
+
+	c.data.HTTPSPort = 70000
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "https_port") {
+		t.Errorf("Validate() error = %v, want invalid https_port", err)
+	}
+}
+
+func TestAppResourceLimits(t *testing.T) {
+	c := NewConfig(testLogger(t))
+	if c.data.AppMemory != DefaultAppMemory || c.data.AppCPUs != "" {
+		t.Errorf("defaults = %q/%q, want %q and no CPU limit", c.data.AppMemory, c.data.AppCPUs, DefaultAppMemory)
+	}
+	tmpFile := t.TempDir() + "/test.env"
+	if err := os.WriteFile(tmpFile, []byte("FUSIONALY_DOMAIN=test.example.com\nAPP_MEMORY_LIMIT=1g\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadFromFile(tmpFile); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.data.AppMemory != "1g" {
+		t.Errorf("AppMemory = %q, want 1g", c.data.AppMemory)
+	}
+	c.data.AppCPUs = "0.5"
+	if err := c.SaveToFile(tmpFile); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	content, _ := os.ReadFile(tmpFile)
+	if !strings.Contains(string(content), "APP_MEMORY_LIMIT=1g") || !strings.Contains(string(content), "APP_CPU_LIMIT=0.5") {
+		t.Errorf("saved config missing the limits:\n%s", content)
+	}
+
+	c.data.AppMemory = "1m"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "app_memory_limit") {
+		t.Errorf("Validate() error = %v, want invalid app_memory_limit", err)
+	}
+	c.data.AppMemory = "1g"
+	c.data.AppCPUs = "-2"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "app_cpu_limit") {
+		t.Errorf("Validate() error = %v, want invalid app_cpu_limit", err)
+	}
+}
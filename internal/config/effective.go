@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/requirements"
+)
+
+// Output formats accepted by PrintEffectiveConfig.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ErrUnsupportedFormat is returned by PrintEffectiveConfig for a format
+// other than FormatText or FormatJSON.
+var ErrUnsupportedFormat = errors.New("unsupported config format")
+
+// Source is the layer a setting's value came from.
+type Source string
+
+// The layers of an effective configuration, lowest precedence first.
+const (
+	SourceDefault Source = "default" // Built into the installer
+	SourceFile    Source = "file"    // The installation's .env file
+	SourceEnv     Source = "env"     // The installer's process environment
+	SourceFlag    Source = "flag"    // A command-line flag
+)
+
+// redacted replaces the value of a secret setting when it is printed.
+const redacted = "<redacted>"
+
+// Setting is one effective configuration value.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+}
+
+// EffectiveConfig is the fully-resolved configuration of an installation,
+// remembering which layer supplied each value. Settings keep the order they
+// were first set in.
+type EffectiveConfig struct {
+	settings []Setting
+}
+
+// Set records value for key from source, replacing an earlier layer's value.
+func (e *EffectiveConfig) Set(key, value string, source Source) {
+	for i := range e.settings {
+		if e.settings[i].Key == key {
+			e.settings[i].Value, e.settings[i].Source = value, source
+			return
+		}
+	}
+	e.settings = append(e.settings, Setting{Key: key, Value: value, Source: source})
+}
+
+// Get returns the setting for key.
+func (e *EffectiveConfig) Get(key string) (Setting, bool) {
+	for _, s := range e.settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Settings returns every setting with its value as stored, secrets included.
+func (e *EffectiveConfig) Settings() []Setting {
+	return append([]Setting(nil), e.settings...)
+}
+
+// runtimeDefaults are the settings the installer reads from its process
+// environment rather than the .env file; only these can come from
+// SourceEnv.
+func runtimeDefaults() []Setting {
+	return []Setting{
+		{Key: "LOG_LEVEL", Value: "info"},
+		{Key: "FUSIONALY_MIN_DISK_GB", Value: strconv.FormatFloat(requirements.DefaultMinDiskGB, 'f', -1, 64)},
+		{Key: "FUSIONALY_MIN_MEMORY_MB", Value: strconv.Itoa(requirements.DefaultMinMemoryMB)},
+	}
+}
+
+// ResolveEffectiveConfig layers the installer's defaults, the .env file at
+// envFile (a missing file adds nothing), the runtime settings found by
+// lookupEnv and finally flags, each keyed like the .env file. Keys in the
+// .env file the installer does not manage are included too, since the app
+// container reads them.
+func ResolveEffectiveConfig(envFile string, lookupEnv func(string) (string, bool), flags map[string]string) (*EffectiveConfig, error) {
+	e := &EffectiveConfig{}
+	for _, s := range envSettings(NewConfig(nil).GetData()) {
+		e.Set(s.Key, s.Value, SourceDefault)
+	}
+	runtime := runtimeDefaults()
+	for _, s := range runtime {
+		e.Set(s.Key, s.Value, SourceDefault)
+	}
+
+	env, err := LoadEnvFile(envFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range env.Keys() {
+		value, _ := env.Get(key)
+		e.Set(key, value, SourceFile)
+	}
+
+	if lookupEnv != nil {
+		for _, s := range runtime {
+			if value, ok := lookupEnv(s.Key); ok && value != "" {
+				e.Set(s.Key, value, SourceEnv)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		e.Set(key, flags[key], SourceFlag)
+	}
+	return e, nil
+}
+
+// envSettings returns the .env keys of d with their values, in the order
+// SaveToFile writes them. Settings that are off when empty read "".
+func envSettings(d ConfigData) []Setting {
+	port := func(p int) string {
+		if p == 0 {
+			return ""
+		}
+		return strconv.Itoa(p)
+	}
+	return []Setting{
+		{Key: "FUSIONALY_DOMAIN", Value: d.Domain},
+		{Key: "APP_IMAGE", Value: d.AppImage},
+		{Key: "CADDY_IMAGE", Value: d.CaddyImage},
+		{Key: "INSTALL_DIR", Value: d.InstallDir},
+		{Key: "BACKUP_PATH", Value: d.BackupPath},
+		{Key: "VERSION", Value: d.Version},
+		{Key: "INSTALLER_URL", Value: d.InstallerURL},
+		{Key: "FUSIONALY_PRIVATE_KEY", Value: d.PrivateKey},
+		{Key: "FUSIONALY_USER", Value: d.User},
+		{Key: "FUSIONALY_LICENSE_KEY", Value: d.LicenseKey},
+		{Key: "ACME_EMAIL", Value: d.ACMEEmail},
+		{Key: "ACME_STAGING", Value: strconv.FormatBool(d.ACMEStaging)},
+		{Key: "RESTART_POLICY", Value: d.RestartPolicy},
+		{Key: "HTTP_PORT", Value: port(d.HTTPPort)},
+		{Key: "HTTPS_PORT", Value: port(d.HTTPSPort)},
+		{Key: "APP_MEMORY_LIMIT", Value: d.AppMemory},
+		{Key: "APP_CPU_LIMIT", Value: d.AppCPUs},
+		{Key: "FUSIONALY_ENV", Value: d.Environment},
+	}
+}
+
+// IsManagedKey reports whether key is a .env setting the installer reads
+// into ConfigData, as opposed to one only the app container uses.
+func IsManagedKey(key string) bool {
+	for _, s := range envSettings(ConfigData{}) {
+		if s.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintEffectiveConfig writes every setting with its source to w, as an
+// aligned table for FormatText or an array of objects for FormatJSON. The
+// values of secrets such as FUSIONALY_PRIVATE_KEY are replaced with
+// <redacted> unless they are empty.
+func (e *EffectiveConfig) PrintEffectiveConfig(w io.Writer, format string) error {
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("%w %q (want %s or %s)", ErrUnsupportedFormat, format, FormatText, FormatJSON)
+	}
+
+	settings := e.Settings()
+	for i, s := range settings {
+		if s.Value != "" && executor.IsSecretVar(s.Key) {
+			settings[i].Value = redacted
+		}
+	}
+
+	if format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(settings)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		value := s.Value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, value, s.Source)
+	}
+	return tw.Flush()
+}
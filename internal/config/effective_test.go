@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveEffectiveConfig_Sources(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := "FUSIONALY_DOMAIN=analytics.example.com\nAPP_MEMORY_LIMIT=1g\nFUSIONALY_PRIVATE_KEY=abcdefghijklmnopqrstuvwxyz012345\nLOG_LEVEL=warn\nSMTP_HOST=mail.example.com\n"
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"FUSIONALY_MIN_DISK_GB": "0.5", "LOG_LEVEL": "error", "APP_IMAGE": "ignored:tag"}
+	lookupEnv := func(key string) (string, bool) { v, ok := env[key]; return v, ok }
+
+	e, err := ResolveEffectiveConfig(envFile, lookupEnv, map[string]string{"LOG_LEVEL": "debug"})
+	if err != nil {
+		t.Fatalf("ResolveEffectiveConfig: %v", err)
+	}
+	for _, want := range []Setting{
+		{"CADDY_IMAGE", "caddy:2.7-alpine", SourceDefault},
+		{"APP_IMAGE", "karloscodes/fusionaly-beta:latest", SourceDefault}, // .env keys never come from the environment
+		{"FUSIONALY_DOMAIN", "analytics.example.com", SourceFile},
+		{"APP_MEMORY_LIMIT", "1g", SourceFile},
+		{"SMTP_HOST", "mail.example.com", SourceFile},
+		{"FUSIONALY_MIN_DISK_GB", "0.5", SourceEnv},
+		{"FUSIONALY_MIN_MEMORY_MB", "512", SourceDefault},
+		{"LOG_LEVEL", "debug", SourceFlag},
+	} {
+		got, ok := e.Get(want.Key)
+		if !ok || got != want {
+			t.Errorf("%s = %+v, want %+v", want.Key, got, want)
+		}
+	}
+}
+
+func TestResolveEffectiveConfig_MissingFile(t *testing.T) {
+	e, err := ResolveEffectiveConfig(filepath.Join(t.TempDir(), ".env"), nil, nil)
+	if err != nil {
+		t.Fatalf("ResolveEffectiveConfig: %v", err)
+	}
+	for _, s := range e.Settings() {
+		if s.Source != SourceDefault {
+			t.Errorf("%s came from %s without a file, environment or flags", s.Key, s.Source)
+		}
+	}
+}
+
+func TestPrintEffectiveConfig(t *testing.T) {
+	e := &EffectiveConfig{}
+	e.Set("FUSIONALY_DOMAIN", "analytics.example.com", SourceFile)
+	e.Set("FUSIONALY_PRIVATE_KEY", "abcdefghijklmnopqrstuvwxyz012345", SourceFile)
+	e.Set("FUSIONALY_LICENSE_KEY", "", SourceDefault)
+	e.Set("LOG_LEVEL", "debug", SourceFlag)
+
+	var text bytes.Buffer
+	if err := e.PrintEffectiveConfig(&text, FormatText); err != nil {
+		t.Fatalf("PrintEffectiveConfig(text): %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if len(lines) != 5 || strings.Fields(lines[0])[0] != "KEY" {
+		t.Fatalf("unexpected table:\n%s", text.String())
+	}
+	for i, want := range [][]string{
+		{"FUSIONALY_DOMAIN", "analytics.example.com", "file"},
+		{"FUSIONALY_PRIVATE_KEY", "<redacted>", "file"},
+		{"FUSIONALY_LICENSE_KEY", "-", "default"},
+		{"LOG_LEVEL", "debug", "flag"},
+	} {
+		if got := strings.Fields(lines[i+1]); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("line %d = %v, want %v", i+1, got, want)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := e.PrintEffectiveConfig(&out, FormatJSON); err != nil {
+		t.Fatalf("PrintEffectiveConfig(json): %v", err)
+	}
+	if strings.Contains(out.String(), "abcdefghijklmnopqrstuvwxyz012345") {
+		t.Errorf("JSON output leaks the private key:\n%s", out.String())
+	}
+	var settings []Setting
+	if err := json.Unmarshal(out.Bytes(), &settings); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(settings) != 4 || settings[3] != (Setting{"LOG_LEVEL", "debug", SourceFlag}) {
+		t.Errorf("settings = %+v", settings)
+	}
+	if v, _ := e.Get("FUSIONALY_PRIVATE_KEY"); v.Value != "abcdefghijklmnopqrstuvwxyz012345" {
+		t.Errorf("printing must not redact the stored value, got %q", v.Value)
+	}
+
+	if err := e.PrintEffectiveConfig(&out, "yaml"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("yaml: got %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestIsManagedKey(t *testing.T) {
+	for key, want := range map[string]bool{"APP_MEMORY_LIMIT": true, "FUSIONALY_DOMAIN": true, "SMTP_HOST": false, "": false} {
+		if got := IsManagedKey(key); got != want {
+			t.Errorf("IsManagedKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// envLine is one line of an env file. Lines that are not KEY=VALUE pairs
+// (comments, blanks, anything unparsable) keep their raw text.
+type envLine struct {
+	key   string
+	value string
+	raw   string
+}
+
+// EnvFile edits a .env file in place: values are updated where they already
+// appear, new keys are appended, and comments, unknown keys and ordering are
+// kept as the operator left them.
+type EnvFile struct {
+	path  string
+	lines []envLine
+	index map[string]int // key -> position in lines
+}
+
+// LoadEnvFile parses path. A missing file yields an empty EnvFile that Save
+// will create.
+func LoadEnvFile(path string) (*EnvFile, error) {
+	e := &EnvFile{path: path, index: make(map[string]int)}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		e.parseLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return e, nil
+}
+
+func (e *EnvFile) parseLine(line string) {
+	trimmed := strings.TrimSpace(line)
+	parts := strings.SplitN(trimmed, "=", 2)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		e.lines = append(e.lines, envLine{raw: line})
+		return
+	}
+
+	key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if i, ok := e.index[key]; ok {
+		// Later duplicates win, matching how LoadFromFile reads the file.
+		e.lines[i].value = value
+		return
+	}
+	e.index[key] = len(e.lines)
+	e.lines = append(e.lines, envLine{key: key, value: value})
+}
+
+// Get returns the value of key and whether it is present.
+func (e *EnvFile) Get(key string) (string, bool) {
+	i, ok := e.index[key]
+	if !ok {
+		return "", false
+	}
+	return e.lines[i].value, true
+}
+
+// Keys returns the keys of the file in the order they appear.
+func (e *EnvFile) Keys() []string {
+	var keys []string
+	for _, line := range e.lines {
+		if line.key != "" {
+			keys = append(keys, line.key)
+		}
+	}
+	return keys
+}
+
+// Set assigns value to key, replacing it where it already appears or
+// appending it otherwise.
+func (e *EnvFile) Set(key, value string) {
+	if i, ok := e.index[key]; ok {
+		e.lines[i].value = value
+		return
+	}
+	e.index[key] = len(e.lines)
+	e.lines = append(e.lines, envLine{key: key, value: value})
+}
+
+// SetIfAbsent assigns value only when key is missing or empty, and reports
+// whether it did. Operator-provided values are never overwritten.
+func (e *EnvFile) SetIfAbsent(key, value string) bool {
+	if existing, ok := e.Get(key); ok && existing != "" {
+		return false
+	}
+	e.Set(key, value)
+	return true
+}
+
+// Save writes the file back atomically, keeping the permissions of an
+// existing file. New files are created readable by the owner only since they
+// hold secrets. During a dry run (see executor.DryRunOf) nothing is written.
+func (e *EnvFile) Save() error {
+	if dry, ok := executor.DryRunOf(executor.Default()); ok {
+		dry.SkipFile("write", e.path)
+		return nil
+	}
+	var b strings.Builder
+	for _, line := range e.lines {
+		if line.key == "" {
+			b.WriteString(line.raw)
+		} else {
+			b.WriteString(line.key + "=" + line.value)
+		}
+		b.WriteByte('\n')
+	}
+
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(e.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(e.path), ".env-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp env file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write env file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set env file permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write env file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), e.path); err != nil {
+		return fmt.Errorf("failed to replace env file: %w", err)
+	}
+	return nil
+}
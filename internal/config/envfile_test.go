@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestEnvFile_FreshWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+
+	env, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFile error: %v", err)
+	}
+	env.Set("FUSIONALY_DOMAIN", "example.com")
+	if !env.SetIfAbsent("FUSIONALY_PRIVATE_KEY", "generated") {
+		t.Error("SetIfAbsent should set a missing key")
+	}
+	if err := env.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "FUSIONALY_DOMAIN=example.com\nFUSIONALY_PRIVATE_KEY=generated\n"
+	if string(got) != want {
+		t.Errorf("file content = %q, want %q", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("new env file permissions = %o, want 600", perm)
+	}
+}
+
+func TestEnvFile_SaveSkippedInDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("FUSIONALY_DOMAIN=old.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	orig := executor.Default()
+	t.Cleanup(func() { executor.SetDefault(orig) })
+	dry := executor.NewDryRunExecutor(nil)
+	executor.SetDefault(executor.WithHistory(dry, nil))
+
+	env, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFile error: %v", err)
+	}
+	env.Set("FUSIONALY_DOMAIN", "new.example.com")
+	if err := env.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "FUSIONALY_DOMAIN=old.example.com\n" {
+		t.Errorf("dry run changed the file: %q", got)
+	}
+	if files := dry.Files(); len(files) != 1 || files[0] != "write "+path {
+		t.Errorf("skipped files = %q, want the .env write", files)
+	}
+}
+
+func TestEnvFile_PartialUpdatePreservesCommentsAndOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	original := `# Fusionaly configuration
+FUSIONALY_DOMAIN=old.example.com
+
+# Edited by the operator
+FUSIONALY_PRIVATE_KEY=operator-key
+CUSTOM_SETTING=keep-me
+`
+	if err := os.WriteFile(path, []byte(original), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFile error: %v", err)
+	}
+	env.Set("FUSIONALY_DOMAIN", "new.example.com")
+	if env.SetIfAbsent("FUSIONALY_PRIVATE_KEY", "regenerated") {
+		t.Error("SetIfAbsent must not overwrite an existing value")
+	}
+	env.SetIfAbsent("VERSION", "latest")
+	if err := env.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Fusionaly configuration
+FUSIONALY_DOMAIN=new.example.com
+
+# Edited by the operator
+FUSIONALY_PRIVATE_KEY=operator-key
+CUSTOM_SETTING=keep-me
+VERSION=latest
+`
+	if string(got) != want {
+		t.Errorf("file content =\n%s\nwant\n%s", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o640 {
+		t.Errorf("existing permissions not kept: %o", perm)
+	}
+}
+
+func TestEnvFile_SetIfAbsentFillsEmptyValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("FUSIONALY_PRIVATE_KEY=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !env.SetIfAbsent("FUSIONALY_PRIVATE_KEY", "generated") {
+		t.Error("SetIfAbsent should fill an empty value")
+	}
+	if v, _ := env.Get("FUSIONALY_PRIVATE_KEY"); v != "generated" {
+		t.Errorf("value = %q, want generated", v)
+	}
+}
+
+func TestSaveToFile_PreservesOperatorLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	original := "# managed by ops\nFUSIONALY_PRIVATE_KEY=keep-this-private-key-value-0123456789\nSMTP_HOST=mail.internal\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig(testLogger(t))
+	c.data.Domain = "example.com"
+	if err := c.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(got)
+	for _, want := range []string{
+		"# managed by ops\n",
+		"FUSIONALY_PRIVATE_KEY=keep-this-private-key-value-0123456789\n",
+		"SMTP_HOST=mail.internal\n",
+		"FUSIONALY_DOMAIN=example.com\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q in:\n%s", want, content)
+		}
+	}
+	if !strings.HasPrefix(content, "# managed by ops\n") {
+		t.Errorf("comment should stay first:\n%s", content)
+	}
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"fusionaly-installer/internal/validation"
+)
+
+// InstallFile declares an unattended installation, e.g. for CI:
+//
+//	domain: analytics.example.com
+//	version: 1.2.3
+//	admin:
+//	  email: admin@example.com
+//	  password: ${FUSIONALY_ADMIN_PASSWORD}
+//	backup:
+//	  path: /var/backups/fusionaly
+//	  schedule: "0 2 * * *"
+//	restart_policy: unless-stopped
+//	resources:
+//	  memory: 1g
+//	  cpus: "1.5"
+//
+// Any value may reference ${ENV_VAR}s or be "file:/path" to read it from a
+// file such as a mounted secret; see resolveValue.
+type InstallFile struct {
+	Domain  string        `yaml:"domain"`
+	Version string        `yaml:"version"` // App image tag; empty installs the release default
+	Admin   InstallAdmin  `yaml:"admin"`
+	Backup  InstallBackup `yaml:"backup"`
+	// RestartPolicy is the docker restart policy of the containers; empty
+	// uses DefaultRestartPolicy.
+	RestartPolicy string           `yaml:"restart_policy"`
+	Resources     InstallResources `yaml:"resources"`
+}
+
+// InstallAdmin is the first admin account. Keep the password out of the
+// file with a ${VAR} or file: reference.
+type InstallAdmin struct {
+	Email    string `yaml:"email"`
+	Password string `yaml:"password"`
+}
+
+// InstallBackup configures database backups. Both fields are optional.
+type InstallBackup struct {
+	Path     string `yaml:"path"`     // Directory for dumps; defaults under the install dir
+	Schedule string `yaml:"schedule"` // Cron expression for `fusionaly backup`; empty schedules none
+}
+
+// InstallResources limits what the app container may use. Both fields are
+// optional; an empty memory keeps DefaultAppMemory and empty cpus leaves the
+// CPU unlimited.
+type InstallResources struct {
+	Memory string `yaml:"memory"` // Docker memory limit, e.g. 512m or 2g
+	CPUs   string `yaml:"cpus"`   // Number of CPUs, possibly fractional
+}
+
+// LoadInstallFile parses the YAML install file at path and resolves every
+// ${VAR} and file: reference in it. Keys it does not recognise are returned
+// as warnings so a typo does not silently drop a setting; unresolvable
+// references, missing required keys and malformed values are errors, all
+// reported together.
+func LoadInstallFile(path string) (*InstallFile, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read install file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("invalid install file %s: %w", path, err)
+	}
+	var warnings []string
+	if len(root.Content) > 0 {
+		warnings = unknownKeys(root.Content[0], "", installFileKeys)
+	}
+
+	var f InstallFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, warnings, fmt.Errorf("invalid install file %s: %w", path, err)
+	}
+	problems := f.resolve(os.LookupEnv, os.ReadFile)
+	if len(problems) == 0 {
+		problems = f.validate()
+	}
+	if len(problems) > 0 {
+		return nil, warnings, fmt.Errorf("invalid install file %s: %s", path, strings.Join(problems, "; "))
+	}
+	return &f, warnings, nil
+}
+
+// installFileKeys lists the recognised keys; nested maps hold a section's keys.
+var installFileKeys = map[string]any{
+	"domain":         nil,
+	"version":        nil,
+	"restart_policy": nil,
+	"admin": map[string]any{
+		"email": nil, "password": nil,
+	},
+	"backup": map[string]any{
+		"path": nil, "schedule": nil,
+	},
+	"resources": map[string]any{
+		"memory": nil, "cpus": nil,
+	},
+}
+
+// unknownKeys walks a mapping node and reports keys missing from known.
+func unknownKeys(node *yaml.Node, prefix string, known map[string]any) []string {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var warnings []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		sub, ok := known[key]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown key %q (line %d) is ignored", prefix+key, node.Content[i].Line))
+			continue
+		}
+		if section, ok := sub.(map[string]any); ok {
+			warnings = append(warnings, unknownKeys(value, prefix+key+".", section)...)
+		}
+	}
+	return warnings
+}
+
+func (f *InstallFile) validate() []string {
+	var problems []string
+	if f.Domain == "" {
+		problems = append(problems, "missing required key domain")
+	} else if err := validation.ValidateDomain(f.Domain); err != nil && !isLocalhostDomain(f.Domain) {
+		problems = append(problems, err.Error())
+	}
+	if f.Version != "" {
+		if err := validation.ValidateImageTag(f.Version); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if f.Admin.Email == "" {
+		problems = append(problems, "missing required key admin.email")
+	} else if err := validation.ValidateEmail(f.Admin.Email); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if f.Admin.Password == "" {
+		problems = append(problems, "missing required key admin.password")
+	}
+
+	if f.RestartPolicy != "" {
+		if err := validation.ValidateRestartPolicy(f.RestartPolicy); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if f.Backup.Path != "" {
+		if err := validation.ValidateFilePath(f.Backup.Path); err != nil {
+			problems = append(problems, err.Error())
+		} else if !filepath.IsAbs(f.Backup.Path) {
+			problems = append(problems, "backup.path must be an absolute path")
+		}
+	}
+	if f.Resources.Memory != "" {
+		if err := validation.ValidateMemoryLimit(f.Resources.Memory); err != nil {
+			problems = append(problems, "resources.memory: "+err.Error())
+		}
+	}
+	if f.Resources.CPUs != "" {
+		if err := validation.ValidateCPULimit(f.Resources.CPUs); err != nil {
+			problems = append(problems, "resources.cpus: "+err.Error())
+		}
+	}
+	return problems
+}
+
+// resolve replaces every value with its resolved form, reporting each
+// reference that cannot be resolved.
+func (f *InstallFile) resolve(lookupEnv func(string) (string, bool), readFile func(string) ([]byte, error)) []string {
+	var problems []string
+	for _, field := range []struct {
+		key   string
+		value *string
+	}{
+		{"domain", &f.Domain},
+		{"version", &f.Version},
+		{"admin.email", &f.Admin.Email},
+		{"admin.password", &f.Admin.Password},
+		{"backup.path", &f.Backup.Path},
+		{"backup.schedule", &f.Backup.Schedule},
+		{"restart_policy", &f.RestartPolicy},
+		{"resources.memory", &f.Resources.Memory},
+		{"resources.cpus", &f.Resources.CPUs},
+	} {
+		resolved, err := resolveValue(*field.value, lookupEnv, readFile)
+		if err != nil {
+			problems = append(problems, field.key+": "+err.Error())
+			continue
+		}
+		*field.value = resolved
+	}
+	return problems
+}
+
+// resolveValue returns the contents of the file for a "file:/path" value,
+// without the trailing newline, and otherwise expands each ${VAR} from the
+// environment. Only the braced form is expanded, so a literal $ in a
+// password survives. A variable that is not set or a file that cannot be
+// read is an error; a variable set to "" expands to "".
+func resolveValue(value string, lookupEnv func(string) (string, bool), readFile func(string) ([]byte, error)) (string, error) {
+	if path, ok := strings.CutPrefix(value, "file:"); ok {
+		if !filepath.IsAbs(path) {
+			return "", fmt.Errorf("file reference %q must be an absolute path", path)
+		}
+		data, err := readFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %w", path, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", value)
+		}
+		name := value[start+2 : start+end]
+		if name == "" {
+			return "", fmt.Errorf("empty ${} reference")
+		}
+		env, ok := lookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		b.WriteString(value[:start])
+		b.WriteString(env)
+		value = value[start+end+1:]
+	}
+}
+
+// CollectFromFile fills in the configuration from f instead of prompting,
+// the unattended counterpart of CollectFromUser.
+func (c *Config) CollectFromFile(ctx context.Context, f *InstallFile) {
+	c.data.Domain = f.Domain
+	c.data.InstallDir = DefaultDataDir
+	c.data.BackupPath = filepath.Join(c.data.InstallDir, "storage", "backups")
+	if f.Backup.Path != "" {
+		c.data.BackupPath = f.Backup.Path
+	}
+	if f.RestartPolicy != "" {
+		c.data.RestartPolicy = f.RestartPolicy
+	}
+	if f.Resources.Memory != "" {
+		c.data.AppMemory = f.Resources.Memory
+	}
+	c.data.AppCPUs = f.Resources.CPUs
+	c.CheckDNSAndStoreWarnings(ctx, c.data.Domain)
+	c.logger.Success("Configuration loaded from install file")
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeInstallFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fusionaly.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadInstallFile(t *testing.T) {
+	t.Setenv("FUSIONALY_ADMIN_PASSWORD", "s3cret-Passw0rd")
+	path := writeInstallFile(t, `# CI install
+domain: analytics.example.com
+version: 1.2.3
+admin:
+  email: admin@example.com
+  password: ${FUSIONALY_ADMIN_PASSWORD}
+backup:
+  path: /var/backups/fusionaly
+  schedule: "0 2 * * *"
+restart_policy: always
+resources:
+  memory: 1g
+  cpus: "0.5"
+`)
+	f, warnings, err := LoadInstallFile(path)
+	if err != nil {
+		t.Fatalf("LoadInstallFile: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	want := &InstallFile{
+		Domain:  "analytics.example.com",
+		Version: "1.2.3",
+		Admin:   InstallAdmin{Email: "admin@example.com", Password: "s3cret-Passw0rd"},
+		Backup:  InstallBackup{Path: "/var/backups/fusionaly", Schedule: "0 2 * * *"},
+
+		RestartPolicy: "always",
+		Resources:     InstallResources{Memory: "1g", CPUs: "0.5"},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("got %+v\nwant %+v", f, want)
+	}
+}
+
+func TestLoadInstallFile_WarnsOnUnknownKeys(t *testing.T) {
+	path := writeInstallFile(t, `domain: analytics.example.com
+verison: 1.2.3
+admin:
+  email: admin@example.com
+  password: s3cret-Passw0rd
+  role: owner
+`)
+	f, warnings, err := LoadInstallFile(path)
+	if err != nil {
+		t.Fatalf("LoadInstallFile: %v", err)
+	}
+	if f.Version != "" {
+		t.Errorf("misspelled key should not set Version, got %q", f.Version)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], `"verison" (line 2)`) || !strings.Contains(warnings[1], `"admin.role" (line 6)`) {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestLoadInstallFile_MissingRequiredKeys(t *testing.T) {
+	path := writeInstallFile(t, "backup:\n  path: relative/dir\nrestart_policy: sometimes\nresources:\n  memory: 1m\n  cpus: \"0\"\n")
+	_, _, err := LoadInstallFile(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"missing required key domain",
+		"missing required key admin.email",
+		"missing required key admin.password",
+		"backup.path must be an absolute path",
+		"restart policy must be one of",
+		"resources.memory: ",
+		"resources.cpus: ",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadInstallFile_MissingReference(t *testing.T) {
+	path := writeInstallFile(t, `domain: analytics.example.com
+admin:
+  email: admin@example.com
+  password: ${FUSIONALY_TEST_UNSET_PASSWORD}
+backup:
+  path: file:/nonexistent/backup-path
+`)
+	_, _, err := LoadInstallFile(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"admin.password: environment variable FUSIONALY_TEST_UNSET_PASSWORD is not set",
+		"backup.path: cannot read /nonexistent/backup-path",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestResolveValue(t *testing.T) {
+	env := map[string]string{"USER_NAME": "admin", "DOMAIN": "example.com", "EMPTY": ""}
+	lookupEnv := func(name string) (string, bool) { v, ok := env[name]; return v, ok }
+	secret := filepath.Join(t.TempDir(), "admin")
+	if err := os.WriteFile(secret, []byte("from-a-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ value, want string }{
+		{"literal", "literal"},
+		{"pa$$word", "pa$$word"},
+		{"$HOME", "$HOME"},
+		{"${USER_NAME}@${DOMAIN}", "admin@example.com"},
+		{"x${EMPTY}y", "xy"},
+		{"file:" + secret, "from-a-file"},
+	} {
+		got, err := resolveValue(tc.value, lookupEnv, os.ReadFile)
+		if err != nil || got != tc.want {
+			t.Errorf("resolveValue(%q) = %q, %v; want %q", tc.value, got, err, tc.want)
+		}
+	}
+
+	for _, value := range []string{"${MISSING}", "${UNTERMINATED", "${}", "file:relative/path", "file:/nonexistent/secret"} {
+		if _, err := resolveValue(value, lookupEnv, os.ReadFile); err == nil {
+			t.Errorf("resolveValue(%q): expected an error", value)
+		}
+	}
+}
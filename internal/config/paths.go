@@ -0,0 +1,35 @@
+package config
+
+import "path/filepath"
+
+const (
+	// DefaultDataDir is where a standard installation keeps its files.
+	DefaultDataDir = "/opt/fusionaly"
+	// DefaultFnctlPath is the app's admin CLI inside the app container.
+	DefaultFnctlPath = "/app/fnctl"
+)
+
+// InstallPaths locates one installation's files. Installs in other
+// directories, or several on one host, each get their own InstallPaths.
+type InstallPaths struct {
+	BinaryPath string // fnctl inside the app container
+	CaddyFile  string // Generated proxy configuration, the stack's only definition file
+	DataDir    string // Install directory holding storage, logs and backups
+	EnvFile    string // The installation's .env file
+}
+
+// DefaultInstallPaths returns the paths of a standard installation.
+func DefaultInstallPaths() InstallPaths {
+	return PathsForDir(DefaultDataDir)
+}
+
+// PathsForDir returns the paths of an installation in dataDir, laid out
+// like a standard one.
+func PathsForDir(dataDir string) InstallPaths {
+	return InstallPaths{
+		BinaryPath: DefaultFnctlPath,
+		CaddyFile:  filepath.Join(dataDir, "Caddyfile"),
+		DataDir:    dataDir,
+		EnvFile:    filepath.Join(dataDir, ".env"),
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// PrivateKeyLength is the size of a generated FUSIONALY_PRIVATE_KEY, which
+// Validate requires to be at least 32 characters.
+const PrivateKeyLength = 32
+
+// GenerateSecret returns a random string of exactly length characters drawn
+// from the URL-safe base64 alphabet (A-Z, a-z, 0-9, '-', '_'), so it can be
+// used verbatim in .env files, URLs and headers.
+func GenerateSecret(length int) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("secret length must be positive, got %d", length)
+	}
+
+	raw := make([]byte, (length*6+7)/8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)[:length], nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var urlSafeSecret = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func TestGenerateSecret(t *testing.T) {
+	for _, length := range []int{1, 16, 32, 43, 64, 100} {
+		secret, err := GenerateSecret(length)
+		if err != nil {
+			t.Fatalf("GenerateSecret(%d) error: %v", length, err)
+		}
+		if len(secret) != length {
+			t.Errorf("GenerateSecret(%d) length = %d", length, len(secret))
+		}
+		if !urlSafeSecret.MatchString(secret) {
+			t.Errorf("GenerateSecret(%d) = %q contains non URL-safe characters", length, secret)
+		}
+	}
+}
+
+func TestGenerateSecret_Differs(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		secret, err := GenerateSecret(32)
+		if err != nil {
+			t.Fatalf("GenerateSecret error: %v", err)
+		}
+		if seen[secret] {
+			t.Fatalf("GenerateSecret produced duplicate secret %q", secret)
+		}
+		seen[secret] = true
+	}
+}
+
+func TestGenerateSecret_InvalidLength(t *testing.T) {
+	for _, length := range []int{0, -1} {
+		if _, err := GenerateSecret(length); err == nil {
+			t.Errorf("GenerateSecret(%d) expected error", length)
+		}
+	}
+}
+
+func TestSaveToFile_KeepsExistingPrivateKey(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+
+	c := NewConfig(testLogger(t))
+	c.data.Domain = "example.com"
+	if err := c.SaveToFile(envFile); err != nil {
+		t.Fatalf("SaveToFile error: %v", err)
+	}
+	generated := c.data.PrivateKey
+	if !urlSafeSecret.MatchString(generated) || len(generated) != PrivateKeyLength {
+		t.Fatalf("unexpected generated key %q", generated)
+	}
+
+	// Re-running the install loads the file and saves it again.
+	reloaded := NewConfig(testLogger(t))
+	if err := reloaded.LoadFromFile(envFile); err != nil {
+		t.Fatalf("LoadFromFile error: %v", err)
+	}
+	if err := reloaded.SaveToFile(envFile); err != nil {
+		t.Fatalf("SaveToFile error: %v", err)
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "FUSIONALY_PRIVATE_KEY="+generated+"\n") {
+		t.Errorf("private key was regenerated:\n%s", data)
+	}
+}
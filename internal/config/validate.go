@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fusionaly-installer/internal/validation"
+)
+
+const (
+	// minPrivateKeyLength matches the check in Config.Validate.
+	minPrivateKeyLength = 32
+	// minPassphraseLength is the shortest BACKUP_PASSPHRASE accepted.
+	minPassphraseLength = 12
+)
+
+// requiredKeys must be present and non-empty in every installation's .env.
+var requiredKeys = []string{
+	"FUSIONALY_DOMAIN",
+	"APP_IMAGE",
+	"CADDY_IMAGE",
+	"INSTALL_DIR",
+	"FUSIONALY_PRIVATE_KEY",
+}
+
+// ValidationError is one problem found by ValidateConfig. Secret values are
+// never included in Message.
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// ValidateConfig checks the .env file at path and reports every problem it
+// finds: missing required keys, malformed domains, emails and paths, ports
+// that are not numbers in range, and secrets shorter than their minimum. The
+// error is only set when the file cannot be read at all.
+func ValidateConfig(path string) ([]ValidationError, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	env, err := LoadEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []ValidationError
+	report := func(key, format string, args ...any) {
+		problems = append(problems, ValidationError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	// reason drops the field/value prefix the validation package adds, which
+	// would repeat the key and could echo a secret.
+	reason := func(err error) string {
+		msg := err.Error()
+		if i := strings.LastIndex(msg, ": "); i >= 0 {
+			return msg[i+2:]
+		}
+		return msg
+	}
+
+	for _, key := range requiredKeys {
+		if value, _ := env.Get(key); value == "" {
+			report(key, "required key is missing or empty")
+		}
+	}
+
+	if domain, _ := env.Get("FUSIONALY_DOMAIN"); domain != "" && !isLocalhostDomain(domain) {
+		if err := validation.ValidateDomain(domain); err != nil {
+			report("FUSIONALY_DOMAIN", "%s", reason(err))
+		}
+	}
+
+	for _, key := range []string{"INSTALL_DIR", "BACKUP_PATH"} {
+		if value, _ := env.Get(key); value != "" {
+			if err := validation.ValidateFilePath(value); err != nil {
+				report(key, "%s", reason(err))
+			}
+		}
+	}
+
+	for _, key := range []string{"ACME_EMAIL", "SMTP_FROM"} {
+		if value, _ := env.Get(key); value != "" {
+			if err := validation.ValidateEmail(value); err != nil {
+				report(key, "%s", reason(err))
+			}
+		}
+	}
+
+	if policy, ok := env.Get("RESTART_POLICY"); ok {
+		if err := validation.ValidateRestartPolicy(policy); err != nil {
+			report("RESTART_POLICY", "%s", reason(err))
+		}
+	}
+
+	for _, line := range env.lines {
+		if strings.HasSuffix(line.key, "_PORT") {
+			if err := validation.ValidatePort(line.value); err != nil {
+				report(line.key, "%s", reason(err))
+			}
+		}
+	}
+
+	if key, _ := env.Get("FUSIONALY_PRIVATE_KEY"); key != "" && len(key) < minPrivateKeyLength {
+		report("FUSIONALY_PRIVATE_KEY", "too short (%d characters, minimum %d)", len(key), minPrivateKeyLength)
+	}
+	if passphrase, _ := env.Get("BACKUP_PASSPHRASE"); passphrase != "" && len(passphrase) < minPassphraseLength {
+		report("BACKUP_PASSPHRASE", "too short (%d characters, minimum %d)", len(passphrase), minPassphraseLength)
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeEnv(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateConfig_Valid(t *testing.T) {
+	path := writeEnv(t, `FUSIONALY_DOMAIN=analytics.example.com
+APP_IMAGE=karloscodes/fusionaly-beta:1.2.3
+CADDY_IMAGE=caddy:2.7-alpine
+INSTALL_DIR=/opt/fusionaly
+BACKUP_PATH=/opt/fusionaly/storage/backups
+FUSIONALY_PRIVATE_KEY=0123456789abcdef0123456789abcdef
+SMTP_PORT=587
+`)
+	problems, err := ValidateConfig(path)
+	if err != nil {
+		t.Fatalf("ValidateConfig error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestValidateConfig_ReportsEveryProblem(t *testing.T) {
+	path := writeEnv(t, `# deliberately broken
+FUSIONALY_DOMAIN=bad..domain
+APP_IMAGE=
+INSTALL_DIR=/opt/fusionaly
+BACKUP_PATH=/opt/<backups>
+FUSIONALY_PRIVATE_KEY=short-secret
+BACKUP_PASSPHRASE=hunter2
+SMTP_PORT=smtp
+FUSIONALY_APP_PORT=70000
+ACME_EMAIL=not-an-email
+RESTART_POLICY=sometimes
+`)
+	problems, err := ValidateConfig(path)
+	if err != nil {
+		t.Fatalf("ValidateConfig error: %v", err)
+	}
+
+	want := map[string]string{
+		"APP_IMAGE":             "missing or empty",
+		"CADDY_IMAGE":           "missing or empty",
+		"FUSIONALY_DOMAIN":      "consecutive dots",
+		"BACKUP_PATH":           "invalid characters",
+		"FUSIONALY_PRIVATE_KEY": "too short",
+		"BACKUP_PASSPHRASE":     "too short",
+		"SMTP_PORT":             "valid integer",
+		"FUSIONALY_APP_PORT":    "between 1 and 65535",
+		"ACME_EMAIL":            "invalid email format",
+		"RESTART_POLICY":        "must be one of",
+	}
+	got := map[string]string{}
+	for _, p := range problems {
+		got[p.Key] = p.Message
+	}
+	for key, msg := range want {
+		if !strings.Contains(got[key], msg) {
+			t.Errorf("%s: got %q, want message containing %q", key, got[key], msg)
+		}
+	}
+	if len(problems) != len(want) {
+		t.Errorf("expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+
+	for _, p := range problems {
+		for _, secret := range []string{"short-secret", "hunter2"} {
+			if strings.Contains(p.Error(), secret) {
+				t.Errorf("problem %q leaks a secret", p.Error())
+			}
+		}
+	}
+}
+
+func TestValidateConfig_MissingFile(t *testing.T) {
+	if _, err := ValidateConfig(filepath.Join(t.TempDir(), ".env")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
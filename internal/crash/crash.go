@@ -0,0 +1,134 @@
+// Package crash turns a panic into a report the operator can attach to a bug
+// report, instead of leaving them with a bare stack trace.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/exitcode"
+	"fusionaly-installer/internal/logging"
+)
+
+// redacted replaces secret values in a report.
+const redacted = "<redacted>"
+
+// Reporter writes a crash report when the command it guards panics.
+type Reporter struct {
+	Logger  *logging.Logger
+	Dir     string            // Where reports are written; os.TempDir() when it does not exist
+	Version string            // Installer version
+	Command string            // Subcommand being run; its arguments are left out as they may hold secrets
+	EnvFile string            // Summarised in the report with secret values redacted
+	History *executor.History // Recent host commands; nil leaves them out
+	Exit    func(code int)    // Ends the process after the report; nil means os.Exit
+
+	now func() time.Time // nil means time.Now
+}
+
+// Recover must be deferred directly, as `defer r.Recover()`, at the top of
+// the command. On a panic it logs it, writes a report with the stack, the
+// recent commands and the configuration, prints where the report is, and
+// exits with exitcode.Generic.
+func (r *Reporter) Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	stack := debug.Stack()
+	r.Logger.Error("Unexpected internal error: %v", v)
+
+	path, err := r.write(v, stack)
+	if err != nil {
+		r.Logger.Error("Failed to write crash report: %v", err)
+		fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", v, stack)
+	} else {
+		fmt.Printf("💥 Fusionaly installer crashed. A crash report was written to %s\n", path)
+		fmt.Println("   Please attach it when reporting the problem; secrets in it are redacted.")
+	}
+
+	exit := r.Exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(exitcode.Generic)
+}
+
+// write saves the report and returns its path.
+func (r *Reporter) write(v any, stack []byte) (string, error) {
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	t := now()
+
+	dir := r.Dir
+	if info, err := os.Stat(dir); dir == "" || err != nil || !info.IsDir() {
+		dir = os.TempDir()
+	}
+	path := filepath.Join(dir, "fusionaly-crash-"+t.UTC().Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(r.report(t, v, stack)), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (r *Reporter) report(t time.Time, v any, stack []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Fusionaly installer crash report\n\n")
+	fmt.Fprintf(&b, "Time:      %s\n", t.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version:   %s\n", r.Version)
+	fmt.Fprintf(&b, "Go:        %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Command:   fusionaly %s\n", r.Command)
+	fmt.Fprintf(&b, "Panic:     %v\n", v)
+
+	b.WriteString("\nRecent commands:\n")
+	var records []executor.CommandRecord
+	if r.History != nil {
+		records = r.History.Recent()
+	}
+	if len(records) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, rec := range records {
+		outcome := fmt.Sprintf("exit %d", rec.ExitCode)
+		if rec.Err != "" {
+			outcome += ": " + rec.Err
+		}
+		fmt.Fprintf(&b, "  %s  %-16s %8s  %s\n", rec.Start.UTC().Format("15:04:05"), rec.Command, rec.Duration.Round(time.Millisecond), outcome)
+	}
+
+	fmt.Fprintf(&b, "\nConfiguration (%s):\n", r.EnvFile)
+	b.WriteString(r.configSummary())
+
+	fmt.Fprintf(&b, "\nStack:\n%s", stack)
+	return b.String()
+}
+
+// configSummary lists the .env settings with every secret value redacted.
+func (r *Reporter) configSummary() string {
+	env, err := config.LoadEnvFile(r.EnvFile)
+	if err != nil {
+		return fmt.Sprintf("  (unreadable: %v)\n", err)
+	}
+	keys := env.Keys()
+	if len(keys) == 0 {
+		return "  (not found)\n"
+	}
+	var b strings.Builder
+	for _, key := range keys {
+		value, _ := env.Get(key)
+		if executor.IsSecretVar(key) && value != "" {
+			value = redacted
+		}
+		fmt.Fprintf(&b, "  %s=%s\n", key, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package crash
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/exitcode"
+	"fusionaly-installer/internal/logging"
+)
+
+type okRunner struct{}
+
+func (okRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	return executor.Result{}, nil
+}
+
+func TestRecoverWritesRedactedReport(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	env := "DOMAIN=analytics.example.com\nFUSIONALY_PRIVATE_KEY=super-secret-key\nFUSIONALY_LICENSE_KEY=license-1234\nAPP_IMAGE=karloscodes/fusionaly-beta:latest\n"
+	if err := os.WriteFile(envFile, []byte(env), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	history := executor.NewHistory(10)
+	exec := executor.WithHistory(okRunner{}, history)
+	exec.Run(context.Background(), "docker", "ps", "--filter", "name=fusionaly-app")
+
+	exitCode := -1
+	r := &Reporter{
+		Logger:  logging.NewLogger(logging.Config{LogDir: t.TempDir()}),
+		Dir:     dir,
+		Version: "1.2.3",
+		Command: "update",
+		EnvFile: envFile,
+		History: history,
+		now:     func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) },
+		Exit:    func(code int) { exitCode = code },
+	}
+	func() {
+		defer r.Recover()
+		panic("forced failure")
+	}()
+
+	if exitCode != exitcode.Generic {
+		t.Errorf("exit code = %d, want %d", exitCode, exitcode.Generic)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "fusionaly-crash-20240501-120000.txt"))
+	if err != nil {
+		t.Fatalf("crash report not written: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		"Version:   1.2.3",
+		"Command:   fusionaly update",
+		"Panic:     forced failure",
+		"docker ps",
+		"DOMAIN=analytics.example.com",
+		"FUSIONALY_PRIVATE_KEY=<redacted>",
+		"FUSIONALY_LICENSE_KEY=<redacted>",
+		"crash_test.go",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+	for _, secret := range []string{"super-secret-key", "license-1234", "name=fusionaly-app"} {
+		if strings.Contains(report, secret) {
+			t.Errorf("report leaks %q", secret)
+		}
+	}
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	r := &Reporter{Exit: func(int) { t.Error("exit called without a panic") }}
+	func() {
+		defer r.Recover()
+	}()
+}
+
+func TestRecoverFallsBackToTempDir(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	r := &Reporter{
+		Logger:  logging.NewLogger(logging.Config{LogDir: t.TempDir()}),
+		Dir:     filepath.Join(tmp, "missing"),
+		EnvFile: filepath.Join(tmp, "missing", ".env"),
+		Exit:    func(int) {},
+	}
+	func() {
+		defer r.Recover()
+		panic("no install dir yet")
+	}()
+
+	matches, _ := filepath.Glob(filepath.Join(tmp, "fusionaly-crash-*.txt"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one report in %s, got %v", tmp, matches)
+	}
+}
@@ -0,0 +1,156 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fusionaly-installer/internal/executor"
+)
+
+const (
+	// DefaultBackupCronFile is the path to the scheduled backup cron file
+	DefaultBackupCronFile = "/etc/cron.d/fusionaly-backup"
+	// DefaultBackupSchedule runs backups at 2:00 AM daily, ahead of the update job
+	DefaultBackupSchedule = "0 2 * * *"
+)
+
+// cronField describes the allowed values of one schedule field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Optional names for min, min+1, ...
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronMacros are the schedule shorthands cron accepts in place of five fields.
+var cronMacros = map[string]bool{
+	"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// ValidateCronExpression checks a five-field cron schedule such as
+// "0 2 * * *", or one of the @daily style macros.
+func ValidateCronExpression(expr string) error {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@") {
+		if !cronMacros[strings.ToLower(expr)] {
+			return fmt.Errorf("invalid cron expression %q: unknown macro", expr)
+		}
+		return nil
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("invalid cron expression %q: want 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+	for i, field := range fields {
+		if err := cronFields[i].validate(field); err != nil {
+			return fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+	return nil
+}
+
+// validate checks a comma-separated list of values, ranges and steps.
+func (f cronField) validate(field string) error {
+	for _, item := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n < 1 {
+				return fmt.Errorf("%s: invalid step %q", f.name, step)
+			}
+		}
+		if rng == "*" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(rng, "-")
+		start, err := f.value(lo)
+		if err != nil {
+			return err
+		}
+		if isRange {
+			end, err := f.value(hi)
+			if err != nil {
+				return err
+			}
+			if start > end {
+				return fmt.Errorf("%s: range %q runs backwards", f.name, rng)
+			}
+		} else if hasStep {
+			return fmt.Errorf("%s: step %q needs * or a range", f.name, item)
+		}
+	}
+	return nil
+}
+
+// value parses a single number or name within the field's bounds.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s: %d is out of range %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// backupCronEntry renders the cron file that runs the backup command.
+func (m *Manager) backupCronEntry(cronExpr string) string {
+	content := "# Fusionaly scheduled backups\n"
+	content += "SHELL=/bin/bash\n"
+	content += "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n"
+	content += fmt.Sprintf("INSTALL_DIR=%s\n", m.installDir)
+	content += fmt.Sprintf("%s root cd %s && %s backup >> %s/logs/backup.log 2>&1\n",
+		strings.Join(strings.Fields(cronExpr), " "),
+		m.installDir,
+		m.binaryPath,
+		m.installDir)
+	return content
+}
+
+// InstallBackupSchedule writes a cron entry that runs the backup command on
+// cronExpr, replacing any previous schedule.
+func (m *Manager) InstallBackupSchedule(cronExpr string) error {
+	if err := ValidateCronExpression(cronExpr); err != nil {
+		return err
+	}
+
+	logsDir := filepath.Join(m.installDir, "logs")
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		m.logger.Warn("Failed to create logs directory: %v", err)
+	}
+
+	if err := executor.WriteFile(executor.Default(), m.backupFile, []byte(m.backupCronEntry(cronExpr)), 0o644); err != nil {
+		return fmt.Errorf("failed to write cron file %s: %w", m.backupFile, err)
+	}
+
+	m.logger.Success("Scheduled backups with cron expression %q", cronExpr)
+	return nil
+}
+
+// RemoveBackupSchedule deletes the scheduled backup cron entry. Removing a
+// schedule that was never installed is not an error.
+func (m *Manager) RemoveBackupSchedule() error {
+	if err := executor.Remove(executor.Default(), m.backupFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cron file %s: %w", m.backupFile, err)
+	}
+	m.logger.Success("Scheduled backups removed")
+	return nil
+}
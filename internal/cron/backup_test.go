@@ -0,0 +1,97 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCronExpression(t *testing.T) {
+	valid := []string{
+		"0 2 * * *",
+		DefaultBackupSchedule,
+		"*/15 * * * *",
+		"30 1-5/2 * * mon-fri",
+		"0 0 1,15 * *",
+		"0 3 * jan,jul 0",
+		"0 3 * * 7",
+		"@daily",
+		"@Weekly",
+	}
+	for _, expr := range valid {
+		if err := ValidateCronExpression(expr); err != nil {
+			t.Errorf("ValidateCronExpression(%q) = %v, want nil", expr, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":             "want 5 fields",
+		"0 2 * *":      "want 5 fields",
+		"0 2 * * * *":  "want 5 fields",
+		"60 2 * * *":   "minute: 60 is out of range",
+		"0 24 * * *":   "hour: 24 is out of range",
+		"0 2 0 * *":    "day of month: 0 is out of range",
+		"0 2 * 13 *":   "month: 13 is out of range",
+		"0 2 * * 8":    "day of week: 8 is out of range",
+		"0 2 * * xyz":  "day of week: invalid value",
+		"*/0 * * * *":  "invalid step",
+		"5/10 * * * *": "needs * or a range",
+		"0 5-1 * * *":  "runs backwards",
+		"@reboot":      "unknown macro",
+	}
+	for expr, want := range invalid {
+		err := ValidateCronExpression(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateCronExpression(%q) = %v, want error containing %q", expr, err, want)
+		}
+	}
+}
+
+func TestBackupCronEntry(t *testing.T) {
+	mgr := NewManager(testLogger(t))
+	got := mgr.backupCronEntry("0  2 * * *")
+	want := "# Fusionaly scheduled backups\n" +
+		"SHELL=/bin/bash\n" +
+		"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n" +
+		"INSTALL_DIR=/opt/fusionaly\n" +
+		"0 2 * * * root cd /opt/fusionaly && /usr/local/bin/fusionaly backup >> /opt/fusionaly/logs/backup.log 2>&1\n"
+	if got != want {
+		t.Errorf("backupCronEntry mismatch\nwant %q\ngot  %q", want, got)
+	}
+}
+
+func TestInstallAndRemoveBackupSchedule(t *testing.T) {
+	dir := t.TempDir()
+	mgr := NewManager(testLogger(t))
+	mgr.installDir = dir
+	mgr.backupFile = filepath.Join(dir, "fusionaly-backup")
+
+	if err := mgr.InstallBackupSchedule("bogus"); err == nil {
+		t.Fatal("expected invalid expression to be rejected")
+	}
+	if _, err := os.Stat(mgr.backupFile); !os.IsNotExist(err) {
+		t.Fatal("cron file should not be written for an invalid expression")
+	}
+
+	if err := mgr.InstallBackupSchedule("@daily"); err != nil {
+		t.Fatalf("InstallBackupSchedule: %v", err)
+	}
+	content, err := os.ReadFile(mgr.backupFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "@daily root cd "+dir) {
+		t.Errorf("unexpected cron file:\n%s", content)
+	}
+
+	if err := mgr.RemoveBackupSchedule(); err != nil {
+		t.Fatalf("RemoveBackupSchedule: %v", err)
+	}
+	if _, err := os.Stat(mgr.backupFile); !os.IsNotExist(err) {
+		t.Error("cron file still present after RemoveBackupSchedule")
+	}
+	if err := mgr.RemoveBackupSchedule(); err != nil {
+		t.Errorf("removing a missing schedule should succeed, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
 )
 
@@ -23,6 +24,7 @@ const (
 type Manager struct {
 	logger     *logging.Logger
 	cronFile   string
+	backupFile string
 	installDir string
 	binaryPath string
 	schedule   string
@@ -33,6 +35,7 @@ func NewManager(logger *logging.Logger) *Manager {
 	return &Manager{
 		logger:     logger,
 		cronFile:   DefaultCronFile,
+		backupFile: DefaultBackupCronFile,
 		installDir: DefaultInstallDir,
 		binaryPath: DefaultBinaryPath,
 		schedule:   DefaultCronSchedule,
@@ -65,7 +68,7 @@ func (m *Manager) SetupCronJob() error {
 		m.logger.Warn("Failed to create logs directory: %v", err)
 	}
 
-	if err := os.WriteFile(m.cronFile, []byte(cronContent), 0o644); err != nil {
+	if err := executor.WriteFile(executor.Default(), m.cronFile, []byte(cronContent), 0o644); err != nil {
 		m.logger.Error("Cron setup failed: %v", err)
 		return fmt.Errorf("failed to write cron file %s: %w", m.cronFile, err)
 	}
@@ -74,3 +77,13 @@ func (m *Manager) SetupCronJob() error {
 	m.logger.InfoWithTime("Automatic updates scheduled for 3:00 AM daily")
 	return nil
 }
+
+// RemoveCronJob deletes the automated update cron job. Removing a job that
+// was never installed is not an error.
+func (m *Manager) RemoveCronJob() error {
+	if err := executor.Remove(executor.Default(), m.cronFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cron file %s: %w", m.cronFile, err)
+	}
+	m.logger.Success("Automatic updates removed")
+	return nil
+}
@@ -2,16 +2,17 @@ package database
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
 )
 
@@ -60,9 +61,15 @@ func DefaultRetentionConfig() RetentionConfig {
 
 // Database manages database operations
 type Database struct {
-	logger    *logging.Logger
-	retention RetentionConfig
-	clock     Clock
+	logger     *logging.Logger
+	retention  RetentionConfig
+	clock      Clock
+	runner     executor.Executor
+	passphrase func() (string, error)
+	isTerminal func() bool                                    // Reports whether stdin is a terminal; nil checks os.Stdin
+	attach     func(ctx context.Context, args []string) error // Runs an interactive client; nil uses attachTerminal
+	input      io.Reader                                      // Answers for RestoreInteractive; nil reads os.Stdin
+	apps       []string                                       // App containers Backup and Restore use; nil means defaultAppContainers
 }
 
 // NewDatabase creates a new Database instance
@@ -71,16 +78,22 @@ func NewDatabase(logger *logging.Logger) *Database {
 		logger:    logger,
 		retention: DefaultRetentionConfig(),
 		clock:     realClock{},
+		runner:    executor.Default(),
 	}
 }
 
+// SetAppContainers sets the app containers whose database Backup and Restore
+// use, e.g. a named instance's docker.Names AppPrimary and AppSecondary.
+func (d *Database) SetAppContainers(names ...string) {
+	d.apps = names
+}
+
 // EnsureSQLiteInstalled installs SQLite if not already available
 func (d *Database) EnsureSQLiteInstalled() error {
 	d.logger.Info("Checking for SQLite installation...")
 
 	// Try to run sqlite3 --version to check if it's installed
-	cmd := exec.Command("sqlite3", "--version")
-	if err := cmd.Run(); err == nil {
+	if _, err := d.command(context.Background(), "sqlite3", "--version"); err == nil {
 		d.logger.Success("SQLite is already installed")
 		return nil
 	}
@@ -92,14 +105,12 @@ func (d *Database) EnsureSQLiteInstalled() error {
 	}
 
 	// Install sqlite3
-	installCmd := exec.Command("apt-get", "install", "-y", "sqlite3")
-	if err := installCmd.Run(); err != nil {
+	if _, err := d.command(context.Background(), "apt-get", "install", "-y", "sqlite3"); err != nil {
 		return fmt.Errorf("failed to install SQLite: %w", err)
 	}
 
 	// Verify installation
-	verifyCmd := exec.Command("sqlite3", "--version")
-	if err := verifyCmd.Run(); err != nil {
+	if _, err := d.command(context.Background(), "sqlite3", "--version"); err != nil {
 		return fmt.Errorf("SQLite installation verification failed: %w", err)
 	}
 
@@ -191,11 +202,8 @@ func (d *Database) BackupDatabase(dbPath, backupDir string) (string, error) {
 	d.logger.Info("Creating backup of %s", dbPath)
 
 	// Create backup using SQLite's .backup command
-	cmd := exec.Command("sqlite3", dbPath, fmt.Sprintf(".backup '%s'", backupFile))
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("sqlite3 backup failed: %w - %s", err, stderr.String())
+	if res, err := d.command(context.Background(), "sqlite3", dbPath, fmt.Sprintf(".backup '%s'", backupFile)); err != nil {
+		return "", fmt.Errorf("sqlite3 backup failed: %w - %s", err, res.Stderr)
 	}
 
 	// Verify the backup was created
@@ -304,20 +312,16 @@ func (d *Database) ValidateBackup(backupFile string) error {
 	}
 
 	// SQLite integrity check using PRAGMA integrity_check
-	cmd := exec.Command("sqlite3", backupFile, "PRAGMA integrity_check;")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	res, err := d.command(context.Background(), "sqlite3", backupFile, "PRAGMA integrity_check;")
+	if err != nil {
 		if d.logger != nil {
-			d.logger.Warn("SQLite integrity check failed: %s", stderr.String())
+			d.logger.Warn("SQLite integrity check failed: %s", res.Stderr)
 		}
 		return fmt.Errorf("backup may be corrupted: %w", err)
 	}
 
 	// Check the output - it should be "ok" for a valid database
-	output := strings.TrimSpace(stdout.String())
+	output := strings.TrimSpace(res.Stdout)
 	if output != "ok" {
 		if d.logger != nil {
 			d.logger.Warn("SQLite integrity check returned issues: %s", output)
@@ -343,19 +347,15 @@ func (d *Database) GetAdminUser(dbPath string) (string, error) {
 	}
 
 	// Query the database for the first user's email
-	cmd := exec.Command("sqlite3", dbPath, "SELECT email FROM users LIMIT 1;")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	res, err := d.command(context.Background(), "sqlite3", dbPath, "SELECT email FROM users LIMIT 1;")
+	if err != nil {
 		if d.logger != nil {
-			d.logger.Warn("Failed to query user from database: %s", stderr.String())
+			d.logger.Warn("Failed to query user from database: %s", res.Stderr)
 		}
 		return "", fmt.Errorf("failed to query database: %w", err)
 	}
 
-	email := strings.TrimSpace(stdout.String())
+	email := strings.TrimSpace(res.Stdout)
 	if email == "" {
 		if d.logger != nil {
 			d.logger.Debug("No user found in database")
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fusionaly-installer/internal/executor"
+)
+
+const (
+	// containerDBPath is where the app container sees the main database.
+	containerDBPath = "/app/storage/fusionaly-production.db"
+
+	dumpFilePrefix = "fusionaly-backup-"
+	dumpFileSuffix = ".sql.gz"
+	dumpTimeFormat = "20060102-150405"
+)
+
+// defaultAppContainers are the blue/green app containers of the default
+// installation.
+var defaultAppContainers = []string{"fusionaly-app-1", "fusionaly-app-2"}
+
+// Backup writes a gzipped SQL dump of the running app's database to destDir,
+// creating the directory if needed, and returns the path of the new file,
+// e.g. fusionaly-backup-20240101-120000.sql.gz. The dump is taken inside the
+// app container so it is consistent with what the app is writing.
+func (d *Database) Backup(ctx context.Context, destDir string) (string, error) {
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	container, err := d.appContainer(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := d.run(ctx, "exec", container, "sqlite3", containerDBPath, ".dump")
+	if err != nil {
+		if msg := strings.TrimSpace(res.Stderr); msg != "" {
+			return "", fmt.Errorf("database dump failed: %s: %w", msg, err)
+		}
+		return "", fmt.Errorf("database dump failed: %w", err)
+	}
+	if strings.TrimSpace(res.Stdout) == "" {
+		return "", fmt.Errorf("database dump is empty")
+	}
+
+	name := dumpFilePrefix + d.clock.Now().Format(dumpTimeFormat) + dumpFileSuffix
+	path := filepath.Join(destDir, name)
+	if err := writeGzip(path, res.Stdout); err != nil {
+		return "", err
+	}
+
+	if d.logger != nil {
+		d.logger.Success("Database dump written to %s", path)
+	}
+	return path, nil
+}
+
+// appContainer returns the installation's running app container, preferring
+// the first one docker lists. docker's name filter matches substrings, so
+// only exact names count: another instance's containers are never picked.
+func (d *Database) appContainer(ctx context.Context) (string, error) {
+	apps := d.apps
+	if len(apps) == 0 {
+		apps = defaultAppContainers
+	}
+	args := []string{"ps"}
+	for _, name := range apps {
+		args = append(args, "--filter", "name="+name)
+	}
+	args = append(args, "--filter", "status=running", "--format", "{{.Names}}")
+	res, err := d.run(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to list app containers: %w", err)
+	}
+	for _, name := range strings.Fields(res.Stdout) {
+		if slices.Contains(apps, name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no running app container found (looked for %s)", strings.Join(apps, ", "))
+}
+
+// run executes a docker command through the configured executor.
+func (d *Database) run(ctx context.Context, args ...string) (executor.Result, error) {
+	return d.command(ctx, "docker", args...)
+}
+
+func (d *Database) command(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	if d.runner == nil {
+		d.runner = executor.Default()
+	}
+	return d.runner.Run(ctx, name, args...)
+}
+
+// writeGzip compresses content into path via a temp file, so a failed write
+// never leaves a truncated backup behind.
+func writeGzip(path, content string) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return fmt.Errorf("failed to compress database dump: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress database dump: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write database dump: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write database dump: %w", err)
+	}
+	return nil
+}
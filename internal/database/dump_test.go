@@ -0,0 +1,122 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fusionaly-installer/internal/executor"
+)
+
+type fakeRunner struct {
+	calls   [][]string
+	results []executor.Result
+	errs    []error
+}
+
+func (f *fakeRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	i := len(f.calls) - 1
+	var res executor.Result
+	var err error
+	if i < len(f.results) {
+		res = f.results[i]
+	}
+	if i < len(f.errs) {
+		err = f.errs[i]
+	}
+	return res, err
+}
+
+func newDumpDatabase(runner *fakeRunner) *Database {
+	db := NewDatabase(nil)
+	db.clock = fixedClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	db.runner = runner
+	return db
+}
+
+func TestBackup_WritesTimestampedDump(t *testing.T) {
+	runner := &fakeRunner{results: []executor.Result{
+		{Stdout: "fusionaly-app-2\n"},
+		{Stdout: "BEGIN TRANSACTION;\nCOMMIT;\n"},
+	}}
+	db := newDumpDatabase(runner)
+	dest := filepath.Join(t.TempDir(), "nested", "backups")
+
+	path, err := db.Backup(context.Background(), dest)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dest, "fusionaly-backup-20240101-120000.sql.gz"), path)
+	assert.Regexp(t, regexp.MustCompile(`^fusionaly-backup-\d{8}-\d{6}\.sql\.gz$`), filepath.Base(path))
+	assert.Equal(t, []string{"docker", "exec", "fusionaly-app-2", "sqlite3", containerDBPath, ".dump"}, runner.calls[1])
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "BEGIN TRANSACTION;\nCOMMIT;\n", string(content))
+}
+
+func TestBackup_CreatesDestDir(t *testing.T) {
+	runner := &fakeRunner{results: []executor.Result{
+		{Stdout: "fusionaly-app-1\n"},
+		{Stdout: "COMMIT;\n"},
+	}}
+	dest := filepath.Join(t.TempDir(), "missing")
+
+	_, err := newDumpDatabase(runner).Backup(context.Background(), dest)
+	require.NoError(t, err)
+
+	info, err := os.Stat(dest)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
+
+func TestBackup_DumpFailure(t *testing.T) {
+	runner := &fakeRunner{
+		results: []executor.Result{{Stdout: "fusionaly-app-1\n"}, {Stderr: "sqlite3: not found"}},
+		errs:    []error{nil, errors.New("exit status 127")},
+	}
+	dest := t.TempDir()
+
+	_, err := newDumpDatabase(runner).Backup(context.Background(), dest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sqlite3: not found")
+
+	entries, _ := os.ReadDir(dest)
+	assert.Empty(t, entries, "no partial backup should be left behind")
+}
+
+func TestBackup_NoRunningContainer(t *testing.T) {
+	runner := &fakeRunner{results: []executor.Result{{Stdout: ""}}}
+
+	_, err := newDumpDatabase(runner).Backup(context.Background(), t.TempDir())
+	require.Error(t, err)
+	assert.Len(t, runner.calls, 1)
+}
+
+func TestBackup_UsesInstanceContainers(t *testing.T) {
+	runner := &fakeRunner{results: []executor.Result{
+		{Stdout: "fusionaly-app-1\nfusionaly-staging-app-2\n"},
+		{Stdout: "COMMIT;\n"},
+	}}
+	db := newDumpDatabase(runner)
+	db.SetAppContainers("fusionaly-staging-app-1", "fusionaly-staging-app-2")
+
+	_, err := db.Backup(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker", "ps", "--filter", "name=fusionaly-staging-app-1", "--filter", "name=fusionaly-staging-app-2", "--filter", "status=running", "--format", "{{.Names}}"}, runner.calls[0])
+	assert.Equal(t, "fusionaly-staging-app-2", runner.calls[1][2], "the default instance's container must not be dumped")
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptedSuffix is appended to the name of an encrypted backup.
+const EncryptedSuffix = ".enc"
+
+// encryptedMagic starts every encrypted backup so restore can recognise it.
+const encryptedMagic = "FNLYENC1"
+
+const (
+	saltSize  = 16
+	nonceSize = 12
+	keySize   = 32
+	chunkSize = 64 * 1024
+
+	// scrypt cost parameters, the interactive-login values from the scrypt paper.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrWrongPassphrase is returned when an encrypted backup fails to
+// authenticate, which means the passphrase is wrong or the file was modified.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted backup")
+
+// ErrPassphraseRequired is returned when an encrypted backup is restored
+// without a way to obtain its passphrase.
+var ErrPassphraseRequired = errors.New("backup is encrypted and needs a passphrase")
+
+// The encrypted format is the magic, a random scrypt salt and a random base
+// nonce, followed by the plaintext sealed with AES-256-GCM in 64 KiB chunks.
+// Each chunk's nonce is the base nonce XOR its index, and the header plus a
+// final-chunk flag is authenticated with it, so reordered, truncated or
+// extended files fail to decrypt.
+
+// EncryptBackup writes an encrypted copy of src to dst.
+func EncryptBackup(src, dst, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cannot read backup: %w", err)
+	}
+	defer in.Close()
+
+	header := make([]byte, len(encryptedMagic)+saltSize+nonceSize)
+	copy(header, encryptedMagic)
+	if _, err := rand.Read(header[len(encryptedMagic):]); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return err
+	}
+
+	return writeAtomic(dst, func(w io.Writer) error {
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		return sealChunks(aead, header, bufio.NewReaderSize(in, chunkSize), w)
+	})
+}
+
+// DecryptBackup writes the decrypted contents of src to dst. It returns
+// ErrWrongPassphrase if the file does not authenticate; dst is only created
+// when the whole file decrypts.
+func DecryptBackup(src, dst, passphrase string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("cannot read backup: %w", err)
+	}
+	defer in.Close()
+
+	header := make([]byte, len(encryptedMagic)+saltSize+nonceSize)
+	if _, err := io.ReadFull(in, header); err != nil || string(header[:len(encryptedMagic)]) != encryptedMagic {
+		return fmt.Errorf("%s is not an encrypted backup", src)
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return err
+	}
+
+	return writeAtomic(dst, func(w io.Writer) error {
+		return openChunks(aead, header, bufio.NewReaderSize(in, chunkSize+aead.Overhead()), w)
+	})
+}
+
+// IsEncrypted reports whether the file at path starts with the encrypted
+// backup header.
+func IsEncrypted(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	magic := make([]byte, len(encryptedMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false, nil
+	}
+	return string(magic) == encryptedMagic, nil
+}
+
+func newAEAD(passphrase string, header []byte) (cipher.AEAD, error) {
+	salt := header[len(encryptedMagic) : len(encryptedMagic)+saltSize]
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce for chunk i.
+func chunkNonce(header []byte, i uint64) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, header[len(header)-nonceSize:])
+	tail := binary.BigEndian.Uint64(nonce[nonceSize-8:])
+	binary.BigEndian.PutUint64(nonce[nonceSize-8:], tail^i)
+	return nonce
+}
+
+// chunkAAD authenticates the header and whether this is the last chunk.
+func chunkAAD(header []byte, last bool) []byte {
+	aad := append([]byte{}, header...)
+	if last {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
+
+func sealChunks(aead cipher.AEAD, header []byte, r *bufio.Reader, w io.Writer) error {
+	buf := make([]byte, chunkSize)
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		_, peekErr := r.Peek(1)
+		last := peekErr != nil
+		if _, err := w.Write(aead.Seal(nil, chunkNonce(header, i), buf[:n], chunkAAD(header, last))); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+func openChunks(aead cipher.AEAD, header []byte, r *bufio.Reader, w io.Writer) error {
+	buf := make([]byte, chunkSize+aead.Overhead())
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		_, peekErr := r.Peek(1)
+		last := peekErr != nil
+		plain, err := aead.Open(nil, chunkNonce(header, i), buf[:n], chunkAAD(header, last))
+		if err != nil {
+			return ErrWrongPassphrase
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// writeAtomic writes dst through a temp file in the same directory so a
+// failure never leaves a partial file behind.
+func writeAtomic(dst string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		if errors.Is(err, ErrWrongPassphrase) {
+			return err
+		}
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	// Cover empty input, a partial chunk and an exact chunk multiple.
+	for _, size := range []int{0, 100, chunkSize, 2*chunkSize + 7} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+		src := filepath.Join(dir, "plain")
+		enc := filepath.Join(dir, "backup.enc")
+		out := filepath.Join(dir, "decrypted")
+		require.NoError(t, os.WriteFile(src, plain, 0o600))
+
+		require.NoError(t, EncryptBackup(src, enc, "correct horse"))
+		encrypted, err := IsEncrypted(enc)
+		require.NoError(t, err)
+		assert.True(t, encrypted, "size %d", size)
+
+		require.NoError(t, DecryptBackup(enc, out, "correct horse"))
+		got, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(plain, got), "round trip mismatch for size %d", size)
+	}
+}
+
+func TestDecryptBackup_WrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain")
+	enc := filepath.Join(dir, "backup.enc")
+	out := filepath.Join(dir, "decrypted")
+	require.NoError(t, os.WriteFile(src, []byte("BEGIN TRANSACTION;\nCOMMIT;\n"), 0o600))
+	require.NoError(t, EncryptBackup(src, enc, "right"))
+
+	err := DecryptBackup(enc, out, "wrong")
+	assert.True(t, errors.Is(err, ErrWrongPassphrase), "got %v", err)
+	_, statErr := os.Stat(out)
+	assert.True(t, os.IsNotExist(statErr), "no output should be written on failure")
+}
+
+func TestDecryptBackup_DetectsTruncation(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain")
+	enc := filepath.Join(dir, "backup.enc")
+	require.NoError(t, os.WriteFile(src, make([]byte, 2*chunkSize), 0o600))
+	require.NoError(t, EncryptBackup(src, enc, "pass"))
+
+	data, err := os.ReadFile(enc)
+	require.NoError(t, err)
+	// Drop the final chunk; what remains still ends on a chunk boundary.
+	header := len(encryptedMagic) + saltSize + nonceSize
+	require.NoError(t, os.WriteFile(enc, data[:header+chunkSize+16], 0o600))
+
+	err = DecryptBackup(enc, filepath.Join(dir, "out"), "pass")
+	assert.True(t, errors.Is(err, ErrWrongPassphrase), "got %v", err)
+}
+
+func TestIsEncrypted_PlainFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	require.NoError(t, os.WriteFile(path, []byte("COMMIT;\n"), 0o600))
+	encrypted, err := IsEncrypted(path)
+	require.NoError(t, err)
+	assert.False(t, encrypted)
+}
+
+func TestRestore_EncryptedBackup(t *testing.T) {
+	plain := writeDump(t, "COMMIT;\n")
+	enc := plain + EncryptedSuffix
+	require.NoError(t, EncryptBackup(plain, enc, "secret"))
+
+	t.Run("without passphrase", func(t *testing.T) {
+		runner := &fakeRunner{}
+		err := newDumpDatabase(runner).Restore(context.Background(), enc, true)
+		assert.True(t, errors.Is(err, ErrPassphraseRequired), "got %v", err)
+		assert.Empty(t, runner.calls)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		runner := &fakeRunner{}
+		db := newDumpDatabase(runner)
+		db.SetPassphraseFunc(func() (string, error) { return "nope", nil })
+		err := db.Restore(context.Background(), enc, true)
+		assert.True(t, errors.Is(err, ErrWrongPassphrase), "got %v", err)
+		assert.Empty(t, runner.calls, "the app must not be stopped")
+	})
+
+	t.Run("correct passphrase", func(t *testing.T) {
+		dir := storageDir(t)
+		runner := &fakeRunner{results: []executor.Result{{Stdout: "fusionaly-app-1\n"}, {Stdout: dir + "\n"}}}
+		db := newDumpDatabase(runner)
+		db.SetPassphraseFunc(func() (string, error) { return "secret", nil })
+		require.NoError(t, db.Restore(context.Background(), enc, true))
+		assert.Equal(t, []string{"docker ps", "docker inspect", "docker stop", "sqlite3", "docker start"}, commandNames(runner.calls))
+	})
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupNameFormats are the file name layouts written by BackupDatabase and
+// Backup (plain or encrypted), with the timestamp between prefix and suffix.
+var backupNameFormats = []struct {
+	prefix, suffix, layout string
+}{
+	{"backup_", ".db", "20060102_150405"},
+	{dumpFilePrefix, dumpFileSuffix, dumpTimeFormat},
+	{dumpFilePrefix, dumpFileSuffix + EncryptedSuffix, dumpTimeFormat},
+}
+
+// backupTimestamp returns the time embedded in a backup file name, or false
+// when name is not a backup.
+func backupTimestamp(name string) (time.Time, bool) {
+	for _, f := range backupNameFormats {
+		if !strings.HasPrefix(name, f.prefix) || !strings.HasSuffix(name, f.suffix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, f.prefix), f.suffix)
+		if t, err := time.Parse(f.layout, stamp); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// PruneBackups keeps the keep most recent backups in dir, ordered by the
+// timestamp in their file names, and deletes the rest. Files that are not
+// backups are left alone. It returns the paths that were removed.
+func (d *Database) PruneBackups(dir string, keep int) ([]string, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("keep must not be negative, got %d", keep)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	type backup struct {
+		path      string
+		createdAt time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if t, ok := backupTimestamp(entry.Name()); ok {
+			backups = append(backups, backup{filepath.Join(dir, entry.Name()), t})
+		}
+	}
+	// Newest first; ReadDir is sorted by name, so ties stay deterministic.
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].createdAt.After(backups[j].createdAt)
+	})
+	if len(backups) <= keep {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, b := range backups[keep:] {
+		if err := os.Remove(b.path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", b.path, err)
+		}
+		removed = append(removed, b.path)
+	}
+	if d.logger != nil {
+		d.logger.Info("Pruned %d old backups from %s", len(removed), dir)
+	}
+	return removed, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneBackups_KeepsNewestAndIgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"backup_20240103_000000.db",
+		"backup_20240101_000000.db",
+		"fusionaly-backup-20240102-120000.sql.gz",
+		"fusionaly-backup-20231231-235959.sql.gz",
+		"backup_notatime.db",
+		"notes.txt",
+		"fusionaly-production.db",
+	}
+	for _, name := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600))
+	}
+	// mtime must not matter: make the oldest backup the most recently written.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fusionaly-backup-20231231-235959.sql.gz"), []byte("y"), 0o600))
+
+	removed, err := NewDatabase(nil).PruneBackups(dir, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "backup_20240101_000000.db"),
+		filepath.Join(dir, "fusionaly-backup-20231231-235959.sql.gz"),
+	}, removed)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"backup_20240103_000000.db",
+		"fusionaly-backup-20240102-120000.sql.gz",
+		"backup_notatime.db",
+		"notes.txt",
+		"fusionaly-production.db",
+	}, left)
+}
+
+func TestPruneBackups_NothingToRemove(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backup_20240101_000000.db"), []byte("x"), 0o600))
+
+	removed, err := NewDatabase(nil).PruneBackups(dir, 5)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+}
+
+func TestPruneBackups_RejectsNegativeKeep(t *testing.T) {
+	_, err := NewDatabase(nil).PruneBackups(t.TempDir(), -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrDatabaseNotEmpty is returned by a non-forced Restore when the live
+// database already holds users, to avoid clobbering a working install.
+var ErrDatabaseNotEmpty = errors.New("database already contains data")
+
+// containerStorageDir is the app container mount that holds the database.
+const containerStorageDir = "/app/storage"
+
+// sidecarSuffixes are the SQLite files that must move with the main database.
+var sidecarSuffixes = []string{"", "-wal", "-shm"}
+
+// Restore loads a dump written by Backup (gzipped or plain SQL) into the app
+// database. The app container is stopped while the dump is loaded and started
+// again afterwards; the previous database is kept next to it with a .bak
+// suffix. Encrypted backups are decrypted with the passphrase from
+// SetPassphraseFunc. Unless force is set, Restore refuses with ErrDatabaseNotEmpty when
+// the current database already has users.
+func (d *Database) Restore(ctx context.Context, backupPath string, force bool) error {
+	source := backupPath
+	encrypted, err := IsEncrypted(backupPath)
+	if err != nil {
+		return fmt.Errorf("cannot read backup: %w", err)
+	}
+	if encrypted {
+		if source, err = d.decryptForRestore(backupPath); err != nil {
+			return err
+		}
+		defer os.Remove(source)
+	}
+
+	sqlFile, err := extractDump(source)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sqlFile)
+
+	container, err := d.appContainer(ctx)
+	if err != nil {
+		return err
+	}
+	dbPath, err := d.hostDBPath(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		empty, err := d.isEmpty(ctx, dbPath)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return fmt.Errorf("%w: %s (use force to overwrite)", ErrDatabaseNotEmpty, dbPath)
+		}
+	}
+
+	if d.logger != nil {
+		d.logger.Info("Stopping %s to restore %s", container, backupPath)
+	}
+	if _, err := d.run(ctx, "stop", container); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", container, err)
+	}
+
+	loadErr := d.load(ctx, dbPath, sqlFile)
+
+	if _, err := d.run(ctx, "start", container); err != nil {
+		if loadErr != nil {
+			return fmt.Errorf("restore failed (%v) and %s did not start: %w", loadErr, container, err)
+		}
+		return fmt.Errorf("database restored but %s did not start: %w", container, err)
+	}
+	if loadErr != nil {
+		return loadErr
+	}
+
+	if d.logger != nil {
+		d.logger.Success("Database restored from %s", backupPath)
+	}
+	return nil
+}
+
+// load moves the current database aside and replays the dump into a fresh
+// file, putting the old database back if the dump does not load.
+func (d *Database) load(ctx context.Context, dbPath, sqlFile string) error {
+	backup := dbPath + ".bak." + d.clock.Now().Format("20060102150405")
+	if err := moveDB(dbPath, backup); err != nil {
+		return fmt.Errorf("failed to move current database aside: %w", err)
+	}
+
+	res, err := d.command(ctx, "sqlite3", dbPath, ".read "+sqlFile)
+	if err != nil {
+		_ = os.Remove(dbPath)
+		if mvErr := moveDB(backup, dbPath); mvErr != nil && d.logger != nil {
+			d.logger.Error("Failed to put the previous database back: %v", mvErr)
+		}
+		if msg := strings.TrimSpace(res.Stderr); msg != "" {
+			return fmt.Errorf("failed to load dump: %s: %w", msg, err)
+		}
+		return fmt.Errorf("failed to load dump: %w", err)
+	}
+	return nil
+}
+
+// hostDBPath resolves the database file on the host through the container's
+// storage bind mount.
+func (d *Database) hostDBPath(ctx context.Context, container string) (string, error) {
+	format := fmt.Sprintf(`{{range .Mounts}}{{if eq .Destination %q}}{{.Source}}{{end}}{{end}}`, containerStorageDir)
+	res, err := d.run(ctx, "inspect", "-f", format, container)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s: %w", container, err)
+	}
+	dir := strings.TrimSpace(res.Stdout)
+	if dir == "" {
+		return "", fmt.Errorf("%s has no %s mount", container, containerStorageDir)
+	}
+	return filepath.Join(dir, filepath.Base(containerDBPath)), nil
+}
+
+// isEmpty reports whether the database has no users yet. A missing database
+// or users table counts as empty.
+func (d *Database) isEmpty(ctx context.Context, dbPath string) (bool, error) {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return true, nil
+	}
+	res, err := d.command(ctx, "sqlite3", dbPath, "SELECT count(*) FROM users;")
+	if err != nil {
+		if strings.Contains(res.Stderr, "no such table") {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to inspect current database: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(res.Stdout))
+	if err != nil {
+		return false, fmt.Errorf("unexpected user count %q", strings.TrimSpace(res.Stdout))
+	}
+	return n == 0, nil
+}
+
+// SetPassphraseFunc sets how Restore obtains the passphrase for an encrypted
+// backup, e.g. by prompting the operator. It is only called for encrypted files.
+func (d *Database) SetPassphraseFunc(fn func() (string, error)) {
+	d.passphrase = fn
+}
+
+// decryptForRestore decrypts an encrypted backup to a temp file.
+func (d *Database) decryptForRestore(backupPath string) (string, error) {
+	if d.passphrase == nil {
+		return "", ErrPassphraseRequired
+	}
+	passphrase, err := d.passphrase()
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "fusionaly-restore-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to stage backup: %w", err)
+	}
+	tmp.Close()
+	if err := DecryptBackup(backupPath, tmp.Name(), passphrase); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// extractDump checks that backupPath is readable and writes its SQL to a temp
+// file, decompressing gzipped dumps.
+func extractDump(backupPath string) (string, error) {
+	f, err := os.Open(backupPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot read backup: %w", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return "", fmt.Errorf("invalid backup %s: %w", backupPath, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tmp, err := os.CreateTemp("", "fusionaly-restore-*.sql")
+	if err != nil {
+		return "", fmt.Errorf("failed to stage backup: %w", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("invalid backup %s: %w", backupPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to stage backup: %w", err)
+	}
+	return tmp.Name(), nil
+}
+
+// moveDB renames a database and whichever sidecar files exist.
+func moveDB(from, to string) error {
+	for _, suffix := range sidecarSuffixes {
+		err := os.Rename(from+suffix, to+suffix)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
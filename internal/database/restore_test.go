@@ -0,0 +1,110 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// writeDump writes a gzipped dump like the ones Backup produces.
+func writeDump(t *testing.T, sql string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fusionaly-backup-20240101-120000.sql.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write([]byte(sql))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+	return path
+}
+
+// storageDir creates a host storage dir with an existing database file.
+func storageDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fusionaly-production.db"), []byte("old"), 0o600))
+	return dir
+}
+
+func commandNames(calls [][]string) []string {
+	var names []string
+	for _, c := range calls {
+		if c[0] == "docker" {
+			names = append(names, "docker "+c[1])
+		} else {
+			names = append(names, c[0])
+		}
+	}
+	return names
+}
+
+func TestRestore_MissingFile(t *testing.T) {
+	runner := &fakeRunner{}
+	err := newDumpDatabase(runner).Restore(context.Background(), filepath.Join(t.TempDir(), "nope.sql.gz"), true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot read backup")
+	assert.Empty(t, runner.calls, "nothing should run for a missing backup")
+}
+
+func TestRestore_RefusesNonEmptyDatabase(t *testing.T) {
+	dir := storageDir(t)
+	runner := &fakeRunner{results: []executor.Result{
+		{Stdout: "fusionaly-app-1\n"},
+		{Stdout: dir + "\n"},
+		{Stdout: "2\n"},
+	}}
+
+	err := newDumpDatabase(runner).Restore(context.Background(), writeDump(t, "COMMIT;\n"), false)
+	require.True(t, errors.Is(err, ErrDatabaseNotEmpty), "got %v", err)
+	assert.Equal(t, []string{"docker ps", "docker inspect", "sqlite3"}, commandNames(runner.calls))
+
+	content, _ := os.ReadFile(filepath.Join(dir, "fusionaly-production.db"))
+	assert.Equal(t, "old", string(content), "database must not be touched")
+}
+
+func TestRestore_ForcedLoadsDumpAndRestarts(t *testing.T) {
+	dir := storageDir(t)
+	runner := &fakeRunner{results: []executor.Result{
+		{Stdout: "fusionaly-app-2\n"},
+		{Stdout: dir + "\n"},
+	}}
+
+	err := newDumpDatabase(runner).Restore(context.Background(), writeDump(t, "COMMIT;\n"), true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"docker ps", "docker inspect", "docker stop", "sqlite3", "docker start"}, commandNames(runner.calls))
+	assert.Equal(t, []string{"docker", "stop", "fusionaly-app-2"}, runner.calls[2])
+	load := runner.calls[3]
+	assert.Equal(t, filepath.Join(dir, "fusionaly-production.db"), load[1])
+	assert.True(t, strings.HasPrefix(load[2], ".read "), "unexpected load command %v", load)
+
+	_, err = os.Stat(filepath.Join(dir, "fusionaly-production.db.bak.20240101120000"))
+	assert.NoError(t, err, "previous database should be kept aside")
+}
+
+func TestRestore_PutsDatabaseBackWhenLoadFails(t *testing.T) {
+	dir := storageDir(t)
+	runner := &fakeRunner{
+		results: []executor.Result{{Stdout: "fusionaly-app-1\n"}, {Stdout: dir + "\n"}, {}, {Stderr: "parse error"}},
+		errs:    []error{nil, nil, nil, errors.New("exit status 1")},
+	}
+
+	err := newDumpDatabase(runner).Restore(context.Background(), writeDump(t, "garbage"), true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse error")
+	assert.Equal(t, "docker start", commandNames(runner.calls)[len(runner.calls)-1], "app must be restarted")
+
+	content, _ := os.ReadFile(filepath.Join(dir, "fusionaly-production.db"))
+	assert.Equal(t, "old", string(content))
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoBackups is returned by RestoreInteractive when the directory holds
+	// no dumps to choose from.
+	ErrNoBackups = errors.New("no backups found")
+
+	// ErrInvalidSelection is returned by RestoreInteractive when the operator
+	// enters something other than the number of a listed backup.
+	ErrInvalidSelection = errors.New("invalid backup selection")
+)
+
+// BackupInfo describes a dump written by Backup.
+type BackupInfo struct {
+	Name      string
+	Path      string
+	CreatedAt time.Time // From the file name
+	Size      int64
+	Encrypted bool
+}
+
+// ListBackups returns the dumps written by Backup in dir, plain or
+// encrypted, newest first. The legacy backup_*.db copies are left out as
+// Restore cannot load them; use restore-db for those.
+func ListBackups(dir string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []BackupInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), dumpFilePrefix) {
+			continue
+		}
+		createdAt, ok := backupTimestamp(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+		backups = append(backups, BackupInfo{
+			Name:      entry.Name(),
+			Path:      filepath.Join(dir, entry.Name()),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+			Encrypted: strings.HasSuffix(entry.Name(), EncryptedSuffix),
+		})
+	}
+	// Newest first; ReadDir is sorted by name, so ties stay deterministic.
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// RestoreInteractive lists the backups in dir, lets the operator pick one by
+// its number and, once they confirm, restores it with Restore. Answers are
+// read from the input set with SetInput, stdin by default. Declining the
+// confirmation is not an error.
+func (d *Database) RestoreInteractive(ctx context.Context, dir string, force bool) error {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("%w in %s", ErrNoBackups, dir)
+	}
+
+	fmt.Println("Available backups:")
+	for i, b := range backups {
+		lock := ""
+		if b.Encrypted {
+			lock = " (encrypted)"
+		}
+		fmt.Printf("  %d) %s  %s  %s%s\n", i+1, b.CreatedAt.Format("2006-01-02 15:04:05"), formatSize(b.Size), b.Name, lock)
+	}
+
+	input := d.input
+	if input == nil {
+		input = os.Stdin
+	}
+	reader := bufio.NewReader(input)
+
+	fmt.Printf("Enter the number of the backup to restore (1-%d): ", len(backups))
+	answer, err := reader.ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("failed to read selection: %w", err)
+	}
+	backup, err := selectBackup(backups, answer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("⚠️  This will replace the current database with %s.\n", backup.Name)
+	fmt.Print("Are you sure you want to continue? (yes/no): ")
+	confirmation, err := reader.ReadString('\n')
+	if err != nil && confirmation == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	confirmation = strings.TrimSpace(strings.ToLower(confirmation))
+	if confirmation != "yes" && confirmation != "y" {
+		if d.logger != nil {
+			d.logger.Info("Restore cancelled by user")
+		}
+		return nil
+	}
+	return d.Restore(ctx, backup.Path, force)
+}
+
+// selectBackup returns the backup numbered by answer, counting from 1 in the
+// order RestoreInteractive lists them.
+func selectBackup(backups []BackupInfo, answer string) (BackupInfo, error) {
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(backups) {
+		return BackupInfo{}, fmt.Errorf("%w %q: must be a number between 1 and %d", ErrInvalidSelection, strings.TrimSpace(answer), len(backups))
+	}
+	return backups[choice-1], nil
+}
+
+// SetInput sets where RestoreInteractive reads the operator's answers; nil
+// restores stdin.
+func (d *Database) SetInput(r io.Reader) {
+	d.input = r
+}
+
+// formatSize renders n in binary units, e.g. "1.5 MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListBackups_NewestFirstWithSizes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"fusionaly-backup-20240102-120000.sql.gz":     "12345",
+		"fusionaly-backup-20240103-080000.sql.gz.enc": "123",
+		"fusionaly-backup-20231231-235959.sql.gz":     "1",
+		"fusionaly-backup-garbage.sql.gz":             "x",
+		"backup_20240104_000000.db":                   "legacy",
+		"notes.txt":                                   "x",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "fusionaly-backup-20240105-000000.sql.gz"), 0o755))
+
+	backups, err := ListBackups(dir)
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+
+	assert.Equal(t, "fusionaly-backup-20240103-080000.sql.gz.enc", backups[0].Name)
+	assert.True(t, backups[0].Encrypted)
+	assert.Equal(t, int64(3), backups[0].Size)
+	assert.Equal(t, time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC), backups[0].CreatedAt)
+
+	assert.Equal(t, filepath.Join(dir, "fusionaly-backup-20240102-120000.sql.gz"), backups[1].Path)
+	assert.False(t, backups[1].Encrypted)
+	assert.Equal(t, int64(5), backups[1].Size)
+
+	assert.Equal(t, "fusionaly-backup-20231231-235959.sql.gz", backups[2].Name)
+}
+
+func TestListBackups_MissingDir(t *testing.T) {
+	_, err := ListBackups(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSelectBackup(t *testing.T) {
+	backups := []BackupInfo{{Name: "newest"}, {Name: "middle"}, {Name: "oldest"}}
+
+	for answer, want := range map[string]string{"1\n": "newest", " 2 \n": "middle", "3": "oldest"} {
+		got, err := selectBackup(backups, answer)
+		require.NoError(t, err, "answer %q", answer)
+		assert.Equal(t, want, got.Name, "answer %q", answer)
+	}
+
+	for _, answer := range []string{"0\n", "4\n", "-1\n", "two\n", "\n"} {
+		_, err := selectBackup(backups, answer)
+		assert.ErrorIs(t, err, ErrInvalidSelection, "answer %q", answer)
+	}
+}
+
+func TestRestoreInteractive_NoBackups(t *testing.T) {
+	err := NewDatabase(nil).RestoreInteractive(context.Background(), t.TempDir(), false)
+	assert.ErrorIs(t, err, ErrNoBackups)
+}
+
+func TestRestoreInteractive_RejectsInvalidSelection(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fusionaly-backup-20240102-120000.sql.gz"), []byte("x"), 0o600))
+
+	fr := &fakeRunner{}
+	db := newDumpDatabase(fr)
+	db.SetInput(strings.NewReader("5\nyes\n"))
+	err := db.RestoreInteractive(context.Background(), dir, false)
+	assert.ErrorIs(t, err, ErrInvalidSelection)
+	assert.Empty(t, fr.calls, "nothing should run for an invalid selection")
+}
+
+func TestRestoreInteractive_CancelledAtConfirmation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fusionaly-backup-20240102-120000.sql.gz"), []byte("x"), 0o600))
+
+	fr := &fakeRunner{}
+	db := newDumpDatabase(fr)
+	db.SetInput(strings.NewReader("1\nno\n"))
+	require.NoError(t, db.RestoreInteractive(context.Background(), dir, false))
+	assert.Empty(t, fr.calls, "a declined restore should not touch the database")
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned by DBShell when stdin is not a terminal.
+var ErrNotTerminal = errors.New("database shell needs an interactive terminal")
+
+// shellCommand returns the client command line for the database at target.
+// Fusionaly stores its data in a SQLite file; a postgres:// URL opens psql
+// for setups that point the app at PostgreSQL instead.
+func shellCommand(target string) ([]string, error) {
+	if target == "" {
+		return nil, fmt.Errorf("no database configured")
+	}
+	scheme, _, isURL := strings.Cut(target, "://")
+	switch {
+	case !isURL:
+		return []string{"sqlite3", "-header", "-column", target}, nil
+	case scheme == "postgres" || scheme == "postgresql":
+		return []string{"psql", target}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database %s://", scheme)
+	}
+}
+
+// DBShell opens an interactive client for the database at target (a SQLite
+// file or a postgres:// URL) with the terminal attached, returning when the
+// operator quits it. It refuses to start without a terminal on stdin, where
+// the client would read an empty script and exit immediately.
+func (d *Database) DBShell(ctx context.Context, target string) error {
+	args, err := shellCommand(target)
+	if err != nil {
+		return err
+	}
+	isTerminal := d.isTerminal
+	if isTerminal == nil {
+		isTerminal = func() bool { return term.IsTerminal(int(os.Stdin.Fd())) }
+	}
+	if !isTerminal() {
+		return fmt.Errorf("%w; run it from an interactive session, or pipe SQL to %s directly", ErrNotTerminal, args[0])
+	}
+	// sqlite3 would silently create an empty database at a wrong path.
+	if args[0] == "sqlite3" {
+		if _, err := os.Stat(target); err != nil {
+			return fmt.Errorf("database not found: %w", err)
+		}
+	}
+
+	attach := d.attach
+	if attach == nil {
+		attach = attachTerminal
+	}
+	d.logger.Debug("Opening %s", args[0])
+	return attach(ctx, args)
+}
+
+// attachTerminal runs args with the installer's stdin, stdout and stderr.
+func attachTerminal(ctx context.Context, args []string) error {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s exited: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/logging"
+)
+
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"/opt/fusionaly/storage/fusionaly-production.db", []string{"sqlite3", "-header", "-column", "/opt/fusionaly/storage/fusionaly-production.db"}},
+		{"postgres://fusionaly@db:5432/fusionaly", []string{"psql", "postgres://fusionaly@db:5432/fusionaly"}},
+		{"postgresql://db/fusionaly", []string{"psql", "postgresql://db/fusionaly"}},
+	}
+	for _, tt := range tests {
+		got, err := shellCommand(tt.target)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shellCommand(%q) = %v, %v; want %v", tt.target, got, err, tt.want)
+		}
+	}
+
+	for _, target := range []string{"", "mysql://db/fusionaly"} {
+		if _, err := shellCommand(target); err == nil {
+			t.Errorf("shellCommand(%q) should fail", target)
+		}
+	}
+}
+
+func newShellDatabase(t *testing.T, terminal bool) (*Database, *[][]string) {
+	var attached [][]string
+	d := NewDatabase(logging.NewLogger(logging.Config{Level: "error"}))
+	d.isTerminal = func() bool { return terminal }
+	d.attach = func(ctx context.Context, args []string) error {
+		attached = append(attached, args)
+		return nil
+	}
+	return d, &attached
+}
+
+func TestDBShellAttachesClient(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fusionaly-production.db")
+	if err := os.WriteFile(dbPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, attached := newShellDatabase(t, true)
+
+	if err := d.DBShell(context.Background(), dbPath); err != nil {
+		t.Fatalf("DBShell returned error: %v", err)
+	}
+	want := [][]string{{"sqlite3", "-header", "-column", dbPath}}
+	if !reflect.DeepEqual(*attached, want) {
+		t.Errorf("attached %v, want %v", *attached, want)
+	}
+}
+
+func TestDBShellRequiresTerminal(t *testing.T) {
+	d, attached := newShellDatabase(t, false)
+
+	err := d.DBShell(context.Background(), "postgres://db/fusionaly")
+	if !errors.Is(err, ErrNotTerminal) {
+		t.Fatalf("expected ErrNotTerminal, got %v", err)
+	}
+	if len(*attached) != 0 {
+		t.Errorf("no client should start without a terminal, got %v", *attached)
+	}
+}
+
+func TestDBShellMissingDatabase(t *testing.T) {
+	d, attached := newShellDatabase(t, true)
+
+	if err := d.DBShell(context.Background(), filepath.Join(t.TempDir(), "missing.db")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+	if len(*attached) != 0 {
+		t.Errorf("sqlite3 must not create a new database, got %v", *attached)
+	}
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrCorruptBackup is returned when a backup fails an integrity check.
+var ErrCorruptBackup = errors.New("backup is corrupt")
+
+// markerSize is how much of the start and end of a dump is kept to check
+// the header and footer markers.
+const markerSize = 64
+
+// VerifyBackupOptions controls the optional checks in Database.VerifyBackup.
+type VerifyBackupOptions struct {
+	// DryRestoreImage, when set, replays the dump into an in-memory database
+	// in a throwaway container started from this image, which must ship
+	// sqlite3 (the app image does).
+	DryRestoreImage string
+}
+
+// VerifyBackup checks that an unencrypted backup is intact: a gzipped dump
+// must decompress to the end with a valid checksum, and the SQL must start
+// with the header and end with the COMMIT that sqlite3 .dump writes. An
+// encrypted backup returns ErrPassphraseRequired; use
+// VerifyBackupWithPassphrase for those.
+func VerifyBackup(path string) error {
+	return VerifyBackupWithPassphrase(path, "")
+}
+
+// VerifyBackupWithPassphrase is VerifyBackup for backups that may be
+// encrypted. Every GCM chunk of an encrypted backup is authenticated, and a
+// failure returns ErrWrongPassphrase.
+func VerifyBackupWithPassphrase(path, passphrase string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot read backup: %w", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, chunkSize)
+	magic, _ := br.Peek(len(encryptedMagic))
+	if string(magic) != encryptedMagic {
+		if strings.HasSuffix(path, EncryptedSuffix) {
+			return fmt.Errorf("%w: %s is missing the encrypted backup header", ErrCorruptBackup, path)
+		}
+		return verifyDump(path, br)
+	}
+	if passphrase == "" {
+		return ErrPassphraseRequired
+	}
+
+	header := make([]byte, len(encryptedMagic)+saltSize+nonceSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return fmt.Errorf("%w: %s: truncated header", ErrCorruptBackup, path)
+	}
+	aead, err := newAEAD(passphrase, header)
+	if err != nil {
+		return err
+	}
+
+	// Decrypt into a pipe so the plaintext is checked as it streams and never
+	// touches the disk. A chunk that fails to authenticate ends the stream
+	// with ErrWrongPassphrase.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(openChunks(aead, header, br, pw))
+	}()
+	err = verifyDump(strings.TrimSuffix(path, EncryptedSuffix), bufio.NewReader(pr))
+	pr.CloseWithError(err)
+	return err
+}
+
+// verifyDump checks the plaintext of a backup, decompressing it when it is
+// gzipped. name decides whether gzip is expected.
+func verifyDump(name string, br *bufio.Reader) error {
+	var r io.Reader = br
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		if errors.Is(err, ErrWrongPassphrase) {
+			return err
+		}
+		return corruptErr(name, "unreadable", err)
+	}
+	isGzip := len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b
+	switch {
+	case isGzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return corruptErr(name, "invalid gzip header", err)
+		}
+		defer zr.Close()
+		r = zr
+	case strings.HasSuffix(name, ".gz"):
+		return fmt.Errorf("%w: %s is not gzip data (bad magic bytes)", ErrCorruptBackup, name)
+	}
+
+	m := &markerWriter{}
+	if _, err := io.Copy(m, r); err != nil {
+		if errors.Is(err, ErrWrongPassphrase) {
+			return err
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return corruptErr(name, "truncated", err)
+		}
+		return corruptErr(name, "unreadable", err)
+	}
+
+	head := strings.TrimLeft(string(m.head), " \t\r\n")
+	head = strings.TrimPrefix(head, "PRAGMA foreign_keys=OFF;")
+	if !strings.HasPrefix(strings.TrimLeft(head, " \t\r\n"), "BEGIN TRANSACTION;") {
+		return fmt.Errorf("%w: %s does not start with a SQL dump header", ErrCorruptBackup, name)
+	}
+	if !strings.HasSuffix(strings.TrimRight(string(m.tail), " \t\r\n"), "COMMIT;") {
+		return fmt.Errorf("%w: %s does not end with COMMIT; the dump is incomplete", ErrCorruptBackup, name)
+	}
+	return nil
+}
+
+func corruptErr(name, reason string, err error) error {
+	return fmt.Errorf("%w: %s: %s: %v", ErrCorruptBackup, name, reason, err)
+}
+
+// markerWriter keeps the first and last markerSize bytes written to it.
+type markerWriter struct {
+	head []byte
+	tail []byte
+}
+
+func (m *markerWriter) Write(p []byte) (int, error) {
+	if n := markerSize - len(m.head); n > 0 {
+		m.head = append(m.head, p[:min(n, len(p))]...)
+	}
+	if len(p) >= markerSize {
+		m.tail = append(m.tail[:0], p[len(p)-markerSize:]...)
+	} else {
+		m.tail = append(m.tail, p...)
+		if extra := len(m.tail) - markerSize; extra > 0 {
+			m.tail = bytes.Clone(m.tail[extra:])
+		}
+	}
+	return len(p), nil
+}
+
+// VerifyBackup checks backupPath like the package-level VerifyBackup,
+// obtaining the passphrase for an encrypted backup from SetPassphraseFunc,
+// and optionally dry-restores it as described by opts.
+func (d *Database) VerifyBackup(ctx context.Context, backupPath string, opts VerifyBackupOptions) error {
+	encrypted, err := IsEncrypted(backupPath)
+	if err != nil {
+		return fmt.Errorf("cannot read backup: %w", err)
+	}
+	var passphrase string
+	if encrypted {
+		if d.passphrase == nil {
+			return ErrPassphraseRequired
+		}
+		if passphrase, err = d.passphrase(); err != nil {
+			return fmt.Errorf("failed to read passphrase: %w", err)
+		}
+	}
+	if err := VerifyBackupWithPassphrase(backupPath, passphrase); err != nil {
+		return err
+	}
+	if d.logger != nil {
+		d.logger.Success("Backup %s passed integrity checks", backupPath)
+	}
+
+	if opts.DryRestoreImage == "" {
+		return nil
+	}
+	return d.dryRestore(ctx, backupPath, passphrase, opts.DryRestoreImage)
+}
+
+// dryRestore replays the dump into an in-memory database inside a
+// throwaway, network-less container so a dump that is well formed but does
+// not load is caught before it is needed.
+func (d *Database) dryRestore(ctx context.Context, backupPath, passphrase, image string) error {
+	source := backupPath
+	if passphrase != "" {
+		tmp, err := os.CreateTemp("", "fusionaly-verify-*")
+		if err != nil {
+			return fmt.Errorf("failed to stage backup: %w", err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := DecryptBackup(backupPath, tmp.Name(), passphrase); err != nil {
+			return err
+		}
+		source = tmp.Name()
+	}
+	sqlFile, err := extractDump(source)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sqlFile)
+
+	if d.logger != nil {
+		d.logger.Info("Dry-restoring %s in a throwaway %s container", backupPath, image)
+	}
+	res, err := d.run(ctx, "run", "--rm", "--network", "none",
+		"-v", sqlFile+":/tmp/backup.sql:ro",
+		"--entrypoint", "sqlite3", image,
+		"-bail", ":memory:", ".read /tmp/backup.sql")
+	if err != nil {
+		if msg := strings.TrimSpace(res.Stderr); msg != "" {
+			return fmt.Errorf("%w: dry restore failed: %s: %v", ErrCorruptBackup, msg, err)
+		}
+		return fmt.Errorf("%w: dry restore failed: %v", ErrCorruptBackup, err)
+	}
+	if d.logger != nil {
+		d.logger.Success("Dry restore of %s succeeded", backupPath)
+	}
+	return nil
+}
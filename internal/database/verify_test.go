@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDump = "PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE users(id INTEGER PRIMARY KEY);\nINSERT INTO users VALUES(1);\nCOMMIT;\n"
+
+func writeTestBackup(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fusionaly-backup-20240101-120000.sql.gz")
+	require.NoError(t, writeGzip(path, content))
+	return path
+}
+
+func TestVerifyBackup_Valid(t *testing.T) {
+	path := writeTestBackup(t, testDump+strings.Repeat("-- padding\n", 10000)+"COMMIT;\n")
+	assert.NoError(t, VerifyBackup(path))
+
+	plain := filepath.Join(t.TempDir(), "dump.sql")
+	require.NoError(t, os.WriteFile(plain, []byte(testDump), 0o600))
+	assert.NoError(t, VerifyBackup(plain))
+}
+
+func TestVerifyBackup_Encrypted(t *testing.T) {
+	path := writeTestBackup(t, testDump)
+	enc := path + EncryptedSuffix
+	require.NoError(t, EncryptBackup(path, enc, "correct horse"))
+
+	assert.NoError(t, VerifyBackupWithPassphrase(enc, "correct horse"))
+	assert.ErrorIs(t, VerifyBackup(enc), ErrPassphraseRequired)
+	assert.ErrorIs(t, VerifyBackupWithPassphrase(enc, "wrong"), ErrWrongPassphrase)
+
+	data, err := os.ReadFile(enc)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(enc, data, 0o600))
+	assert.ErrorIs(t, VerifyBackupWithPassphrase(enc, "correct horse"), ErrWrongPassphrase)
+}
+
+func TestVerifyBackup_Truncated(t *testing.T) {
+	path := writeTestBackup(t, testDump)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-10], 0o600))
+
+	err = VerifyBackup(path)
+	assert.ErrorIs(t, err, ErrCorruptBackup)
+	assert.Contains(t, err.Error(), "truncated")
+
+	incomplete := writeTestBackup(t, "BEGIN TRANSACTION;\nCREATE TABLE users(id INTEGER PRIMARY KEY);\n")
+	err = VerifyBackup(incomplete)
+	assert.ErrorIs(t, err, ErrCorruptBackup)
+	assert.Contains(t, err.Error(), "COMMIT")
+}
+
+func TestVerifyBackup_CorruptedMagic(t *testing.T) {
+	path := writeTestBackup(t, testDump)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[0], data[1] = 'P', 'K'
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	err = VerifyBackup(path)
+	assert.ErrorIs(t, err, ErrCorruptBackup)
+	assert.Contains(t, err.Error(), "magic")
+
+	notDump := filepath.Join(t.TempDir(), "notes.sql")
+	require.NoError(t, os.WriteFile(notDump, []byte("hello\n"), 0o600))
+	assert.ErrorIs(t, VerifyBackup(notDump), ErrCorruptBackup)
+}
+
+func TestDatabaseVerifyBackup_DryRestore(t *testing.T) {
+	path := writeTestBackup(t, testDump)
+	runner := &fakeRunner{}
+	db := newDumpDatabase(runner)
+
+	require.NoError(t, db.VerifyBackup(context.Background(), path, VerifyBackupOptions{DryRestoreImage: "karloscodes/fusionaly-beta:latest"}))
+	require.Len(t, runner.calls, 1)
+	call := strings.Join(runner.calls[0], " ")
+	assert.Contains(t, call, "docker run --rm --network none")
+	assert.Contains(t, call, "--entrypoint sqlite3 karloscodes/fusionaly-beta:latest -bail :memory: .read /tmp/backup.sql")
+}
+
+func TestDatabaseVerifyBackup_SkipsDryRestoreWhenCorrupt(t *testing.T) {
+	path := writeTestBackup(t, "BEGIN TRANSACTION;\n")
+	runner := &fakeRunner{}
+	db := newDumpDatabase(runner)
+
+	err := db.VerifyBackup(context.Background(), path, VerifyBackupOptions{DryRestoreImage: "app"})
+	assert.ErrorIs(t, err, ErrCorruptBackup)
+	assert.Empty(t, runner.calls)
+}
@@ -0,0 +1,236 @@
+// Package diagnostics gathers the state of an installation into a single
+// report for troubleshooting.
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/requirements"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Check is one line of the report.
+type Check struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// Report is the result of Doctor.
+type Report struct {
+	InstallerVersion string  `json:"installer_version"`
+	AppImage         string  `json:"app_image,omitempty"`
+	Checks           []Check `json:"checks"`
+}
+
+// Healthy reports whether no check failed.
+func (r Report) Healthy() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteText prints the report for a terminal.
+func (r Report) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "Installer version: %s\n", r.InstallerVersion); err != nil {
+		return err
+	}
+	if r.AppImage != "" {
+		fmt.Fprintf(w, "App image:         %s\n", r.AppImage)
+	}
+	fmt.Fprintln(w)
+	for _, c := range r.Checks {
+		if _, err := fmt.Fprintf(w, "[%-4s] %-24s %s\n", c.Status, c.Name, c.Detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON prints the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Diagnostics runs the checks behind the doctor command.
+type Diagnostics struct {
+	runner     executor.Executor
+	installDir string
+	version    string
+	disk       func(path string) error         // Free-space check; nil uses requirements.Checker
+	proxy      func(ctx context.Context) error // Reverse proxy check; nil uses docker.Stack.CheckProxy
+}
+
+// New creates a Diagnostics for the installation in installDir, reporting
+// version as the installer version.
+func New(runner executor.Executor, installDir, version string) *Diagnostics {
+	return &Diagnostics{runner: runner, installDir: installDir, version: version}
+}
+
+// Doctor runs every check and collects the results. Checks are independent:
+// a failing one is recorded in the report and the rest still run. An error
+// is only returned when ctx ends before the report is complete.
+func (d *Diagnostics) Doctor(ctx context.Context) (Report, error) {
+	report := Report{InstallerVersion: d.version}
+
+	checks := []func(context.Context, *Report) []Check{
+		d.checkVersion,
+		d.checkDaemon,
+		d.checkContainers,
+		d.checkPorts,
+		d.checkProxy,
+		d.checkDisk,
+	}
+	for _, check := range checks {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Checks = append(report.Checks, check(ctx, &report)...)
+	}
+	return report, nil
+}
+
+func (d *Diagnostics) docker(ctx context.Context, args ...string) (string, error) {
+	res, err := d.runner.Run(ctx, "docker", args...)
+	if err != nil {
+		if msg := strings.TrimSpace(res.Stderr); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(res.Stdout), nil
+}
+
+func (d *Diagnostics) checkVersion(ctx context.Context, r *Report) []Check {
+	env, err := config.LoadEnvFile(filepath.Join(d.installDir, ".env"))
+	if err != nil {
+		return []Check{{Name: "configuration", Status: StatusFail, Detail: err.Error()}}
+	}
+	image, ok := env.Get("APP_IMAGE")
+	if !ok || image == "" {
+		return []Check{{Name: "configuration", Status: StatusWarn, Detail: "no APP_IMAGE in " + filepath.Join(d.installDir, ".env")}}
+	}
+	r.AppImage = image
+	return []Check{{Name: "configuration", Status: StatusOK, Detail: "app image " + image}}
+}
+
+func (d *Diagnostics) checkDaemon(ctx context.Context, r *Report) []Check {
+	version, err := d.docker(ctx, "info", "--format", "{{.ServerVersion}}")
+	if err != nil {
+		return []Check{{Name: "docker daemon", Status: StatusFail, Detail: err.Error()}}
+	}
+	return []Check{{Name: "docker daemon", Status: StatusOK, Detail: "server " + version}}
+}
+
+func (d *Diagnostics) checkContainers(ctx context.Context, r *Report) []Check {
+	out, err := d.docker(ctx, "ps", "-a", "--filter", "name=fusionaly-", "--format", "{{.Names}}")
+	if err != nil {
+		return []Check{{Name: "containers", Status: StatusFail, Detail: err.Error()}}
+	}
+	existing := map[string]bool{}
+	for _, name := range strings.Fields(out) {
+		existing[name] = true
+	}
+
+	var checks []Check
+	var apps []string
+	for _, name := range []string{docker.AppNamePrimary, docker.AppNameSecondary} {
+		if existing[name] {
+			apps = append(apps, name)
+		}
+	}
+	if len(apps) == 0 {
+		checks = append(checks, Check{Name: "app container", Status: StatusFail, Detail: "no app container found"})
+	}
+	for _, name := range append(apps, docker.CaddyName) {
+		if name == docker.CaddyName && !existing[name] {
+			checks = append(checks, Check{Name: name, Status: StatusFail, Detail: "container not found"})
+			continue
+		}
+		checks = append(checks, d.containerHealth(ctx, name))
+	}
+	return checks
+}
+
+func (d *Diagnostics) containerHealth(ctx context.Context, name string) Check {
+	state, err := d.docker(ctx, "inspect", "--format", docker.HealthFormat, name)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Detail: err.Error()}
+	}
+	switch state {
+	case "healthy", "running":
+		return Check{Name: name, Status: StatusOK, Detail: state}
+	case "starting", "restarting":
+		return Check{Name: name, Status: StatusWarn, Detail: state}
+	default:
+		return Check{Name: name, Status: StatusFail, Detail: state}
+	}
+}
+
+func (d *Diagnostics) checkPorts(ctx context.Context, r *Report) []Check {
+	out, err := d.docker(ctx, "port", docker.CaddyName)
+	if err != nil {
+		return []Check{{Name: "port bindings", Status: StatusFail, Detail: err.Error()}}
+	}
+	var missing []string
+	for _, port := range []string{"80/tcp", "443/tcp"} {
+		if !strings.Contains(out+"\n", port+" ->") {
+			missing = append(missing, port)
+		}
+	}
+	if len(missing) > 0 {
+		return []Check{{Name: "port bindings", Status: StatusFail, Detail: "not published: " + strings.Join(missing, ", ")}}
+	}
+	return []Check{{Name: "port bindings", Status: StatusOK, Detail: "80/tcp and 443/tcp published"}}
+}
+
+// checkProxy probes the proxy itself, which can be down while the app
+// container is healthy.
+func (d *Diagnostics) checkProxy(ctx context.Context, r *Report) []Check {
+	check := d.proxy
+	if check == nil {
+		stack := docker.NewStack(nil, d.runner)
+		stack.SetEnvFile(filepath.Join(d.installDir, ".env"))
+		check = stack.CheckProxy
+	}
+	if err := check(ctx); err != nil {
+		return []Check{{Name: "reverse proxy", Status: StatusFail, Detail: err.Error()}}
+	}
+	return []Check{{Name: "reverse proxy", Status: StatusOK, Detail: "answering on its HTTP and HTTPS ports"}}
+}
+
+func (d *Diagnostics) checkDisk(ctx context.Context, r *Report) []Check {
+	check := d.disk
+	if check == nil {
+		opts := requirements.DefaultPreflightOptions(d.installDir)
+		checker := requirements.NewChecker(nil)
+		check = func(path string) error {
+			return checker.CheckDiskSpace(path, uint64(opts.MinDiskGB*(1<<30)))
+		}
+	}
+	if err := check(d.installDir); err != nil {
+		return []Check{{Name: "disk space", Status: StatusFail, Detail: err.Error()}}
+	}
+	return []Check{{Name: "disk space", Status: StatusOK, Detail: "enough free space on " + d.installDir}}
+}
@@ -0,0 +1,201 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+)
+
+// fakeRunner answers docker commands by their joined arguments.
+type fakeRunner struct {
+	results map[string]executor.Result
+	errs    map[string]error
+}
+
+func (f *fakeRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	key := strings.Join(args, " ")
+	for prefix, err := range f.errs {
+		if strings.HasPrefix(key, prefix) {
+			return f.results[prefix], err
+		}
+	}
+	for prefix, res := range f.results {
+		if strings.HasPrefix(key, prefix) {
+			return res, nil
+		}
+	}
+	return executor.Result{}, errors.New("unexpected command: " + key)
+}
+
+func newTestDiagnostics(t *testing.T, runner *fakeRunner) *Diagnostics {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_IMAGE=karloscodes/fusionaly-beta:1.2.0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d := New(runner, dir, "0.9.0")
+	d.disk = func(string) error { return nil }
+	d.proxy = func(context.Context) error { return nil }
+	return d
+}
+
+func statuses(r Report) map[string]Status {
+	m := map[string]Status{}
+	for _, c := range r.Checks {
+		m[c.Name] = c.Status
+	}
+	return m
+}
+
+func TestDoctor_AllHealthy(t *testing.T) {
+	runner := &fakeRunner{results: map[string]executor.Result{
+		"info":                 {Stdout: "27.1.1\n"},
+		"ps -a":                {Stdout: "fusionaly-app-1\nfusionaly-caddy\n"},
+		"inspect --format":     {Stdout: "healthy\n"},
+		"port fusionaly-caddy": {Stdout: "80/tcp -> 0.0.0.0:80\n443/tcp -> 0.0.0.0:443\n"},
+	}}
+
+	report, err := newTestDiagnostics(t, runner).Doctor(context.Background())
+	if err != nil {
+		t.Fatalf("Doctor returned error: %v", err)
+	}
+	if !report.Healthy() {
+		t.Errorf("expected a healthy report, got %+v", report.Checks)
+	}
+	if report.AppImage != "karloscodes/fusionaly-beta:1.2.0" || report.InstallerVersion != "0.9.0" {
+		t.Errorf("unexpected versions: %+v", report)
+	}
+}
+
+func TestDoctor_MixedStates(t *testing.T) {
+	runner := &fakeRunner{
+		results: map[string]executor.Result{
+			"info":  {Stderr: "Cannot connect to the Docker daemon"},
+			"ps -a": {Stdout: "fusionaly-app-2\nfusionaly-caddy\n"},
+			"inspect --format " + docker.HealthFormat + " fusionaly-app-2": {Stdout: "unhealthy\n"},
+			"inspect --format " + docker.HealthFormat + " fusionaly-caddy": {Stdout: "running\n"},
+			"port fusionaly-caddy": {Stdout: "80/tcp -> 0.0.0.0:80\n"},
+		},
+		errs: map[string]error{"info": errors.New("exit status 1")},
+	}
+	d := newTestDiagnostics(t, runner)
+	d.disk = func(string) error { return errors.New("0.5 GB available, 2.0 GB required") }
+
+	report, err := d.Doctor(context.Background())
+	if err != nil {
+		t.Fatalf("Doctor returned error: %v", err)
+	}
+	if report.Healthy() {
+		t.Error("report should not be healthy")
+	}
+
+	want := map[string]Status{
+		"configuration":   StatusOK,
+		"docker daemon":   StatusFail,
+		"fusionaly-app-2": StatusFail,
+		"fusionaly-caddy": StatusOK,
+		"port bindings":   StatusFail,
+		"disk space":      StatusFail,
+	}
+	got := statuses(report)
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("%s: got %q, want %q", name, got[name], status)
+		}
+	}
+	for _, c := range report.Checks {
+		if c.Name == "docker daemon" && !strings.Contains(c.Detail, "Cannot connect") {
+			t.Errorf("daemon detail should carry stderr, got %q", c.Detail)
+		}
+		if c.Name == "port bindings" && !strings.Contains(c.Detail, "443/tcp") {
+			t.Errorf("port detail should name the missing port, got %q", c.Detail)
+		}
+	}
+}
+
+func TestDoctor_ProxyDown(t *testing.T) {
+	runner := &fakeRunner{results: map[string]executor.Result{
+		"info":                 {Stdout: "27.1.1\n"},
+		"ps -a":                {Stdout: "fusionaly-app-1\nfusionaly-caddy\n"},
+		"inspect --format":     {Stdout: "healthy\n"},
+		"port fusionaly-caddy": {Stdout: "80/tcp -> 0.0.0.0:80\n443/tcp -> 0.0.0.0:443\n"},
+	}}
+	d := newTestDiagnostics(t, runner)
+	d.proxy = func(context.Context) error {
+		return &docker.ProxyError{Container: docker.CaddyName, Port: 80, Err: errors.New("connection refused")}
+	}
+
+	report, err := d.Doctor(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := statuses(report)
+	if got["reverse proxy"] != StatusFail || got["fusionaly-app-1"] != StatusOK {
+		t.Errorf("the proxy should fail on its own while the app is healthy: %v", got)
+	}
+	if report.Healthy() {
+		t.Error("a report with the proxy down should not be healthy")
+	}
+}
+
+func TestDoctor_NoContainersAndNoConfig(t *testing.T) {
+	runner := &fakeRunner{results: map[string]executor.Result{
+		"info":  {Stdout: "27.1.1"},
+		"ps -a": {Stdout: ""},
+		"port":  {},
+	}, errs: map[string]error{"port": errors.New("No such container")}}
+	d := New(runner, t.TempDir(), "dev")
+	d.disk = func(string) error { return nil }
+
+	report, err := d.Doctor(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := statuses(report)
+	if got["configuration"] != StatusWarn || got["app container"] != StatusFail || got["fusionaly-caddy"] != StatusFail {
+		t.Errorf("unexpected statuses: %v", got)
+	}
+	if got["docker daemon"] != StatusOK || got["disk space"] != StatusOK {
+		t.Errorf("independent checks should still pass: %v", got)
+	}
+}
+
+func TestDoctor_CancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := newTestDiagnostics(t, &fakeRunner{}).Doctor(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestReport_Output(t *testing.T) {
+	r := Report{InstallerVersion: "1.0.0", AppImage: "app:1", Checks: []Check{{Name: "disk space", Status: StatusWarn, Detail: "low"}}}
+
+	var text bytes.Buffer
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "[warn] disk space") || !strings.Contains(text.String(), "app:1") {
+		t.Errorf("unexpected text output:\n%s", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := r.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Checks[0].Status != StatusWarn || decoded.InstallerVersion != "1.0.0" {
+		t.Errorf("unexpected decoded report: %+v", decoded)
+	}
+}
@@ -0,0 +1,122 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/requirements"
+)
+
+// The ways VerifyPublicAccess can fail, matched with errors.Is.
+var (
+	ErrPublicDNS         = errors.New("domain does not resolve")
+	ErrPublicUnreachable = errors.New("site is unreachable")
+	ErrPublicTLS         = errors.New("TLS certificate is not valid")
+	ErrPublicHTTP        = errors.New("site returned an error")
+)
+
+// PublicAccessError reports which stage of VerifyPublicAccess failed.
+type PublicAccessError struct {
+	Domain     string
+	Kind       error // One of ErrPublicDNS, ErrPublicUnreachable, ErrPublicTLS or ErrPublicHTTP
+	StatusCode int   // Final HTTP status for ErrPublicHTTP
+	Err        error
+}
+
+func (e *PublicAccessError) Error() string {
+	if e.Kind == ErrPublicHTTP && e.Err == nil {
+		return fmt.Sprintf("%s: https://%s returned status %d", e.Kind, e.Domain, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: https://%s: %v", e.Kind, e.Domain, e.Err)
+}
+
+func (e *PublicAccessError) Unwrap() error { return e.Err }
+
+// Is matches the error's Kind.
+func (e *PublicAccessError) Is(target error) bool { return target == e.Kind }
+
+// PublicAccessConfig controls how VerifyPublicAccess reaches the site.
+type PublicAccessConfig struct {
+	Client   *http.Client          // nil uses a client with a 15s timeout and the system roots
+	Resolver requirements.Resolver // nil uses net.DefaultResolver
+}
+
+// VerifyPublicAccess checks that https://domain/ is reachable the way a
+// visitor would reach it: the domain resolves, the certificate is trusted
+// and matches it, and the page answers 200 once redirects are followed.
+// The returned error is a *PublicAccessError matching the stage that failed.
+func VerifyPublicAccess(ctx context.Context, domain string) error {
+	return VerifyPublicAccessWithConfig(ctx, domain, PublicAccessConfig{})
+}
+
+// VerifyPublicAccessWithConfig is like VerifyPublicAccess with an explicit
+// client and resolver.
+func VerifyPublicAccessWithConfig(ctx context.Context, domain string, cfg PublicAccessConfig) error {
+	resolver := cfg.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	fail := func(kind, err error) error {
+		return &PublicAccessError{Domain: domain, Kind: kind, Err: err}
+	}
+
+	// Resolve first so a missing record is not reported as a dial error.
+	if _, err := resolver.LookupHost(ctx, domain); err != nil {
+		return fail(ErrPublicDNS, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+domain+"/", nil)
+	if err != nil {
+		return fail(ErrPublicUnreachable, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if isTLSError(err) {
+			return fail(ErrPublicTLS, err)
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return fail(ErrPublicDNS, err)
+		}
+		return fail(ErrPublicUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	// A redirect may have left HTTPS behind.
+	if resp.TLS == nil {
+		return fail(ErrPublicTLS, fmt.Errorf("redirected to %s, which is not served over HTTPS", resp.Request.URL))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &PublicAccessError{Domain: domain, Kind: ErrPublicHTTP, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// isTLSError reports whether err comes from the TLS handshake or from
+// verifying the server's certificate.
+func isTLSError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) ||
+		// net/http replaces the record header error of a plain HTTP server
+		// with an unwrapped one of its own.
+		strings.Contains(err.Error(), "server gave HTTP response to HTTPS client")
+}
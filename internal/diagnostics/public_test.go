@@ -0,0 +1,113 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubResolver resolves every host to 127.0.0.1, or fails with err.
+type stubResolver struct{ err error }
+
+func (r stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []string{"127.0.0.1"}, nil
+}
+
+// stubConfig dials srv for every host. httptest's certificate is valid for
+// example.com, so trusted reports whether clients trust it.
+func stubConfig(srv *httptest.Server, trusted bool) PublicAccessConfig {
+	pool := x509.NewCertPool()
+	if trusted {
+		pool.AddCert(srv.Certificate())
+	}
+	addr := srv.Listener.Addr().String()
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+	return PublicAccessConfig{Client: &http.Client{Transport: transport}, Resolver: stubResolver{}}
+}
+
+func TestVerifyPublicAccess_FollowsRedirects(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	if err := VerifyPublicAccessWithConfig(context.Background(), "example.com", stubConfig(srv, true)); err != nil {
+		t.Fatalf("VerifyPublicAccess: %v", err)
+	}
+}
+
+func TestVerifyPublicAccess_DNSFailure(t *testing.T) {
+	cfg := PublicAccessConfig{Resolver: stubResolver{err: &net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}}}
+	err := VerifyPublicAccessWithConfig(context.Background(), "example.com", cfg)
+	if !errors.Is(err, ErrPublicDNS) {
+		t.Fatalf("expected ErrPublicDNS, got %v", err)
+	}
+}
+
+func TestVerifyPublicAccess_TLSFailures(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	for name, tc := range map[string]struct {
+		domain  string
+		trusted bool
+	}{
+		"untrusted certificate":        {"example.com", false},
+		"certificate for another name": {"analytics.test", true},
+	} {
+		err := VerifyPublicAccessWithConfig(context.Background(), tc.domain, stubConfig(srv, tc.trusted))
+		if !errors.Is(err, ErrPublicTLS) {
+			t.Errorf("%s: expected ErrPublicTLS, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifyPublicAccess_PlainHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	err := VerifyPublicAccessWithConfig(context.Background(), "example.com", stubConfig(srv, false))
+	if !errors.Is(err, ErrPublicTLS) {
+		t.Fatalf("expected ErrPublicTLS for a server without TLS, got %v", err)
+	}
+}
+
+func TestVerifyPublicAccess_HTTPError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := VerifyPublicAccessWithConfig(context.Background(), "example.com", stubConfig(srv, true))
+	var accessErr *PublicAccessError
+	if !errors.Is(err, ErrPublicHTTP) || !errors.As(err, &accessErr) || accessErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected ErrPublicHTTP with status 502, got %v", err)
+	}
+}
+
+func TestVerifyPublicAccess_Unreachable(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	cfg := stubConfig(srv, true)
+	srv.Close()
+
+	if err := VerifyPublicAccessWithConfig(context.Background(), "example.com", cfg); !errors.Is(err, ErrPublicUnreachable) {
+		t.Fatalf("expected ErrPublicUnreachable, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ImageDigest returns the registry digest ("sha256:...") that the local copy
+// of image was pulled as. Tags can be moved to other images; the digest
+// cannot, so recording it pins what was actually deployed.
+func (d *Docker) ImageDigest(image string) (string, error) {
+	digests, err := d.repoDigests(image)
+	if err != nil {
+		return "", err
+	}
+	repo := imageRepo(image)
+	for _, ref := range digests {
+		if name, digest, ok := strings.Cut(ref, "@"); ok && name == repo {
+			return digest, nil
+		}
+	}
+	if len(digests) > 0 {
+		_, digest, _ := strings.Cut(digests[0], "@")
+		return digest, nil
+	}
+	return "", fmt.Errorf("image %s has no registry digest; it was not pulled from a registry", image)
+}
+
+// ContainerImageDigests returns the registry digests of the image container
+// was created from, which is what is actually running regardless of what
+// its tag points to today.
+func (d *Docker) ContainerImageDigests(container string) ([]string, error) {
+	out, err := d.RunCommand("inspect", "--format", "{{.Image}}", container)
+	if err != nil {
+		return nil, fmt.Errorf("inspect %s: %w", container, err)
+	}
+	refs, err := d.repoDigests(strings.TrimSpace(out))
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if _, digest, ok := strings.Cut(ref, "@"); ok {
+			digests = append(digests, digest)
+		}
+	}
+	return digests, nil
+}
+
+// repoDigests returns the "repo@sha256:..." references of a local image.
+func (d *Docker) repoDigests(image string) ([]string, error) {
+	out, err := d.RunCommand("image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	if err != nil {
+		return nil, fmt.Errorf("inspect image %s: %w", image, err)
+	}
+	var refs []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &refs); err != nil {
+		return nil, fmt.Errorf("failed to parse digests of %s: %w", image, err)
+	}
+	return refs, nil
+}
+
+// imageRepo strips the tag and digest from an image reference.
+func imageRepo(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestImageDigest(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: `["mirror.example.com/fusionaly-beta@sha256:aaa","karloscodes/fusionaly-beta@sha256:bbb"]` + "\n"},
+	}}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	digest, err := d.ImageDigest("karloscodes/fusionaly-beta:v1.4.2")
+	if err != nil {
+		t.Fatalf("ImageDigest returned error: %v", err)
+	}
+	if digest != "sha256:bbb" {
+		t.Errorf("digest = %q, want the one for the pulled repository", digest)
+	}
+}
+
+func TestImageDigestLocalBuild(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: "[]\n"}}}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	if _, err := d.ImageDigest("fusionaly:dev"); err == nil {
+		t.Fatal("expected an error for an image without registry digests")
+	}
+}
+
+func TestContainerImageDigests(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: "sha256:imageid\n"},
+		{Stdout: `["karloscodes/fusionaly-beta@sha256:bbb"]`},
+	}}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	digests, err := d.ContainerImageDigests(AppNamePrimary)
+	if err != nil {
+		t.Fatalf("ContainerImageDigests returned error: %v", err)
+	}
+	if !reflect.DeepEqual(digests, []string{"sha256:bbb"}) {
+		t.Errorf("digests = %v", digests)
+	}
+	want := [][]string{
+		{"docker", "inspect", "--format", "{{.Image}}", AppNamePrimary},
+		{"docker", "image", "inspect", "--format", "{{json .RepoDigests}}", "sha256:imageid"},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
@@ -0,0 +1,112 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+)
+
+// Usage is the disk space, in bytes, an installation takes up.
+type Usage struct {
+	Data    int64 // The install directory: database, uploads, logs, certificates
+	Volumes int64 // Docker volumes labelled with the installation's project
+	Images  int64 // Local images of the installation's app and proxy repositories
+}
+
+// Total returns the space of every category together.
+func (u Usage) Total() int64 {
+	return u.Data + u.Volumes + u.Images
+}
+
+// String renders u as one line per category, sizes formatted like docker's.
+func (u Usage) String() string {
+	return fmt.Sprintf("Data:    %s\nVolumes: %s\nImages:  %s\nTotal:   %s\n",
+		formatBytes(u.Data), formatBytes(u.Volumes), formatBytes(u.Images), formatBytes(u.Total()))
+}
+
+// DiskUsage measures the space the installation consumes. Volumes are
+// selected by ProjectLabel and images by the repositories in the .env file,
+// as in Prune, so other docker resources on the host are not counted.
+func (s *Stack) DiskUsage(ctx context.Context) (Usage, error) {
+	var u Usage
+	var err error
+	if u.Data, err = s.diskUsage(s.dataDir()); err != nil {
+		return u, err
+	}
+
+	res, err := s.runner.Run(ctx, "docker", "volume", "ls", "--quiet", "--filter", "label="+s.names().label())
+	if err != nil {
+		return u, &StackError{Action: "volume ls", Service: s.names().Project, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	for _, volume := range strings.Fields(res.Stdout) {
+		res, err := s.runner.Run(ctx, "docker", "volume", "inspect", "--format", "{{.Mountpoint}}", volume)
+		if err != nil {
+			return u, &StackError{Action: "volume inspect", Service: volume, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+		}
+		size, err := s.diskUsage(strings.TrimSpace(res.Stdout))
+		if err != nil {
+			return u, err
+		}
+		u.Volumes += size
+	}
+
+	ids, err := s.imageIDs(ctx)
+	if err != nil {
+		return u, err
+	}
+	for _, id := range ids {
+		size, err := s.imageSize(ctx, id)
+		if err != nil {
+			return u, err
+		}
+		u.Images += size
+	}
+	return u, nil
+}
+
+// dataDir returns the INSTALL_DIR of the .env file, falling back to the
+// directory holding it.
+func (s *Stack) dataDir() string {
+	if env, err := config.LoadEnvFile(s.envFile()); err == nil {
+		if dir, ok := env.Get("INSTALL_DIR"); ok && dir != "" {
+			return dir
+		}
+	}
+	return filepath.Dir(s.envFile())
+}
+
+func (s *Stack) diskUsage(path string) (int64, error) {
+	if s.du != nil {
+		return s.du(path)
+	}
+	return dirSize(path)
+}
+
+// dirSize sums the sizes of the regular files under dir. A missing dir
+// takes no space.
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestDiskUsageTotalsPerCategory(t *testing.T) {
+	env := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(env, []byte("INSTALL_DIR=/srv/fusionaly\nAPP_IMAGE=karloscodes/fusionaly-beta:1.2.3\nCADDY_IMAGE=caddy:2.7-alpine\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: "staging_certs\nstaging_cache\n"},
+		{Stdout: "/var/lib/docker/volumes/staging_certs/_data\n"},
+		{Stdout: "/var/lib/docker/volumes/staging_cache/_data\n"},
+		{Stdout: "sha256:aaa\nsha256:bbb\n"},
+		{Stdout: "sha256:ccc\n"},
+		{Stdout: "300000000\n"},
+		{Stdout: "200000000\n"},
+		{Stdout: "45000000\n"},
+	}}
+	s := NewStack(testLogger(t), fr)
+	s.SetNames(NamesFor("staging"))
+	s.SetEnvFile(env)
+	sizes := map[string]int64{
+		"/srv/fusionaly": 1500,
+		"/var/lib/docker/volumes/staging_certs/_data": 20,
+		"/var/lib/docker/volumes/staging_cache/_data": 30,
+	}
+	var measured []string
+	s.du = func(path string) (int64, error) {
+		measured = append(measured, path)
+		return sizes[path], nil
+	}
+
+	u, err := s.DiskUsage(context.Background())
+	if err != nil {
+		t.Fatalf("DiskUsage returned error: %v", err)
+	}
+	want := Usage{Data: 1500, Volumes: 50, Images: 545000000}
+	if u != want {
+		t.Errorf("usage = %+v, want %+v", u, want)
+	}
+	if u.Total() != 545001550 {
+		t.Errorf("total = %d, want 545001550", u.Total())
+	}
+	if len(measured) != 3 {
+		t.Errorf("measured %v, want the install dir and both volumes", measured)
+	}
+	wantCalls := [][]string{
+		{"docker", "volume", "ls", "--quiet", "--filter", "label=com.fusionaly.project=staging"},
+		{"docker", "volume", "inspect", "--format", "{{.Mountpoint}}", "staging_certs"},
+		{"docker", "volume", "inspect", "--format", "{{.Mountpoint}}", "staging_cache"},
+		{"docker", "image", "ls", "--quiet", "--no-trunc", "--filter", "reference=karloscodes/fusionaly-beta"},
+		{"docker", "image", "ls", "--quiet", "--no-trunc", "--filter", "reference=caddy"},
+		{"docker", "image", "inspect", "--format", "{{.Size}}", "sha256:aaa"},
+		{"docker", "image", "inspect", "--format", "{{.Size}}", "sha256:bbb"},
+		{"docker", "image", "inspect", "--format", "{{.Size}}", "sha256:ccc"},
+	}
+	if !reflect.DeepEqual(fr.calls, wantCalls) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", wantCalls, fr.calls)
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "storage"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"storage/fusionaly.db": 100, ".env": 20} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := dirSize(dir); err != nil || got != 120 {
+		t.Errorf("dirSize = %d, %v; want 120", got, err)
+	}
+	if got, err := dirSize(filepath.Join(dir, "missing")); err != nil || got != 0 {
+		t.Errorf("dirSize of a missing dir = %d, %v; want 0", got, err)
+	}
+}
@@ -2,10 +2,10 @@ package docker
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -14,16 +14,17 @@ import (
 	"fusionaly-installer/internal/config"
 	"fusionaly-installer/internal/database"
 	"fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
 )
 
+// Names of the default installation's docker objects; see DefaultNames.
 const (
 	NetworkName      = "fusionaly-network"
 	CaddyName        = "fusionaly-caddy"
 	AppNamePrimary   = "fusionaly-app-1"
 	AppNameSecondary = "fusionaly-app-2"
 	MaxRetries       = 3
-	HealthCheckTries = 5
 )
 
 //go:embed templates/Caddyfile.tmpl
@@ -32,30 +33,62 @@ var caddyfileTemplate string
 type Docker struct {
 	logger *logging.Logger
 	db     *database.Database
+	runner executor.Executor // Runs the docker CLI; nil means executor.Default()
+
+	pollInterval time.Duration // Health polling interval; zero uses DefaultHealthPollInterval
+
+	osRelease string            // os-release file consulted before installing; empty means OSReleasePath
+	consent   func(Distro) bool // Asks before installing Docker; nil prompts on stdin
+
+	pullConcurrency int // Images pulled at once; zero uses DefaultPullConcurrency
+
+	ns    Names // Containers and network managed; zero uses DefaultNames
+	ports Ports // Host ports the proxy publishes; zero uses DefaultPorts
+
+	offline bool // Deploy never pulls; the images were loaded with LoadImages
 }
 
 func NewDocker(logger *logging.Logger, db *database.Database) *Docker {
 	return &Docker{
 		logger: logger,
 		db:     db,
+		runner: executor.Default(),
 	}
 }
 
+// run invokes the docker CLI through the configured executor.
+func (d *Docker) run(ctx context.Context, args ...string) (executor.Result, error) {
+	return d.host(ctx, "docker", args...)
+}
+
+// host invokes any host command through the configured executor.
+func (d *Docker) host(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	if d.runner == nil {
+		d.runner = executor.Default()
+	}
+	return d.runner.Run(ctx, name, args...)
+}
+
+// writeFile writes a generated file, leaving it alone during a dry run of the
+// configured executor.
+func (d *Docker) writeFile(name, content string) error {
+	if d.runner == nil {
+		d.runner = executor.Default()
+	}
+	return executor.WriteFile(d.runner, name, []byte(content), 0o644)
+}
+
 func (d *Docker) RunCommand(args ...string) (string, error) {
 	if len(args) == 0 {
 		return "", errors.NewDockerError("", "", fmt.Errorf("no docker command provided"))
 	}
 	
 	d.logger.Debug("Running docker %s", strings.Join(args, " "))
-	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	
-	if err := cmd.Run(); err != nil {
-		return "", errors.NewDockerError(args[0], "", fmt.Errorf("%w - %s", err, stderr.String()))
+	res, err := d.run(context.Background(), args...)
+	if err != nil {
+		return "", errors.NewDockerError(args[0], "", fmt.Errorf("%w - %s", err, res.Stderr))
 	}
-	return stdout.String(), nil
+	return res.Stdout, nil
 }
 
 func (d *Docker) EnsureInstalled() error {
@@ -64,21 +97,8 @@ func (d *Docker) EnsureInstalled() error {
 		return nil
 	}
 
-	d.logger.Info("Docker not found, installing...")
-	output, err := exec.Command("bash", "-c", "curl -fsSL https://get.docker.com | sh").CombinedOutput()
-	if err != nil {
-		d.logger.Error("Docker installation failed: %s", string(output))
-		return fmt.Errorf("install failed: %w", err)
-	}
-	d.logger.Success("Docker installed successfully")
-
-	for _, cmd := range [][]string{
-		{"systemctl", "start", "docker"},
-		{"systemctl", "enable", "docker"},
-	} {
-		if err := exec.Command(cmd[0], cmd[1:]...).Run(); err != nil {
-			return fmt.Errorf("%s failed: %w", cmd[1], err)
-		}
+	if err := d.installDocker(context.Background()); err != nil {
+		return err
 	}
 
 	version, err := d.RunCommand("version")
@@ -89,11 +109,17 @@ func (d *Docker) EnsureInstalled() error {
 	return nil
 }
 
+// PullImages downloads the app and proxy images ahead of Deploy, so a
+// registry problem is reported before any container is replaced.
+func (d *Docker) PullImages(data config.ConfigData) error {
+	return d.PullImagesContext(context.Background(), []string{data.AppImage, data.CaddyImage})
+}
+
 func (d *Docker) Deploy(conf *config.Config) error {
 	data := conf.GetData()
 	dataDir := data.InstallDir
 
-	if d.IsRunning(CaddyName) && (d.IsRunning(AppNamePrimary) || d.IsRunning(AppNameSecondary)) {
+	if d.IsRunning(d.names().Caddy) && (d.IsRunning(d.names().AppPrimary) || d.IsRunning(d.names().AppSecondary)) {
 		return nil
 	}
 
@@ -109,8 +135,8 @@ func (d *Docker) Deploy(conf *config.Config) error {
 		}
 	}
 
-	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
-		if _, err := d.RunCommand("network", "create", NetworkName); err != nil {
+	if _, err := d.RunCommand("network", "inspect", d.names().Network); err != nil {
+		if _, err := d.RunCommand("network", "create", d.names().Network); err != nil {
 			return fmt.Errorf("create network: %w", err)
 		}
 	}
@@ -120,11 +146,15 @@ func (d *Docker) Deploy(conf *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("generate Caddyfile: %w", err)
 	}
-	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+	if err := d.writeFile(caddyFile, caddyContent); err != nil {
 		return fmt.Errorf("write Caddyfile: %w", err)
 	}
 
-	for _, image := range []string{data.AppImage, data.CaddyImage} {
+	pulls := []string{data.AppImage, data.CaddyImage}
+	if d.offline {
+		pulls = nil // LoadImages put them on the host already
+	}
+	for _, image := range pulls {
 		for i := 0; i < MaxRetries; i++ {
 			if _, err := d.RunCommand("pull", image); err == nil {
 				d.logImageDigest(image)
@@ -138,30 +168,30 @@ func (d *Docker) Deploy(conf *config.Config) error {
 	}
 
 	// Deploy app first
-	if err := d.DeployApp(data, AppNamePrimary); err != nil {
+	if err := d.DeployApp(data, d.names().AppPrimary); err != nil {
 		d.logger.Error("Initial app deployment failed, running diagnostics...")
-		if d.containerExists(AppNamePrimary) {
-			d.DiagnoseContainerStartup(AppNamePrimary)
+		if d.containerExists(d.names().AppPrimary) {
+			d.DiagnoseContainerStartup(d.names().AppPrimary)
 		}
 		return fmt.Errorf("initial app deploy failed: %w", err)
 	}
 
-	if err := d.waitForAppHealth(AppNamePrimary); err != nil {
+	if err := d.waitForAppHealth(d.names().AppPrimary); err != nil {
 		d.logger.Error("Initial app health check failed, running diagnostics...")
-		d.DiagnoseContainerStartup(AppNamePrimary)
-		if cleanupErr := d.StopAndRemove(AppNamePrimary); cleanupErr != nil {
-			d.logger.Error("Failed to cleanup unhealthy container %s: %v", AppNamePrimary, cleanupErr)
+		d.DiagnoseContainerStartup(d.names().AppPrimary)
+		if cleanupErr := d.StopAndRemove(d.names().AppPrimary); cleanupErr != nil {
+			d.logger.Error("Failed to cleanup unhealthy container %s: %v", d.names().AppPrimary, cleanupErr)
 		}
-		return errors.NewDockerError("health_check", AppNamePrimary, err)
+		return errors.NewDockerError("health_check", d.names().AppPrimary, err)
 	}
 
-	if !d.IsRunning(CaddyName) {
+	if !d.IsRunning(d.names().Caddy) {
 		if err := d.deployCaddy(data, caddyFile); err != nil {
 			return fmt.Errorf("deploy caddy: %w", err)
 		}
 	} else {
-		if err := d.ensureNetworkConnected(CaddyName, NetworkName); err != nil {
-			return fmt.Errorf("failed to ensure network for %s: %w", CaddyName, err)
+		if err := d.ensureNetworkConnected(d.names().Caddy, d.names().Network); err != nil {
+			return fmt.Errorf("failed to ensure network for %s: %w", d.names().Caddy, err)
 		}
 	}
 
@@ -173,9 +203,9 @@ func (d *Docker) Update(conf *config.Config) error {
 	data := conf.GetData()
 	dataDir := data.InstallDir
 
-	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
-		d.logger.Info("Creating Docker network %s", NetworkName)
-		if _, err := d.RunCommand("network", "create", NetworkName); err != nil {
+	if _, err := d.RunCommand("network", "inspect", d.names().Network); err != nil {
+		d.logger.Info("Creating Docker network %s", d.names().Network)
+		if _, err := d.RunCommand("network", "create", d.names().Network); err != nil {
 			return fmt.Errorf("create network: %w", err)
 		}
 		d.logger.Success("Network created")
@@ -212,10 +242,10 @@ func (d *Docker) Update(conf *config.Config) error {
 	}
 
 	// Determine current and new app instances
-	currentName := AppNamePrimary
-	newName := AppNameSecondary
-	if d.IsRunning(AppNameSecondary) && !d.IsRunning(AppNamePrimary) {
-		currentName, newName = AppNameSecondary, AppNamePrimary
+	currentName := d.names().AppPrimary
+	newName := d.names().AppSecondary
+	if d.IsRunning(d.names().AppSecondary) && !d.IsRunning(d.names().AppPrimary) {
+		currentName, newName = d.names().AppSecondary, d.names().AppPrimary
 	}
 
 	// Deploy the new app instance
@@ -243,7 +273,7 @@ func (d *Docker) Update(conf *config.Config) error {
 		time.Sleep(time.Duration(i+1) * time.Second)
 	}
 
-	if err := d.ensureNetworkConnected(newName, NetworkName); err != nil {
+	if err := d.ensureNetworkConnected(newName, d.names().Network); err != nil {
 		if cleanupErr := d.StopAndRemove(newName); cleanupErr != nil {
 			d.logger.Error("Failed to cleanup container %s after network error: %v", newName, cleanupErr)
 		}
@@ -266,14 +296,14 @@ func (d *Docker) Update(conf *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("generate Caddyfile: %w", err)
 	}
-	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+	if err := d.writeFile(caddyFile, caddyContent); err != nil {
 		return fmt.Errorf("write Caddyfile: %w", err)
 	}
 	d.logger.Info("Reloading Caddy configuration to point to %s...", newName)
-	if _, err := d.RunCommand("exec", CaddyName, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
+	if _, err := d.RunCommand("exec", d.names().Caddy, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
 		d.logger.Warn("Caddy reload failed: %v. Attempting full Caddy redeploy as a fallback.", err)
 		// Fallback to stop and redeploy if reload fails
-		if cleanupErr := d.StopAndRemove(CaddyName); cleanupErr != nil {
+		if cleanupErr := d.StopAndRemove(d.names().Caddy); cleanupErr != nil {
 			d.logger.Error("Failed to cleanup Caddy container during fallback: %v", cleanupErr)
 		}
 		if errRedeploy := d.deployCaddy(data, caddyFile); errRedeploy != nil {
@@ -305,14 +335,14 @@ func (d *Docker) UpdateWithDebug(conf *config.Config) error {
 
 	d.logger.Debug("Install directory: %s", dataDir)
 
-	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
-		d.logger.Info("Creating Docker network %s", NetworkName)
-		if _, err := d.RunCommand("network", "create", NetworkName); err != nil {
+	if _, err := d.RunCommand("network", "inspect", d.names().Network); err != nil {
+		d.logger.Info("Creating Docker network %s", d.names().Network)
+		if _, err := d.RunCommand("network", "create", d.names().Network); err != nil {
 			return fmt.Errorf("create network: %w", err)
 		}
 		d.logger.Success("Network created")
 	} else {
-		d.logger.Debug("Docker network %s already exists", NetworkName)
+		d.logger.Debug("Docker network %s already exists", d.names().Network)
 	}
 
 	// Show current running containers
@@ -356,10 +386,10 @@ func (d *Docker) UpdateWithDebug(conf *config.Config) error {
 	}
 
 	// Determine current and new app instances
-	currentName := AppNamePrimary
-	newName := AppNameSecondary
-	if d.IsRunning(AppNameSecondary) && !d.IsRunning(AppNamePrimary) {
-		currentName, newName = AppNameSecondary, AppNamePrimary
+	currentName := d.names().AppPrimary
+	newName := d.names().AppSecondary
+	if d.IsRunning(d.names().AppSecondary) && !d.IsRunning(d.names().AppPrimary) {
+		currentName, newName = d.names().AppSecondary, d.names().AppPrimary
 	}
 
 	d.logger.Debug("Current container: %s, New container: %s", currentName, newName)
@@ -390,7 +420,7 @@ func (d *Docker) UpdateWithDebug(conf *config.Config) error {
 	}
 
 	d.logger.Debug("Ensuring network connectivity for %s", newName)
-	if err := d.ensureNetworkConnected(newName, NetworkName); err != nil {
+	if err := d.ensureNetworkConnected(newName, d.names().Network); err != nil {
 		if cleanupErr := d.StopAndRemove(newName); cleanupErr != nil {
 			d.logger.Error("Failed to cleanup container %s after network error: %v", newName, cleanupErr)
 		}
@@ -415,19 +445,19 @@ func (d *Docker) UpdateWithDebug(conf *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("generate Caddyfile: %w", err)
 	}
-	if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+	if err := d.writeFile(caddyFile, caddyContent); err != nil {
 		return fmt.Errorf("write Caddyfile: %w", err)
 	}
 	d.logger.Debug("Caddyfile written, reloading Caddy configuration...")
 	d.logger.Info("Reloading Caddy configuration to point to %s...", newName)
-	if _, err := d.RunCommand("exec", CaddyName, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
+	if _, err := d.RunCommand("exec", d.names().Caddy, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
 		d.logger.Warn("Caddy reload failed: %v. Attempting full Caddy redeploy as a fallback.", err)
 		// Show Caddy logs before fallback
 		d.logger.Debug("Showing Caddy logs before redeploy:")
-		d.ShowContainerLogs(CaddyName, 50)
+		d.ShowContainerLogs(d.names().Caddy, 50)
 		
 		// Fallback to stop and redeploy if reload fails
-		if cleanupErr := d.StopAndRemove(CaddyName); cleanupErr != nil {
+		if cleanupErr := d.StopAndRemove(d.names().Caddy); cleanupErr != nil {
 			d.logger.Error("Failed to cleanup Caddy container during fallback: %v", cleanupErr)
 		}
 		if errRedeploy := d.deployCaddy(data, caddyFile); errRedeploy != nil {
@@ -469,9 +499,9 @@ func (d *Docker) Reload(conf *config.Config) error {
 	d.logger.Info("Starting container reload with latest environment variables")
 
 	// Ensure network exists
-	if _, err := d.RunCommand("network", "inspect", NetworkName); err != nil {
-		d.logger.Info("Creating Docker network %s", NetworkName)
-		if _, err := d.RunCommand("network", "create", NetworkName); err != nil {
+	if _, err := d.RunCommand("network", "inspect", d.names().Network); err != nil {
+		d.logger.Info("Creating Docker network %s", d.names().Network)
+		if _, err := d.RunCommand("network", "create", d.names().Network); err != nil {
 			return fmt.Errorf("create network: %w", err)
 		}
 		d.logger.Success("Network created")
@@ -479,13 +509,13 @@ func (d *Docker) Reload(conf *config.Config) error {
 
 	// Find which app container is running
 	currentName := ""
-	if d.IsRunning(AppNamePrimary) {
-		currentName = AppNamePrimary
-	} else if d.IsRunning(AppNameSecondary) {
-		currentName = AppNameSecondary
+	if d.IsRunning(d.names().AppPrimary) {
+		currentName = d.names().AppPrimary
+	} else if d.IsRunning(d.names().AppSecondary) {
+		currentName = d.names().AppSecondary
 	} else {
 		d.logger.Warn("No app container running, will deploy primary")
-		currentName = AppNamePrimary
+		currentName = d.names().AppPrimary
 	}
 
 	d.logger.Info("Restarting app container: %s", currentName)
@@ -506,7 +536,7 @@ func (d *Docker) Reload(conf *config.Config) error {
 	}
 
 	// Restart Caddy container
-	if d.IsRunning(CaddyName) {
+	if d.IsRunning(d.names().Caddy) {
 		d.logger.Info("Restarting Caddy container")
 
 		caddyFile := filepath.Join(dataDir, "Caddyfile")
@@ -516,16 +546,16 @@ func (d *Docker) Reload(conf *config.Config) error {
 		}
 
 		// Write the Caddyfile
-		if err := os.WriteFile(caddyFile, []byte(caddyContent), 0o644); err != nil {
+		if err := d.writeFile(caddyFile, caddyContent); err != nil {
 			return fmt.Errorf("write Caddyfile: %w", err)
 		}
 
 		// Reload Caddy
 		d.logger.Info("Reloading Caddy configuration with new environment variables...")
-		if _, err := d.RunCommand("exec", CaddyName, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
+		if _, err := d.RunCommand("exec", d.names().Caddy, "caddy", "reload", "--config", "/etc/caddy/Caddyfile"); err != nil {
 			d.logger.Warn("Caddy reload failed: %v. Attempting full Caddy redeploy as a fallback.", err)
 			// Fallback to stop and redeploy if reload fails
-			if cleanupErr := d.StopAndRemove(CaddyName); cleanupErr != nil {
+			if cleanupErr := d.StopAndRemove(d.names().Caddy); cleanupErr != nil {
 				d.logger.Error("Failed to cleanup Caddy container during fallback: %v", cleanupErr)
 			}
 			if errRedeploy := d.deployCaddy(data, caddyFile); errRedeploy != nil {
@@ -542,32 +572,36 @@ func (d *Docker) Reload(conf *config.Config) error {
 }
 
 func (d *Docker) deployCaddy(data config.ConfigData, caddyFile string) error {
-	if cleanupErr := d.StopAndRemove(CaddyName); cleanupErr != nil {
+	if cleanupErr := d.StopAndRemove(d.names().Caddy); cleanupErr != nil {
 		// Only log if it's not a "no such container" error
 		if !strings.Contains(cleanupErr.Error(), "No such container") {
 			d.logger.Warn("Failed to cleanup existing Caddy container: %v", cleanupErr)
 		}
 	}
-	_, err := d.RunCommand("run", "-d",
-		"--name", CaddyName,
-		"--network", NetworkName,
-		"--pull", "always",
-		"-p", "80:80", "-p", "443:443", "-p", "443:443/udp",
+	args := []string{"run", "-d",
+		"--name", d.names().Caddy,
+		"--network", d.names().Network,
+		"--label", d.names().label(),
+		"--pull", d.pullPolicy(),
+	}
+	args = append(args, d.proxyPorts(data).publishArgs()...)
+	args = append(args,
 		"-v", caddyFile+":/etc/caddy/Caddyfile:ro",
 		"-v", filepath.Join(data.InstallDir, "caddy")+":/data",
 		"-v", filepath.Join(data.InstallDir, "caddy", "config")+":/config",
 		"-v", filepath.Join(data.InstallDir, "logs")+":/data/logs",
 		"-e", "DOMAIN="+data.Domain,
 		"--memory=256m",
-		"--restart", "unless-stopped",
+		"--restart", restartPolicy(data),
 		data.CaddyImage,
 	)
+	_, err := d.RunCommand(args...)
 	if err != nil {
 		return fmt.Errorf("start caddy: %w", err)
 	}
-	_, err = d.RunCommand("exec", CaddyName, "chmod", "-R", "755", "/data")
+	_, err = d.RunCommand("exec", d.names().Caddy, "chmod", "-R", "755", "/data")
 	if err != nil {
-		return fmt.Errorf("failed to set permissions on /data directory in %s container: %w", CaddyName, err)
+		return fmt.Errorf("failed to set permissions on /data directory in %s container: %w", d.names().Caddy, err)
 	}
 	return nil
 }
@@ -581,8 +615,9 @@ func (d *Docker) DeployApp(data config.ConfigData, name string) error {
 	}
 	args := []string{"run", "-d",
 		"--name", name,
-		"--network", NetworkName,
-		"--pull", "always",
+		"--network", d.names().Network,
+		"--label", d.names().label(),
+		"--pull", d.pullPolicy(),
 		"-v", filepath.Join(data.InstallDir, "storage") + ":/app/storage",
 		"-v", filepath.Join(data.InstallDir, "logs") + ":/app/logs",
 		"-e", "FUSIONALY_LOG_LEVEL=debug",
@@ -591,10 +626,12 @@ func (d *Docker) DeployApp(data config.ConfigData, name string) error {
 		"-e", "FUSIONALY_PRIVATE_KEY=" + data.PrivateKey,
 		"-e", "SERVER_INSTANCE_ID=" + name,
 		"-e", "FUSIONALY_LICENSE_KEY=" + data.LicenseKey,
-		"--memory=512m",
-		"--restart", "unless-stopped",
-		data.AppImage,
 	}
+	args = append(args, appResources(data)...)
+	args = append(args,
+		"--restart", restartPolicy(data),
+		data.AppImage,
+	)
 	
 	_, err := d.RunCommand(args...)
 	if err != nil {
@@ -603,6 +640,29 @@ func (d *Docker) DeployApp(data config.ConfigData, name string) error {
 	return nil
 }
 
+// appResources returns the docker run limits of the app container, defaulting
+// the memory for configurations saved before it was configurable.
+func appResources(data config.ConfigData) []string {
+	memory := data.AppMemory
+	if memory == "" {
+		memory = config.DefaultAppMemory
+	}
+	args := []string{"--memory=" + memory}
+	if data.AppCPUs != "" {
+		args = append(args, "--cpus="+data.AppCPUs)
+	}
+	return args
+}
+
+// restartPolicy returns the configured restart policy, defaulting for
+// configurations saved before it was configurable.
+func restartPolicy(data config.ConfigData) string {
+	if data.RestartPolicy == "" {
+		return config.DefaultRestartPolicy
+	}
+	return data.RestartPolicy
+}
+
 func (d *Docker) StopAndRemove(name string) error {
 	if name == "" {
 		return errors.NewDockerError("stop_and_remove", name, fmt.Errorf("container name cannot be empty"))
@@ -645,33 +705,68 @@ func (d *Docker) IsRunning(name string) bool {
 }
 
 func (d *Docker) ExecuteCommand(command ...string) error {
-	containerName := AppNamePrimary
-	if !d.IsRunning(containerName) {
-		containerName = AppNameSecondary
-		if !d.IsRunning(containerName) {
-			return fmt.Errorf("no running app container found")
-		}
+	return d.ExecuteCommandContext(context.Background(), command...)
+}
+
+// ExecuteCommandContext is like ExecuteCommand but kills the command when ctx
+// is cancelled or its deadline passes.
+func (d *Docker) ExecuteCommandContext(ctx context.Context, command ...string) error {
+	_, stderr, err := d.ExecuteCommandOutputContext(ctx, command...)
+	if err != nil && stderr != "" {
+		return fmt.Errorf("%w - %s", err, stderr)
+	}
+	return err
+}
+
+// ExecuteCommandOutput runs a command inside the active app container and
+// returns what it printed on stdout and stderr alongside the exit error.
+func (d *Docker) ExecuteCommandOutput(command ...string) (string, string, error) {
+	return d.ExecuteCommandOutputContext(context.Background(), command...)
+}
+
+// ExecuteCommandOutputContext is like ExecuteCommandOutput but kills the
+// command when ctx is cancelled or its deadline passes.
+func (d *Docker) ExecuteCommandOutputContext(ctx context.Context, command ...string) (string, string, error) {
+	if len(command) == 0 {
+		return "", "", fmt.Errorf("no command provided")
+	}
+
+	containerName, err := d.RunningAppContainer()
+	if err != nil {
+		return "", "", err
 	}
 
 	args := []string{"exec", containerName}
 	args = append(args, command...)
 
-	d.logger.Debug("Executing in app container %s: %s", containerName, strings.Join(command, " "))
+	// Arguments may carry secrets (e.g. admin passwords), so callers are
+	// responsible for logging a redacted form of the command.
+	d.logger.Debug("Executing %s in app container %s", command[0], containerName)
 
-	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to execute in container %s: %w - %s", containerName, err, stderr.String())
+	res, err := d.run(ctx, args...)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return res.Stdout, res.Stderr, fmt.Errorf("failed to execute in container %s: %w", containerName, ctxErr)
+		}
+		return res.Stdout, res.Stderr, fmt.Errorf("failed to execute in container %s: %w", containerName, err)
 	}
 
-	if stdout.Len() > 0 {
-		d.logger.Debug("Command output: %s", stdout.String())
+	if res.Stdout != "" {
+		d.logger.Debug("Command output: %s", res.Stdout)
 	}
 
-	return nil
+	return res.Stdout, res.Stderr, nil
+}
+
+// RunningAppContainer returns the name of the app container that is
+// currently running, preferring the primary one.
+func (d *Docker) RunningAppContainer() (string, error) {
+	for _, name := range []string{d.names().AppPrimary, d.names().AppSecondary} {
+		if d.IsRunning(name) {
+			return name, nil
+		}
+	}
+	return "", ErrNoRunningApp
 }
 
 func (d *Docker) ensureNetworkConnected(container, network string) error {
@@ -722,8 +817,12 @@ func (d *Docker) generateCaddyfileForContainer(data config.ConfigData, container
 		tlsConfig = "internal"
 	} else {
 		d.logger.Info("Using Let's Encrypt for production environment")
-		// Use database user email if available, otherwise generate admin email for Let's Encrypt
-		if data.User != "" {
+		// Prefer the email set by ConfigureTLS, then the database user email,
+		// otherwise generate admin email for Let's Encrypt
+		if data.ACMEEmail != "" {
+			d.logger.Info("Using configured email for Let's Encrypt: %s", data.ACMEEmail)
+			tlsConfig = data.ACMEEmail
+		} else if data.User != "" {
 			d.logger.Info("Using database admin user email for Let's Encrypt: %s", data.User)
 			tlsConfig = data.User
 		} else {
@@ -732,14 +831,24 @@ func (d *Docker) generateCaddyfileForContainer(data config.ConfigData, container
 		}
 	}
 
+	var stagingCA string
+	if data.ACMEStaging && tlsConfig != "internal" {
+		d.logger.Info("Using the Let's Encrypt staging CA")
+		stagingCA = LetsEncryptStagingCA
+	}
+
 	tplData := struct {
 		Domain          string
 		TLSConfig       string
 		ActiveContainer string
+		StagingCA       string
+		Maintenance     bool
 	}{
 		Domain:          data.Domain,
 		TLSConfig:       tlsConfig,
 		ActiveContainer: containerName,
+		StagingCA:       stagingCA,
+		Maintenance:     maintenanceEnabled(data.InstallDir),
 	}
 
 	tmpl, err := template.New("caddyfile").Parse(caddyfileTemplate)
@@ -758,30 +867,24 @@ func (d *Docker) generateCaddyfileForContainer(data config.ConfigData, container
 
 // getActiveContainer determines which app container is currently running
 func (d *Docker) getActiveContainer() string {
-	if d.IsRunning(AppNamePrimary) {
-		return AppNamePrimary
+	if d.IsRunning(d.names().AppPrimary) {
+		return d.names().AppPrimary
 	}
-	if d.IsRunning(AppNameSecondary) {
-		return AppNameSecondary
+	if d.IsRunning(d.names().AppSecondary) {
+		return d.names().AppSecondary
 	}
 	// Default to primary if neither is running (initial deployment)
-	return AppNamePrimary
+	return d.names().AppPrimary
 }
 
 func (d *Docker) waitForAppHealth(name string) error {
 	d.logger.Info("Waiting for %s to become healthy...", name)
-	for i := 0; i < HealthCheckTries; i++ {
-		if _, err := d.RunCommand("exec", name, "curl", "-f", "http://localhost:8080/_health"); err == nil {
-			d.logger.Success("%s is healthy", name)
-			return nil
-		}
-		time.Sleep(2 * time.Second)
-		if i == HealthCheckTries-1 {
-			d.logger.Error("Container %s failed to become healthy after %d attempts", name, HealthCheckTries)
-			d.logContainerLogs(name)
-			return fmt.Errorf("app %s not healthy after %d attempts", name, HealthCheckTries)
-		}
+	if err := d.AwaitHealthy(context.Background(), DeployHealthOptions(name)); err != nil {
+		d.logger.Error("Container %s failed to become healthy: %v", name, err)
+		d.logContainerLogs(name)
+		return fmt.Errorf("app %s not healthy: %w", name, err)
 	}
+	d.logger.Success("%s is healthy", name)
 	return nil
 }
 
@@ -946,7 +1049,7 @@ func (d *Docker) DiagnoseContainerStartup(containerName string) {
 }
 
 func (d *Docker) logCaddyVersion() {
-	output, err := d.RunCommand("exec", CaddyName, "caddy", "version")
+	output, err := d.RunCommand("exec", d.names().Caddy, "caddy", "version")
 	if err == nil {
 		d.logger.Info("Caddy version: %s", strings.TrimSpace(output))
 	} else {
@@ -985,13 +1088,13 @@ func (d *Docker) containerExists(name string) bool {
 // VerifyContainersRunning checks if the Fusionaly containers are running
 func (d *Docker) VerifyContainersRunning() (bool, error) {
 	// Check app container
-	appRunning, err := d.isContainerRunning("fusionaly-app")
+	appRunning, err := d.isContainerRunning(d.names().Project + "-app")
 	if err != nil {
 		return false, fmt.Errorf("failed to check app container: %w", err)
 	}
 
 	// Check Caddy container
-	caddyRunning, err := d.isContainerRunning("fusionaly-caddy")
+	caddyRunning, err := d.isContainerRunning(d.names().Caddy)
 	if err != nil {
 		return false, fmt.Errorf("failed to check Caddy container: %w", err)
 	}
@@ -1001,13 +1104,12 @@ func (d *Docker) VerifyContainersRunning() (bool, error) {
 
 // isContainerRunning checks if a specific container is running
 func (d *Docker) isContainerRunning(containerName string) (bool, error) {
-	cmd := exec.Command("docker", "ps", "--filter", "name="+containerName, "--format", "{{.Names}}")
-	output, err := cmd.CombinedOutput()
+	output, err := d.RunCommand("ps", "--filter", "name="+containerName, "--format", "{{.Names}}")
 	if err != nil {
 		return false, fmt.Errorf("failed to check container status: %w", err)
 	}
 
-	return strings.Contains(string(output), containerName), nil
+	return strings.Contains(output, containerName), nil
 }
 
 // generateAdminEmail generates the admin email for Let's Encrypt based on the domain
@@ -1058,7 +1160,7 @@ func extractBaseDomain(domain string) string {
 func (d *Docker) ShowContainerStatus() {
 	d.logger.Debug("=== Container Status ===")
 	
-	containers := []string{CaddyName, AppNamePrimary, AppNameSecondary}
+	containers := []string{d.names().Caddy, d.names().AppPrimary, d.names().AppSecondary}
 	for _, container := range containers {
 		if d.IsRunning(container) {
 			status, err := d.RunCommand("inspect", "--format", "{{.State.Status}}", container)
@@ -1,10 +1,12 @@
 package docker
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
 )
 
@@ -151,3 +153,118 @@ func TestGenerateAdminEmail(t *testing.T) {
 	}
 }
 
+
+func TestDeployApp_PullsPinnedVersion(t *testing.T) {
+	fr := &fakeRunner{}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	cfg := config.NewConfig(testLogger(t))
+	if err := cfg.PinAppVersion("1.4.2"); err != nil {
+		t.Fatalf("PinAppVersion error: %v", err)
+	}
+	if err := d.DeployApp(cfg.GetData(), AppNamePrimary); err != nil {
+		t.Fatalf("DeployApp error: %v", err)
+	}
+
+	run := fr.calls[len(fr.calls)-1]
+	if run[1] != "run" || !strings.Contains(strings.Join(run, " "), "--pull always") {
+		t.Fatalf("expected docker run --pull always, got %v", run)
+	}
+	if image := run[len(run)-1]; image != "karloscodes/fusionaly-beta:1.4.2" {
+		t.Errorf("pulled image = %q, want karloscodes/fusionaly-beta:1.4.2", image)
+	}
+}
+
+func TestDryRun_DeployAppRecordsCommands(t *testing.T) {
+	orig := executor.Default()
+	t.Cleanup(func() { executor.SetDefault(orig) })
+	dry := executor.NewDryRunExecutor(nil)
+	executor.SetDefault(dry)
+
+	d := NewDocker(testLogger(t), nil)
+	data := config.NewConfig(testLogger(t)).GetData()
+	data.InstallDir = t.TempDir()
+	if err := d.DeployApp(data, AppNamePrimary); err != nil {
+		t.Fatalf("DeployApp error: %v", err)
+	}
+
+	cmds := dry.Commands()
+	if len(cmds) != 3 {
+		t.Fatalf("expected stop, rm and run, got %v", cmds)
+	}
+	for i, want := range [][]string{{"docker", "stop", AppNamePrimary}, {"docker", "rm", "-f", AppNamePrimary}} {
+		if strings.Join(cmds[i], " ") != strings.Join(want, " ") {
+			t.Errorf("command %d = %v, want %v", i, cmds[i], want)
+		}
+	}
+	if run := cmds[2]; run[1] != "run" || run[len(run)-1] != data.AppImage {
+		t.Errorf("unexpected run command %v", run)
+	}
+}
+
+func TestDeploy_UsesRestartPolicy(t *testing.T) {
+	for _, tc := range []struct{ policy, want string }{
+		{"always", "--restart always"},
+		{"", "--restart unless-stopped"},
+	} {
+		fr := &fakeRunner{}
+		d := &Docker{logger: testLogger(t), runner: fr}
+		data := config.NewConfig(testLogger(t)).GetData()
+		data.InstallDir = t.TempDir()
+		data.RestartPolicy = tc.policy
+
+		if err := d.DeployApp(data, AppNamePrimary); err != nil {
+			t.Fatalf("DeployApp error: %v", err)
+		}
+		if err := d.deployCaddy(data, filepath.Join(data.InstallDir, "Caddyfile")); err != nil {
+			t.Fatalf("deployCaddy error: %v", err)
+		}
+
+		runs := 0
+		for _, call := range fr.calls {
+			if call[1] != "run" {
+				continue
+			}
+			runs++
+			if !strings.Contains(strings.Join(call, " "), tc.want) {
+				t.Errorf("policy %q: expected %q in %v", tc.policy, tc.want, call)
+			}
+		}
+		if runs != 2 {
+			t.Errorf("policy %q: expected app and caddy runs, got %v", tc.policy, fr.calls)
+		}
+	}
+}
+
+func TestDeployApp_UsesResourceLimits(t *testing.T) {
+	for _, tc := range []struct {
+		memory, cpus string
+		want         []string
+		unwanted     string
+	}{
+		{"2g", "1.5", []string{"--memory=2g", "--cpus=1.5"}, ""},
+		{"", "", []string{"--memory=512m"}, "--cpus"},
+	} {
+		fr := &fakeRunner{}
+		d := &Docker{logger: testLogger(t), runner: fr}
+		data := config.NewConfig(testLogger(t)).GetData()
+		data.InstallDir = t.TempDir()
+		data.AppMemory, data.AppCPUs = tc.memory, tc.cpus
+
+		if err := d.DeployApp(data, AppNamePrimary); err != nil {
+			t.Fatalf("DeployApp error: %v", err)
+		}
+		run := strings.Join(fr.calls[len(fr.calls)-1], " ")
+		for _, want := range tc.want {
+			if !strings.Contains(run, want) {
+				t.Errorf("limits %q/%q: expected %q in %s", tc.memory, tc.cpus, want, run)
+			}
+		}
+		if tc.unwanted != "" && strings.Contains(run, tc.unwanted) {
+			t.Errorf("limits %q/%q: unexpected %q in %s", tc.memory, tc.cpus, tc.unwanted, run)
+		}
+		if !strings.HasSuffix(run, data.AppImage) {
+			t.Errorf("image must stay the last argument: %s", run)
+		}
+	}
+}
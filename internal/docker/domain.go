@@ -0,0 +1,52 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/validation"
+)
+
+// SetDomain moves the installation to domain. The domain is validated and
+// must resolve to this server before anything is written. The Caddyfile is
+// regenerated and the running proxy reloaded in place, then FUSIONALY_DOMAIN
+// is saved to the .env file; a proxy that rejects the new configuration
+// keeps the old Caddyfile and leaves the .env file untouched.
+//
+// The app container keeps the domain it was started with until it is next
+// redeployed (e.g. with `fusionaly reload`), which SetDomain does not do so
+// the site stays up.
+func (s *Stack) SetDomain(ctx context.Context, domain string) error {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if err := validation.ValidateDomain(domain); err != nil {
+		return err
+	}
+	if err := s.checkDNS(ctx, domain); err != nil {
+		return err
+	}
+
+	conf := config.NewConfig(s.logger)
+	if err := conf.LoadFromFile(s.envFile()); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	data := conf.GetData()
+	if data.Domain == domain {
+		s.logger.Info("Domain is already %s", domain)
+		return nil
+	}
+	previous := data.Domain
+	data.Domain = domain
+	conf.SetData(data)
+
+	if err := s.reloadCaddyfile(ctx, data); err != nil {
+		return err
+	}
+	if err := conf.SaveToFile(s.envFile()); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	s.logger.Success("Domain changed from %s to %s", previous, domain)
+	s.logger.Info("Run 'fusionaly reload' so the app picks up the new domain")
+	return nil
+}
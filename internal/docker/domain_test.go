@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetDomainUpdatesEveryLocation(t *testing.T) {
+	fr := &fakeRunner{}
+	s, dir := newTLSStack(t, fr, "203.0.113.10")
+
+	if err := s.SetDomain(context.Background(), " Analytics.Example.com "); err != nil {
+		t.Fatalf("SetDomain returned error: %v", err)
+	}
+
+	env, _ := os.ReadFile(s.env)
+	if !strings.Contains(string(env), "FUSIONALY_DOMAIN=analytics.example.com\n") || strings.Contains(string(env), "old.example.com") {
+		t.Errorf(".env not updated:\n%s", env)
+	}
+	caddyfile, err := os.ReadFile(filepath.Join(dir, "Caddyfile"))
+	if err != nil || !strings.Contains(string(caddyfile), "analytics.example.com:443") {
+		t.Errorf("Caddyfile not updated (%v):\n%s", err, caddyfile)
+	}
+	if len(fr.calls) == 0 || strings.Join(fr.calls[len(fr.calls)-1], " ") != "docker exec "+CaddyName+" caddy reload --config /etc/caddy/Caddyfile" {
+		t.Errorf("expected the proxy to be reloaded in place, got %v", fr.calls)
+	}
+	for _, call := range fr.calls {
+		if call[1] == "restart" || call[1] == "stop" || call[1] == "rm" {
+			t.Errorf("SetDomain should not restart containers, ran %v", call)
+		}
+	}
+}
+
+func TestSetDomainRejectsInvalidDomainWithoutChanges(t *testing.T) {
+	for _, domain := range []string{"bad..domain", "not a domain", ""} {
+		fr := &fakeRunner{}
+		s, dir := newTLSStack(t, fr, "203.0.113.10")
+		before, _ := os.ReadFile(s.env)
+
+		if err := s.SetDomain(context.Background(), domain); err == nil {
+			t.Errorf("SetDomain(%q) should fail", domain)
+		}
+		after, _ := os.ReadFile(s.env)
+		if string(before) != string(after) {
+			t.Errorf("SetDomain(%q) changed .env:\n%s", domain, after)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "Caddyfile")); !os.IsNotExist(err) {
+			t.Errorf("SetDomain(%q) wrote a Caddyfile", domain)
+		}
+		if len(fr.calls) != 0 {
+			t.Errorf("SetDomain(%q) ran docker: %v", domain, fr.calls)
+		}
+	}
+}
+
+func TestSetDomainKeepsEnvWhenReloadFails(t *testing.T) {
+	// getActiveContainer checks both app slots before the reload runs.
+	fr := &fakeRunner{errs: []error{nil, nil, errors.New("exit status 1")}}
+	s, _ := newTLSStack(t, fr, "203.0.113.10")
+	before, _ := os.ReadFile(s.env)
+
+	if err := s.SetDomain(context.Background(), "analytics.example.com"); !errors.Is(err, ErrStackFailed) {
+		t.Fatalf("expected a stack error, got %v", err)
+	}
+	after, _ := os.ReadFile(s.env)
+	if string(before) != string(after) {
+		t.Errorf(".env should be untouched when the proxy rejects the config:\n%s", after)
+	}
+}
@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/errors"
+)
+
+// DefaultHealthPollInterval is how often AwaitHealthy re-inspects a container.
+const DefaultHealthPollInterval = 2 * time.Second
+
+// HealthFormat is the docker inspect template that prints the health status,
+// or the plain container state when the image defines no HEALTHCHECK.
+const HealthFormat = "{{if .State.Health}}{{.State.Health.Status}}{{else}}{{.State.Status}}{{end}}"
+
+// DefaultHealthSuccesses is how many consecutive healthy probes the deploy
+// and update gates require, so an app that passes once and then crashes is
+// not mistaken for a healthy one.
+const DefaultHealthSuccesses = 2
+
+// DefaultDeployHealthTimeout bounds the health gate after a container is
+// (re)deployed.
+const DefaultDeployHealthTimeout = 60 * time.Second
+
+// HealthOptions controls AwaitHealthy.
+type HealthOptions struct {
+	Container string
+	Timeout   time.Duration // Overall deadline; zero leaves it to ctx
+	Interval  time.Duration // Delay between probes; zero uses the Docker's poll interval
+	Successes int           // Consecutive healthy probes required; less than 1 means 1
+	// Exec, when set, runs in the container on every probe (e.g. curl against
+	// the health endpoint) and must succeed for the probe to count.
+	Exec []string
+}
+
+// DeployHealthOptions returns the gate applied to an app container after
+// install, update or reload: docker's health status and the app's own
+// health endpoint must both pass DefaultHealthSuccesses times in a row.
+func DeployHealthOptions(container string) HealthOptions {
+	return HealthOptions{
+		Container: container,
+		Timeout:   DefaultDeployHealthTimeout,
+		Successes: DefaultHealthSuccesses,
+		Exec:      []string{"curl", "-f", "http://localhost:8080/_health"},
+	}
+}
+
+// WaitForHealthy polls `docker inspect` until container reports "healthy" or
+// timeout elapses. It is AwaitHealthy with a single required success.
+func (d *Docker) WaitForHealthy(ctx context.Context, container string, timeout time.Duration) error {
+	return d.AwaitHealthy(ctx, HealthOptions{Container: container, Timeout: timeout})
+}
+
+// AwaitHealthy polls `docker inspect` until the container has been healthy
+// for opts.Successes consecutive probes, or until the timeout elapses. A
+// failed probe resets the count. Containers without a HEALTHCHECK count as
+// healthy while running. An "unhealthy" status fails immediately, since
+// docker only reports it after the container's own retries are exhausted.
+func (d *Docker) AwaitHealthy(ctx context.Context, opts HealthOptions) error {
+	container := opts.Container
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = d.pollInterval
+	}
+	if interval <= 0 {
+		interval = DefaultHealthPollInterval
+	}
+	required := max(opts.Successes, 1)
+
+	d.logger.Debug("Waiting for %s to report healthy", container)
+	last := "unknown"
+	successes := 0
+	for {
+		healthy, status, err := d.probeHealth(ctx, container, opts.Exec)
+		if err != nil {
+			return err
+		}
+		if status != "" {
+			last = status
+		}
+		if healthy {
+			if successes++; successes >= required {
+				return nil
+			}
+		} else {
+			successes = 0
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return errors.NewDockerError("health_check", container,
+				fmt.Errorf("not healthy within %s (last status: %s): %w", opts.Timeout, last, ctx.Err()))
+		}
+	}
+}
+
+// probeHealth inspects container once and, when docker considers it healthy,
+// runs exec in it. An error means the container reported unhealthy.
+func (d *Docker) probeHealth(ctx context.Context, container string, exec []string) (bool, string, error) {
+	res, err := d.run(ctx, "inspect", "--format", HealthFormat, container)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, "", nil
+		}
+		return false, strings.TrimSpace(res.Stderr), nil
+	}
+	status := strings.TrimSpace(res.Stdout)
+	switch status {
+	case "healthy", "running":
+	case "unhealthy":
+		return false, status, errors.NewDockerError("health_check", container, fmt.Errorf("container reported unhealthy"))
+	default:
+		return false, status, nil
+	}
+	if len(exec) == 0 {
+		return true, status, nil
+	}
+	if res, err := d.run(ctx, append([]string{"exec", container}, exec...)...); err != nil {
+		return false, fmt.Sprintf("%s, %s failed: %s", status, exec[0], strings.TrimSpace(res.Stderr)), nil
+	}
+	return true, status, nil
+}
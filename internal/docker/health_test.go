@@ -0,0 +1,159 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// fakeRunner replays scripted results and records every command it receives.
+type fakeRunner struct {
+	calls   [][]string
+	results []executor.Result
+	errs    []error
+}
+
+func (f *fakeRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	i := len(f.calls) - 1
+	var res executor.Result
+	var err error
+	if i < len(f.results) {
+		res = f.results[i]
+	} else if len(f.results) > 0 {
+		res = f.results[len(f.results)-1]
+	}
+	if i < len(f.errs) {
+		err = f.errs[i]
+	}
+	return res, err
+}
+
+func TestWaitForHealthyPollsUntilHealthy(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: "starting\n"},
+		{Stdout: "starting\n"},
+		{Stdout: "healthy\n"},
+	}}
+	d := &Docker{logger: testLogger(t), runner: fr, pollInterval: time.Millisecond}
+
+	if err := d.WaitForHealthy(context.Background(), AppNamePrimary, time.Second); err != nil {
+		t.Fatalf("WaitForHealthy returned error: %v", err)
+	}
+	if len(fr.calls) != 3 {
+		t.Fatalf("expected 3 inspections, got %d", len(fr.calls))
+	}
+	want := []string{"docker", "inspect", "--format", HealthFormat, AppNamePrimary}
+	if fmt.Sprint(fr.calls[0]) != fmt.Sprint(want) {
+		t.Errorf("unexpected command %v, want %v", fr.calls[0], want)
+	}
+}
+
+func TestWaitForHealthyTimesOut(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: "starting\n"}}}
+	d := &Docker{logger: testLogger(t), runner: fr, pollInterval: time.Millisecond}
+
+	err := d.WaitForHealthy(context.Background(), AppNamePrimary, 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWaitForHealthyFailsFastOnUnhealthy(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: "starting\n"}, {Stdout: "unhealthy\n"}}}
+	d := &Docker{logger: testLogger(t), runner: fr, pollInterval: time.Millisecond}
+
+	if err := d.WaitForHealthy(context.Background(), AppNamePrimary, time.Second); err == nil {
+		t.Fatal("expected error for unhealthy container")
+	}
+	if len(fr.calls) != 2 {
+		t.Errorf("expected 2 inspections, got %d", len(fr.calls))
+	}
+}
+
+func TestWaitForHealthyAcceptsRunningWithoutHealthcheck(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: "running\n"}}}
+	d := &Docker{logger: testLogger(t), runner: fr, pollInterval: time.Millisecond}
+
+	if err := d.WaitForHealthy(context.Background(), AppNamePrimary, time.Second); err != nil {
+		t.Fatalf("WaitForHealthy returned error: %v", err)
+	}
+}
+
+func TestWaitForHealthyRetriesInspectErrors(t *testing.T) {
+	fr := &fakeRunner{
+		results: []executor.Result{{Stderr: "No such object"}, {Stdout: "healthy\n"}},
+		errs:    []error{errors.New("exit status 1")},
+	}
+	d := &Docker{logger: testLogger(t), runner: fr, pollInterval: time.Millisecond}
+
+	if err := d.WaitForHealthy(context.Background(), AppNamePrimary, time.Second); err != nil {
+		t.Fatalf("WaitForHealthy returned error: %v", err)
+	}
+}
+
+func TestAwaitHealthyRequiresConsecutiveSuccesses(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: "healthy\n"},
+		{Stdout: "starting\n"},
+		{Stdout: "healthy\n"},
+		{Stdout: "healthy\n"},
+		{Stdout: "starting\n"},
+		{Stdout: "healthy\n"},
+		{Stdout: "healthy\n"},
+		{Stdout: "healthy\n"},
+	}}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	opts := HealthOptions{Container: AppNamePrimary, Timeout: time.Second, Interval: time.Millisecond, Successes: 3}
+	if err := d.AwaitHealthy(context.Background(), opts); err != nil {
+		t.Fatalf("AwaitHealthy returned error: %v", err)
+	}
+	if len(fr.calls) != 8 {
+		t.Errorf("expected to stop at the third healthy probe in a row (8 inspections), got %d", len(fr.calls))
+	}
+}
+
+func TestAwaitHealthyTimesOutWhileFlapping(t *testing.T) {
+	var results []executor.Result
+	for i := 0; i < 1000; i++ {
+		results = append(results, executor.Result{Stdout: "healthy\n"}, executor.Result{Stdout: "starting\n"})
+	}
+	fr := &fakeRunner{results: results}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	opts := HealthOptions{Container: AppNamePrimary, Timeout: 20 * time.Millisecond, Interval: time.Millisecond, Successes: 2}
+	err := d.AwaitHealthy(context.Background(), opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestAwaitHealthyRunsExecProbe(t *testing.T) {
+	fr := &fakeRunner{
+		results: []executor.Result{
+			{Stdout: "running\n"}, {Stderr: "curl: (7) Failed to connect"},
+			{Stdout: "running\n"}, {},
+			{Stdout: "running\n"}, {},
+		},
+		errs: []error{nil, errors.New("exit status 7")},
+	}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	opts := DeployHealthOptions(AppNamePrimary)
+	opts.Interval = time.Millisecond
+	if err := d.AwaitHealthy(context.Background(), opts); err != nil {
+		t.Fatalf("AwaitHealthy returned error: %v", err)
+	}
+	if len(fr.calls) != 6 {
+		t.Fatalf("expected three inspect+exec probes, got %v", fr.calls)
+	}
+	want := []string{"docker", "exec", AppNamePrimary, "curl", "-f", "http://localhost:8080/_health"}
+	if fmt.Sprint(fr.calls[1]) != fmt.Sprint(want) {
+		t.Errorf("unexpected probe command %v, want %v", fr.calls[1], want)
+	}
+}
@@ -0,0 +1,201 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// OSReleasePath identifies the host distribution.
+const OSReleasePath = "/etc/os-release"
+
+// convenienceScript installs Docker from download.docker.com on the
+// distributions that get.docker.com supports.
+const convenienceScript = "curl -fsSL https://get.docker.com | sh"
+
+var (
+	// ErrUnsupportedDistro is returned when Docker is missing and the
+	// installer does not know how to install it on this distribution.
+	ErrUnsupportedDistro = errors.New("unsupported distribution for automatic Docker installation")
+
+	// ErrInstallDeclined is returned when the operator refuses to let the
+	// installer install Docker.
+	ErrInstallDeclined = errors.New("docker installation declined")
+)
+
+// Distro is the subset of /etc/os-release used to pick an install method.
+type Distro struct {
+	ID         string   // e.g. "ubuntu"
+	IDLike     []string // e.g. ["debian"]
+	VersionID  string   // e.g. "22.04"
+	PrettyName string   // e.g. "Ubuntu 22.04.4 LTS"
+}
+
+func (d Distro) String() string {
+	if d.PrettyName != "" {
+		return d.PrettyName
+	}
+	return strings.TrimSpace(d.ID + " " + d.VersionID)
+}
+
+// ParseOSRelease reads an os-release file.
+func ParseOSRelease(r io.Reader) (Distro, error) {
+	var d Distro
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch key {
+		case "ID":
+			d.ID = strings.ToLower(value)
+		case "ID_LIKE":
+			d.IDLike = strings.Fields(strings.ToLower(value))
+		case "VERSION_ID":
+			d.VersionID = value
+		case "PRETTY_NAME":
+			d.PrettyName = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Distro{}, fmt.Errorf("read os-release: %w", err)
+	}
+	if d.ID == "" {
+		return Distro{}, fmt.Errorf("os-release has no ID")
+	}
+	return d, nil
+}
+
+// DetectDistro parses the os-release file at path.
+func DetectDistro(path string) (Distro, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Distro{}, fmt.Errorf("detect distribution: %w", err)
+	}
+	defer f.Close()
+	return ParseOSRelease(f)
+}
+
+// InstallCommand returns the shell command that installs Docker on d.
+// Distributions get.docker.com does not know are matched through ID_LIKE
+// when they are RHEL rebuilds, which Docker's CentOS packages fit.
+func (d Distro) InstallCommand() (string, error) {
+	switch d.ID {
+	case "ubuntu", "debian", "raspbian", "centos", "rhel", "fedora":
+		return convenienceScript, nil
+	case "amzn":
+		// Amazon Linux ships Docker in its own repositories.
+		return "yum -y install docker", nil
+	}
+	for _, like := range d.IDLike {
+		if like == "rhel" || like == "centos" {
+			return "dnf -y install dnf-plugins-core && " +
+				"dnf config-manager --add-repo https://download.docker.com/linux/centos/docker-ce.repo && " +
+				"dnf -y install docker-ce docker-ce-cli containerd.io docker-buildx-plugin", nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedDistro, d)
+}
+
+// SetInstallConsent replaces the prompt EnsureInstalled uses to ask before
+// installing Docker.
+func (d *Docker) SetInstallConsent(consent func(distro Distro) bool) {
+	d.consent = consent
+}
+
+// promptInstallConsent asks on stdin before installing Docker. Non-interactive
+// installs (NONINTERACTIVE=1) have already consented.
+func promptInstallConsent(distro Distro) bool {
+	if os.Getenv("NONINTERACTIVE") == "1" {
+		return true
+	}
+	fmt.Printf("Docker is not installed. Install it now for %s? [Y/n]: ", distro)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// ConfirmInstall settles, before any progress output starts, whether
+// EnsureInstalled may install Docker: it returns nil when Docker is present or
+// the operator agrees, so the later install runs without prompting.
+func (d *Docker) ConfirmInstall() error {
+	if _, err := d.RunCommand("version"); err == nil {
+		return nil
+	}
+	distro, _, err := d.installPlan()
+	if err != nil {
+		return err
+	}
+	if !d.askConsent(distro) {
+		return ErrInstallDeclined
+	}
+	d.consent = func(Distro) bool { return true }
+	return nil
+}
+
+// installPlan detects the host distribution and how to install Docker on it.
+func (d *Docker) installPlan() (Distro, string, error) {
+	path := d.osRelease
+	if path == "" {
+		path = OSReleasePath
+	}
+	distro, err := DetectDistro(path)
+	if err != nil {
+		return Distro{}, "", err
+	}
+	script, err := distro.InstallCommand()
+	if err != nil {
+		return distro, "", err
+	}
+	return distro, script, nil
+}
+
+func (d *Docker) askConsent(distro Distro) bool {
+	if d.consent == nil {
+		return promptInstallConsent(distro)
+	}
+	return d.consent(distro)
+}
+
+// installDocker installs Docker for the host distribution once the operator
+// agrees, then starts and enables the daemon.
+func (d *Docker) installDocker(ctx context.Context) error {
+	distro, script, err := d.installPlan()
+	if err != nil {
+		return err
+	}
+	if !d.askConsent(distro) {
+		return ErrInstallDeclined
+	}
+
+	d.logger.Info("Docker not found, installing for %s...", distro)
+	res, err := d.host(ctx, "sh", "-c", script)
+	if err != nil {
+		d.logger.Error("Docker installation failed: %s", res.Stdout+res.Stderr)
+		return fmt.Errorf("install failed: %w", err)
+	}
+	d.logger.Success("Docker installed successfully")
+
+	for _, cmd := range [][]string{
+		{"systemctl", "start", "docker"},
+		{"systemctl", "enable", "docker"},
+	} {
+		if _, err := d.host(ctx, cmd[0], cmd[1:]...); err != nil {
+			return fmt.Errorf("%s failed: %w", cmd[1], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+const (
+	ubuntuOSRelease = `PRETTY_NAME="Ubuntu 22.04.4 LTS"
+NAME="Ubuntu"
+VERSION_ID="22.04"
+VERSION="22.04.4 LTS (Jammy Jellyfish)"
+ID=ubuntu
+ID_LIKE=debian
+`
+	debianOSRelease = `PRETTY_NAME="Debian GNU/Linux 12 (bookworm)"
+NAME="Debian GNU/Linux"
+VERSION_ID="12"
+ID=debian
+`
+	rockyOSRelease = `NAME="Rocky Linux"
+VERSION="9.3 (Blue Onyx)"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+VERSION_ID="9.3"
+PRETTY_NAME="Rocky Linux 9.3 (Blue Onyx)"
+`
+	amazonOSRelease = `NAME="Amazon Linux"
+VERSION="2023"
+ID="amzn"
+ID_LIKE="fedora"
+VERSION_ID="2023"
+PRETTY_NAME="Amazon Linux 2023"
+`
+	alpineOSRelease = `NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.19.1
+PRETTY_NAME="Alpine Linux v3.19"
+`
+	mintOSRelease = `NAME="Linux Mint"
+VERSION="21.3 (Virginia)"
+ID=linuxmint
+ID_LIKE="ubuntu debian"
+PRETTY_NAME="Linux Mint 21.3"
+VERSION_ID="21.3"
+`
+)
+
+func TestParseOSRelease(t *testing.T) {
+	d, err := ParseOSRelease(strings.NewReader(rockyOSRelease))
+	if err != nil {
+		t.Fatalf("ParseOSRelease: %v", err)
+	}
+	if d.ID != "rocky" || d.VersionID != "9.3" || d.PrettyName != "Rocky Linux 9.3 (Blue Onyx)" {
+		t.Errorf("unexpected distro: %+v", d)
+	}
+	if strings.Join(d.IDLike, ",") != "rhel,centos,fedora" {
+		t.Errorf("IDLike = %v", d.IDLike)
+	}
+
+	if _, err := ParseOSRelease(strings.NewReader("NAME=Unknown\n")); err == nil {
+		t.Error("expected an error for os-release without ID")
+	}
+}
+
+func TestInstallCommandPerDistro(t *testing.T) {
+	tests := []struct {
+		name      string
+		osRelease string
+		want      string
+		wantErr   error
+	}{
+		{"ubuntu", ubuntuOSRelease, convenienceScript, nil},
+		{"debian", debianOSRelease, convenienceScript, nil},
+		{"rocky via ID_LIKE", rockyOSRelease, "docker-ce.repo", nil},
+		{"amazon linux", amazonOSRelease, "yum -y install docker", nil},
+		{"alpine", alpineOSRelease, "", ErrUnsupportedDistro},
+		{"ubuntu derivative", mintOSRelease, "", ErrUnsupportedDistro},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseOSRelease(strings.NewReader(tt.osRelease))
+			if err != nil {
+				t.Fatalf("ParseOSRelease: %v", err)
+			}
+			cmd, err := d.InstallCommand()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("InstallCommand() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallCommand: %v", err)
+			}
+			if !strings.Contains(cmd, tt.want) {
+				t.Errorf("InstallCommand() = %q, want it to contain %q", cmd, tt.want)
+			}
+		})
+	}
+}
+
+func newInstallDocker(t *testing.T, osRelease string, fr *fakeRunner) *Docker {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "os-release")
+	if err := os.WriteFile(path, []byte(osRelease), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &Docker{logger: testLogger(t), runner: fr, osRelease: path}
+}
+
+func TestEnsureInstalledRunsInstallAfterConsent(t *testing.T) {
+	// docker version fails first, then succeeds after the install.
+	fr := &fakeRunner{errs: []error{errors.New("not found")}, results: []executor.Result{{}, {}, {}, {}, {Stdout: "Docker version 27.0.1\n"}}}
+	d := newInstallDocker(t, debianOSRelease, fr)
+	var asked Distro
+	d.SetInstallConsent(func(distro Distro) bool { asked = distro; return true })
+
+	if err := d.EnsureInstalled(); err != nil {
+		t.Fatalf("EnsureInstalled: %v", err)
+	}
+	if asked.ID != "debian" {
+		t.Errorf("consent asked for %+v", asked)
+	}
+	want := []string{
+		"docker version",
+		"sh -c " + convenienceScript,
+		"systemctl start docker",
+		"systemctl enable docker",
+		"docker version",
+	}
+	if len(fr.calls) != len(want) {
+		t.Fatalf("calls = %v", fr.calls)
+	}
+	for i, call := range fr.calls {
+		if strings.Join(call, " ") != want[i] {
+			t.Errorf("call %d = %v, want %s", i, call, want[i])
+		}
+	}
+}
+
+func TestEnsureInstalledDeclined(t *testing.T) {
+	fr := &fakeRunner{errs: []error{errors.New("not found")}}
+	d := newInstallDocker(t, ubuntuOSRelease, fr)
+	d.SetInstallConsent(func(Distro) bool { return false })
+
+	if err := d.EnsureInstalled(); !errors.Is(err, ErrInstallDeclined) {
+		t.Fatalf("expected ErrInstallDeclined, got %v", err)
+	}
+	if len(fr.calls) != 1 {
+		t.Errorf("nothing should run after consent is declined, got %v", fr.calls)
+	}
+}
+
+func TestConfirmInstallUnsupportedDistro(t *testing.T) {
+	fr := &fakeRunner{errs: []error{errors.New("not found")}}
+	d := newInstallDocker(t, alpineOSRelease, fr)
+	d.SetInstallConsent(func(Distro) bool {
+		t.Error("consent should not be asked on an unsupported distro")
+		return true
+	})
+
+	if err := d.ConfirmInstall(); !errors.Is(err, ErrUnsupportedDistro) {
+		t.Fatalf("expected ErrUnsupportedDistro, got %v", err)
+	}
+}
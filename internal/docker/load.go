@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	apperrors "fusionaly-installer/internal/errors"
+)
+
+// ErrMissingImages is matched (via errors.Is) by every MissingImagesError.
+var ErrMissingImages = errors.New("image bundle is missing required images")
+
+// MissingImagesError lists the images an installation needs that a bundle
+// loaded with LoadImages did not contain.
+type MissingImagesError struct {
+	Bundle  string
+	Missing []string
+	Loaded  []string
+}
+
+func (e *MissingImagesError) Error() string {
+	loaded := strings.Join(e.Loaded, ", ")
+	if loaded == "" {
+		loaded = "none"
+	}
+	return fmt.Sprintf("%s %s: %s (bundle contains: %s)", ErrMissingImages, e.Bundle, strings.Join(e.Missing, ", "), loaded)
+}
+
+func (e *MissingImagesError) Is(target error) bool {
+	return target == ErrMissingImages
+}
+
+// SetOffline makes Deploy use the images already on the host, as loaded by
+// LoadImages, instead of pulling them from a registry.
+func (d *Docker) SetOffline(offline bool) {
+	d.offline = offline
+}
+
+// pullPolicy is the --pull value of the containers Deploy runs.
+func (d *Docker) pullPolicy() string {
+	if d.offline {
+		return "never"
+	}
+	return "always"
+}
+
+// LoadImages imports the image bundle at tarPath, as written by
+// `docker save`, for hosts that cannot reach a registry. Every image in
+// expected must be among the tags the bundle loaded, otherwise a
+// *MissingImagesError lists the ones that were not. Images are matched by
+// repository and tag, so a digest in an expected reference is ignored.
+func (d *Docker) LoadImages(ctx context.Context, tarPath string, expected []string) error {
+	if _, err := os.Stat(tarPath); err != nil {
+		return fmt.Errorf("image bundle: %w", err)
+	}
+
+	d.logger.Info("Loading images from %s", tarPath)
+	res, err := d.run(ctx, "load", "--input", tarPath)
+	if err != nil {
+		return apperrors.NewDockerError("load", "", fmt.Errorf("%w - %s", err, strings.TrimSpace(res.Stderr)))
+	}
+
+	loaded := parseLoadedImages(res.Stdout)
+	have := map[string]bool{}
+	for _, image := range loaded {
+		have[normalizeImage(image)] = true
+	}
+	var missing []string
+	for _, image := range expected {
+		if !have[normalizeImage(image)] {
+			missing = append(missing, image)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingImagesError{Bundle: tarPath, Missing: missing, Loaded: loaded}
+	}
+	d.logger.Success("Loaded %s", strings.Join(expected, ", "))
+	return nil
+}
+
+// parseLoadedImages returns the tags from the "Loaded image: <ref>" lines of
+// `docker load`. Untagged images, reported as "Loaded image ID: ...", are
+// skipped.
+func parseLoadedImages(out string) []string {
+	var images []string
+	for _, line := range strings.Split(out, "\n") {
+		if ref, ok := strings.CutPrefix(strings.TrimSpace(line), "Loaded image: "); ok {
+			images = append(images, strings.TrimSpace(ref))
+		}
+	}
+	return images
+}
+
+// normalizeImage reduces an image reference to the repository:tag form
+// docker load prints, dropping any digest and Docker Hub's implicit
+// docker.io/library/ prefix and defaulting the tag to latest.
+func normalizeImage(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	image = strings.TrimPrefix(image, "docker.io/")
+	image = strings.TrimPrefix(image, "library/")
+	if imageRepo(image) == image {
+		image += ":latest"
+	}
+	return image
+}
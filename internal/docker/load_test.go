@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+const loadOutput = `Loaded image: karloscodes/fusionaly-beta:1.2.3
+Loaded image ID: sha256:4f0c2b1e
+Loaded image: caddy:2.7-alpine
+`
+
+func writeBundle(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fusionaly-images.tar")
+	if err := os.WriteFile(path, []byte("tar"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadImagesVerifiesExpectedTags(t *testing.T) {
+	bundle := writeBundle(t)
+	fr := &fakeRunner{results: []executor.Result{{Stdout: loadOutput}}}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	err := d.LoadImages(context.Background(), bundle, []string{"karloscodes/fusionaly-beta:1.2.3", "docker.io/library/caddy:2.7-alpine"})
+	if err != nil {
+		t.Fatalf("LoadImages returned error: %v", err)
+	}
+	want := [][]string{{"docker", "load", "--input", bundle}}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestLoadImagesListsMissingTags(t *testing.T) {
+	bundle := writeBundle(t)
+	fr := &fakeRunner{results: []executor.Result{{Stdout: loadOutput}}}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	err := d.LoadImages(context.Background(), bundle, []string{"karloscodes/fusionaly-beta:1.3.0", "caddy:2.7-alpine", "redis"})
+	if !errors.Is(err, ErrMissingImages) {
+		t.Fatalf("expected ErrMissingImages, got %v", err)
+	}
+	var missing *MissingImagesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a *MissingImagesError, got %T", err)
+	}
+	if want := []string{"karloscodes/fusionaly-beta:1.3.0", "redis"}; !reflect.DeepEqual(missing.Missing, want) {
+		t.Errorf("missing = %v, want %v", missing.Missing, want)
+	}
+	if want := []string{"karloscodes/fusionaly-beta:1.2.3", "caddy:2.7-alpine"}; !reflect.DeepEqual(missing.Loaded, want) {
+		t.Errorf("loaded = %v, want %v", missing.Loaded, want)
+	}
+}
+
+func TestLoadImagesRejectsMissingBundle(t *testing.T) {
+	fr := &fakeRunner{}
+	d := &Docker{logger: testLogger(t), runner: fr}
+
+	err := d.LoadImages(context.Background(), filepath.Join(t.TempDir(), "missing.tar"), []string{"caddy:2.7-alpine"})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+	if len(fr.calls) != 0 {
+		t.Errorf("docker load should not run, got %v", fr.calls)
+	}
+}
+
+func TestOfflineDeployNeverPulls(t *testing.T) {
+	d := &Docker{}
+	if d.pullPolicy() != "always" {
+		t.Errorf("pull policy = %q, want always", d.pullPolicy())
+	}
+	d.SetOffline(true)
+	if d.pullPolicy() != "never" {
+		t.Errorf("offline pull policy = %q, want never", d.pullPolicy())
+	}
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPattern is returned by LogsSearch for a pattern that is not a
+// valid regular expression.
+var ErrInvalidPattern = errors.New("invalid log search pattern")
+
+// LogLine is one line of container output, tagged with the service that
+// wrote it.
+type LogLine struct {
+	Service string
+	Time    time.Time // From docker's --timestamps; zero when it could not be parsed
+	Text    string
+}
+
+// LogsSearch collects the logs every service wrote during the last since
+// (all of them when since is not positive) and returns the lines matching
+// pattern, oldest first. The pattern is compiled before any docker command
+// runs.
+func (s *Stack) LogsSearch(ctx context.Context, pattern string, since time.Duration) ([]LogLine, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidPattern, pattern, err)
+	}
+
+	var lines []LogLine
+	for _, service := range []string{ServiceApp, ServiceProxy} {
+		container, err := s.container(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		args := []string{"logs", "--timestamps"}
+		if since > 0 {
+			args = append(args, "--since", since.String())
+		}
+		res, err := s.runner.Run(ctx, "docker", append(args, container)...)
+		if err != nil {
+			return nil, &StackError{Action: "logs", Service: service, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+		}
+		// Caddy logs to stderr, the app to both.
+		for _, out := range []string{res.Stdout, res.Stderr} {
+			for _, line := range parseLogLines(service, out) {
+				if re.MatchString(line.Text) {
+					lines = append(lines, line)
+				}
+			}
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+	return lines, nil
+}
+
+// parseLogLines splits `docker logs --timestamps` output, whose lines start
+// with an RFC 3339 timestamp and a space.
+func parseLogLines(service, out string) []LogLine {
+	var lines []LogLine
+	for _, raw := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if raw == "" {
+			continue
+		}
+		line := LogLine{Service: service, Text: raw}
+		if stamp, text, ok := strings.Cut(raw, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+				line.Time, line.Text = t, text
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
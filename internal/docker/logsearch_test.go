@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestLogsSearchTagsAndFilters(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: AppNamePrimary + "\n"},
+		{
+			Stdout: "2026-10-14T10:00:01.000000000Z GET /health 200\n" +
+				"2026-10-14T10:00:03.000000000Z ERROR database is locked\n",
+			Stderr: "2026-10-14T10:00:04.000000000Z warn: slow query\n",
+		},
+		{Stderr: "2026-10-14T10:00:02.000000000Z {\"level\":\"error\",\"msg\":\"ERROR dial tcp: connection refused\"}\n" +
+			"2026-10-14T10:00:05.000000000Z {\"level\":\"info\",\"msg\":\"serving\"}\n"},
+	}}
+	s := NewStack(testLogger(t), fr)
+
+	lines, err := s.LogsSearch(context.Background(), "(?i)error|slow", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("LogsSearch returned error: %v", err)
+	}
+
+	var got []string
+	for _, line := range lines {
+		got = append(got, line.Service+" "+line.Time.Format("15:04:05"))
+	}
+	want := []string{"caddy 10:00:02", "app 10:00:03", "app 10:00:04"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+	if lines[1].Text != "ERROR database is locked" {
+		t.Errorf("timestamp not stripped: %q", lines[1].Text)
+	}
+
+	wantCalls := [][]string{
+		{"docker", "ps", "-a", "--filter", "name=fusionaly-app-", "--format", "{{.Names}}"},
+		{"docker", "logs", "--timestamps", "--since", "30m0s", AppNamePrimary},
+		{"docker", "logs", "--timestamps", "--since", "30m0s", CaddyName},
+	}
+	if !reflect.DeepEqual(fr.calls, wantCalls) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", wantCalls, fr.calls)
+	}
+}
+
+func TestLogsSearchInvalidPattern(t *testing.T) {
+	fr := &fakeRunner{}
+	s := NewStack(testLogger(t), fr)
+
+	_, err := s.LogsSearch(context.Background(), "(unclosed", time.Hour)
+	if !errors.Is(err, ErrInvalidPattern) {
+		t.Fatalf("expected ErrInvalidPattern, got %v", err)
+	}
+	if len(fr.calls) != 0 {
+		t.Errorf("no docker command should run for an invalid pattern, got %v", fr.calls)
+	}
+}
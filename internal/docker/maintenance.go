@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+)
+
+// MaintenanceFile marks an installation as in maintenance when it exists in
+// the install directory. Every Caddyfile generated while it is present makes
+// the proxy answer 503 with a static maintenance page instead of proxying to
+// the app, so a deploy during maintenance does not switch the page off.
+const MaintenanceFile = ".maintenance"
+
+func maintenanceEnabled(installDir string) bool {
+	_, err := os.Stat(filepath.Join(installDir, MaintenanceFile))
+	return err == nil
+}
+
+// MaintenanceMode reports whether the proxy is serving the maintenance page.
+func (s *Stack) MaintenanceMode() (bool, error) {
+	conf := config.NewConfig(s.logger)
+	if err := conf.LoadFromFile(s.envFile()); err != nil {
+		return false, fmt.Errorf("load config: %w", err)
+	}
+	return maintenanceEnabled(conf.GetData().InstallDir), nil
+}
+
+// SetMaintenanceMode switches the proxy to the maintenance page, or back to
+// the app, and reloads it. When Caddy rejects the new configuration the
+// previous mode is kept.
+func (s *Stack) SetMaintenanceMode(ctx context.Context, on bool) error {
+	conf := config.NewConfig(s.logger)
+	if err := conf.LoadFromFile(s.envFile()); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	data := conf.GetData()
+	was := maintenanceEnabled(data.InstallDir)
+	if err := setMaintenanceFile(s.runner, data.InstallDir, on); err != nil {
+		return err
+	}
+	if err := s.reloadCaddyfile(ctx, data); err != nil {
+		if restoreErr := setMaintenanceFile(s.runner, data.InstallDir, was); restoreErr != nil {
+			s.logger.Error("Failed to restore maintenance mode: %v", restoreErr)
+		}
+		return err
+	}
+	if on {
+		s.logger.Success("Maintenance mode enabled")
+	} else {
+		s.logger.Success("Maintenance mode disabled")
+	}
+	return nil
+}
+
+func setMaintenanceFile(runner executor.Executor, installDir string, on bool) error {
+	path := filepath.Join(installDir, MaintenanceFile)
+	if on {
+		if err := executor.WriteFile(runner, path, nil, 0o644); err != nil {
+			return fmt.Errorf("enable maintenance mode: %w", err)
+		}
+		return nil
+	}
+	if err := executor.Remove(runner, path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("disable maintenance mode: %w", err)
+	}
+	return nil
+}
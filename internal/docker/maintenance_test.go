@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetMaintenanceModeTogglesProxy(t *testing.T) {
+	fr := &fakeRunner{}
+	s, dir := newTLSStack(t, fr)
+	caddyFile := filepath.Join(dir, "Caddyfile")
+
+	if err := s.SetMaintenanceMode(context.Background(), true); err != nil {
+		t.Fatalf("SetMaintenanceMode(true) returned error: %v", err)
+	}
+	content, _ := os.ReadFile(caddyFile)
+	if !strings.Contains(string(content), "Down for maintenance") || !strings.Contains(string(content), "503") {
+		t.Errorf("Caddyfile should serve the maintenance page:\n%s", content)
+	}
+	if strings.Contains(string(content), "reverse_proxy") {
+		t.Errorf("Caddyfile should not proxy to the app in maintenance:\n%s", content)
+	}
+	if on, err := s.MaintenanceMode(); err != nil || !on {
+		t.Errorf("MaintenanceMode() = %v, %v; want true", on, err)
+	}
+
+	if err := s.SetMaintenanceMode(context.Background(), false); err != nil {
+		t.Fatalf("SetMaintenanceMode(false) returned error: %v", err)
+	}
+	content, _ = os.ReadFile(caddyFile)
+	if strings.Contains(string(content), "Down for maintenance") || !strings.Contains(string(content), "reverse_proxy") {
+		t.Errorf("Caddyfile should proxy to the app again:\n%s", content)
+	}
+	if on, err := s.MaintenanceMode(); err != nil || on {
+		t.Errorf("MaintenanceMode() = %v, %v; want false", on, err)
+	}
+
+	reloads := 0
+	for _, call := range fr.calls {
+		if strings.Join(call, " ") == "docker exec fusionaly-caddy caddy reload --config /etc/caddy/Caddyfile" {
+			reloads++
+		}
+	}
+	if reloads != 2 {
+		t.Errorf("caddy reloaded %d times, want 2", reloads)
+	}
+}
+
+func TestSetMaintenanceModeKeepsModeWhenReloadFails(t *testing.T) {
+	fr := &fakeRunner{}
+	s, _ := newTLSStack(t, fr)
+	// getActiveContainer checks both app slots before the reload runs.
+	fr.errs = []error{nil, nil, errors.New("exit status 1")}
+
+	if err := s.SetMaintenanceMode(context.Background(), true); !errors.Is(err, ErrStackFailed) {
+		t.Fatalf("expected ErrStackFailed, got %v", err)
+	}
+	if on, _ := s.MaintenanceMode(); on {
+		t.Error("maintenance mode should stay off when Caddy rejects the reload")
+	}
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"strconv"
+
+	"fusionaly-installer/internal/config"
+)
+
+// DefaultProject prefixes the docker objects of the default installation.
+const DefaultProject = "fusionaly"
+
+// ProjectLabel is set on every container an installation runs, with its
+// Project as the value, so cleanup can be scoped to that installation.
+const ProjectLabel = "com.fusionaly.project"
+
+// Names are the docker objects belonging to one installation. Every name
+// starts with Project, so installations with different projects never share
+// a container or network.
+type Names struct {
+	Project      string
+	Network      string
+	Caddy        string
+	AppPrimary   string
+	AppSecondary string
+}
+
+// DefaultNames returns the names used by a standard installation, i.e.
+// NetworkName, CaddyName, AppNamePrimary and AppNameSecondary.
+func DefaultNames() Names {
+	return NamesFor(DefaultProject)
+}
+
+// NamesFor returns the names of the installation whose objects are
+// prefixed with project.
+func NamesFor(project string) Names {
+	return Names{
+		Project:      project,
+		Network:      project + "-network",
+		Caddy:        project + "-caddy",
+		AppPrimary:   project + "-app-1",
+		AppSecondary: project + "-app-2",
+	}
+}
+
+// Apps returns the two app container names, primary first.
+func (n Names) Apps() []string {
+	return []string{n.AppPrimary, n.AppSecondary}
+}
+
+// appPrefix is the name filter matching both app containers.
+func (n Names) appPrefix() string {
+	return n.Project + "-app-"
+}
+
+// label is the docker filter and --label value selecting n's objects.
+func (n Names) label() string {
+	return ProjectLabel + "=" + n.Project
+}
+
+// Ports are the host ports the proxy publishes.
+type Ports struct {
+	HTTP  int
+	HTTPS int
+}
+
+// DefaultPorts returns the standard web ports.
+func DefaultPorts() Ports {
+	return Ports{HTTP: 80, HTTPS: 443}
+}
+
+// publishArgs returns the docker run flags mapping p onto the proxy's
+// fixed container ports.
+func (p Ports) publishArgs() []string {
+	http, https := strconv.Itoa(p.HTTP), strconv.Itoa(p.HTTPS)
+	return []string{"-p", http + ":80", "-p", https + ":443", "-p", https + ":443/udp"}
+}
+
+// SetNames makes d manage the containers and network in n instead of the
+// default installation's.
+func (d *Docker) SetNames(n Names) {
+	d.ns = n
+}
+
+// SetPorts changes the host ports the proxy is published on.
+func (d *Docker) SetPorts(p Ports) {
+	d.ports = p
+}
+
+func (d *Docker) names() Names {
+	if d.ns.Project == "" {
+		return DefaultNames()
+	}
+	return d.ns
+}
+
+func (d *Docker) publishedPorts() Ports {
+	if d.ports.HTTP == 0 {
+		return DefaultPorts()
+	}
+	return d.ports
+}
+
+// proxyPorts returns the ports the proxy publishes for data: those moved
+// with Stack.SetPorts take precedence over the instance's.
+func (d *Docker) proxyPorts(data config.ConfigData) Ports {
+	ports := d.publishedPorts()
+	if data.HTTPPort != 0 {
+		ports.HTTP = data.HTTPPort
+	}
+	if data.HTTPSPort != 0 {
+		ports.HTTPS = data.HTTPSPort
+	}
+	return ports
+}
+
+// SetNames makes s drive the containers in n instead of the default
+// installation's.
+func (s *Stack) SetNames(n Names) {
+	s.ns = n
+}
+
+// SetInstancePorts tells s which ports the installation was created with,
+// for when its .env file does not override them.
+func (s *Stack) SetInstancePorts(p Ports) {
+	s.ports = p
+}
+
+// SetEnvFile points s at another installation's .env file.
+func (s *Stack) SetEnvFile(path string) {
+	s.env = path
+}
+
+func (s *Stack) names() Names {
+	if s.ns.Project == "" {
+		return DefaultNames()
+	}
+	return s.ns
+}
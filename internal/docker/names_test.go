@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNamesForProject(t *testing.T) {
+	if DefaultNames() != (Names{Project: "fusionaly", Network: NetworkName, Caddy: CaddyName, AppPrimary: AppNamePrimary, AppSecondary: AppNameSecondary}) {
+		t.Errorf("DefaultNames = %+v", DefaultNames())
+	}
+	n := NamesFor("fusionaly-acme")
+	if n.Caddy != "fusionaly-acme-caddy" || n.AppSecondary != "fusionaly-acme-app-2" || n.Network != "fusionaly-acme-network" {
+		t.Errorf("NamesFor = %+v", n)
+	}
+}
+
+func TestStackUsesInstanceNames(t *testing.T) {
+	fr := &fakeRunner{}
+	s := NewStack(testLogger(t), fr)
+	s.SetNames(NamesFor("fusionaly-acme"))
+
+	if err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: newInstallDir(t)}); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+	want := [][]string{
+		{"docker", "rm", "--force", "fusionaly-acme-caddy"},
+		{"docker", "rm", "--force", "fusionaly-acme-app-1"},
+		{"docker", "rm", "--force", "fusionaly-acme-app-2"},
+		{"docker", "network", "rm", "fusionaly-acme-network"},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestPortsPublishArgs(t *testing.T) {
+	got := Ports{HTTP: 8080, HTTPS: 8443}.publishArgs()
+	want := []string{"-p", "8080:80", "-p", "8443:443", "-p", "8443:443/udp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("publishArgs = %v, want %v", got, want)
+	}
+}
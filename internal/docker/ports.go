@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/requirements"
+)
+
+// privilegedPortLimit is the first port an unprivileged user may bind.
+const privilegedPortLimit = 1024
+
+// SetPorts moves the proxy to the http and https host ports. Ports the
+// proxy does not publish yet must be free, checked as the install preflight
+// does; ports below 1024 need root. The proxy container is recreated, as
+// docker cannot change the ports of an existing one, and the new ports are
+// saved to the .env file only once it runs, so later deploys keep them.
+func (s *Stack) SetPorts(ctx context.Context, http, https int) error {
+	for _, port := range []int{http, https} {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+		}
+	}
+	if http == https {
+		return fmt.Errorf("HTTP and HTTPS ports must differ, both are %d", http)
+	}
+	geteuid := s.geteuid
+	if geteuid == nil {
+		geteuid = os.Geteuid
+	}
+	if (http < privilegedPortLimit || https < privilegedPortLimit) && geteuid() != 0 {
+		return fmt.Errorf("%w: ports below %d can only be published as root", executor.ErrNeedsPrivileges, privilegedPortLimit)
+	}
+
+	conf := config.NewConfig(s.logger)
+	if err := conf.LoadFromFile(s.envFile()); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	data := conf.GetData()
+	d := &Docker{logger: s.logger, runner: s.runner, ns: s.ns, ports: s.ports}
+	current := d.proxyPorts(data)
+	if current == (Ports{HTTP: http, HTTPS: https}) {
+		s.logger.Info("The proxy already publishes ports %d and %d", http, https)
+		return nil
+	}
+
+	// The proxy holds its current ports, so only the new ones are probed.
+	var probe []int
+	for _, port := range []int{http, https} {
+		if port != current.HTTP && port != current.HTTPS {
+			probe = append(probe, port)
+		}
+	}
+	if len(probe) > 0 {
+		portsFree := s.portsFree
+		if portsFree == nil {
+			portsFree = requirements.NewChecker(s.logger).CheckPortsFree
+		}
+		if err := portsFree(probe...); err != nil {
+			return err
+		}
+	}
+
+	data.HTTPPort, data.HTTPSPort = http, https
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	if err := d.deployCaddy(data, caddyFile); err != nil {
+		s.logger.Error("Proxy failed to start on ports %d and %d, restoring %d and %d", http, https, current.HTTP, current.HTTPS)
+		data.HTTPPort, data.HTTPSPort = current.HTTP, current.HTTPS
+		if restoreErr := d.deployCaddy(data, caddyFile); restoreErr != nil {
+			return fmt.Errorf("%w; restoring the previous ports also failed: %v", err, restoreErr)
+		}
+		return err
+	}
+
+	conf.SetData(data)
+	if err := conf.SaveToFile(s.envFile()); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	s.logger.Success("Proxy now publishes HTTP on %d and HTTPS on %d", http, https)
+	return nil
+}
@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/requirements"
+)
+
+func newPortsStack(t *testing.T, fr *fakeRunner, euid int) (*Stack, string, *[][]int) {
+	t.Helper()
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("FUSIONALY_DOMAIN=analytics.example.com\nINSTALL_DIR="+dir+"\nFUSIONALY_PRIVATE_KEY=key\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var probed [][]int
+	s := NewStack(testLogger(t), fr)
+	s.SetEnvFile(envFile)
+	s.geteuid = func() int { return euid }
+	s.portsFree = func(ports ...int) error {
+		probed = append(probed, ports)
+		return nil
+	}
+	return s, envFile, &probed
+}
+
+func TestSetPortsRecreatesProxyAndSavesPorts(t *testing.T) {
+	fr := &fakeRunner{}
+	s, envFile, probed := newPortsStack(t, fr, 1000)
+
+	if err := s.SetPorts(context.Background(), 8080, 8443); err != nil {
+		t.Fatalf("SetPorts returned error: %v", err)
+	}
+	if want := [][]int{{8080, 8443}}; !reflect.DeepEqual(*probed, want) {
+		t.Errorf("preflight probed %v, want %v", *probed, want)
+	}
+	var run string
+	for _, call := range fr.calls {
+		if call[1] == "run" {
+			run = strings.Join(call, " ")
+		}
+	}
+	if !strings.Contains(run, "--name fusionaly-caddy") || !strings.Contains(run, "-p 8080:80 -p 8443:443 -p 8443:443/udp") {
+		t.Errorf("proxy not recreated on the new ports: %q", run)
+	}
+	content, _ := os.ReadFile(envFile)
+	if !strings.Contains(string(content), "HTTP_PORT=8080\n") || !strings.Contains(string(content), "HTTPS_PORT=8443\n") {
+		t.Errorf("env file should record the new ports:\n%s", content)
+	}
+}
+
+func TestSetPortsProbesOnlyNewPorts(t *testing.T) {
+	fr := &fakeRunner{}
+	s, _, probed := newPortsStack(t, fr, 0)
+
+	if err := s.SetPorts(context.Background(), 80, 8443); err != nil {
+		t.Fatalf("SetPorts returned error: %v", err)
+	}
+	if want := [][]int{{8443}}; !reflect.DeepEqual(*probed, want) {
+		t.Errorf("preflight probed %v, want %v", *probed, want)
+	}
+}
+
+func TestSetPortsStopsWhenPortsAreBusy(t *testing.T) {
+	fr := &fakeRunner{}
+	s, envFile, _ := newPortsStack(t, fr, 1000)
+	s.portsFree = func(ports ...int) error {
+		return &requirements.PortInUseError{Ports: ports}
+	}
+
+	err := s.SetPorts(context.Background(), 8080, 8443)
+	if !errors.Is(err, requirements.ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+	if len(fr.calls) != 0 {
+		t.Errorf("the proxy should not be touched, got %v", fr.calls)
+	}
+	content, _ := os.ReadFile(envFile)
+	if strings.Contains(string(content), "HTTP_PORT") {
+		t.Errorf("env file should be unchanged:\n%s", content)
+	}
+}
+
+func TestSetPortsRejectsPrivilegedPortsWithoutRoot(t *testing.T) {
+	fr := &fakeRunner{}
+	s, _, probed := newPortsStack(t, fr, 1000)
+
+	err := s.SetPorts(context.Background(), 81, 8443)
+	if !errors.Is(err, executor.ErrNeedsPrivileges) {
+		t.Fatalf("expected ErrNeedsPrivileges, got %v", err)
+	}
+	if len(*probed) != 0 || len(fr.calls) != 0 {
+		t.Error("nothing should run without the privileges to bind the port")
+	}
+}
+
+func TestSetPortsRestoresProxyWhenStartFails(t *testing.T) {
+	// stop and rm succeed, the run on the new ports fails.
+	fr := &fakeRunner{errs: []error{nil, nil, errors.New("exit status 125")}}
+	s, envFile, _ := newPortsStack(t, fr, 0)
+
+	if err := s.SetPorts(context.Background(), 8080, 8443); err == nil {
+		t.Fatal("expected the proxy start failure")
+	}
+	last := fr.calls[len(fr.calls)-2]
+	if !strings.Contains(strings.Join(last, " "), "-p 80:80 -p 443:443") {
+		t.Errorf("proxy should be restored on the previous ports, got %v", last)
+	}
+	content, _ := os.ReadFile(envFile)
+	if strings.Contains(string(content), "HTTP_PORT") {
+		t.Errorf("env file should be unchanged:\n%s", content)
+	}
+}
@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/config"
+)
+
+// ErrProxyDown is matched (via errors.Is) by every ProxyError.
+var ErrProxyDown = errors.New("reverse proxy is down")
+
+// DefaultProxyProbeTimeout bounds each connection CheckProxy makes.
+const DefaultProxyProbeTimeout = 5 * time.Second
+
+// ProxyError reports why CheckProxy found the proxy down: its container is
+// not running (Port is 0), or nothing answers on one of its ports.
+type ProxyError struct {
+	Container string
+	State     string // Container state, when the container is not running
+	Port      int
+	Err       error
+}
+
+func (e *ProxyError) Error() string {
+	if e.Port == 0 {
+		if e.Err != nil {
+			return fmt.Sprintf("%s: cannot inspect %s: %v", ErrProxyDown, e.Container, e.Err)
+		}
+		return fmt.Sprintf("%s: container %s is %s", ErrProxyDown, e.Container, e.State)
+	}
+	return fmt.Sprintf("%s: nothing answers on port %d: %v", ErrProxyDown, e.Port, e.Err)
+}
+
+func (e *ProxyError) Is(target error) bool {
+	return target == ErrProxyDown
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// CheckProxy verifies the reverse proxy on its own, since a healthy app is
+// unreachable without it: the proxy container must be running, its HTTP
+// port must answer an HTTP request on this host (a redirect to HTTPS
+// counts) and its HTTPS port must accept connections. Certificates are not
+// checked; see diagnostics.VerifyPublicAccess for that.
+func (s *Stack) CheckProxy(ctx context.Context) error {
+	container := s.names().Caddy
+	res, err := s.runner.Run(ctx, "docker", "inspect", "--format", HealthFormat, container)
+	if err != nil {
+		if msg := strings.TrimSpace(res.Stderr); msg != "" {
+			err = errors.New(msg)
+		}
+		return &ProxyError{Container: container, Err: err}
+	}
+	if state := strings.TrimSpace(res.Stdout); state != "running" && state != "healthy" {
+		return &ProxyError{Container: container, State: state}
+	}
+
+	ports := s.proxyPorts()
+	client := &http.Client{
+		Timeout: DefaultProxyProbeTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "http://"+proxyAddress(ports.HTTP)+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &ProxyError{Container: container, Port: ports.HTTP, Err: err}
+	}
+	resp.Body.Close()
+
+	dialer := net.Dialer{Timeout: DefaultProxyProbeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress(ports.HTTPS))
+	if err != nil {
+		return &ProxyError{Container: container, Port: ports.HTTPS, Err: err}
+	}
+	conn.Close()
+	return nil
+}
+
+// proxyAddress is where CheckProxy reaches a published port.
+func proxyAddress(port int) string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+// proxyPorts returns the ports the proxy publishes: HTTP_PORT and
+// HTTPS_PORT from the .env file, then the instance's, then DefaultPorts.
+func (s *Stack) proxyPorts() Ports {
+	d := &Docker{ports: s.ports}
+	var data config.ConfigData
+	if env, err := config.LoadEnvFile(s.envFile()); err == nil {
+		for key, port := range map[string]*int{"HTTP_PORT": &data.HTTPPort, "HTTPS_PORT": &data.HTTPSPort} {
+			if v, ok := env.Get(key); ok {
+				*port, _ = strconv.Atoi(v)
+			}
+		}
+	}
+	return d.proxyPorts(data)
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// proxyStack returns a Stack whose .env publishes the proxy on the given
+// ports and whose container reports state.
+func proxyStack(t *testing.T, state string, httpPort, httpsPort int) (*Stack, *fakeRunner) {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := fmt.Sprintf("HTTP_PORT=%d\nHTTPS_PORT=%d\n", httpPort, httpsPort)
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeRunner{results: []executor.Result{{Stdout: state + "\n"}}}
+	s := NewStack(testLogger(t), fr)
+	s.SetEnvFile(envFile)
+	return s, fr
+}
+
+func listenerPort(t *testing.T, addr net.Addr) int {
+	t.Helper()
+	return addr.(*net.TCPAddr).Port
+}
+
+// closedPort returns a local port nothing listens on.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listenerPort(t, ln.Addr())
+	ln.Close()
+	return port
+}
+
+func TestCheckProxyUp(t *testing.T) {
+	// Caddy answers plain HTTP with a redirect to HTTPS.
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com/", http.StatusPermanentRedirect)
+	}))
+	defer web.Close()
+	tls := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tls.Close()
+
+	s, fr := proxyStack(t, "running", listenerPort(t, web.Listener.Addr()), listenerPort(t, tls.Listener.Addr()))
+	if err := s.CheckProxy(context.Background()); err != nil {
+		t.Fatalf("CheckProxy: %v", err)
+	}
+	if want := []string{"docker", "inspect", "--format", HealthFormat, CaddyName}; strings.Join(fr.calls[0], " ") != strings.Join(want, " ") {
+		t.Errorf("inspected %v, want %v", fr.calls[0], want)
+	}
+}
+
+func TestCheckProxyContainerStopped(t *testing.T) {
+	s, _ := proxyStack(t, "exited", closedPort(t), closedPort(t))
+	err := s.CheckProxy(context.Background())
+	var proxyErr *ProxyError
+	if !errors.As(err, &proxyErr) || !errors.Is(err, ErrProxyDown) {
+		t.Fatalf("expected a ProxyError, got %v", err)
+	}
+	if proxyErr.State != "exited" || proxyErr.Port != 0 {
+		t.Errorf("got %+v, want the exited container", proxyErr)
+	}
+}
+
+func TestCheckProxyMissingContainer(t *testing.T) {
+	s, fr := proxyStack(t, "", closedPort(t), closedPort(t))
+	fr.results = []executor.Result{{Stderr: "Error: No such object: fusionaly-caddy\n"}}
+	fr.errs = []error{errors.New("exit status 1")}
+
+	err := s.CheckProxy(context.Background())
+	if !errors.Is(err, ErrProxyDown) || !strings.Contains(err.Error(), "No such object") {
+		t.Errorf("expected docker's stderr in the error, got %v", err)
+	}
+}
+
+func TestCheckProxyPortDown(t *testing.T) {
+	web := httptest.NewServer(http.NotFoundHandler())
+	defer web.Close()
+	httpPort := listenerPort(t, web.Listener.Addr())
+
+	for name, ports := range map[string][2]int{
+		"http":  {closedPort(t), httpPort},
+		"https": {httpPort, closedPort(t)},
+	} {
+		s, _ := proxyStack(t, "running", ports[0], ports[1])
+		err := s.CheckProxy(context.Background())
+		var proxyErr *ProxyError
+		if !errors.As(err, &proxyErr) {
+			t.Errorf("%s: expected a ProxyError, got %v", name, err)
+			continue
+		}
+		want := ports[0]
+		if name == "https" {
+			want = ports[1]
+		}
+		if proxyErr.Port != want {
+			t.Errorf("%s: error names port %d, want %d", name, proxyErr.Port, want)
+		}
+	}
+}
+
+func TestStackProxyPorts(t *testing.T) {
+	s := NewStack(testLogger(t), &fakeRunner{})
+	s.SetEnvFile(filepath.Join(t.TempDir(), ".env"))
+	if got := s.proxyPorts(); got != DefaultPorts() {
+		t.Errorf("without a .env file got %+v, want the defaults", got)
+	}
+	s.SetInstancePorts(Ports{HTTP: 8080, HTTPS: 8443})
+	if got := s.proxyPorts(); got != (Ports{HTTP: 8080, HTTPS: 8443}) {
+		t.Errorf("got %+v, want the instance's ports", got)
+	}
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+)
+
+// ErrInvalidProxyConfig is returned by RegenerateProxyConfig when Caddy
+// rejects the configuration generated from the current settings.
+var ErrInvalidProxyConfig = errors.New("generated proxy configuration is invalid")
+
+// RegenerateProxyConfig rebuilds the Caddyfile from the .env settings.
+func (s *Stack) RegenerateProxyConfig() error {
+	return s.RegenerateProxyConfigContext(context.Background())
+}
+
+// RegenerateProxyConfigContext is like RegenerateProxyConfig but aborts when
+// ctx is done. The Caddyfile is derived from the domain, TLS and maintenance
+// settings alone, so a drifted or corrupted file is replaced as a whole. The
+// new file is written next to the old one and checked with `caddy validate`
+// in a throwaway proxy container; only a valid file is renamed into place, so
+// neither a rejected configuration nor a crash mid-write ever replaces
+// the working one.
+func (s *Stack) RegenerateProxyConfigContext(ctx context.Context) error {
+	conf := config.NewConfig(s.logger)
+	if err := conf.LoadFromFile(s.envFile()); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	data := conf.GetData()
+
+	d := &Docker{logger: s.logger, runner: s.runner, ns: s.ns}
+	content, err := d.generateCaddyfile(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	tmp, err := writeTemp(caddyFile, content)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	res, err := s.runner.Run(ctx, "docker", "run", "--rm",
+		"-v", tmp+":/etc/caddy/Caddyfile:ro",
+		data.CaddyImage,
+		"caddy", "validate", "--config", "/etc/caddy/Caddyfile", "--adapter", "caddyfile")
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		detail := strings.TrimSpace(res.Stderr)
+		if detail == "" {
+			detail = err.Error()
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidProxyConfig, detail)
+	}
+
+	if dry, ok := executor.DryRunOf(s.runner); ok {
+		dry.SkipFile("write", caddyFile)
+		return nil
+	}
+	if err := os.Rename(tmp, caddyFile); err != nil {
+		return fmt.Errorf("write Caddyfile: %w", err)
+	}
+
+	// The Caddyfile is bind-mounted as a single file, which pins the inode
+	// the container started with; after the rename only a restart makes the
+	// proxy see the new file, where `caddy reload` would reread the old one.
+	s.logger.Debug("Running docker restart %s", s.names().Caddy)
+	res, err = s.runner.Run(ctx, "docker", "restart", s.names().Caddy)
+	if err != nil {
+		return &StackError{Action: "restart", Service: ServiceProxy, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	s.logger.Success("Proxy configuration regenerated")
+	return nil
+}
+
+// writeTemp writes content to a new file in the directory of path and
+// returns its name.
+func writeTemp(path, content string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("write Caddyfile: %w", err)
+	}
+	_, err = f.WriteString(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("write Caddyfile: %w", err)
+	}
+	return f.Name(), nil
+}
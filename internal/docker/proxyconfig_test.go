@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// validateRunner lets a test look at the filesystem while `caddy validate`
+// runs and decide its outcome; other commands succeed.
+type validateRunner struct {
+	calls    [][]string
+	validate func(tmp string) (executor.Result, error)
+}
+
+func (r *validateRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	if len(args) > 3 && args[0] == "run" {
+		tmp, _, _ := strings.Cut(args[3], ":")
+		return r.validate(tmp)
+	}
+	return executor.Result{}, nil
+}
+
+func newProxyConfigStack(t *testing.T, r *validateRunner) (*Stack, string) {
+	t.Helper()
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("FUSIONALY_DOMAIN=analytics.example.com\nINSTALL_DIR="+dir+"\nFUSIONALY_PRIVATE_KEY=key\nACME_EMAIL=ops@example.com\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	caddyFile := filepath.Join(dir, "Caddyfile")
+	if err := os.WriteFile(caddyFile, []byte("corrupted {"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStack(testLogger(t), r)
+	s.SetEnvFile(envFile)
+	return s, caddyFile
+}
+
+func TestRegenerateProxyConfigWritesAtomically(t *testing.T) {
+	r := &validateRunner{}
+	s, caddyFile := newProxyConfigStack(t, r)
+	var validated string
+	r.validate = func(tmp string) (executor.Result, error) {
+		if filepath.Dir(tmp) != filepath.Dir(caddyFile) {
+			t.Errorf("temp file %s should sit next to the Caddyfile so the rename is atomic", tmp)
+		}
+		content, _ := os.ReadFile(tmp)
+		validated = string(content)
+		if current, _ := os.ReadFile(caddyFile); string(current) != "corrupted {" {
+			t.Errorf("Caddyfile replaced before validation: %q", current)
+		}
+		return executor.Result{}, nil
+	}
+
+	if err := s.RegenerateProxyConfig(); err != nil {
+		t.Fatalf("RegenerateProxyConfig returned error: %v", err)
+	}
+	content, _ := os.ReadFile(caddyFile)
+	if string(content) != validated || !strings.Contains(validated, "analytics.example.com:443") || !strings.Contains(validated, "email ops@example.com") {
+		t.Errorf("Caddyfile should hold the validated config, got:\n%s", content)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(caddyFile))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+	last := r.calls[len(r.calls)-1]
+	if strings.Join(last, " ") != "docker restart fusionaly-caddy" {
+		t.Errorf("proxy should be restarted last, got %v", last)
+	}
+}
+
+func TestRegenerateProxyConfigKeepsFileWhenInvalid(t *testing.T) {
+	r := &validateRunner{validate: func(string) (executor.Result, error) {
+		return executor.Result{Stderr: "Error: adapting config using caddyfile: unrecognized directive", ExitCode: 1}, errors.New("exit status 1")
+	}}
+	s, caddyFile := newProxyConfigStack(t, r)
+
+	err := s.RegenerateProxyConfig()
+	if !errors.Is(err, ErrInvalidProxyConfig) || !strings.Contains(err.Error(), "unrecognized directive") {
+		t.Fatalf("expected ErrInvalidProxyConfig with Caddy's message, got %v", err)
+	}
+	if content, _ := os.ReadFile(caddyFile); string(content) != "corrupted {" {
+		t.Errorf("an invalid config must not be applied, Caddyfile is now %q", content)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(caddyFile))
+	if len(entries) != 2 {
+		t.Errorf("expected only the .env file and Caddyfile, got %d entries", len(entries))
+	}
+	for _, call := range r.calls {
+		if call[1] == "restart" || call[1] == "exec" {
+			t.Errorf("proxy should not be touched, got %v", call)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+)
+
+// PruneOptions controls what Prune removes besides stopped containers and
+// dangling images.
+type PruneOptions struct {
+	// Volumes also removes unused volumes labelled with the installation's
+	// project. Off by default because a volume may hold data.
+	Volumes bool
+}
+
+// Prune removes this installation's stopped containers and dangling images
+// and returns the disk space reclaimed. Containers and volumes are selected
+// by ProjectLabel, so other projects on the host are never touched;
+// containers created before the label was introduced are left alone until
+// the next deploy labels them. Pulled images cannot carry the label, so
+// dangling images are matched by the repositories of the installation's app
+// and proxy images instead.
+func (s *Stack) Prune(ctx context.Context, opts PruneOptions) (int64, error) {
+	filter := "label=" + s.names().label()
+
+	freed, err := s.prune(ctx, "container", "prune", "--force", "--filter", filter)
+	if err != nil {
+		return freed, err
+	}
+
+	images, err := s.pruneImages(ctx)
+	freed += images
+	if err != nil {
+		return freed, err
+	}
+
+	if opts.Volumes {
+		volumes, err := s.prune(ctx, "volume", "prune", "--force", "--filter", filter)
+		freed += volumes
+		if err != nil {
+			return freed, err
+		}
+	}
+
+	s.logger.Success("Reclaimed %s", formatBytes(freed))
+	return freed, nil
+}
+
+// prune runs a docker prune command and parses the space it reclaimed.
+func (s *Stack) prune(ctx context.Context, args ...string) (int64, error) {
+	s.logger.Debug("Running docker %s", strings.Join(args, " "))
+	res, err := s.runner.Run(ctx, "docker", args...)
+	if err != nil {
+		return 0, &StackError{Action: args[0] + " prune", Service: s.names().Project, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	return parseReclaimed(res.Stdout), nil
+}
+
+// pruneImages removes the dangling images of the installation's
+// repositories. An image still used by a container cannot be removed and is
+// skipped.
+func (s *Stack) pruneImages(ctx context.Context) (int64, error) {
+	ids, err := s.imageIDs(ctx, "--filter", "dangling=true")
+	if err != nil {
+		return 0, err
+	}
+
+	var freed int64
+	for _, id := range ids {
+		size, err := s.imageSize(ctx, id)
+		if err != nil {
+			return freed, err
+		}
+		if res, err := s.runner.Run(ctx, "docker", "image", "rm", id); err != nil {
+			s.logger.Debug("Keeping image %s: %s", id, strings.TrimSpace(res.Stderr))
+			continue
+		}
+		freed += size
+	}
+	return freed, nil
+}
+
+// imageIDs lists the images of the installation's repositories that match
+// the extra docker image ls filters, without duplicates.
+func (s *Stack) imageIDs(ctx context.Context, filters ...string) ([]string, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, repo := range s.imageRepos() {
+		args := append([]string{"image", "ls", "--quiet", "--no-trunc"}, filters...)
+		res, err := s.runner.Run(ctx, "docker", append(args, "--filter", "reference="+repo)...)
+		if err != nil {
+			return nil, &StackError{Action: "image ls", Service: repo, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+		}
+		for _, id := range strings.Fields(res.Stdout) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (s *Stack) imageSize(ctx context.Context, id string) (int64, error) {
+	res, err := s.runner.Run(ctx, "docker", "image", "inspect", "--format", "{{.Size}}", id)
+	if err != nil {
+		return 0, &StackError{Action: "image inspect", Service: id, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(res.Stdout), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse size of image %s: %w", id, err)
+	}
+	return size, nil
+}
+
+// imageRepos returns the repositories of the app and proxy images in the
+// .env file, falling back to the defaults when it cannot be read.
+func (s *Stack) imageRepos() []string {
+	data := config.NewConfig(s.logger).GetData()
+	app, caddy := data.AppImage, data.CaddyImage
+	if env, err := config.LoadEnvFile(s.envFile()); err == nil {
+		if v, ok := env.Get("APP_IMAGE"); ok && v != "" {
+			app = v
+		}
+		if v, ok := env.Get("CADDY_IMAGE"); ok && v != "" {
+			caddy = v
+		}
+	}
+	return []string{imageRepo(app), imageRepo(caddy)}
+}
+
+// byteUnits are the suffixes docker uses for reclaimed space, which are
+// powers of 1000.
+var byteUnits = []string{"B", "kB", "MB", "GB", "TB", "PB"}
+
+// parseReclaimed reads the "Total reclaimed space: 1.25MB" line of a docker
+// prune command, returning 0 when it is missing or unparsable.
+func parseReclaimed(out string) int64 {
+	for _, line := range strings.Split(out, "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "Total reclaimed space:")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		for i := len(byteUnits) - 1; i >= 0; i-- {
+			number, ok := strings.CutSuffix(value, byteUnits[i])
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0
+			}
+			for range i {
+				n *= 1000
+			}
+			return int64(n)
+		}
+	}
+	return 0
+}
+
+// formatBytes renders n the way docker does, e.g. "1.25MB".
+func formatBytes(n int64) string {
+	value, unit := float64(n), 0
+	for value >= 1000 && unit < len(byteUnits)-1 {
+		value /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.4g%s", value, byteUnits[unit])
+}
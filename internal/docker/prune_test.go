@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestPruneScopesToProjectLabel(t *testing.T) {
+	env := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(env, []byte("APP_IMAGE=karloscodes/fusionaly-beta:1.2.3\nCADDY_IMAGE=caddy:2.7-alpine\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeRunner{
+		results: []executor.Result{
+			{Stdout: "Deleted Containers:\n4f0c\n\nTotal reclaimed space: 1.5MB\n"},
+			{Stdout: "sha256:aaa\n"},
+			{Stdout: "sha256:bbb\n"},
+			{Stdout: "1000000\n"},
+			{},
+			{Stdout: "2000\n"},
+			{Stderr: "image is being used by running container"},
+			{Stdout: "Total reclaimed space: 0B\n"},
+		},
+		errs: []error{nil, nil, nil, nil, nil, nil, errors.New("exit status 1")},
+	}
+	s := NewStack(testLogger(t), fr)
+	s.SetNames(NamesFor("staging"))
+	s.SetEnvFile(env)
+
+	freed, err := s.Prune(context.Background(), PruneOptions{Volumes: true})
+	if err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if freed != 2500000 {
+		t.Errorf("freed = %d, want 2500000", freed)
+	}
+	want := [][]string{
+		{"docker", "container", "prune", "--force", "--filter", "label=com.fusionaly.project=staging"},
+		{"docker", "image", "ls", "--quiet", "--no-trunc", "--filter", "dangling=true", "--filter", "reference=karloscodes/fusionaly-beta"},
+		{"docker", "image", "ls", "--quiet", "--no-trunc", "--filter", "dangling=true", "--filter", "reference=caddy"},
+		{"docker", "image", "inspect", "--format", "{{.Size}}", "sha256:aaa"},
+		{"docker", "image", "rm", "sha256:aaa"},
+		{"docker", "image", "inspect", "--format", "{{.Size}}", "sha256:bbb"},
+		{"docker", "image", "rm", "sha256:bbb"},
+		{"docker", "volume", "prune", "--force", "--filter", "label=com.fusionaly.project=staging"},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestPruneKeepsVolumesByDefault(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: "Total reclaimed space: 0B\n"}, {}}}
+	s := NewStack(testLogger(t), fr)
+	s.SetEnvFile(filepath.Join(t.TempDir(), ".env"))
+
+	if _, err := s.Prune(context.Background(), PruneOptions{}); err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if got := fr.calls[0]; got[len(got)-1] != "label=com.fusionaly.project=fusionaly" {
+		t.Errorf("container prune not scoped to the default project: %v", got)
+	}
+	for _, call := range fr.calls {
+		if call[1] == "volume" {
+			t.Errorf("volumes pruned without PruneOptions.Volumes: %v", call)
+		}
+	}
+}
+
+func TestParseReclaimed(t *testing.T) {
+	for out, want := range map[string]int64{
+		"Total reclaimed space: 0B":                           0,
+		"Total reclaimed space: 512B":                         512,
+		"Deleted Images:\nx\n\nTotal reclaimed space: 12.5kB": 12500,
+		"Total reclaimed space: 1.2GB":                        1200000000,
+		"nothing to report":                                   0,
+	} {
+		if got := parseReclaimed(out); got != want {
+			t.Errorf("parseReclaimed(%q) = %d, want %d", out, got, want)
+		}
+	}
+}
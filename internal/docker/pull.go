@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
+)
+
+// DefaultPullConcurrency is how many images PullImagesContext downloads at
+// once unless SetPullConcurrency says otherwise.
+const DefaultPullConcurrency = 2
+
+// PullError reports one image that could not be pulled. Err is the
+// *errors.DockerError from the docker CLI, or the context error when the
+// pull never started.
+type PullError struct {
+	Image string
+	Err   error
+}
+
+func (e *PullError) Error() string {
+	return fmt.Sprintf("pull %s: %v", e.Image, e.Err)
+}
+
+func (e *PullError) Unwrap() error {
+	return e.Err
+}
+
+// SetPullConcurrency sets how many images are pulled at once. Values below
+// one restore DefaultPullConcurrency.
+func (d *Docker) SetPullConcurrency(n int) {
+	d.pullConcurrency = n
+}
+
+// PullImagesContext downloads images concurrently, at most the configured
+// number at a time. Every pull runs to completion even when another fails;
+// the failures are returned together, in the order the images were given,
+// as an errors.Join of *PullError values.
+func (d *Docker) PullImagesContext(ctx context.Context, images []string) error {
+	limit := d.pullConcurrency
+	if limit < 1 {
+		limit = DefaultPullConcurrency
+	}
+	if d.runner == nil {
+		d.runner = executor.Default()
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+	errs := make([]error, len(images))
+	slots := make(chan struct{}, limit)
+	total := len(images)
+
+	for n, image := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				errs[n] = &PullError{Image: image, Err: ctx.Err()}
+				return
+			}
+			defer func() { <-slots }()
+
+			d.logger.Info("Pulling %s", image)
+			start := time.Now()
+			res, err := d.run(ctx, "pull", image)
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if err != nil {
+				errs[n] = &PullError{Image: image, Err: apperrors.NewDockerError("pull", "", fmt.Errorf("%w - %s", err, strings.TrimSpace(res.Stderr)))}
+				d.logger.Error("Pull %s failed (%d/%d done): %v", image, done, total, err)
+				return
+			}
+			d.logger.Success("Pulled %s in %s (%d/%d done)", image, time.Since(start).Round(100*time.Millisecond), done, total)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	apperrors "fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
+)
+
+// pullRunner simulates docker pull with per-image delays and failures and
+// tracks how many pulls were in flight at once.
+type pullRunner struct {
+	delays map[string]time.Duration
+	fail   map[string]bool
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	pulled    []string
+}
+
+func (p *pullRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	image := args[len(args)-1]
+	p.mu.Lock()
+	p.active++
+	if p.active > p.maxActive {
+		p.maxActive = p.active
+	}
+	p.mu.Unlock()
+
+	time.Sleep(p.delays[image] + time.Millisecond)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	p.pulled = append(p.pulled, image)
+	if p.fail[image] {
+		return executor.Result{ExitCode: 1, Stderr: "manifest unknown"}, errors.New("exit status 1")
+	}
+	return executor.Result{}, nil
+}
+
+func TestPullImagesContext_AggregatesFailures(t *testing.T) {
+	pr := &pullRunner{
+		delays: map[string]time.Duration{"app:slow": 30 * time.Millisecond},
+		fail:   map[string]bool{"app:broken": true},
+	}
+	d := &Docker{logger: testLogger(t), runner: pr}
+
+	err := d.PullImagesContext(context.Background(), []string{"app:slow", "app:broken", "caddy:2"})
+	if err == nil {
+		t.Fatal("expected an error for the failing pull")
+	}
+	if len(pr.pulled) != 3 {
+		t.Errorf("every image should be pulled despite the failure, got %v", pr.pulled)
+	}
+
+	var pullErr *PullError
+	if !errors.As(err, &pullErr) || pullErr.Image != "app:broken" {
+		t.Fatalf("expected a *PullError for app:broken, got %v", err)
+	}
+	var dockerErr *apperrors.DockerError
+	if !errors.As(err, &dockerErr) {
+		t.Errorf("pull failures should carry a DockerError, got %v", err)
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 1 {
+		t.Errorf("expected exactly one failure, got %v", err)
+	}
+}
+
+func TestPullImagesContext_HonoursConcurrencyLimit(t *testing.T) {
+	images := []string{"a", "b", "c", "d", "e"}
+	delays := map[string]time.Duration{}
+	for _, image := range images {
+		delays[image] = 10 * time.Millisecond
+	}
+
+	for _, limit := range []int{1, 2, 5} {
+		pr := &pullRunner{delays: delays}
+		d := &Docker{logger: testLogger(t), runner: pr}
+		d.SetPullConcurrency(limit)
+
+		if err := d.PullImagesContext(context.Background(), images); err != nil {
+			t.Fatalf("limit %d: unexpected error: %v", limit, err)
+		}
+		if pr.maxActive > limit {
+			t.Errorf("limit %d: %d pulls ran at once", limit, pr.maxActive)
+		}
+		if limit > 1 && pr.maxActive < 2 {
+			t.Errorf("limit %d: pulls did not run concurrently", limit)
+		}
+	}
+}
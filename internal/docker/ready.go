@@ -0,0 +1,114 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNotReady is returned by WaitForReady when the app never became ready.
+var ErrNotReady = errors.New("app not ready")
+
+// ErrNoRunningApp is returned by RunningAppContainer when neither app
+// container is running, e.g. while one restarts.
+var ErrNoRunningApp = errors.New("no running app container found")
+
+// Probe states reported by an HTTP health check.
+const (
+	ProbeDown  = "down"  // No HTTP response: the process is not serving at all
+	ProbeLive  = "live"  // The process answers but is not ready for traffic
+	ProbeReady = "ready" // The app is serving requests with its database migrated
+)
+
+// ReadinessConfig controls how WaitForReady polls.
+type ReadinessConfig struct {
+	Client          *http.Client  // Client for the probes; nil uses a client with a 5s timeout
+	InitialInterval time.Duration // Delay after the first failed probe
+	MaxInterval     time.Duration // Upper bound for the doubling delay
+}
+
+// DefaultReadinessConfig returns the polling used after a deploy.
+func DefaultReadinessConfig() ReadinessConfig {
+	return ReadinessConfig{
+		InitialInterval: 250 * time.Millisecond,
+		MaxInterval:     5 * time.Second,
+	}
+}
+
+// healthBody is the optional JSON body of the app's health endpoint.
+type healthBody struct {
+	Ready *bool `json:"ready"`
+}
+
+// WaitForReady polls the app's HTTP health endpoint at url until it reports
+// ready, backing off between probes, or until ctx is done.
+func WaitForReady(ctx context.Context, url string) error {
+	return WaitForReadyWithConfig(ctx, url, DefaultReadinessConfig())
+}
+
+// WaitForReadyWithConfig is like WaitForReady with explicit polling settings.
+// A probe that gets no response means the app is not live; a non-2xx status,
+// such as the 503 served while migrations run, or a 2xx body with
+// "ready": false means it is live but not ready. Only readiness succeeds.
+func WaitForReadyWithConfig(ctx context.Context, url string, cfg ReadinessConfig) error {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	interval := cfg.InitialInterval
+	if interval <= 0 {
+		interval = DefaultReadinessConfig().InitialInterval
+	}
+
+	last := ProbeDown
+	for {
+		state, err := probe(ctx, client, url)
+		if state == ProbeReady {
+			return nil
+		}
+		if ctx.Err() != nil {
+			// The probe was cut short; keep the last complete result.
+			return fmt.Errorf("%w: %s is %s: %w", ErrNotReady, url, last, ctx.Err())
+		}
+		last = state
+		if err != nil {
+			last = fmt.Sprintf("%s (%v)", state, err)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s is %s: %w", ErrNotReady, url, last, ctx.Err())
+		}
+		if interval *= 2; cfg.MaxInterval > 0 && interval > cfg.MaxInterval {
+			interval = cfg.MaxInterval
+		}
+	}
+}
+
+// probe performs one health check and classifies the result.
+func probe(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ProbeDown, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ProbeDown, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return ProbeLive, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var body healthBody
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Ready != nil && !*body.Ready {
+		return ProbeLive, fmt.Errorf("health endpoint reports not ready")
+	}
+	return ProbeReady, nil
+}
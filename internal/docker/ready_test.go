@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastReadiness() ReadinessConfig {
+	return ReadinessConfig{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+}
+
+func TestWaitForReady_WaitsForReadiness(t *testing.T) {
+	var probes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := probes.Add(1); {
+		case n <= 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case n == 3:
+			w.Write([]byte(`{"status":"ok","ready":false}`))
+		default:
+			w.Write([]byte(`{"status":"ok","ready":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForReadyWithConfig(ctx, srv.URL+"/_health", fastReadiness()); err != nil {
+		t.Fatalf("WaitForReady: %v", err)
+	}
+	if n := probes.Load(); n != 4 {
+		t.Errorf("expected 4 probes (503, 503, not ready, ready), got %d", n)
+	}
+}
+
+func TestWaitForReady_LiveButNeverReady(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := WaitForReadyWithConfig(ctx, srv.URL, fastReadiness())
+	if !errors.Is(err, ErrNotReady) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrNotReady wrapping the deadline, got %v", err)
+	}
+	if !strings.Contains(err.Error(), ProbeLive) {
+		t.Errorf("error should report the app as live: %v", err)
+	}
+}
+
+func TestWaitForReady_NotLive(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := WaitForReadyWithConfig(ctx, url, fastReadiness())
+	if !errors.Is(err, ErrNotReady) || !strings.Contains(err.Error(), ProbeDown) {
+		t.Fatalf("expected a not-live ErrNotReady, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+// Services managed by a Stack.
+const (
+	ServiceApp   = "app"
+	ServiceProxy = "caddy"
+)
+
+// ErrStackFailed is matched (via errors.Is) by every StackError.
+var ErrStackFailed = errors.New("stack command failed")
+
+// StackError reports a docker command that failed for one service, with the
+// exit code and whatever docker printed on stderr.
+type StackError struct {
+	Action   string
+	Service  string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *StackError) Error() string {
+	msg := fmt.Sprintf("%s: docker %s %s failed (exit code %d)", ErrStackFailed, e.Action, e.Service, e.ExitCode)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+func (e *StackError) Is(target error) bool {
+	return target == ErrStackFailed
+}
+
+func (e *StackError) Unwrap() error {
+	return e.Err
+}
+
+// Stack starts, stops and restarts the Fusionaly containers as a unit. The
+// containers are created by Deploy with `docker run`; Stack only drives
+// their lifecycle afterwards, always keeping the app up before the proxy.
+type Stack struct {
+	logger *logging.Logger
+	runner executor.Executor
+	out    io.Writer // Destination for streamed logs; nil means os.Stdout
+	env    string    // Path of the .env file; empty means DefaultEnvFile
+	ns     Names     // Containers driven; zero uses DefaultNames
+	ports  Ports     // Ports the installation was created with; zero uses DefaultPorts
+
+	resolver  Resolver                                // DNS lookups for ConfigureTLS; nil means net.DefaultResolver
+	serverIPs func(context.Context) ([]string, error) // Addresses of this host; nil means config.ServerIPs
+	staging   bool                                    // ConfigureTLS uses the Let's Encrypt staging CA
+	du        func(string) (int64, error)             // Space used under a path; nil means dirSize
+	portsFree func(ports ...int) error                // Port availability check for SetPorts; nil means requirements.Checker.CheckPortsFree
+	geteuid   func() int                              // Effective user ID; nil means os.Geteuid
+}
+
+// DefaultEnvFile is the .env file of a standard installation.
+const DefaultEnvFile = "/opt/fusionaly/.env"
+
+func (s *Stack) envFile() string {
+	if s.env == "" {
+		return DefaultEnvFile
+	}
+	return s.env
+}
+
+// NewStack creates a Stack that runs docker through runner.
+func NewStack(logger *logging.Logger, runner executor.Executor) *Stack {
+	return &Stack{logger: logger, runner: runner}
+}
+
+// Up starts the app container and then the proxy.
+func (s *Stack) Up(ctx context.Context) error {
+	for _, service := range []string{ServiceApp, ServiceProxy} {
+		if err := s.do(ctx, "start", service); err != nil {
+			return err
+		}
+	}
+	s.logger.Success("Fusionaly containers started")
+	return nil
+}
+
+// DefaultStopTimeout is how long Stop lets a container shut down before
+// docker kills it. It leaves the app time to finish writes and close the
+// database cleanly.
+const DefaultStopTimeout = 30 * time.Second
+
+// Down stops the containers with DefaultStopTimeout.
+func (s *Stack) Down(ctx context.Context) error {
+	return s.Stop(ctx, DefaultStopTimeout)
+}
+
+// Stop stops the proxy first so no traffic reaches a stopping app. Each
+// container gets SIGTERM and is only killed once timeout has passed; a
+// timeout that is not positive uses DefaultStopTimeout.
+func (s *Stack) Stop(ctx context.Context, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	// docker stop takes whole seconds; round up so a short timeout never
+	// becomes an immediate kill.
+	seconds := strconv.Itoa(int((timeout + time.Second - 1) / time.Second))
+	for _, service := range []string{ServiceProxy, ServiceApp} {
+		container, err := s.container(ctx, service)
+		if err != nil {
+			return err
+		}
+		s.logger.Debug("Running docker stop -t %s %s", seconds, container)
+		res, err := s.runner.Run(ctx, "docker", "stop", "-t", seconds, container)
+		if err != nil {
+			return &StackError{Action: "stop", Service: service, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+		}
+	}
+	s.logger.Success("Fusionaly containers stopped")
+	return nil
+}
+
+// Restart restarts a single service ("app" or "caddy").
+func (s *Stack) Restart(service string) error {
+	return s.RestartContext(context.Background(), service)
+}
+
+// RestartContext is like Restart but aborts when ctx is done.
+func (s *Stack) RestartContext(ctx context.Context, service string) error {
+	if err := s.do(ctx, "restart", service); err != nil {
+		return err
+	}
+	s.logger.Success("Restarted %s", service)
+	return nil
+}
+
+// Logs writes the logs of service ("app" or "caddy") to stdout. tail limits
+// output to the last lines when positive. With follow set, new lines keep
+// streaming until ctx is cancelled, which is not reported as an error.
+func (s *Stack) Logs(ctx context.Context, service string, follow bool, tail int) error {
+	container, err := s.container(ctx, service)
+	if err != nil {
+		return err
+	}
+	args := logsArgs(container, follow, tail)
+
+	out := s.out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	var res executor.Result
+	if streamer, ok := s.runner.(executor.Streamer); ok {
+		res, err = streamer.Stream(ctx, out, out, "docker", args...)
+	} else {
+		res, err = s.runner.Run(ctx, "docker", args...)
+		io.WriteString(out, res.Stdout+res.Stderr)
+	}
+	if follow && errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if err != nil {
+		return &StackError{Action: "logs", Service: service, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	return nil
+}
+
+// logsArgs builds the `docker logs` arguments for container.
+func logsArgs(container string, follow bool, tail int) []string {
+	args := []string{"logs"}
+	if follow {
+		args = append(args, "--follow")
+	}
+	if tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(tail))
+	}
+	return append(args, container)
+}
+
+// do runs `docker <action> <container>` for service.
+func (s *Stack) do(ctx context.Context, action, service string) error {
+	container, err := s.container(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debug("Running docker %s %s", action, container)
+	res, err := s.runner.Run(ctx, "docker", action, container)
+	if err != nil {
+		return &StackError{Action: action, Service: service, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	return nil
+}
+
+// AppContainer returns the name of the existing app container, running or
+// not.
+func (s *Stack) AppContainer(ctx context.Context) (string, error) {
+	return s.container(ctx, ServiceApp)
+}
+
+// container maps a service to its container name. The app lives in whichever
+// blue/green slot currently exists, preferring the primary one.
+func (s *Stack) container(ctx context.Context, service string) (string, error) {
+	switch service {
+	case ServiceProxy:
+		return s.names().Caddy, nil
+	case ServiceApp:
+	default:
+		return "", fmt.Errorf("unknown service %q (want %s or %s)", service, ServiceApp, ServiceProxy)
+	}
+
+	res, err := s.runner.Run(ctx, "docker", "ps", "-a", "--filter", "name="+s.names().appPrefix(), "--format", "{{.Names}}")
+	if err != nil {
+		return "", &StackError{Action: "ps", Service: service, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	names := strings.Fields(res.Stdout)
+	for _, name := range s.names().Apps() {
+		for _, existing := range names {
+			if existing == name {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no app container found; run install first")
+}
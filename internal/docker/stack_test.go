@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestStackUpStartsAppBeforeProxy(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: AppNameSecondary + "\n"}}}
+	s := NewStack(testLogger(t), fr)
+
+	if err := s.Up(context.Background()); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+	want := [][]string{
+		{"docker", "ps", "-a", "--filter", "name=fusionaly-app-", "--format", "{{.Names}}"},
+		{"docker", "start", AppNameSecondary},
+		{"docker", "start", CaddyName},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestStackDownStopsProxyFirst(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{}, {Stdout: AppNamePrimary + "\n" + AppNameSecondary + "\n"}}}
+	s := NewStack(testLogger(t), fr)
+
+	if err := s.Down(context.Background()); err != nil {
+		t.Fatalf("Down returned error: %v", err)
+	}
+	want := [][]string{
+		{"docker", "stop", "-t", "30", CaddyName},
+		{"docker", "ps", "-a", "--filter", "name=fusionaly-app-", "--format", "{{.Names}}"},
+		{"docker", "stop", "-t", "30", AppNamePrimary},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestStackStopPassesTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    string
+	}{
+		{2 * time.Minute, "120"},
+		{1500 * time.Millisecond, "2"},
+		{0, "30"},
+	}
+	for _, tt := range tests {
+		fr := &fakeRunner{results: []executor.Result{{}, {Stdout: AppNamePrimary + "\n"}}}
+		s := NewStack(testLogger(t), fr)
+
+		if err := s.Stop(context.Background(), tt.timeout); err != nil {
+			t.Fatalf("Stop(%s) returned error: %v", tt.timeout, err)
+		}
+		want := [][]string{
+			{"docker", "stop", "-t", tt.want, CaddyName},
+			{"docker", "ps", "-a", "--filter", "name=fusionaly-app-", "--format", "{{.Names}}"},
+			{"docker", "stop", "-t", tt.want, AppNamePrimary},
+		}
+		if !reflect.DeepEqual(fr.calls, want) {
+			t.Errorf("Stop(%s) commands mismatch\nwant %v\ngot  %v", tt.timeout, want, fr.calls)
+		}
+	}
+}
+
+func TestStackRestart(t *testing.T) {
+	fr := &fakeRunner{}
+	s := NewStack(testLogger(t), fr)
+
+	if err := s.Restart(ServiceProxy); err != nil {
+		t.Fatalf("Restart returned error: %v", err)
+	}
+	want := [][]string{{"docker", "restart", CaddyName}}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+
+	if err := s.Restart("db"); err == nil {
+		t.Error("expected error for unknown service")
+	}
+}
+
+func TestStackSurfacesFailures(t *testing.T) {
+	fr := &fakeRunner{
+		results: []executor.Result{{ExitCode: 1, Stderr: "Error response from daemon: No such container: fusionaly-caddy\n"}},
+		errs:    []error{errors.New("exit status 1")},
+	}
+	s := NewStack(testLogger(t), fr)
+
+	err := s.Down(context.Background())
+	if !errors.Is(err, ErrStackFailed) {
+		t.Fatalf("expected ErrStackFailed, got %v", err)
+	}
+	var stackErr *StackError
+	if !errors.As(err, &stackErr) {
+		t.Fatalf("expected *StackError, got %T", err)
+	}
+	if stackErr.Service != ServiceProxy || stackErr.Action != "stop" || stackErr.ExitCode != 1 {
+		t.Errorf("unexpected error fields: %+v", stackErr)
+	}
+	if stackErr.Stderr == "" {
+		t.Error("expected captured stderr")
+	}
+	if len(fr.calls) != 1 {
+		t.Errorf("Down should stop at the first failure, got %v", fr.calls)
+	}
+}
+
+func TestStackUpWithoutAppContainer(t *testing.T) {
+	fr := &fakeRunner{}
+	s := NewStack(testLogger(t), fr)
+
+	if err := s.Up(context.Background()); err == nil {
+		t.Fatal("expected error when no app container exists")
+	}
+}
+
+func TestStackLogsArgs(t *testing.T) {
+	tests := []struct {
+		follow bool
+		tail   int
+		want   []string
+	}{
+		{false, 0, []string{"docker", "logs", AppNamePrimary}},
+		{true, 0, []string{"docker", "logs", "--follow", AppNamePrimary}},
+		{false, 100, []string{"docker", "logs", "--tail", "100", AppNamePrimary}},
+		{true, 20, []string{"docker", "logs", "--follow", "--tail", "20", AppNamePrimary}},
+	}
+	for _, tt := range tests {
+		fr := &fakeRunner{results: []executor.Result{{Stdout: AppNamePrimary + "\n"}, {Stdout: "line\n"}}}
+		var out bytes.Buffer
+		s := &Stack{logger: testLogger(t), runner: fr, out: &out}
+
+		if err := s.Logs(context.Background(), ServiceApp, tt.follow, tt.tail); err != nil {
+			t.Fatalf("follow=%v tail=%d: %v", tt.follow, tt.tail, err)
+		}
+		if got := fr.calls[len(fr.calls)-1]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("follow=%v tail=%d: got %v, want %v", tt.follow, tt.tail, got, tt.want)
+		}
+		if out.String() != "line\n" {
+			t.Errorf("logs not written to output, got %q", out.String())
+		}
+	}
+}
+
+func TestStackLogsProxyAndCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fr := &fakeRunner{errs: []error{context.Canceled}}
+	s := &Stack{logger: testLogger(t), runner: fr, out: &bytes.Buffer{}}
+
+	if err := s.Logs(ctx, ServiceProxy, true, 0); err != nil {
+		t.Errorf("cancelling a follow should not be an error, got %v", err)
+	}
+	if want := []string{"docker", "logs", "--follow", CaddyName}; !reflect.DeepEqual(fr.calls[0], want) {
+		t.Errorf("got %v, want %v", fr.calls[0], want)
+	}
+
+	fr = &fakeRunner{errs: []error{context.Canceled}}
+	s.runner = fr
+	if err := s.Logs(ctx, ServiceProxy, false, 0); !errors.Is(err, ErrStackFailed) {
+		t.Errorf("a cancelled non-follow read should fail, got %v", err)
+	}
+	if err := s.Logs(ctx, "db", false, 0); err == nil {
+		t.Error("expected an error for an unknown service")
+	}
+}
@@ -0,0 +1,158 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"fusionaly-installer/internal/config"
+)
+
+// Service states reported by Stack.Status.
+const (
+	StateRunning   = "running"
+	StateStopped   = "stopped"
+	StateUnhealthy = "unhealthy"
+)
+
+// ServiceStatus describes one Fusionaly container.
+type ServiceStatus struct {
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	State     string `json:"state"`
+	Image     string `json:"image"`
+	Tag       string `json:"tag"`
+	Uptime    string `json:"uptime,omitempty"`
+}
+
+// Status summarises the deployment.
+type Status struct {
+	Domain   string          `json:"domain"`
+	Services []ServiceStatus `json:"services"`
+}
+
+// WriteTable prints the status as an aligned table.
+func (st Status) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "Domain: %s\n\n", st.Domain); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tCONTAINER\tSTATE\tTAG\tUPTIME")
+	for _, s := range st.Services {
+		uptime := s.Uptime
+		if uptime == "" {
+			uptime = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Service, s.Container, s.State, s.Tag, uptime)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON prints the status as indented JSON.
+func (st Status) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(st)
+}
+
+// psEntry is one line of `docker ps --format '{{json .}}'`.
+type psEntry struct {
+	Names  string `json:"Names"`
+	Image  string `json:"Image"`
+	State  string `json:"State"`
+	Status string `json:"Status"`
+}
+
+// Status reports the state, image and uptime of every Fusionaly container
+// from a single `docker ps` call, along with the domain in the .env file.
+func (s *Stack) Status(ctx context.Context) (Status, error) {
+	var st Status
+	if env, err := config.LoadEnvFile(s.envFile()); err == nil {
+		st.Domain, _ = env.Get("FUSIONALY_DOMAIN")
+	}
+
+	res, err := s.runner.Run(ctx, "docker", "ps", "-a", "--filter", "name="+s.names().Project+"-", "--format", "{{json .}}")
+	if err != nil {
+		return st, &StackError{Action: "ps", Service: "all", ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	services, err := parsePS(res.Stdout, s.names())
+	if err != nil {
+		return st, err
+	}
+	st.Services = services
+	return st, nil
+}
+
+// parsePS turns `docker ps --format '{{json .}}'` output into ServiceStatus
+// values, app containers first.
+func parsePS(output string, names Names) ([]ServiceStatus, error) {
+	var apps, others []ServiceStatus
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var e psEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse docker ps output %q: %w", line, err)
+		}
+
+		svc := ServiceStatus{
+			Container: e.Names,
+			State:     serviceState(e.State, e.Status),
+			Image:     e.Image,
+			Tag:       imageTag(e.Image),
+		}
+		if svc.State != StateStopped {
+			svc.Uptime = uptime(e.Status)
+		}
+		switch {
+		case e.Names == names.Caddy:
+			svc.Service = ServiceProxy
+			others = append(others, svc)
+		case strings.HasPrefix(e.Names, names.appPrefix()):
+			svc.Service = ServiceApp
+			apps = append(apps, svc)
+		default:
+			continue
+		}
+	}
+	return append(apps, others...), nil
+}
+
+// serviceState folds docker's state and health into running, stopped or
+// unhealthy.
+func serviceState(state, status string) string {
+	if state != "running" {
+		return StateStopped
+	}
+	if strings.Contains(status, "(unhealthy)") {
+		return StateUnhealthy
+	}
+	return StateRunning
+}
+
+// uptime extracts "2 hours" from a docker status such as
+// "Up 2 hours (healthy)".
+func uptime(status string) string {
+	if !strings.HasPrefix(status, "Up ") {
+		return ""
+	}
+	up := strings.TrimPrefix(status, "Up ")
+	if i := strings.Index(up, " ("); i >= 0 {
+		up = up[:i]
+	}
+	return up
+}
+
+// imageTag returns the tag of image, or "latest" when it has none.
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
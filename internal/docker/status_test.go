@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+const cannedPS = `{"Names":"fusionaly-caddy","Image":"caddy:2.7-alpine","State":"running","Status":"Up 3 days"}
+{"Names":"fusionaly-app-2","Image":"karloscodes/fusionaly-beta:1.4.2","State":"running","Status":"Up 2 hours (healthy)"}
+{"Names":"fusionaly-app-1","Image":"localhost:5000/fusionaly@sha256:abc","State":"exited","Status":"Exited (0) 2 hours ago"}
+`
+
+func TestStackStatusParsesPS(t *testing.T) {
+	dir := t.TempDir()
+	env := filepath.Join(dir, ".env")
+	if err := os.WriteFile(env, []byte("FUSIONALY_DOMAIN=analytics.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeRunner{results: []executor.Result{{Stdout: cannedPS}}}
+	s := &Stack{logger: testLogger(t), runner: fr, env: env}
+
+	st, err := s.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if len(fr.calls) != 1 {
+		t.Errorf("expected a single docker call, got %v", fr.calls)
+	}
+	want := Status{
+		Domain: "analytics.example.com",
+		Services: []ServiceStatus{
+			{Service: ServiceApp, Container: "fusionaly-app-2", State: StateRunning, Image: "karloscodes/fusionaly-beta:1.4.2", Tag: "1.4.2", Uptime: "2 hours"},
+			{Service: ServiceApp, Container: "fusionaly-app-1", State: StateStopped, Image: "localhost:5000/fusionaly@sha256:abc", Tag: "latest"},
+			{Service: ServiceProxy, Container: CaddyName, State: StateRunning, Image: "caddy:2.7-alpine", Tag: "2.7-alpine", Uptime: "3 days"},
+		},
+	}
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("status mismatch\nwant %+v\ngot  %+v", want, st)
+	}
+}
+
+func TestStackStatusUnhealthy(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: `{"Names":"fusionaly-app-1","Image":"app:1","State":"running","Status":"Up 5 minutes (unhealthy)"}`}}}
+	s := &Stack{logger: testLogger(t), runner: fr, env: filepath.Join(t.TempDir(), ".env")}
+
+	st, err := s.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Services[0].State != StateUnhealthy || st.Services[0].Uptime != "5 minutes" {
+		t.Errorf("unexpected service: %+v", st.Services[0])
+	}
+	if st.Domain != "" {
+		t.Errorf("domain should be empty without an .env, got %q", st.Domain)
+	}
+}
+
+func TestStackStatusErrors(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stderr: "daemon down"}}, errs: []error{errors.New("exit status 1")}}
+	s := &Stack{logger: testLogger(t), runner: fr}
+	if _, err := s.Status(context.Background()); !errors.Is(err, ErrStackFailed) {
+		t.Errorf("expected ErrStackFailed, got %v", err)
+	}
+
+	fr = &fakeRunner{results: []executor.Result{{Stdout: "not json\n"}}}
+	s.runner = fr
+	if _, err := s.Status(context.Background()); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestStatusRendering(t *testing.T) {
+	st := Status{Domain: "example.com", Services: []ServiceStatus{
+		{Service: ServiceApp, Container: "fusionaly-app-1", State: StateRunning, Tag: "1.0.0", Uptime: "1 hour"},
+		{Service: ServiceProxy, Container: CaddyName, State: StateStopped, Tag: "2.7-alpine"},
+	}}
+
+	var table bytes.Buffer
+	if err := st.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[2], "SERVICE") {
+		t.Fatalf("unexpected table:\n%s", table.String())
+	}
+	col := strings.Index(lines[2], "STATE")
+	if strings.Index(lines[3], "running") != col || strings.Index(lines[4], "stopped") != col {
+		t.Errorf("STATE column is not aligned:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := st.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Status
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, st) {
+		t.Errorf("JSON round trip failed: %v\n%s", err, out.String())
+	}
+}
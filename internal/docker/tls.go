@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/validation"
+)
+
+// LetsEncryptStagingCA is the ACME directory of Let's Encrypt's staging
+// environment. Its certificates are not trusted by browsers, but its rate
+// limits are far more generous, which makes it the right CA while testing.
+const LetsEncryptStagingCA = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+// ErrDNSMismatch is returned by ConfigureTLS when the domain does not resolve
+// to this server, so Let's Encrypt could not complete the HTTP challenge.
+var ErrDNSMismatch = errors.New("domain does not resolve to this server")
+
+// Resolver looks up the addresses of a host. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// UseStagingCA makes ConfigureTLS issue certificates from the Let's Encrypt
+// staging CA instead of the production one.
+func (s *Stack) UseStagingCA(enabled bool) {
+	s.staging = enabled
+}
+
+// ConfigureTLS points the proxy at domain and lets Caddy obtain its
+// certificate from Let's Encrypt, registering the account under email.
+func (s *Stack) ConfigureTLS(domain, email string) error {
+	return s.ConfigureTLSContext(context.Background(), domain, email)
+}
+
+// ConfigureTLSContext is like ConfigureTLS but aborts when ctx is done. The
+// domain must resolve to this server; otherwise nothing is changed and
+// ErrDNSMismatch is returned. The settings are saved to the .env file once
+// Caddy has reloaded the new configuration.
+func (s *Stack) ConfigureTLSContext(ctx context.Context, domain, email string) error {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if err := validation.ValidateDomain(domain); err != nil {
+		return err
+	}
+	if err := validation.ValidateEmail(email); err != nil {
+		return err
+	}
+	if err := s.checkDNS(ctx, domain); err != nil {
+		return err
+	}
+
+	conf := config.NewConfig(s.logger)
+	if err := conf.LoadFromFile(s.envFile()); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	data := conf.GetData()
+	data.Domain = domain
+	data.ACMEEmail = email
+	data.ACMEStaging = s.staging
+	conf.SetData(data)
+
+	if err := s.reloadCaddyfile(ctx, data); err != nil {
+		return err
+	}
+
+	if err := conf.SaveToFile(s.envFile()); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	if s.staging {
+		s.logger.Success("TLS configured for %s using the Let's Encrypt staging CA", domain)
+	} else {
+		s.logger.Success("TLS configured for %s", domain)
+	}
+	return nil
+}
+
+// reloadCaddyfile regenerates the Caddyfile from data and reloads the
+// running proxy with it, putting the previous file back if Caddy rejects it.
+func (s *Stack) reloadCaddyfile(ctx context.Context, data config.ConfigData) error {
+	d := &Docker{logger: s.logger, runner: s.runner, ns: s.ns}
+	content, err := d.generateCaddyfile(data)
+	if err != nil {
+		return fmt.Errorf("generate Caddyfile: %w", err)
+	}
+	caddyFile := filepath.Join(data.InstallDir, "Caddyfile")
+	previous, err := os.ReadFile(caddyFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read Caddyfile: %w", err)
+	}
+	if err := executor.WriteFile(s.runner, caddyFile, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write Caddyfile: %w", err)
+	}
+
+	res, err := s.runner.Run(ctx, "docker", "exec", s.names().Caddy, "caddy", "reload", "--config", "/etc/caddy/Caddyfile")
+	if err != nil {
+		if previous != nil {
+			if restoreErr := executor.WriteFile(s.runner, caddyFile, previous, 0o644); restoreErr != nil {
+				s.logger.Error("Failed to restore previous Caddyfile: %v", restoreErr)
+			}
+		}
+		return &StackError{Action: "exec", Service: ServiceProxy, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	return nil
+}
+
+// checkDNS returns ErrDNSMismatch unless one of the addresses domain
+// resolves to belongs to this server.
+func (s *Stack) checkDNS(ctx context.Context, domain string) error {
+	resolver := s.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupHost(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("%w: lookup %s: %v", ErrDNSMismatch, domain, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("%w: no A/AAAA records found for %s", ErrDNSMismatch, domain)
+	}
+
+	serverIPs := s.serverIPs
+	if serverIPs == nil {
+		serverIPs = config.ServerIPs
+	}
+	ips, err := serverIPs(ctx)
+	if err != nil {
+		return fmt.Errorf("determine server IP: %w", err)
+	}
+
+	for _, addr := range addrs {
+		for _, ip := range ips {
+			if sameIP(addr, ip) {
+				s.logger.Success("DNS verified: %s resolves to %s", domain, addr)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s resolves to %s, server IP(s): %s", ErrDNSMismatch, domain, strings.Join(addrs, ", "), strings.Join(ips, ", "))
+}
+
+// sameIP compares two textual addresses, treating different spellings of the
+// same IPv6 address as equal.
+func sameIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(strings.TrimSpace(a)), net.ParseIP(strings.TrimSpace(b))
+	if ipA == nil || ipB == nil {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+	return ipA.Equal(ipB)
+}
@@ -0,0 +1,158 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeResolver struct {
+	addrs map[string][]string
+	err   error
+}
+
+func (f fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.addrs[host], nil
+}
+
+func newTLSStack(t *testing.T, fr *fakeRunner, addrs ...string) (*Stack, string) {
+	t.Helper()
+	t.Setenv("ENV", "")
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("FUSIONALY_DOMAIN=old.example.com\nINSTALL_DIR="+dir+"\nFUSIONALY_PRIVATE_KEY=key\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStack(testLogger(t), fr)
+	s.env = envFile
+	s.resolver = fakeResolver{addrs: map[string][]string{"analytics.example.com": addrs}}
+	s.serverIPs = func(context.Context) ([]string, error) { return []string{"203.0.113.10"}, nil }
+	return s, dir
+}
+
+func TestCheckDNS(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver fakeResolver
+		wantErr  error
+	}{
+		{"matches server", fakeResolver{addrs: map[string][]string{"a.example.com": {"198.51.100.1", "203.0.113.10"}}}, nil},
+		{"points elsewhere", fakeResolver{addrs: map[string][]string{"a.example.com": {"198.51.100.1"}}}, ErrDNSMismatch},
+		{"no records", fakeResolver{}, ErrDNSMismatch},
+		{"lookup fails", fakeResolver{err: errors.New("no such host")}, ErrDNSMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStack(testLogger(t), &fakeRunner{})
+			s.resolver = tt.resolver
+			s.serverIPs = func(context.Context) ([]string, error) { return []string{"203.0.113.10"}, nil }
+
+			err := s.checkDNS(context.Background(), "a.example.com")
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("checkDNS() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckDNSComparesIPv6Addresses(t *testing.T) {
+	s := NewStack(testLogger(t), &fakeRunner{})
+	s.resolver = fakeResolver{addrs: map[string][]string{"a.example.com": {"2001:db8::1"}}}
+	s.serverIPs = func(context.Context) ([]string, error) { return []string{"2001:0db8:0000::0001"}, nil }
+
+	if err := s.checkDNS(context.Background(), "a.example.com"); err != nil {
+		t.Errorf("checkDNS() = %v, want nil", err)
+	}
+}
+
+func TestConfigureTLSRejectsDNSMismatch(t *testing.T) {
+	fr := &fakeRunner{}
+	s, dir := newTLSStack(t, fr, "198.51.100.1")
+
+	err := s.ConfigureTLS("analytics.example.com", "ops@example.com")
+	if !errors.Is(err, ErrDNSMismatch) {
+		t.Fatalf("expected ErrDNSMismatch, got %v", err)
+	}
+	if len(fr.calls) != 0 {
+		t.Errorf("expected no docker commands, got %v", fr.calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Caddyfile")); !os.IsNotExist(err) {
+		t.Errorf("Caddyfile should not be written on DNS mismatch")
+	}
+}
+
+func TestConfigureTLSWritesStagingConfig(t *testing.T) {
+	fr := &fakeRunner{}
+	s, dir := newTLSStack(t, fr, "203.0.113.10")
+	s.UseStagingCA(true)
+
+	if err := s.ConfigureTLS("analytics.example.com", "ops@example.com"); err != nil {
+		t.Fatalf("ConfigureTLS returned error: %v", err)
+	}
+
+	caddyfile, err := os.ReadFile(filepath.Join(dir, "Caddyfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"email ops@example.com", "acme_ca " + LetsEncryptStagingCA, "analytics.example.com:443"} {
+		if !strings.Contains(string(caddyfile), want) {
+			t.Errorf("Caddyfile missing %q:\n%s", want, caddyfile)
+		}
+	}
+
+	last := fr.calls[len(fr.calls)-1]
+	if strings.Join(last, " ") != "docker exec "+CaddyName+" caddy reload --config /etc/caddy/Caddyfile" {
+		t.Errorf("expected caddy reload, got %v", last)
+	}
+
+	env, err := os.ReadFile(s.env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"FUSIONALY_DOMAIN=analytics.example.com", "ACME_EMAIL=ops@example.com", "ACME_STAGING=true"} {
+		if !strings.Contains(string(env), want) {
+			t.Errorf(".env missing %q:\n%s", want, env)
+		}
+	}
+}
+
+func TestConfigureTLSProductionOmitsStagingCA(t *testing.T) {
+	s, dir := newTLSStack(t, &fakeRunner{}, "203.0.113.10")
+
+	if err := s.ConfigureTLS("analytics.example.com", "ops@example.com"); err != nil {
+		t.Fatalf("ConfigureTLS returned error: %v", err)
+	}
+	caddyfile, err := os.ReadFile(filepath.Join(dir, "Caddyfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(caddyfile), "acme_ca") {
+		t.Errorf("production Caddyfile should not set acme_ca:\n%s", caddyfile)
+	}
+}
+
+func TestConfigureTLSRestoresCaddyfileWhenReloadFails(t *testing.T) {
+	fr := &fakeRunner{}
+	s, dir := newTLSStack(t, fr, "203.0.113.10")
+	caddyFile := filepath.Join(dir, "Caddyfile")
+	if err := os.WriteFile(caddyFile, []byte("old config"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// getActiveContainer checks both app slots before the reload runs.
+	fr.errs = []error{nil, nil, errors.New("exit status 1")}
+
+	err := s.ConfigureTLS("analytics.example.com", "ops@example.com")
+	if !errors.Is(err, ErrStackFailed) {
+		t.Fatalf("expected ErrStackFailed, got %v", err)
+	}
+	got, _ := os.ReadFile(caddyFile)
+	if string(got) != "old config" {
+		t.Errorf("Caddyfile not restored, got %q", got)
+	}
+}
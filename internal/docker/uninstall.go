@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// UninstallOptions controls how much of an installation Uninstall removes.
+type UninstallOptions struct {
+	// InstallDir holds the database, uploads, logs and .env file.
+	InstallDir string
+	// RemoveData also deletes the containers' volumes and InstallDir. Without
+	// it the data is left in place so a later install picks it up again.
+	RemoveData bool
+}
+
+// Uninstall removes the Fusionaly containers and network, proxy first so no
+// traffic reaches a stopping app. Containers or a network that are already
+// gone are skipped.
+func (s *Stack) Uninstall(ctx context.Context, opts UninstallOptions) error {
+	if opts.RemoveData {
+		if err := checkRemovableDir(opts.InstallDir); err != nil {
+			return err
+		}
+	}
+
+	rm := []string{"rm", "--force"}
+	if opts.RemoveData {
+		rm = append(rm, "--volumes")
+	}
+	for _, container := range []string{s.names().Caddy, s.names().AppPrimary, s.names().AppSecondary} {
+		if err := s.remove(ctx, append(rm, container)...); err != nil {
+			return err
+		}
+	}
+	if err := s.remove(ctx, "network", "rm", s.names().Network); err != nil {
+		return err
+	}
+	s.logger.Success("Fusionaly containers and network removed")
+
+	if !opts.RemoveData {
+		s.logger.Info("Data kept in %s; reinstalling will reuse it", opts.InstallDir)
+		return nil
+	}
+	// A dry run only reports the removal.
+	if err := executor.RemoveAll(s.runner, opts.InstallDir); err != nil {
+		return fmt.Errorf("remove %s: %w", opts.InstallDir, err)
+	}
+	if _, dry := executor.DryRunOf(s.runner); !dry {
+		s.logger.Success("Removed %s", opts.InstallDir)
+	}
+	return nil
+}
+
+// remove runs a docker removal, treating an already missing object as done.
+func (s *Stack) remove(ctx context.Context, args ...string) error {
+	s.logger.Debug("Running docker %s", strings.Join(args, " "))
+	res, err := s.runner.Run(ctx, "docker", args...)
+	if err == nil {
+		return nil
+	}
+	stderr := strings.ToLower(res.Stderr)
+	if strings.Contains(stderr, "no such container") || strings.Contains(stderr, "not found") {
+		return nil
+	}
+	last := len(args) - 1
+	return &StackError{Action: strings.Join(args[:last], " "), Service: args[last], ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+}
+
+// checkRemovableDir refuses to delete paths that cannot be an install
+// directory, so a bad InstallDir never wipes the system.
+func checkRemovableDir(dir string) error {
+	clean := filepath.Clean(dir)
+	if dir == "" || !filepath.IsAbs(clean) || clean == "/" || isSystemDir(clean) {
+		return fmt.Errorf("refusing to remove install directory %q", dir)
+	}
+	return nil
+}
+
+func isSystemDir(dir string) bool {
+	switch dir {
+	case "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/opt", "/proc", "/root", "/sbin", "/srv", "/sys", "/tmp", "/usr", "/var":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func newInstallDir(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "fusionaly")
+	if err := os.MkdirAll(filepath.Join(dir, "storage"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "storage", "fusionaly-production.db"), []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestUninstallPreservesData(t *testing.T) {
+	fr := &fakeRunner{}
+	s := NewStack(testLogger(t), fr)
+	dir := newInstallDir(t)
+
+	if err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: dir}); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+	want := [][]string{
+		{"docker", "rm", "--force", CaddyName},
+		{"docker", "rm", "--force", AppNamePrimary},
+		{"docker", "rm", "--force", AppNameSecondary},
+		{"docker", "network", "rm", NetworkName},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "storage", "fusionaly-production.db")); err != nil {
+		t.Errorf("database should be kept: %v", err)
+	}
+}
+
+func TestUninstallRemoveData(t *testing.T) {
+	fr := &fakeRunner{}
+	s := NewStack(testLogger(t), fr)
+	dir := newInstallDir(t)
+
+	if err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: dir, RemoveData: true}); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+	want := [][]string{
+		{"docker", "rm", "--force", "--volumes", CaddyName},
+		{"docker", "rm", "--force", "--volumes", AppNamePrimary},
+		{"docker", "rm", "--force", "--volumes", AppNameSecondary},
+		{"docker", "network", "rm", NetworkName},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("install dir should be removed, stat err = %v", err)
+	}
+}
+
+func TestUninstallDryRunKeepsData(t *testing.T) {
+	dry := executor.NewDryRunExecutor(nil)
+	s := NewStack(testLogger(t), dry)
+	dir := newInstallDir(t)
+
+	if err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: dir, RemoveData: true}); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "storage", "fusionaly-production.db")); err != nil {
+		t.Errorf("dry run should keep the database: %v", err)
+	}
+	if files := dry.Files(); !reflect.DeepEqual(files, []string{"rm -rf " + dir}) {
+		t.Errorf("skipped files = %q, want the install dir removal", files)
+	}
+}
+
+func TestUninstallSkipsMissingContainers(t *testing.T) {
+	missing := errors.New("exit status 1")
+	fr := &fakeRunner{
+		results: []executor.Result{{}, {Stderr: "Error response from daemon: No such container: fusionaly-app-1"}, {}, {Stderr: "Error response from daemon: network fusionaly-network not found"}},
+		errs:    []error{nil, missing, nil, missing},
+	}
+	s := NewStack(testLogger(t), fr)
+
+	if err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: newInstallDir(t)}); err != nil {
+		t.Fatalf("Uninstall returned error: %v", err)
+	}
+}
+
+func TestUninstallStopsOnDockerFailure(t *testing.T) {
+	fr := &fakeRunner{
+		results: []executor.Result{{ExitCode: 1, Stderr: "permission denied"}},
+		errs:    []error{errors.New("exit status 1")},
+	}
+	s := NewStack(testLogger(t), fr)
+	dir := newInstallDir(t)
+
+	err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: dir, RemoveData: true})
+	if !errors.Is(err, ErrStackFailed) {
+		t.Fatalf("expected ErrStackFailed, got %v", err)
+	}
+	if len(fr.calls) != 1 {
+		t.Errorf("expected to stop after the failed command, got %v", fr.calls)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("data must survive a failed uninstall: %v", err)
+	}
+}
+
+func TestUninstallRefusesSystemDirectories(t *testing.T) {
+	for _, dir := range []string{"", "/", "/opt", "relative/path", "/etc/"} {
+		fr := &fakeRunner{}
+		s := NewStack(testLogger(t), fr)
+		if err := s.Uninstall(context.Background(), UninstallOptions{InstallDir: dir, RemoveData: true}); err == nil {
+			t.Errorf("expected refusal for %q", dir)
+		}
+		if len(fr.calls) != 0 {
+			t.Errorf("no docker commands should run for %q, got %v", dir, fr.calls)
+		}
+	}
+}
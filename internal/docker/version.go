@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNotInstalled is returned when no Fusionaly app container exists yet.
+var ErrNotInstalled = errors.New("fusionaly is not installed")
+
+// InstalledVersion returns the app version actually deployed, read from the
+// image tag of the app container, running slot first. When the image is only
+// tagged "latest", the running app is asked with `fnctl version` instead.
+// The .env file is never consulted: it says what should run, not what does.
+func (s *Stack) InstalledVersion(ctx context.Context) (string, error) {
+	res, err := s.runner.Run(ctx, "docker", "ps", "-a", "--filter", "name="+s.names().appPrefix(), "--format", "{{.Names}}\t{{.State}}")
+	if err != nil {
+		return "", &StackError{Action: "ps", Service: ServiceApp, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	container, running := pickAppContainer(res.Stdout, s.names())
+	if container == "" {
+		return "", ErrNotInstalled
+	}
+
+	res, err = s.runner.Run(ctx, "docker", "inspect", "--format", "{{.Config.Image}}", container)
+	if err != nil {
+		return "", &StackError{Action: "inspect", Service: ServiceApp, ExitCode: res.ExitCode, Stderr: res.Stderr, Err: err}
+	}
+	tag := imageTag(strings.TrimSpace(res.Stdout))
+	if tag != "latest" || !running {
+		return tag, nil
+	}
+
+	res, err = s.runner.Run(ctx, "docker", "exec", container, "/app/fnctl", "version")
+	if err != nil {
+		s.logger.Debug("fnctl version failed, reporting image tag: %v", err)
+		return tag, nil
+	}
+	if fields := strings.Fields(res.Stdout); len(fields) > 0 {
+		return fields[len(fields)-1], nil
+	}
+	return tag, nil
+}
+
+// pickAppContainer chooses the app container to report from
+// "<name>\t<state>" lines: a running one over a stopped one, and the primary
+// slot over the secondary.
+func pickAppContainer(output string, names Names) (name string, running bool) {
+	states := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		n, state, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if n == names.AppPrimary || n == names.AppSecondary {
+			states[n] = state
+		}
+	}
+	for _, n := range names.Apps() {
+		if states[n] == "running" {
+			return n, true
+		}
+	}
+	for _, n := range names.Apps() {
+		if _, ok := states[n]; ok {
+			return n, false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestInstalledVersionPrefersRunningContainer(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: AppNamePrimary + "\texited\n" + AppNameSecondary + "\trunning\n"},
+		{Stdout: "karloscodes/fusionaly-beta:v1.4.2\n"},
+	}}
+	s := NewStack(testLogger(t), fr)
+
+	version, err := s.InstalledVersion(context.Background())
+	if err != nil {
+		t.Fatalf("InstalledVersion returned error: %v", err)
+	}
+	if version != "v1.4.2" {
+		t.Errorf("version = %q, want v1.4.2", version)
+	}
+	want := [][]string{
+		{"docker", "ps", "-a", "--filter", "name=fusionaly-app-", "--format", "{{.Names}}\t{{.State}}"},
+		{"docker", "inspect", "--format", "{{.Config.Image}}", AppNameSecondary},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestInstalledVersionAsksFnctlForLatestTag(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{
+		{Stdout: AppNamePrimary + "\trunning\n"},
+		{Stdout: "karloscodes/fusionaly-beta:latest\n"},
+		{Stdout: "fusionaly v1.5.0\n"},
+	}}
+	s := NewStack(testLogger(t), fr)
+
+	version, err := s.InstalledVersion(context.Background())
+	if err != nil || version != "v1.5.0" {
+		t.Fatalf("InstalledVersion = %q, %v; want v1.5.0", version, err)
+	}
+	if last := fr.calls[len(fr.calls)-1]; !reflect.DeepEqual(last, []string{"docker", "exec", AppNamePrimary, "/app/fnctl", "version"}) {
+		t.Errorf("expected fnctl version call, got %v", last)
+	}
+}
+
+func TestInstalledVersionNotInstalled(t *testing.T) {
+	fr := &fakeRunner{results: []executor.Result{{Stdout: ""}}}
+	s := NewStack(testLogger(t), fr)
+
+	if _, err := s.InstalledVersion(context.Background()); !errors.Is(err, ErrNotInstalled) {
+		t.Fatalf("expected ErrNotInstalled, got %v", err)
+	}
+	if len(fr.calls) != 1 {
+		t.Errorf("nothing should be inspected without a container, got %v", fr.calls)
+	}
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// boundExecutor runs commands through an inner Executor and also stops them
+// when a process-wide context ends.
+type boundExecutor struct {
+	inner Executor
+	base  context.Context
+}
+
+// WithBaseContext returns an Executor that runs commands through e and
+// cancels them when base ends, in addition to the context each call passes.
+// It lets a signal handler stop commands started deep inside components that
+// were only given context.Background().
+func WithBaseContext(e Executor, base context.Context) Executor {
+	return &boundExecutor{inner: e, base: base}
+}
+
+// baseErr returns the error for a command stopped because b.base ended:
+// its cause when that matches b.base.Err(), such as a timeout that is also a
+// deadline, and otherwise b.base.Err() itself. A deadline on the base is
+// thus still reported as one rather than as a plain cancellation.
+func (b *boundExecutor) baseErr() error {
+	err := b.base.Err()
+	if cause := context.Cause(b.base); errors.Is(cause, err) {
+		return cause
+	}
+	return err
+}
+
+// stopped replaces err with baseErr when the command failed because b.base
+// ended rather than the caller's ctx.
+func (b *boundExecutor) stopped(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() == nil && b.base.Err() != nil {
+		return b.baseErr()
+	}
+	return err
+}
+
+// merge returns ctx, additionally cancelled when b.base ends.
+func (b *boundExecutor) merge(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(b.base, func() { cancel(context.Cause(b.base)) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// Run implements Executor.
+func (b *boundExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	if b.base.Err() != nil {
+		return Result{ExitCode: -1}, b.baseErr()
+	}
+	merged, cancel := b.merge(ctx)
+	defer cancel()
+	res, err := b.inner.Run(merged, name, args...)
+	return res, b.stopped(ctx, err)
+}
+
+// Stream implements Streamer. When the inner Executor cannot stream, the
+// command's buffered output is written once it finishes.
+func (b *boundExecutor) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error) {
+	if b.base.Err() != nil {
+		return Result{ExitCode: -1}, b.baseErr()
+	}
+	merged, cancel := b.merge(ctx)
+	defer cancel()
+	if streamer, ok := b.inner.(Streamer); ok {
+		res, err := streamer.Stream(merged, stdout, stderr, name, args...)
+		return res, b.stopped(ctx, err)
+	}
+	res, err := b.inner.Run(merged, name, args...)
+	io.WriteString(stdout, res.Stdout)
+	io.WriteString(stderr, res.Stderr)
+	return Result{ExitCode: res.ExitCode}, b.stopped(ctx, err)
+}
+
+// Unwrap returns the Executor commands are run through.
+func (b *boundExecutor) Unwrap() Executor {
+	return b.inner
+}
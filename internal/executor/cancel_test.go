@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithBaseContextCancelsRunningCommand(t *testing.T) {
+	base, cancel := context.WithCancel(context.Background())
+	e := WithBaseContext(NewCommandExecutor(), base)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := e.Run(context.Background(), "sleep", "5")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command was not stopped promptly (%s)", elapsed)
+	}
+}
+
+func TestWithBaseContextRefusesNewCommandsOnceCancelled(t *testing.T) {
+	base, cancel := context.WithCancel(context.Background())
+	cancel()
+	dry := NewDryRunExecutor(nil)
+
+	if _, err := WithBaseContext(dry, base).Run(context.Background(), "docker", "ps"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(dry.Commands()) != 0 {
+		t.Errorf("no command should reach the inner executor, got %v", dry.Commands())
+	}
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DryRunExecutor records and prints every command it is asked to run without
+// executing anything. Each command reports success with empty output. Secrets
+// are hidden from the printed commands the way ScriptRecordingExecutor hides
+// them from its script.
+type DryRunExecutor struct {
+	mu       sync.Mutex
+	out      io.Writer
+	commands [][]string
+	files    []string
+	secrets  *redactor
+}
+
+// NewDryRunExecutor returns a DryRunExecutor that prints commands to out; a
+// nil out only records them.
+func NewDryRunExecutor(out io.Writer) *DryRunExecutor {
+	return &DryRunExecutor{out: out, secrets: newRedactor(goQuote)}
+}
+
+// Run implements Executor.
+func (e *DryRunExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	cmd := append([]string{name}, args...)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.commands = append(e.commands, cmd)
+	if e.out != nil {
+		words, _ := e.secrets.words(cmd)
+		fmt.Fprintf(e.out, "[dry-run] %s\n", strings.Join(words, " "))
+	}
+	return Result{}, nil
+}
+
+// Stream implements Streamer; like Run it only records the command.
+func (e *DryRunExecutor) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error) {
+	return e.Run(ctx, name, args...)
+}
+
+// AddSecret implements SecretRegistry: value is printed as "${name}".
+func (e *DryRunExecutor) AddSecret(name, value string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.secrets.add(name, value)
+}
+
+// SkipFile records and prints a change to path that the dry run does not
+// make, e.g. "write" or "rm -rf". See WriteFile.
+func (e *DryRunExecutor) SkipFile(op, path string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.files = append(e.files, op+" "+path)
+	if e.out != nil {
+		fmt.Fprintf(e.out, "[dry-run] %s %s\n", op, goQuote(path))
+	}
+}
+
+// Commands returns the commands recorded so far, oldest first.
+func (e *DryRunExecutor) Commands() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([][]string, len(e.commands))
+	for i, c := range e.commands {
+		out[i] = append([]string(nil), c...)
+	}
+	return out
+}
+
+// Files returns the file changes skipped so far, oldest first, as
+// "<op> <path>".
+func (e *DryRunExecutor) Files() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.files...)
+}
+
+// goQuote renders arg so it can be copied into a shell, quoting it when it
+// contains anything beyond plain word characters.
+func goQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+%") == "" {
+		return arg
+	}
+	return strconv.Quote(arg)
+}
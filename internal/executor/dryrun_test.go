@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDryRunExecutor_RecordsWithoutExecuting(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "created")
+	var out bytes.Buffer
+	e := NewDryRunExecutor(&out)
+
+	res, err := e.Run(context.Background(), "touch", marker)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if res != (Result{}) {
+		t.Errorf("expected an empty result, got %+v", res)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("dry run must not execute the command")
+	}
+
+	if _, err := e.Run(context.Background(), "docker", "exec", "c", "sh", "-c", "echo hi"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"touch", marker}, {"docker", "exec", "c", "sh", "-c", "echo hi"}}
+	if got := e.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, got)
+	}
+	wantOut := "[dry-run] touch " + marker + "\n[dry-run] docker exec c sh -c \"echo hi\"\n"
+	if out.String() != wantOut {
+		t.Errorf("output mismatch\nwant %q\ngot  %q", wantOut, out.String())
+	}
+}
+
+func TestDryRunExecutor_HonoursCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e := NewDryRunExecutor(nil)
+	if _, err := e.Run(ctx, "true"); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(e.Commands()) != 0 {
+		t.Error("a cancelled command should not be recorded")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	orig := Default()
+	t.Cleanup(func() { SetDefault(orig) })
+
+	if _, ok := orig.(*CommandExecutor); !ok {
+		t.Fatalf("default should be a CommandExecutor, got %T", orig)
+	}
+	dry := NewDryRunExecutor(nil)
+	SetDefault(dry)
+	if Default() != Executor(dry) {
+		t.Error("SetDefault did not replace the default executor")
+	}
+}
+
+func TestDryRunExecutor_HidesSecrets(t *testing.T) {
+	var out bytes.Buffer
+	e := NewDryRunExecutor(&out)
+	e.AddSecret("ADMIN_PASSWORD", "SuperSecretPass123")
+
+	e.Run(context.Background(), "docker", "exec", "fusionaly-app-1", "/app/fnctl", "create-admin-user", "a@b.com", "SuperSecretPass123")
+	e.Run(context.Background(), "docker", "run", "-e", "FUSIONALY_PRIVATE_KEY=s3cr3t", "app")
+
+	want := "[dry-run] docker exec fusionaly-app-1 /app/fnctl create-admin-user a@b.com \"${ADMIN_PASSWORD}\"\n" +
+		"[dry-run] docker run -e FUSIONALY_PRIVATE_KEY=\"${FUSIONALY_PRIVATE_KEY}\" app\n"
+	if out.String() != want {
+		t.Errorf("output mismatch\nwant %q\ngot  %q", want, out.String())
+	}
+}
+
+func TestWriteFile_SkippedInDryRun(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	dry := NewDryRunExecutor(&out)
+	// Wrapped as main wraps the default executor
+	e := WithBaseContext(WithHistory(dry, NewHistory(5)), context.Background())
+
+	path := filepath.Join(dir, ".env")
+	if err := WriteFile(e, path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveAll(e, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("a dry run must not write files")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("a dry run must not remove files: %v", err)
+	}
+	if want := []string{"write " + path, "rm -rf " + dir}; !reflect.DeepEqual(dry.Files(), want) {
+		t.Errorf("files = %v, want %v", dry.Files(), want)
+	}
+
+	if err := WriteFile(NewCommandExecutor(), path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("a real executor should write the file: %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// ErrNeedsPrivileges is returned by a CommandExecutor configured with UseSudo
+// when sudo is not installed or would need a password.
+var ErrNeedsPrivileges = errors.New("command needs root privileges")
+
+// Result holds what a finished command printed and how it exited.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Executor runs host commands. It exists so packages that shell out (docker,
+// systemctl, ...) can be tested with a fake instead of real binaries.
+type Executor interface {
+	Run(ctx context.Context, name string, args ...string) (Result, error)
+}
+
+// Streamer is implemented by executors that can pass a command's output
+// through while it runs, for long-lived commands such as `docker logs -f`.
+// The returned Result only carries the exit code.
+type Streamer interface {
+	Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error)
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultExecutor Executor = NewCommandExecutor()
+)
+
+// Default returns the Executor that components use when they are not given
+// one explicitly. It is a CommandExecutor unless SetDefault replaced it.
+func Default() Executor {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultExecutor
+}
+
+// SetDefault replaces the Executor returned by Default, e.g. with a
+// DryRunExecutor for --dry-run. It should be called before components are
+// constructed.
+func SetDefault(e Executor) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultExecutor = e
+}
+
+// Config tunes a CommandExecutor.
+type Config struct {
+	// UseSudo runs every command as `sudo -n <name> <args...>`, for hosts
+	// where docker and systemctl need root. -n makes sudo fail rather
+	// than prompt, so a missing sudoers rule surfaces as ErrNeedsPrivileges.
+	UseSudo bool
+
+	// Env is merged onto os.Environ() for every command, overriding
+	// variables of the same name. The installer's own environment is
+	// never modified.
+	Env map[string]string
+
+	// WorkDir is the directory commands run in; empty means the
+	// installer's current directory. It must exist when a command runs.
+	WorkDir string
+
+	// Logger, when set, gets a debug line for every command. Values of
+	// secret-looking variables in Env are redacted.
+	Logger *logging.Logger
+}
+
+// CommandExecutor runs commands with os/exec.
+type CommandExecutor struct {
+	config Config
+}
+
+// NewCommandExecutor returns an Executor backed by os/exec.
+func NewCommandExecutor() *CommandExecutor {
+	return NewCommandExecutorWithConfig(Config{})
+}
+
+// NewCommandExecutorWithConfig returns a CommandExecutor using config.
+func NewCommandExecutorWithConfig(config Config) *CommandExecutor {
+	return &CommandExecutor{config: config}
+}
+
+// WithEnv returns a copy of e whose commands also see env, layered over any
+// variables e already sets.
+func (e *CommandExecutor) WithEnv(env map[string]string) *CommandExecutor {
+	merged := make(map[string]string, len(e.config.Env)+len(env))
+	for k, v := range e.config.Env {
+		merged[k] = v
+	}
+	for k, v := range env {
+		merged[k] = v
+	}
+	config := e.config
+	config.Env = merged
+	return &CommandExecutor{config: config}
+}
+
+// commandLine returns the program and arguments actually executed for name
+// and args.
+func (e *CommandExecutor) commandLine(name string, args []string) (string, []string) {
+	if !e.config.UseSudo {
+		return name, args
+	}
+	sudoArgs := []string{"-n"}
+	if len(e.config.Env) > 0 {
+		// sudo resets the environment unless told which variables to keep.
+		sudoArgs = append(sudoArgs, "--preserve-env="+strings.Join(e.envKeys(), ","))
+	}
+	return "sudo", append(append(sudoArgs, name), args...)
+}
+
+func (e *CommandExecutor) command(ctx context.Context, name string, args []string) *exec.Cmd {
+	if e.config.Logger != nil {
+		e.config.Logger.Debug("Running %s", e.describe(name, args))
+	}
+	name, args = e.commandLine(name, args)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = e.environ()
+	cmd.Dir = e.config.WorkDir
+	return cmd
+}
+
+// checkWorkDir reports a missing working directory up front; os/exec would
+// otherwise fail with an error that names the command, not the directory.
+func (e *CommandExecutor) checkWorkDir() error {
+	if e.config.WorkDir == "" {
+		return nil
+	}
+	info, err := os.Stat(e.config.WorkDir)
+	if err != nil {
+		return fmt.Errorf("working directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("working directory %s is not a directory", e.config.WorkDir)
+	}
+	return nil
+}
+
+// envKeys returns the names of the configured variables in a stable order.
+func (e *CommandExecutor) envKeys() []string {
+	keys := make([]string, 0, len(e.config.Env))
+	for k := range e.config.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// environ returns the child environment, or nil to inherit the parent's
+// unchanged when no variables are configured.
+func (e *CommandExecutor) environ() []string {
+	if len(e.config.Env) == 0 {
+		return nil
+	}
+	env := os.Environ()
+	for _, k := range e.envKeys() {
+		env = append(env, k+"="+e.config.Env[k])
+	}
+	return env
+}
+
+// describe renders a command for logs, with the configured variables in
+// front and secret values replaced.
+func (e *CommandExecutor) describe(name string, args []string) string {
+	var parts []string
+	for _, k := range e.envKeys() {
+		v := e.config.Env[k]
+		if IsSecretVar(k) {
+			v = "<redacted>"
+		}
+		parts = append(parts, k+"="+v)
+	}
+	name, args = e.commandLine(name, args)
+	parts = append(parts, name)
+	return strings.Join(append(parts, args...), " ")
+}
+
+// IsSecretVar reports whether a variable name looks like it holds a
+// credential.
+func IsSecretVar(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range []string{"SECRET", "PASSWORD", "PASSPHRASE", "TOKEN", "KEY", "CREDENTIAL"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// privilegeError turns sudo's own failures into ErrNeedsPrivileges so they
+// are not mistaken for the wrapped command failing.
+func (e *CommandExecutor) privilegeError(err error, stderr string) error {
+	if !e.config.UseSudo || err == nil {
+		return err
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: sudo is not installed", ErrNeedsPrivileges)
+	}
+	if strings.Contains(stderr, "a password is required") || strings.Contains(stderr, "a terminal is required") {
+		return fmt.Errorf("%w: sudo needs a password; allow passwordless sudo for this user or run as root", ErrNeedsPrivileges)
+	}
+	return err
+}
+
+// Run executes name with args and waits for it to finish. A non-zero exit is
+// reported both in Result.ExitCode and as an error; when ctx ends the command
+// is killed and ctx.Err() is returned.
+func (e *CommandExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	if err := e.checkWorkDir(); err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := e.command(ctx, name, args)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	res, err := finish(ctx, cmd.Run(), Result{Stdout: stdout.String(), Stderr: stderr.String()})
+	return res, e.privilegeError(err, res.Stderr)
+}
+
+// finish fills in the exit code for a completed command and prefers
+// ctx.Err() when the command was killed because ctx ended.
+func finish(ctx context.Context, err error, res Result) (Result, error) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return res, ctxErr
+		}
+		if res.ExitCode == 0 {
+			res.ExitCode = -1
+		}
+	}
+	return res, err
+}
+
+// Stream runs name like Run but copies its output to stdout and stderr as it
+// is produced instead of buffering it.
+func (e *CommandExecutor) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error) {
+	// sudo reports its own failures before the command starts, so the head
+	// of stderr is enough to recognise them.
+	if err := e.checkWorkDir(); err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	errHead := &headWriter{max: 4096}
+	cmd := e.command(ctx, name, args)
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(stderr, errHead)
+	res, err := finish(ctx, cmd.Run(), Result{})
+	return res, e.privilegeError(err, errHead.buf.String())
+}
+
+// headWriter keeps the first max bytes written to it and discards the rest.
+type headWriter struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.max - w.buf.Len(); room > 0 {
+		w.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
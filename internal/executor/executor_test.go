@@ -0,0 +1,233 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandExecutorCapturesOutput(t *testing.T) {
+	res, err := NewCommandExecutor().Run(context.Background(), "sh", "-c", "echo out; echo err >&2")
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if res.Stdout != "out\n" || res.Stderr != "err\n" || res.ExitCode != 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestCommandExecutorExitCode(t *testing.T) {
+	res, err := NewCommandExecutor().Run(context.Background(), "sh", "-c", "echo boom >&2; exit 3")
+	if err == nil {
+		t.Fatal("expected error for non-zero exit")
+	}
+	if res.ExitCode != 3 || res.Stderr != "boom\n" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestCommandExecutorMissingBinary(t *testing.T) {
+	res, err := NewCommandExecutor().Run(context.Background(), "definitely-not-a-real-binary")
+	if err == nil {
+		t.Fatal("expected error for missing binary")
+	}
+	if res.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1", res.ExitCode)
+	}
+}
+
+func TestCommandExecutorHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := NewCommandExecutor().Run(ctx, "sleep", "5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestCommandExecutor_Stream(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	res, err := NewCommandExecutor().Stream(context.Background(), &stdout, &stderr, "sh", "-c", "echo out; echo err >&2; exit 3")
+	if err == nil {
+		t.Fatal("expected an error for a non-zero exit")
+	}
+	if res.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", res.ExitCode)
+	}
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Errorf("unexpected output: stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+}
+
+func TestCommandExecutorPrefixesSudoOnlyWhenEnabled(t *testing.T) {
+	name, args := NewCommandExecutor().commandLine("docker", []string{"ps", "-a"})
+	if name != "docker" || strings.Join(args, " ") != "ps -a" {
+		t.Errorf("without sudo got %s %v", name, args)
+	}
+
+	name, args = NewCommandExecutorWithConfig(Config{UseSudo: true}).commandLine("docker", []string{"ps", "-a"})
+	if name != "sudo" || strings.Join(args, " ") != "-n docker ps -a" {
+		t.Errorf("with sudo got %s %v", name, args)
+	}
+}
+
+// fakeSudo puts a sudo script on PATH that runs body.
+func fakeSudo(t *testing.T, body string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sudo"), []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestCommandExecutorSudoRunsCommand(t *testing.T) {
+	fakeSudo(t, `[ "$1" = "-n" ] || exit 9; shift; exec "$@"`)
+
+	res, err := NewCommandExecutorWithConfig(Config{UseSudo: true}).Run(context.Background(), "sh", "-c", "echo root")
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if res.Stdout != "root\n" {
+		t.Errorf("unexpected stdout %q", res.Stdout)
+	}
+}
+
+func TestCommandExecutorSudoNeedsPassword(t *testing.T) {
+	fakeSudo(t, `echo "sudo: a password is required" >&2; exit 1`)
+	e := NewCommandExecutorWithConfig(Config{UseSudo: true})
+
+	if _, err := e.Run(context.Background(), "docker", "ps"); !errors.Is(err, ErrNeedsPrivileges) {
+		t.Errorf("Run: expected ErrNeedsPrivileges, got %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if _, err := e.Stream(context.Background(), &stdout, &stderr, "docker", "logs", "-f", "x"); !errors.Is(err, ErrNeedsPrivileges) {
+		t.Errorf("Stream: expected ErrNeedsPrivileges, got %v", err)
+	}
+}
+
+func TestCommandExecutorSudoMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := NewCommandExecutorWithConfig(Config{UseSudo: true}).Run(context.Background(), "docker", "ps")
+	if !errors.Is(err, ErrNeedsPrivileges) {
+		t.Fatalf("expected ErrNeedsPrivileges, got %v", err)
+	}
+}
+
+func TestCommandExecutorCommandFailureIsNotPrivilegeError(t *testing.T) {
+	fakeSudo(t, `shift; exec "$@"`)
+
+	_, err := NewCommandExecutorWithConfig(Config{UseSudo: true}).Run(context.Background(), "sh", "-c", "exit 2")
+	if err == nil || errors.Is(err, ErrNeedsPrivileges) {
+		t.Fatalf("expected a plain exit error, got %v", err)
+	}
+}
+
+func TestCommandExecutorInjectsEnv(t *testing.T) {
+	e := NewCommandExecutorWithConfig(Config{Env: map[string]string{"COMPOSE_PROJECT_NAME": "fusionaly"}}).
+		WithEnv(map[string]string{"FUSIONALY_TEST_VAR": "child-only"})
+
+	res, err := e.Run(context.Background(), "sh", "-c", `echo "$COMPOSE_PROJECT_NAME $FUSIONALY_TEST_VAR $HOME"`)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if want := "fusionaly child-only " + os.Getenv("HOME") + "\n"; res.Stdout != want {
+		t.Errorf("child saw %q, want %q", res.Stdout, want)
+	}
+	for _, key := range []string{"COMPOSE_PROJECT_NAME", "FUSIONALY_TEST_VAR"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s leaked into the parent environment", key)
+		}
+	}
+}
+
+func TestCommandExecutorEnvOverridesParent(t *testing.T) {
+	t.Setenv("FUSIONALY_TEST_VAR", "parent")
+	e := NewCommandExecutorWithConfig(Config{Env: map[string]string{"FUSIONALY_TEST_VAR": "child"}})
+
+	res, err := e.Run(context.Background(), "sh", "-c", `echo "$FUSIONALY_TEST_VAR"`)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if res.Stdout != "child\n" {
+		t.Errorf("child saw %q, want child", res.Stdout)
+	}
+	if os.Getenv("FUSIONALY_TEST_VAR") != "parent" {
+		t.Error("parent environment was modified")
+	}
+}
+
+func TestWithEnvDoesNotModifyOriginal(t *testing.T) {
+	base := NewCommandExecutorWithConfig(Config{Env: map[string]string{"A": "1"}})
+	derived := base.WithEnv(map[string]string{"A": "2", "B": "3"})
+
+	if base.config.Env["A"] != "1" || len(base.config.Env) != 1 {
+		t.Errorf("base env changed: %v", base.config.Env)
+	}
+	if derived.config.Env["A"] != "2" || derived.config.Env["B"] != "3" {
+		t.Errorf("derived env = %v", derived.config.Env)
+	}
+}
+
+func TestDescribeRedactsSecrets(t *testing.T) {
+	e := NewCommandExecutorWithConfig(Config{Env: map[string]string{
+		"COMPOSE_PROJECT_NAME":  "fusionaly",
+		"S3_SECRET_KEY":         "abc123",
+		"FUSIONALY_PRIVATE_KEY": "deadbeef",
+		"SMTP_PASSWORD":         "hunter2",
+	}})
+
+	got := e.describe("docker", []string{"compose", "up"})
+	want := "COMPOSE_PROJECT_NAME=fusionaly FUSIONALY_PRIVATE_KEY=<redacted> S3_SECRET_KEY=<redacted> SMTP_PASSWORD=<redacted> docker compose up"
+	if got != want {
+		t.Errorf("describe() = %q, want %q", got, want)
+	}
+}
+
+func TestSudoPreservesInjectedEnv(t *testing.T) {
+	e := NewCommandExecutorWithConfig(Config{UseSudo: true, Env: map[string]string{"B": "2", "A": "1"}})
+	name, args := e.commandLine("docker", []string{"ps"})
+	if name != "sudo" || strings.Join(args, " ") != "-n --preserve-env=A,B docker ps" {
+		t.Errorf("got %s %v", name, args)
+	}
+}
+
+func TestCommandExecutorWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	res, err := NewCommandExecutorWithConfig(Config{WorkDir: dir}).Run(context.Background(), "pwd", "-P")
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	want, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(res.Stdout); got != want {
+		t.Errorf("command ran in %q, want %q", got, want)
+	}
+}
+
+func TestCommandExecutorMissingWorkDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	e := NewCommandExecutorWithConfig(Config{WorkDir: dir})
+
+	res, err := e.Run(context.Background(), "pwd")
+	if err == nil || !strings.Contains(err.Error(), "working directory") || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a working directory error, got %v", err)
+	}
+	if res.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1", res.ExitCode)
+	}
+
+	var out bytes.Buffer
+	if _, err := e.Stream(context.Background(), &out, &out, "pwd"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stream: expected a working directory error, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package executor
+
+import "os"
+
+// DryRunOf returns the DryRunExecutor that e is or wraps, if any. Code that
+// changes files directly rather than through a command uses it to leave them
+// alone during a dry run; see WriteFile.
+func DryRunOf(e Executor) (*DryRunExecutor, bool) {
+	for e != nil {
+		if dry, ok := e.(*DryRunExecutor); ok {
+			return dry, true
+		}
+		w, ok := e.(interface{ Unwrap() Executor })
+		if !ok {
+			break
+		}
+		e = w.Unwrap()
+	}
+	return nil, false
+}
+
+// WriteFile is os.WriteFile, except that during a dry run of e the write is
+// only reported.
+func WriteFile(e Executor, name string, data []byte, perm os.FileMode) error {
+	if dry, ok := DryRunOf(e); ok {
+		dry.SkipFile("write", name)
+		return nil
+	}
+	return os.WriteFile(name, data, perm)
+}
+
+// Remove is os.Remove, except that during a dry run of e the removal is only
+// reported.
+func Remove(e Executor, name string) error {
+	if dry, ok := DryRunOf(e); ok {
+		dry.SkipFile("rm", name)
+		return nil
+	}
+	return os.Remove(name)
+}
+
+// RemoveAll is os.RemoveAll, except that during a dry run of e the removal is
+// only reported.
+func RemoveAll(e Executor, path string) error {
+	if dry, ok := DryRunOf(e); ok {
+		dry.SkipFile("rm -rf", path)
+		return nil
+	}
+	return os.RemoveAll(path)
+}
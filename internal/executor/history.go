@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// CommandRecord is one command remembered by a History.
+type CommandRecord struct {
+	Command  string // Binary and subcommand only; see traceName
+	Start    time.Time
+	Duration time.Duration
+	ExitCode int
+	Err      string // Empty when the command succeeded
+}
+
+// History remembers the most recent commands run through the Executors it
+// wraps, so a crash report can show what the installer was doing.
+type History struct {
+	mu      sync.Mutex
+	size    int
+	records []CommandRecord
+	now     func() time.Time
+}
+
+// NewHistory returns a History keeping the last size commands.
+func NewHistory(size int) *History {
+	return &History{size: size, now: time.Now}
+}
+
+// Recent returns the remembered commands, oldest first.
+func (h *History) Recent() []CommandRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]CommandRecord(nil), h.records...)
+}
+
+func (h *History) add(r CommandRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	if over := len(h.records) - h.size; over > 0 {
+		h.records = h.records[over:]
+	}
+}
+
+// historyExecutor records every command run through an inner Executor.
+type historyExecutor struct {
+	inner   Executor
+	history *History
+}
+
+// WithHistory returns an Executor that runs commands through e and records
+// each one in h once it finishes.
+func WithHistory(e Executor, h *History) Executor {
+	return &historyExecutor{inner: e, history: h}
+}
+
+// Run implements Executor.
+func (x *historyExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	start := x.history.now()
+	res, err := x.inner.Run(ctx, name, args...)
+	x.record(start, name, args, res, err)
+	return res, err
+}
+
+// Stream implements Streamer, falling back to a buffered Run when the inner
+// Executor cannot stream.
+func (x *historyExecutor) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error) {
+	start := x.history.now()
+	var res Result
+	var err error
+	if streamer, ok := x.inner.(Streamer); ok {
+		res, err = streamer.Stream(ctx, stdout, stderr, name, args...)
+	} else {
+		res, err = x.inner.Run(ctx, name, args...)
+		io.WriteString(stdout, res.Stdout)
+		io.WriteString(stderr, res.Stderr)
+		res = Result{ExitCode: res.ExitCode}
+	}
+	x.record(start, name, args, res, err)
+	return res, err
+}
+
+func (x *historyExecutor) record(start time.Time, name string, args []string, res Result, err error) {
+	r := CommandRecord{
+		Command:  traceName(name, args),
+		Start:    start,
+		Duration: x.history.now().Sub(start),
+		ExitCode: res.ExitCode,
+	}
+	if err != nil {
+		r.Err = err.Error()
+	}
+	x.history.add(r)
+}
+
+// Unwrap returns the Executor commands are run through.
+func (x *historyExecutor) Unwrap() Executor {
+	return x.inner
+}
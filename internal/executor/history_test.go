@@ -0,0 +1,30 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestHistoryKeepsMostRecentCommands(t *testing.T) {
+	h := NewHistory(2)
+	e := WithHistory(NewDryRunExecutor(nil), h)
+	for i := range 3 {
+		e.Run(context.Background(), "docker", fmt.Sprintf("cmd%d", i), "--secret-arg")
+	}
+
+	got := h.Recent()
+	if len(got) != 2 || got[0].Command != "docker cmd1" || got[1].Command != "docker cmd2" {
+		t.Errorf("Recent() = %+v, want docker cmd1 and docker cmd2", got)
+	}
+}
+
+func TestHistoryRecordsFailures(t *testing.T) {
+	h := NewHistory(10)
+	WithHistory(failingExecutor{}, h).Run(context.Background(), "docker", "inspect", "fusionaly-app-1")
+
+	got := h.Recent()
+	if len(got) != 1 || got[0].ExitCode != 125 || got[0].Err != "exit status 125" {
+		t.Errorf("Recent() = %+v, want one failed docker inspect", got)
+	}
+}
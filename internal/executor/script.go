@@ -0,0 +1,255 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// scriptHeader starts a new recorded script. Replaying stops at the first
+// failing command, as the installer would.
+const scriptHeader = "#!/bin/sh\n# Commands recorded by fusionaly. Set the variables each command\n# checks for before replaying it.\nset -eu\n"
+
+// SecretRegistry is implemented by executors that write the commands they run
+// somewhere people read them, so callers passing a secret as a plain argument
+// can have it hidden there.
+type SecretRegistry interface {
+	AddSecret(name, value string)
+}
+
+// ScriptRecordingExecutor runs commands through an inner Executor and appends
+// each one to a shell script first, so the exact sequence can be reviewed or
+// replayed by hand. Secrets never reach the script: a registered secret
+// value, the value of a NAME=value argument whose name looks like a
+// credential (see IsSecretVar) and the value of a --password style flag are
+// written as "${NAME}" references, with a check that NAME is set before its
+// first use.
+type ScriptRecordingExecutor struct {
+	mu       sync.Mutex
+	inner    Executor
+	path     string
+	secrets  *redactor
+	declared map[string]bool
+}
+
+// NewScriptRecordingExecutor returns an Executor that records to the script
+// at path and runs commands through inner. An existing script is appended
+// to; a new one gets a shebang and is made executable.
+func NewScriptRecordingExecutor(inner Executor, path string) *ScriptRecordingExecutor {
+	return &ScriptRecordingExecutor{
+		inner:    inner,
+		path:     path,
+		secrets:  newRedactor(shellQuote),
+		declared: make(map[string]bool),
+	}
+}
+
+// AddSecret makes every occurrence of value in a recorded command appear as
+// "${name}" instead. Empty values are ignored, and a value registered before
+// keeps its variable. A name already standing for another value gets a
+// numeric suffix, e.g. ADMIN_PASSWORD_2, so replaying never mixes them up.
+// The secret is passed on to the inner Executor when it hides secrets too.
+func (x *ScriptRecordingExecutor) AddSecret(name, value string) {
+	x.mu.Lock()
+	x.secrets.add(name, value)
+	x.mu.Unlock()
+	if inner, ok := x.inner.(SecretRegistry); ok {
+		inner.AddSecret(name, value)
+	}
+}
+
+// Unwrap returns the Executor commands are run through.
+func (x *ScriptRecordingExecutor) Unwrap() Executor {
+	return x.inner
+}
+
+// Run implements Executor. A command that cannot be recorded is not run.
+func (x *ScriptRecordingExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	if err := x.record(name, args); err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	return x.inner.Run(ctx, name, args...)
+}
+
+// Stream implements Streamer, falling back to a buffered Run when the inner
+// Executor cannot stream.
+func (x *ScriptRecordingExecutor) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error) {
+	if err := x.record(name, args); err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	if streamer, ok := x.inner.(Streamer); ok {
+		return streamer.Stream(ctx, stdout, stderr, name, args...)
+	}
+	res, err := x.inner.Run(ctx, name, args...)
+	io.WriteString(stdout, res.Stdout)
+	io.WriteString(stderr, res.Stderr)
+	return Result{ExitCode: res.ExitCode}, err
+}
+
+// record appends the script lines for one command.
+func (x *ScriptRecordingExecutor) record(name string, args []string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var b strings.Builder
+	words, vars := x.secrets.words(append([]string{name}, args...))
+	for _, v := range vars {
+		if !x.declared[v] {
+			fmt.Fprintf(&b, ": \"${%s:?set %s before replaying}\"\n", v, v)
+		}
+	}
+	b.WriteString(strings.Join(words, " ") + "\n")
+
+	f, err := os.OpenFile(x.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o700)
+	if err != nil {
+		return fmt.Errorf("failed to open command script: %w", err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		if _, err := f.WriteString(scriptHeader); err != nil {
+			return fmt.Errorf("failed to write command script: %w", err)
+		}
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write command script: %w", err)
+	}
+	for _, v := range vars {
+		x.declared[v] = true
+	}
+	return nil
+}
+
+// secretFlag matches the --flag of a "--flag value" or "--flag=value" pair
+// whose value is a credential.
+var secretFlag = regexp.MustCompile(`^--?([A-Za-z][A-Za-z0-9-]*)$`)
+
+// redactor renders commands as shell words with their secrets replaced by
+// variable references. It is shared by the script recorder and dry runs and
+// is not safe for concurrent use.
+type redactor struct {
+	secrets map[string]string // Value -> variable name
+	quote   func(string) string
+}
+
+func newRedactor(quote func(string) string) *redactor {
+	return &redactor{secrets: make(map[string]string), quote: quote}
+}
+
+// add registers value as the secret variable name, see
+// ScriptRecordingExecutor.AddSecret.
+func (r *redactor) add(name, value string) {
+	if value == "" {
+		return
+	}
+	if _, ok := r.secrets[value]; ok {
+		return
+	}
+	taken := make(map[string]bool, len(r.secrets))
+	for _, n := range r.secrets {
+		taken[n] = true
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	r.secrets[value] = unique
+}
+
+// words renders cmd as shell words and returns the variables they
+// reference, in order of first use.
+func (r *redactor) words(cmd []string) ([]string, []string) {
+	var vars []string
+	use := func(v string) string {
+		if !slices.Contains(vars, v) {
+			vars = append(vars, v)
+		}
+		return `"${` + v + `}"`
+	}
+
+	words := make([]string, len(cmd))
+	for i, arg := range cmd {
+		if i > 0 {
+			if m := secretFlag.FindStringSubmatch(cmd[i-1]); m != nil && IsSecretVar(m[1]) && !strings.HasPrefix(arg, "-") {
+				words[i] = use(secretVarName(m[1]))
+				continue
+			}
+		}
+		if key, value, ok := strings.Cut(arg, "="); ok && value != "" && IsSecretVar(key) {
+			if m := secretFlag.FindStringSubmatch(key); m != nil {
+				words[i] = r.quote(key+"=") + use(secretVarName(m[1]))
+				continue
+			}
+			if isVarName(key) {
+				words[i] = r.quote(key+"=") + use(key)
+				continue
+			}
+		}
+		words[i] = r.substitute(arg, use)
+	}
+	return words, vars
+}
+
+// substitute quotes arg, replacing each registered secret value in it with a
+// variable reference. Longer values are replaced first so a secret
+// containing another is not split.
+func (r *redactor) substitute(arg string, use func(string) string) string {
+	values := make([]string, 0, len(r.secrets))
+	for v := range r.secrets {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	var b strings.Builder
+	rest := arg
+	for rest != "" {
+		at, match := -1, ""
+		for _, v := range values {
+			if i := strings.Index(rest, v); i >= 0 && (at < 0 || i < at) {
+				at, match = i, v
+			}
+		}
+		if at < 0 {
+			break
+		}
+		if at > 0 {
+			b.WriteString(r.quote(rest[:at]))
+		}
+		b.WriteString(use(r.secrets[match]))
+		rest = rest[at+len(match):]
+	}
+	if rest != "" || b.Len() == 0 {
+		b.WriteString(r.quote(rest))
+	}
+	return b.String()
+}
+
+// secretVarName turns a flag name such as "admin-password" into the variable
+// ADMIN_PASSWORD.
+func secretVarName(flag string) string {
+	return strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// isVarName reports whether s is a valid shell variable name.
+func isVarName(s string) bool {
+	for i, r := range s {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && (i == 0 || !(r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// shellQuote renders s as a single POSIX shell word. Unlike goQuote's
+// Go-style quoting, single quotes keep $ and backslashes literal on replay.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScriptRecordingExecutorWritesReplayableScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "install.sh")
+	dry := NewDryRunExecutor(nil)
+	x := NewScriptRecordingExecutor(dry, path)
+	x.AddSecret("FUSIONALY_LICENSE_KEY", "lic-123456")
+
+	ctx := context.Background()
+	x.Run(ctx, "docker", "pull", "karloscodes/fusionaly-beta:latest")
+	x.Run(ctx, "docker", "run", "-e", "FUSIONALY_PRIVATE_KEY=s3cr3t", "-e", "FUSIONALY_DOMAIN=example.com", "app")
+	x.Run(ctx, "fnctl", "activate", "--license=lic-123456", "--password", "hunter2")
+	x.Run(ctx, "sh", "-c", "echo 'it''s' $HOME")
+	x.Run(ctx, "docker", "restart", "-e", "FUSIONALY_PRIVATE_KEY=s3cr3t")
+
+	if len(dry.Commands()) != 5 {
+		t.Fatalf("every command should still run, got %v", dry.Commands())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := scriptHeader + strings.Join([]string{
+		"docker pull karloscodes/fusionaly-beta:latest",
+		`: "${FUSIONALY_PRIVATE_KEY:?set FUSIONALY_PRIVATE_KEY before replaying}"`,
+		`docker run -e FUSIONALY_PRIVATE_KEY="${FUSIONALY_PRIVATE_KEY}" -e FUSIONALY_DOMAIN=example.com app`,
+		`: "${FUSIONALY_LICENSE_KEY:?set FUSIONALY_LICENSE_KEY before replaying}"`,
+		`: "${PASSWORD:?set PASSWORD before replaying}"`,
+		`fnctl activate --license="${FUSIONALY_LICENSE_KEY}" --password "${PASSWORD}"`,
+		`sh -c 'echo '\''it'\'''\''s'\'' $HOME'`,
+		`docker restart -e FUSIONALY_PRIVATE_KEY="${FUSIONALY_PRIVATE_KEY}"`,
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Errorf("script mismatch\nwant:\n%s\ngot:\n%s", want, data)
+	}
+	for _, secret := range []string{"s3cr3t", "lic-123456", "hunter2"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("script leaks %q", secret)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("script should be executable, got %v %v", info.Mode(), err)
+	}
+}
+
+func TestScriptRecordingExecutorAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "install.sh")
+	NewScriptRecordingExecutor(NewDryRunExecutor(nil), path).Run(context.Background(), "docker", "ps")
+	NewScriptRecordingExecutor(NewDryRunExecutor(nil), path).Run(context.Background(), "docker", "images")
+
+	data, _ := os.ReadFile(path)
+	if got := string(data); got != scriptHeader+"docker ps\ndocker images\n" {
+		t.Errorf("second executor should append without a new header, got:\n%s", got)
+	}
+}
+
+func TestScriptRecordingExecutorReplaysLiterally(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "replay.sh")
+	out := filepath.Join(dir, "out")
+	x := NewScriptRecordingExecutor(NewDryRunExecutor(nil), path)
+	x.AddSecret("TOKEN", "tok")
+	x.Run(context.Background(), "sh", "-c", `printf '%s|%s' "$0" "$1" > "$2"`, "a $b 'c'", "pre-tok-post", out)
+
+	cmd := exec.Command(sh, path)
+	cmd.Env = append(os.Environ(), "TOKEN=tok")
+	if b, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("replay failed: %v\n%s", err, b)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "a $b 'c'|pre-tok-post" {
+		t.Errorf("replay produced %q", got)
+	}
+
+	cmd = exec.Command(sh, path)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	if err := cmd.Run(); err == nil {
+		t.Error("replay without TOKEN set should fail before running the command")
+	}
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// tracingExecutor logs every command run through an inner Executor.
+type tracingExecutor struct {
+	inner  Executor
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// WithTracing returns an Executor that runs commands through e and logs each
+// one: a debug line when it starts, a debug line with its duration and exit
+// code when it succeeds, and an error line when it fails. Failures that are
+// part of normal operation (probing for a missing container, say) are
+// reported too, so it is meant for debugging sessions rather than every run.
+func WithTracing(e Executor, logger *logging.Logger) Executor {
+	return &tracingExecutor{inner: e, logger: logger, now: time.Now}
+}
+
+// Run implements Executor.
+func (t *tracingExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	start := t.begin(name, args)
+	res, err := t.inner.Run(ctx, name, args...)
+	t.end(start, name, args, res, err)
+	return res, err
+}
+
+// Stream implements Streamer, falling back to a buffered Run when the inner
+// Executor cannot stream.
+func (t *tracingExecutor) Stream(ctx context.Context, stdout, stderr io.Writer, name string, args ...string) (Result, error) {
+	start := t.begin(name, args)
+	var res Result
+	var err error
+	if streamer, ok := t.inner.(Streamer); ok {
+		res, err = streamer.Stream(ctx, stdout, stderr, name, args...)
+	} else {
+		res, err = t.inner.Run(ctx, name, args...)
+		io.WriteString(stdout, res.Stdout)
+		io.WriteString(stderr, res.Stderr)
+		res = Result{ExitCode: res.ExitCode}
+	}
+	t.end(start, name, args, res, err)
+	return res, err
+}
+
+func (t *tracingExecutor) begin(name string, args []string) time.Time {
+	t.logger.With(map[string]any{"command": traceName(name, args)}).Debug("Command started")
+	return t.now()
+}
+
+func (t *tracingExecutor) end(start time.Time, name string, args []string, res Result, err error) {
+	duration := t.now().Sub(start)
+	logger := t.logger.With(map[string]any{
+		"command":     traceName(name, args),
+		"duration_ms": duration.Milliseconds(),
+		"exit_code":   res.ExitCode,
+	})
+	if err != nil {
+		logger.Error("Command failed after %s: %v", duration.Round(time.Millisecond), err)
+		return
+	}
+	logger.Debug("Command finished in %s", duration.Round(time.Millisecond))
+}
+
+// traceName identifies a command by its binary and subcommand only, since
+// later arguments may carry passwords (e.g. `docker exec ... fnctl
+// create-admin-user <email> <password>`).
+func traceName(name string, args []string) string {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return name
+	}
+	return name + " " + args[0]
+}
+
+// Unwrap returns the Executor commands are run through.
+func (t *tracingExecutor) Unwrap() Executor {
+	return t.inner
+}
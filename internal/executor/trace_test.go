@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// failingExecutor fails every command with exit code 125.
+type failingExecutor struct{}
+
+func (failingExecutor) Run(ctx context.Context, name string, args ...string) (Result, error) {
+	return Result{Stderr: "no such container", ExitCode: 125}, errors.New("exit status 125")
+}
+
+// traceEntries runs one command through a tracing executor wrapping inner,
+// with a clock that advances 1.5s per reading, and returns the JSON entries
+// logged.
+func traceEntries(t *testing.T, inner Executor) ([]map[string]any, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := logging.NewLogger(logging.Config{Level: "debug", Format: "json"})
+	logger.SetOutput(&buf)
+
+	e := WithTracing(inner, logger).(*tracingExecutor)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time {
+		clock = clock.Add(1500 * time.Millisecond)
+		return clock
+	}
+	_, err := e.Run(context.Background(), "docker", "inspect", "--format", "{{.State.Status}}", "fusionaly-app-1")
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if jsonErr := json.Unmarshal([]byte(line), &entry); jsonErr != nil {
+			t.Fatalf("invalid log line %q: %v", line, jsonErr)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, err
+}
+
+func TestWithTracingLogsSuccess(t *testing.T) {
+	entries, err := traceEntries(t, NewDryRunExecutor(nil))
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected start and end entries, got %v", entries)
+	}
+	start, end := entries[0], entries[1]
+	if start["level"] != "debug" || start["command"] != "docker inspect" {
+		t.Errorf("unexpected start entry %v", start)
+	}
+	if end["level"] != "debug" || end["duration_ms"] != float64(1500) || end["exit_code"] != float64(0) {
+		t.Errorf("unexpected end entry %v", end)
+	}
+}
+
+func TestWithTracingLogsFailureAtErrorLevel(t *testing.T) {
+	entries, err := traceEntries(t, failingExecutor{})
+	if err == nil {
+		t.Fatal("expected the inner error to be returned")
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected start and end entries, got %v", entries)
+	}
+	end := entries[1]
+	if end["level"] != "error" || end["duration_ms"] != float64(1500) || end["exit_code"] != float64(125) {
+		t.Errorf("unexpected failure entry %v", end)
+	}
+	if msg, _ := end["msg"].(string); !strings.Contains(msg, "exit status 125") {
+		t.Errorf("failure entry does not mention the error: %v", end)
+	}
+}
+
+func TestTraceNameOmitsArguments(t *testing.T) {
+	got := traceName("docker", []string{"exec", "fusionaly-app-1", "/app/fnctl", "create-admin-user", "a@b.c", "hunter22"})
+	if got != "docker exec" {
+		t.Errorf("traceName = %q, want %q", got, "docker exec")
+	}
+}
@@ -0,0 +1,112 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"fusionaly-installer/internal/admin"
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/database"
+	"fusionaly-installer/internal/docker"
+	apperrors "fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/instance"
+	"fusionaly-installer/internal/lock"
+	"fusionaly-installer/internal/signals"
+	"fusionaly-installer/internal/updater"
+)
+
+// Exit codes returned by the fusionaly CLI. They are part of its interface:
+// scripts may branch on them, so existing values must not change.
+const (
+	OK          = 0   // Success
+	Generic     = 1   // Any failure not covered below
+	Invalid     = 2   // Bad usage, arguments, input or configuration
+	NotFound    = 3   // A requested user, backup, version or file does not exist, or nothing is installed
+	Executor    = 4   // A docker, fnctl or other host command failed
+	Locked      = 5   // Another fusionaly command holds the installer lock
+	Permission  = 6   // Root privileges or file permissions are missing
+	Timeout     = 124 // The --timeout limit was reached, as timeout(1) reports it
+	Interrupted = 130 // Cancelled by SIGINT/SIGTERM, as a shell reports it
+)
+
+// invalid holds the sentinels that mean the caller supplied something
+// unusable; retrying with the same input fails the same way.
+var invalid = []error{
+	apperrors.ErrInvalidInput,
+	admin.ErrInvalidEmail,
+	admin.ErrWeakPassword,
+	admin.ErrPasswordMismatch,
+	admin.ErrInvalidResetToken,
+	admin.ErrDuplicateEmail,
+	admin.ErrDisallowedCommand,
+	admin.ErrUnsupportedFormat,
+	admin.ErrResetNotForced,
+	admin.ErrProductionReset,
+	admin.ErrInvalidDataBundle,
+	admin.ErrDataVersionMismatch,
+	config.ErrUnsupportedFormat,
+	database.ErrWrongPassphrase,
+	database.ErrPassphraseRequired,
+	database.ErrCorruptBackup,
+	database.ErrNotTerminal,
+	database.ErrInvalidSelection,
+	docker.ErrInvalidPattern,
+	docker.ErrInvalidProxyConfig,
+	docker.ErrMissingImages,
+}
+
+var notFound = []error{
+	apperrors.ErrNotFound,
+	admin.ErrAdminNotFound,
+	updater.ErrNoPreviousVersion,
+	docker.ErrNotInstalled,
+	database.ErrNoBackups,
+	instance.ErrUnknownInstance,
+	os.ErrNotExist,
+}
+
+var executorFailed = []error{
+	admin.ErrExecutor,
+	docker.ErrStackFailed,
+}
+
+// ExitCode maps err to the CLI exit code for its kind, checking the most
+// specific kinds first: an fnctl failure that reports a missing user is
+// NotFound rather than Executor. nil maps to OK and unrecognised errors to
+// Generic.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return OK
+	case errors.Is(err, lock.ErrAlreadyRunning):
+		return Locked
+	case errors.Is(err, signals.ErrTimedOut):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Interrupted
+	case errors.Is(err, executor.ErrNeedsPrivileges), errors.Is(err, os.ErrPermission):
+		return Permission
+	case isAny(err, notFound):
+		return NotFound
+	case isAny(err, invalid):
+		return Invalid
+	case isAny(err, executorFailed):
+		return Executor
+	}
+	var dockerErr *apperrors.DockerError
+	if errors.As(err, &dockerErr) {
+		return Executor
+	}
+	return Generic
+}
+
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
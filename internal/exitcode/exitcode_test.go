@@ -0,0 +1,57 @@
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/admin"
+	"fusionaly-installer/internal/docker"
+	apperrors "fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/lock"
+	"fusionaly-installer/internal/signals"
+	"fusionaly-installer/internal/updater"
+)
+
+func TestExitCode(t *testing.T) {
+	ctx, cancel := signals.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, OK},
+		{"unknown", errors.New("boom"), Generic},
+		{"validation error", apperrors.NewValidationError("domain", "x", "bad"), Invalid},
+		{"weak password", &admin.WeakPasswordError{Failed: []string{"too short"}}, Invalid},
+		{"invalid email", fmt.Errorf("%w: bad", admin.ErrInvalidEmail), Invalid},
+		{"admin not found", fmt.Errorf("delete: %w", admin.ErrAdminNotFound), NotFound},
+		{"fnctl reports missing user", &admin.CommandError{Action: "change", Stderr: "user not found", Err: errors.New("exit status 1")}, NotFound},
+		{"no previous version", updater.ErrNoPreviousVersion, NotFound},
+		{"not installed", docker.ErrNotInstalled, NotFound},
+		{"missing file", &os.PathError{Op: "open", Path: "/x", Err: os.ErrNotExist}, NotFound},
+		{"fnctl failure", &admin.CommandError{Action: "create", Err: errors.New("exit status 1")}, Executor},
+		{"stack failure", &docker.StackError{Action: "restart", Service: "app", Err: errors.New("exit status 1")}, Executor},
+		{"docker error", apperrors.NewDockerError("pull", "app", errors.New("timeout")), Executor},
+		{"lock held", fmt.Errorf("%w (pid 42)", lock.ErrAlreadyRunning), Locked},
+		{"needs sudo", fmt.Errorf("%w: sudo needs a password", executor.ErrNeedsPrivileges), Permission},
+		{"permission denied", &os.PathError{Op: "open", Path: "/etc/x", Err: os.ErrPermission}, Permission},
+		{"interrupted", fmt.Errorf("install: %w", context.Canceled), Interrupted},
+		{"timed out", fmt.Errorf("install: %w", context.Cause(ctx)), Timeout},
+		{"operation deadline", fmt.Errorf("wait: %w", context.DeadlineExceeded), Generic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
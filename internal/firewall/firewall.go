@@ -0,0 +1,172 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+// Backend is a host firewall the installer knows how to configure.
+type Backend string
+
+const (
+	// BackendNone means no active, supported firewall was found.
+	BackendNone Backend = ""
+	// BackendUFW is Ubuntu's Uncomplicated Firewall.
+	BackendUFW Backend = "ufw"
+	// BackendFirewalld is the firewalld daemon used on Fedora and RHEL.
+	BackendFirewalld Backend = "firewalld"
+)
+
+// Port is a port to allow together with its protocol, "tcp" or "udp".
+type Port struct {
+	Number   int
+	Protocol string
+}
+
+// TCP returns the TCP port number.
+func TCP(number int) Port { return Port{Number: number, Protocol: "tcp"} }
+
+// UDP returns the UDP port number.
+func UDP(number int) Port { return Port{Number: number, Protocol: "udp"} }
+
+// String formats p the way ufw and firewalld take it, e.g. "443/udp".
+func (p Port) String() string {
+	return strconv.Itoa(p.Number) + "/" + p.Protocol
+}
+
+// Manager opens ports in the host firewall.
+type Manager struct {
+	logger   *logging.Logger
+	runner   executor.Executor
+	lookPath func(file string) (string, error)
+}
+
+// NewManager creates a Manager that runs ufw and firewall-cmd through runner.
+func NewManager(logger *logging.Logger, runner executor.Executor) *Manager {
+	return &Manager{
+		logger:   logger,
+		runner:   runner,
+		lookPath: exec.LookPath,
+	}
+}
+
+// Detect returns the active firewall. An installed but inactive ufw or a
+// stopped firewalld is not filtering anything, so it counts as none.
+func (m *Manager) Detect(ctx context.Context) Backend {
+	if _, err := m.lookPath("ufw"); err == nil {
+		if res, err := m.runner.Run(ctx, "ufw", "status"); err == nil && strings.Contains(res.Stdout, "Status: active") {
+			return BackendUFW
+		}
+	}
+	if _, err := m.lookPath("firewall-cmd"); err == nil {
+		if res, err := m.runner.Run(ctx, "firewall-cmd", "--state"); err == nil && strings.TrimSpace(res.Stdout) == "running" {
+			return BackendFirewalld
+		}
+	}
+	return BackendNone
+}
+
+// ConfigureFirewall allows inbound traffic on ports in the active firewall.
+func (m *Manager) ConfigureFirewall(ports ...Port) error {
+	return m.ConfigureFirewallContext(context.Background(), ports...)
+}
+
+// ConfigureFirewallContext is ConfigureFirewall with a context. Ports that
+// are already allowed are skipped, so running it again adds nothing. Without
+// a supported firewall it only logs and returns nil.
+func (m *Manager) ConfigureFirewallContext(ctx context.Context, ports ...Port) error {
+	if os.Getenv("ENV") == "test" {
+		m.logger.InfoWithTime("Skipping firewall setup in test environment")
+		return nil
+	}
+
+	switch backend := m.Detect(ctx); backend {
+	case BackendUFW:
+		return m.configureUFW(ctx, ports)
+	case BackendFirewalld:
+		return m.configureFirewalld(ctx, ports)
+	default:
+		m.logger.Info("No active ufw or firewalld found; make sure ports %s are open in any other firewall", portList(ports))
+		return nil
+	}
+}
+
+func (m *Manager) configureUFW(ctx context.Context, ports []Port) error {
+	res, err := m.runner.Run(ctx, "ufw", "status")
+	if err != nil {
+		return fmt.Errorf("ufw status failed: %w - %s", err, strings.TrimSpace(res.Stderr))
+	}
+	allowed := ufwAllowed(res.Stdout)
+
+	for _, port := range ports {
+		rule := port.String()
+		if allowed[rule] {
+			m.logger.Debug("ufw already allows %s", rule)
+			continue
+		}
+		if res, err := m.runner.Run(ctx, "ufw", "allow", rule); err != nil {
+			return fmt.Errorf("ufw allow %s failed: %w - %s", rule, err, strings.TrimSpace(res.Stderr))
+		}
+		m.logger.Success("Allowed %s in ufw", rule)
+	}
+	return nil
+}
+
+// ufwAllowed returns the port rules `ufw status` lists as ALLOW. A bare
+// port allows every protocol, so it covers port/tcp and port/udp too.
+func ufwAllowed(status string) map[string]bool {
+	allowed := map[string]bool{}
+	for _, line := range strings.Split(status, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] != "ALLOW" {
+			continue
+		}
+		rule := fields[0]
+		if !strings.Contains(rule, "/") {
+			allowed[rule+"/tcp"] = true
+			allowed[rule+"/udp"] = true
+			continue
+		}
+		allowed[rule] = true
+	}
+	return allowed
+}
+
+func (m *Manager) configureFirewalld(ctx context.Context, ports []Port) error {
+	added := false
+	for _, port := range ports {
+		rule := port.String()
+		// --query-port exits non-zero when the port is not open.
+		if _, err := m.runner.Run(ctx, "firewall-cmd", "--permanent", "--query-port="+rule); err == nil {
+			m.logger.Debug("firewalld already allows %s", rule)
+			continue
+		}
+		if res, err := m.runner.Run(ctx, "firewall-cmd", "--permanent", "--add-port="+rule); err != nil {
+			return fmt.Errorf("firewall-cmd --add-port=%s failed: %w - %s", rule, err, strings.TrimSpace(res.Stderr))
+		}
+		m.logger.Success("Allowed %s in firewalld", rule)
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	if res, err := m.runner.Run(ctx, "firewall-cmd", "--reload"); err != nil {
+		return fmt.Errorf("firewall-cmd --reload failed: %w - %s", err, strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
+
+func portList(ports []Port) string {
+	list := make([]string, len(ports))
+	for i, port := range ports {
+		list[i] = port.String()
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,145 @@
+package firewall
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+func testLogger(t *testing.T) *logging.Logger {
+	dir := t.TempDir()
+	return logging.NewLogger(logging.Config{LogDir: dir})
+}
+
+// fakeRunner answers each command with the result registered for its
+// joined command line; unregistered commands succeed with no output.
+type fakeRunner struct {
+	calls   [][]string
+	results map[string]executor.Result
+	errs    map[string]error
+}
+
+func (f *fakeRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	call := append([]string{name}, args...)
+	f.calls = append(f.calls, call)
+	key := strings.Join(call, " ")
+	return f.results[key], f.errs[key]
+}
+
+func newTestManager(t *testing.T, fr *fakeRunner, installed ...string) *Manager {
+	t.Setenv("ENV", "")
+	m := NewManager(testLogger(t), fr)
+	m.lookPath = func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/sbin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	return m
+}
+
+const ufwActive = `Status: active
+
+To                         Action      From
+--                         ------      ----
+22/tcp                     ALLOW       Anywhere
+80                         ALLOW       Anywhere
+22/tcp (v6)                ALLOW       Anywhere (v6)
+`
+
+// webPorts are the ports the installer opens for the default proxy.
+var webPorts = []Port{TCP(80), TCP(443), UDP(443)}
+
+func TestConfigureFirewallUFW(t *testing.T) {
+	fr := &fakeRunner{results: map[string]executor.Result{"ufw status": {Stdout: ufwActive}}}
+	m := newTestManager(t, fr, "ufw")
+
+	if err := m.ConfigureFirewall(webPorts...); err != nil {
+		t.Fatalf("ConfigureFirewall: %v", err)
+	}
+	want := [][]string{
+		{"ufw", "status"},
+		{"ufw", "status"},
+		{"ufw", "allow", "443/tcp"},
+		{"ufw", "allow", "443/udp"},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestConfigureFirewallFirewalld(t *testing.T) {
+	fr := &fakeRunner{
+		results: map[string]executor.Result{"firewall-cmd --state": {Stdout: "running\n"}},
+		errs: map[string]error{
+			"firewall-cmd --permanent --query-port=443/tcp": errors.New("exit status 1"),
+			"firewall-cmd --permanent --query-port=443/udp": errors.New("exit status 1"),
+		},
+	}
+	m := newTestManager(t, fr, "firewall-cmd")
+
+	if err := m.ConfigureFirewall(webPorts...); err != nil {
+		t.Fatalf("ConfigureFirewall: %v", err)
+	}
+	want := [][]string{
+		{"firewall-cmd", "--state"},
+		{"firewall-cmd", "--permanent", "--query-port=80/tcp"},
+		{"firewall-cmd", "--permanent", "--query-port=443/tcp"},
+		{"firewall-cmd", "--permanent", "--add-port=443/tcp"},
+		{"firewall-cmd", "--permanent", "--query-port=443/udp"},
+		{"firewall-cmd", "--permanent", "--add-port=443/udp"},
+		{"firewall-cmd", "--reload"},
+	}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestConfigureFirewallFirewalldAlreadyOpen(t *testing.T) {
+	fr := &fakeRunner{results: map[string]executor.Result{"firewall-cmd --state": {Stdout: "running\n"}}}
+	m := newTestManager(t, fr, "firewall-cmd")
+
+	if err := m.ConfigureFirewall(webPorts...); err != nil {
+		t.Fatalf("ConfigureFirewall: %v", err)
+	}
+	for _, call := range fr.calls {
+		if call[len(call)-1] == "--reload" || strings.HasPrefix(call[len(call)-1], "--add-port") {
+			t.Errorf("nothing should change when every port is open, got %v", call)
+		}
+	}
+}
+
+func TestConfigureFirewallNoBackend(t *testing.T) {
+	// ufw is installed but inactive, firewalld is absent.
+	fr := &fakeRunner{results: map[string]executor.Result{"ufw status": {Stdout: "Status: inactive\n"}}}
+	m := newTestManager(t, fr, "ufw")
+
+	if err := m.ConfigureFirewall(webPorts...); err != nil {
+		t.Fatalf("ConfigureFirewall: %v", err)
+	}
+	want := [][]string{{"ufw", "status"}}
+	if !reflect.DeepEqual(fr.calls, want) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", want, fr.calls)
+	}
+}
+
+func TestConfigureFirewallUFWFailure(t *testing.T) {
+	fr := &fakeRunner{
+		results: map[string]executor.Result{"ufw status": {Stdout: "Status: active\n"}},
+		errs:    map[string]error{"ufw allow 80/tcp": errors.New("exit status 1")},
+	}
+	m := newTestManager(t, fr, "ufw")
+
+	err := m.ConfigureFirewall(webPorts...)
+	if err == nil || !strings.Contains(err.Error(), "ufw allow 80/tcp") {
+		t.Fatalf("expected ufw allow error, got %v", err)
+	}
+}
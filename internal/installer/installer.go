@@ -2,6 +2,8 @@ package installer
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -9,12 +11,19 @@ import (
 	"strings"
 	"time"
 
+	"fusionaly-installer/internal/admin"
 	"fusionaly-installer/internal/config"
 	"fusionaly-installer/internal/cron"
 	"fusionaly-installer/internal/database"
 	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/firewall"
+	"fusionaly-installer/internal/instance"
 	"fusionaly-installer/internal/logging"
 	"fusionaly-installer/internal/requirements"
+	"fusionaly-installer/internal/systemd"
+	"fusionaly-installer/internal/updater"
+	"fusionaly-installer/internal/validation"
 )
 
 const (
@@ -31,6 +40,52 @@ type Installer struct {
 	database     *database.Database
 	binaryPath   string
 	portWarnings []string
+	options      InstallOptions
+	paths        config.InstallPaths
+	ports        docker.Ports
+	names        docker.Names
+	instance     string
+	ctx          context.Context         // Of the running installation; nil means context.Background()
+	secrets      executor.SecretRegistry // Told about the install file's admin password; nil for none
+}
+
+// InstallOptions tunes a fresh installation.
+type InstallOptions struct {
+	Version      string      // App image tag to pin (e.g. "1.2.3"); empty installs the release default
+	OnProgress   func(Event) // Called as each install step starts, completes or fails; may be nil
+	SkipFirewall bool        // Leave the host firewall alone instead of opening the web ports
+	SkipNetwork  bool        // Don't wait for the image registry to be reachable, for air-gapped hosts
+	Metrics      MetricsSink // Receives each step's duration and result; nil records nothing
+	ImageTarball string      // `docker save` bundle to load instead of pulling; implies SkipNetwork
+	StrictClock  bool        // Fail instead of warning when the host clock is out of sync
+	// File answers every prompt for an unattended install and adds the admin
+	// account and backup schedule it declares; see InstallOptionsFromFile.
+	File *config.InstallFile
+}
+
+// InstallOptionsFromFile reads the install file at path into InstallOptions.
+// The warnings name keys in the file that were ignored.
+func InstallOptionsFromFile(path string) (InstallOptions, []string, error) {
+	f, warnings, err := config.LoadInstallFile(path)
+	if err != nil {
+		return InstallOptions{}, warnings, err
+	}
+	return InstallOptions{Version: f.Version, File: f}, warnings, nil
+}
+
+// Validate rejects malformed options before any system changes are made.
+func (o InstallOptions) Validate() error {
+	if o.File != nil {
+		if o.File.Backup.Schedule != "" {
+			if err := cron.ValidateCronExpression(o.File.Backup.Schedule); err != nil {
+				return fmt.Errorf("backup.schedule: %w", err)
+			}
+		}
+	}
+	if o.Version == "" {
+		return nil
+	}
+	return validation.ValidateImageTag(o.Version)
 }
 
 func NewInstaller(logger *logging.Logger) *Installer {
@@ -42,7 +97,53 @@ func NewInstaller(logger *logging.Logger) *Installer {
 		docker:     d,
 		database:   db,
 		binaryPath: DefaultBinaryPath,
+		paths:      config.DefaultInstallPaths(),
+		ports:      docker.DefaultPorts(),
+		names:      docker.DefaultNames(),
+	}
+}
+
+// SetInstallPaths installs into paths.DataDir instead of DefaultInstallDir
+// and writes the configuration to paths.EnvFile.
+func (i *Installer) SetInstallPaths(paths config.InstallPaths) {
+	i.paths = paths
+	i.applyInstallPaths()
+}
+
+// SetInstance installs inst instead of the default installation: its data
+// directory, containers and proxy ports.
+func (i *Installer) SetInstance(inst instance.Instance) {
+	i.SetInstallPaths(inst.Paths())
+	i.docker.SetNames(inst.Names())
+	i.docker.SetPorts(inst.Ports)
+	i.ports = inst.Ports
+	i.names = inst.Names()
+	i.instance = inst.Name
+}
+
+// SetSecretRegistry hides the secrets the installer passes to host commands,
+// such as the admin password, from whatever records those commands.
+func (i *Installer) SetSecretRegistry(r executor.SecretRegistry) {
+	i.secrets = r
+}
+
+// applyInstallPaths points the configuration at i.paths.DataDir, moving a
+// backup path that lived under the old install dir along with it.
+func (i *Installer) applyInstallPaths() {
+	data := i.config.GetData()
+	if data.InstallDir == i.paths.DataDir {
+		return
 	}
+	if rel, err := filepath.Rel(data.InstallDir, data.BackupPath); err == nil && !strings.HasPrefix(rel, "..") {
+		data.BackupPath = filepath.Join(i.paths.DataDir, rel)
+	}
+	data.InstallDir = i.paths.DataDir
+	i.config.SetData(data)
+}
+
+// InstallPaths returns where the installation's files live.
+func (i *Installer) InstallPaths() config.InstallPaths {
+	return i.paths
 }
 
 func (i *Installer) GetConfig() *config.Config {
@@ -66,78 +167,186 @@ func (i *Installer) RunWithConfig(cfg *config.Config) error {
 
 // RunCompleteInstallation runs the complete installation process with proper coordination
 func (i *Installer) RunCompleteInstallation() error {
-	totalSteps := 7
+	return i.RunCompleteInstallationWithOptions(InstallOptions{})
+}
 
-	// Step 1: Display welcome message and collect ALL user input upfront
-	i.displayWelcomeMessage()
-	fmt.Println("Please provide the required configuration details:")
-	reader := bufio.NewReader(os.Stdin)
-	i.config = config.NewConfig(i.logger)
-	if err := i.config.CollectFromUser(reader); err != nil {
-		return fmt.Errorf("failed to collect configuration: %w", err)
-	}
+// RunCompleteInstallationWithOptions is like RunCompleteInstallation but
+// applies opts, e.g. pinning the app image to a specific version.
+func (i *Installer) RunCompleteInstallationWithOptions(opts InstallOptions) error {
+	return i.RunCompleteInstallationContext(context.Background(), opts)
+}
 
-	// Step 2: Validate system requirements (no system changes yet)
-	i.logger.Info("Step 1/%d: Checking system requirements", totalSteps)
-	checker := requirements.NewChecker(i.logger)
-	if err := checker.CheckSystemRequirements(); err != nil {
-		return fmt.Errorf("system requirements check failed: %w", err)
-	}
-	i.logger.Success("System requirements verified")
+// InstallFromTarball installs on a host without internet access: the images
+// are loaded from the `docker save` bundle at tarPath instead of being
+// pulled, and the install aborts listing any image the bundle lacks.
+func (i *Installer) InstallFromTarball(ctx context.Context, tarPath string) error {
+	return i.RunCompleteInstallationContext(ctx, InstallOptions{ImageTarball: tarPath})
+}
 
-	// Step 3: Install SQLite
-	i.logger.Info("Step 2/%d: Installing SQLite", totalSteps)
-	if err := i.database.EnsureSQLiteInstalled(); err != nil {
-		return fmt.Errorf("failed to install SQLite: %w", err)
+// RunCompleteInstallationContext is like RunCompleteInstallationWithOptions
+// but aborts the image load when ctx is done.
+func (i *Installer) RunCompleteInstallationContext(ctx context.Context, opts InstallOptions) error {
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("invalid install options: %w", err)
 	}
-	i.logger.Success("SQLite installed")
-
-	// Step 4: Install Docker
-	i.logger.Info("Step 3/%d: Installing Docker", totalSteps)
-	progressChan := make(chan int, 1)
-	go i.showProgress(progressChan, "Docker installation")
-	if err := i.docker.EnsureInstalled(); err != nil {
-		close(progressChan)
-		return fmt.Errorf("failed to install Docker: %w", err)
+	if opts.ImageTarball != "" {
+		opts.SkipNetwork = true
 	}
-	progressChan <- 100
-	close(progressChan)
-	i.logger.Success("Docker installed")
+	i.options = opts
+	i.ctx = ctx
 
-	// Step 5: Configure system
-	i.logger.Info("Step 4/%d: Configuring system", totalSteps)
-	if err := i.configureSystem(); err != nil {
-		return fmt.Errorf("failed to configure system: %w", err)
+	// Step 1: Display welcome message and collect ALL user input upfront
+	i.displayWelcomeMessage()
+	i.config = config.NewConfig(i.logger)
+	steps := i.installSteps()
+	if opts.File != nil {
+		// An install file is the operator's consent to everything it implies.
+		i.config.CollectFromFile(i.runContext(), opts.File)
+		i.docker.SetInstallConsent(func(docker.Distro) bool { return true })
+		steps = append(steps, i.adminStep())
+	} else {
+		fmt.Println("Please provide the required configuration details:")
+		reader := bufio.NewReader(os.Stdin)
+		if err := i.config.CollectFromUser(i.runContext(), reader); err != nil {
+			return fmt.Errorf("failed to collect configuration: %w", err)
+		}
 	}
-	i.logger.Success("System configured")
+	i.applyInstallPaths()
 
-	// Step 6: Deploy application
-	i.logger.Info("Step 5/%d: Deploying application", totalSteps)
-	deployProgressChan := make(chan int, 1)
-	go i.showProgress(deployProgressChan, "Application deployment")
-	if err := i.docker.Deploy(i.config); err != nil {
-		close(deployProgressChan)
-		return fmt.Errorf("failed to deploy application: %w", err)
-	}
-	deployProgressChan <- 100
-	close(deployProgressChan)
-	i.logger.Success("Application deployed")
+	return i.runSteps(steps, opts.OnProgress)
+}
 
-	// Step 7: Setup maintenance
-	i.logger.Info("Step 6/%d: Setting up maintenance", totalSteps)
-	if err := i.setupMaintenance(); err != nil {
-		return fmt.Errorf("failed to setup maintenance: %w", err)
-	}
-	i.logger.Success("Maintenance configured")
+// adminStep creates the install file's admin account once the app is
+// healthy. An account that already exists is left alone.
+func (i *Installer) adminStep() step {
+	return step{StepAdmin, "Creating admin user", func() error {
+		cfg := admin.DefaultConfig()
+		cfg.Paths = i.paths
+		cfg.Names = i.names
+		cfg.Secrets = i.secrets
+		email, password := i.options.File.Admin.Email, i.options.File.Admin.Password
+		if _, err := admin.NewManager(i.logger, cfg).CreateAdminUserIfNotExists(email, password); err != nil {
+			return fmt.Errorf("failed to create admin user %s: %w", email, err)
+		}
+		i.logger.Success("Admin user %s ready", email)
+		return nil
+	}}
+}
 
-	// Step 8: Verify installation
-	i.logger.Info("Step 7/%d: Verifying installation", totalSteps)
-	if _, err := i.VerifyInstallation(); err != nil {
-		return fmt.Errorf("installation verification failed: %w", err)
+// imageStep pulls the app and proxy images, or loads them from the
+// ImageTarball bundle for an offline install.
+func (i *Installer) imageStep() step {
+	if i.options.ImageTarball == "" {
+		return step{StepPull, "Pulling images", func() error {
+			if err := i.docker.PullImages(i.config.GetData()); err != nil {
+				return fmt.Errorf("failed to pull images: %w", err)
+			}
+			i.logger.Success("Images pulled")
+			return nil
+		}}
 	}
-	i.logger.Success("Installation verified")
+	return step{StepPull, "Loading images", func() error {
+		data := i.config.GetData()
+		if err := i.docker.LoadImages(i.runContext(), i.options.ImageTarball, []string{data.AppImage, data.CaddyImage}); err != nil {
+			return fmt.Errorf("failed to load images: %w", err)
+		}
+		i.docker.SetOffline(true)
+		i.logger.Success("Images loaded")
+		return nil
+	}}
+}
 
-	return nil
+// installSteps lists the stages of a complete installation in order. User
+// input has already been collected, so nothing here prompts except the
+// Docker install consent.
+func (i *Installer) installSteps() []step {
+	return []step{
+		{StepPreflight, "Checking system requirements", func() error {
+			// No system changes are made before this passes
+			checker := requirements.NewChecker(i.logger)
+			checker.SetPorts(i.ports.HTTP, i.ports.HTTPS)
+			if err := checker.CheckSystemRequirements(); err != nil {
+				return fmt.Errorf("system requirements check failed: %w", err)
+			}
+			if err := checker.PreflightCheck(requirements.DefaultPreflightOptions(i.config.GetData().InstallDir)); err != nil {
+				return fmt.Errorf("preflight check failed: %w", err)
+			}
+			if !i.options.SkipNetwork {
+				checker.SetRegistryHost(requirements.RegistryHost(i.config.GetData().AppImage))
+				if err := checker.WaitForNetwork(i.runContext(), requirements.DefaultNetworkTimeout); err != nil {
+					return fmt.Errorf("preflight check failed: %w", err)
+				}
+				// A skewed clock breaks certificate issuance and TLS validation
+				if _, err := checker.CheckClockSkew(i.runContext(), requirements.DefaultMaxClockSkew); errors.Is(err, requirements.ErrClockSkew) {
+					if i.options.StrictClock {
+						return fmt.Errorf("preflight check failed: %w", err)
+					}
+					i.logger.Warn("%v", err)
+				} else if err != nil {
+					i.logger.Warn("Could not check the clock: %v", err)
+				}
+			}
+			i.logger.Success("System requirements verified")
+			return nil
+		}},
+		{StepSQLite, "Installing SQLite", func() error {
+			if err := i.database.EnsureSQLiteInstalled(); err != nil {
+				return fmt.Errorf("failed to install SQLite: %w", err)
+			}
+			i.logger.Success("SQLite installed")
+			return nil
+		}},
+		{StepDocker, "Installing Docker", func() error {
+			if err := i.docker.ConfirmInstall(); err != nil {
+				return fmt.Errorf("failed to install Docker: %w", err)
+			}
+			progressChan := make(chan int, 1)
+			go i.showProgress(progressChan, "Docker installation")
+			if err := i.docker.EnsureInstalled(); err != nil {
+				close(progressChan)
+				return fmt.Errorf("failed to install Docker: %w", err)
+			}
+			progressChan <- 100
+			close(progressChan)
+			i.logger.Success("Docker installed")
+			return nil
+		}},
+		{StepConfigure, "Configuring system", func() error {
+			if err := i.configureSystem(); err != nil {
+				return fmt.Errorf("failed to configure system: %w", err)
+			}
+			i.logger.Success("System configured")
+			return nil
+		}},
+		i.imageStep(),
+		{StepUp, "Deploying application", func() error {
+			deployProgressChan := make(chan int, 1)
+			go i.showProgress(deployProgressChan, "Application deployment")
+			if err := i.docker.Deploy(i.config); err != nil {
+				close(deployProgressChan)
+				return fmt.Errorf("failed to deploy application: %w", err)
+			}
+			deployProgressChan <- 100
+			close(deployProgressChan)
+			i.logger.Success("Application deployed")
+			i.recordDeployment()
+			return nil
+		}},
+		{StepMaintenance, "Setting up maintenance", func() error {
+			if err := i.setupMaintenance(); err != nil {
+				return fmt.Errorf("failed to setup maintenance: %w", err)
+			}
+			i.logger.Success("Maintenance configured")
+			return nil
+		}},
+		{StepHealth, "Verifying installation", func() error {
+			if _, err := i.VerifyInstallation(); err != nil {
+				return fmt.Errorf("installation verification failed: %w", err)
+			}
+			i.logger.Success("Installation verified")
+			return nil
+		}},
+	}
 }
 
 // displayWelcomeMessage shows the initial welcome and requirements message
@@ -160,7 +369,7 @@ func (i *Installer) configureSystem() error {
 	}
 	
 	// Handle .env file configuration
-	envFile := filepath.Join(data.InstallDir, ".env")
+	envFile := i.paths.EnvFile
 	if _, err := os.Stat(envFile); os.IsNotExist(err) {
 		// No existing .env file - save the user-provided configuration
 		if err := i.config.SaveToFile(envFile); err != nil {
@@ -174,11 +383,21 @@ func (i *Installer) configureSystem() error {
 	}
 	
 	// Fetch server configuration
-	if err := i.config.FetchFromServer(""); err != nil {
+	if err := i.config.FetchFromServer(i.runContext(), ""); err != nil {
 		i.logger.Warn("Using defaults due to server config fetch failure: %v", err)
 	} else {
 		i.logger.Debug("Server configuration fetched")
 	}
+
+	// Pin the app image after the release config so the pin wins
+	if i.options.Version != "" {
+		if err := i.config.PinAppVersion(i.options.Version); err != nil {
+			return err
+		}
+		if err := i.config.SaveToFile(envFile); err != nil {
+			return fmt.Errorf("failed to save pinned version to %s: %w", envFile, err)
+		}
+	}
 	
 	// Validate final configuration
 	if err := i.config.Validate(); err != nil {
@@ -230,7 +449,14 @@ func (i *Installer) setupMaintenance() error {
 	if err := cronManager.SetupCronJob(); err != nil {
 		return fmt.Errorf("failed to setup cron: %w", err)
 	}
-	
+	if f := i.options.File; f != nil && f.Backup.Schedule != "" {
+		if err := cronManager.InstallBackupSchedule(f.Backup.Schedule); err != nil {
+			return fmt.Errorf("failed to schedule backups: %w", err)
+		}
+	}
+
+	i.setupBootUnit()
+	i.setupFirewall()
 	return nil
 }
 
@@ -291,6 +517,10 @@ func (i *Installer) Run() error {
 	i.logger.Info("Step 3/%d: Setting up Docker", totalSteps)
 	// Step 3: Docker
 	i.logger.Info("Installing Docker...")
+	if err := i.docker.ConfirmInstall(); err != nil {
+		i.logger.Error("Docker installation failed: %v", err)
+		return fmt.Errorf("failed to install Docker: %w", err)
+	}
 	// Show progress indicator for Docker installation
 	progressChan := make(chan int, 1)
 	go i.showProgress(progressChan, "Docker installation")
@@ -309,7 +539,7 @@ func (i *Installer) Run() error {
 	if err := i.createInstallDir(data.InstallDir); err != nil {
 		return fmt.Errorf("failed to create install dir: %w", err)
 	}
-	envFile := filepath.Join(data.InstallDir, ".env")
+	envFile := i.paths.EnvFile
 	if _, err := os.Stat(envFile); os.IsNotExist(err) {
 		// No existing .env file - save the user-provided configuration
 		if err := i.config.SaveToFile(envFile); err != nil {
@@ -344,7 +574,7 @@ func (i *Installer) Run() error {
 	}
 
 	i.logger.Info("Fetching server configuration...")
-	if err := i.config.FetchFromServer(""); err != nil {
+	if err := i.config.FetchFromServer(i.runContext(), ""); err != nil {
 		i.logger.Warn("Using defaults due to server config fetch failure: %v", err)
 	} else {
 		i.logger.Success("Server configuration fetched")
@@ -369,6 +599,7 @@ func (i *Installer) Run() error {
 	deployProgressChan <- 100
 	close(deployProgressChan)
 	i.logger.Success("Deployment completed")
+	i.recordDeployment()
 
 	i.logger.Info("Step 6/%d: Setting up maintenance", totalSteps)
 	// Step 6: Maintenance setup
@@ -385,9 +616,46 @@ func (i *Installer) Run() error {
 	}
 	i.logger.Success("Daily automatic updates configured for 3:00 AM")
 
+	i.setupBootUnit()
+	i.setupFirewall()
 	return nil
 }
 
+// runContext returns the context of the running installation, which
+// RunCompleteInstallationContext sets.
+func (i *Installer) runContext() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
+}
+
+// setupBootUnit installs the systemd unit that starts the stack on boot.
+// Docker's restart policy still covers most reboots, so failures only warn.
+func (i *Installer) setupBootUnit() {
+	opts := systemd.DefaultUnitOptions()
+	opts.InstallDir = i.config.GetData().InstallDir
+	units := systemd.NewManager(i.logger, executor.Default())
+	units.SetInstance(i.instance)
+	if err := units.InstallSystemdUnit(i.runContext(), opts); err != nil {
+		i.logger.Warn("Failed to install systemd unit: %v", err)
+	}
+}
+
+// setupFirewall opens the proxy's ports in ufw or firewalld, including UDP
+// on the HTTPS port since Caddy also serves HTTP/3 there. Hosts often have
+// ports that are already open elsewhere, so failures only warn.
+func (i *Installer) setupFirewall() {
+	if i.options.SkipFirewall {
+		i.logger.Info("Skipping firewall setup; make sure TCP ports %d and %d and UDP port %d are open", i.ports.HTTP, i.ports.HTTPS, i.ports.HTTPS)
+		return
+	}
+	ports := []firewall.Port{firewall.TCP(i.ports.HTTP), firewall.TCP(i.ports.HTTPS), firewall.UDP(i.ports.HTTPS)}
+	if err := firewall.NewManager(i.logger, executor.Default()).ConfigureFirewallContext(i.runContext(), ports...); err != nil {
+		i.logger.Warn("Failed to configure the firewall: %v", err)
+	}
+}
+
 // ListBackups returns available database backups
 func (i *Installer) ListBackups() ([]database.BackupFile, error) {
 	backupDir := i.GetBackupDir()
@@ -451,6 +719,11 @@ func (i *Installer) VerifyInstallation() ([]string, error) {
 		return warnings, fmt.Errorf("Docker containers are not running properly")
 	}
 
+	// A healthy app is still unreachable if the proxy is down
+	if err := i.checkProxy(); err != nil {
+		return warnings, fmt.Errorf("installation verification failed: %w", err)
+	}
+
 	// Skip database check in test environment
 	if os.Getenv("ENV") != "test" {
 		// Check that the database exists
@@ -464,6 +737,34 @@ func (i *Installer) VerifyInstallation() ([]string, error) {
 	return warnings, nil
 }
 
+// proxyCheckAttempts is how many times checkProxy probes the proxy, which
+// may still be binding its ports right after it starts.
+const proxyCheckAttempts = 5
+
+// checkProxy verifies the installation's proxy answers on its ports.
+func (i *Installer) checkProxy() error {
+	ctx := i.runContext()
+	stack := docker.NewStack(i.logger, executor.Default())
+	stack.SetNames(i.names)
+	stack.SetInstancePorts(i.ports)
+	stack.SetEnvFile(i.paths.EnvFile)
+
+	var err error
+	for attempt := 1; attempt <= proxyCheckAttempts; attempt++ {
+		if err = stack.CheckProxy(ctx); err == nil {
+			return nil
+		}
+		if attempt < proxyCheckAttempts {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return err
+}
+
 // checkPort checks if a port is available
 func checkPort(port int) bool {
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
@@ -558,7 +859,7 @@ func (i *Installer) installBinary() error {
 	}
 
 	// Write to destination
-	if err := os.WriteFile(i.binaryPath, sourceData, 0755); err != nil {
+	if err := executor.WriteFile(executor.Default(), i.binaryPath, sourceData, 0755); err != nil {
 		return fmt.Errorf("failed to write binary to %s: %w", i.binaryPath, err)
 	}
 
@@ -603,3 +904,22 @@ func extractBaseDomain(domain string) string {
 	// - "sub.domain.example.org" -> "example.org"
 	return strings.Join(parts[len(parts)-2:], ".")
 }
+
+// recordDeployment adds the deployed image and its digest to the version
+// history so a later rollback has a known-good version to return to and a
+// later start can detect that the image changed underneath it.
+func (i *Installer) recordDeployment() {
+	data := i.config.GetData()
+	if err := updater.RecordDeployment(data.InstallDir, data.AppImage); err != nil {
+		i.logger.Warn("Failed to record version history: %v", err)
+		return
+	}
+	digest, err := i.docker.ImageDigest(data.AppImage)
+	if err != nil {
+		i.logger.Warn("Failed to resolve image digest for %s: %v", data.AppImage, err)
+		return
+	}
+	if err := updater.RecordDigest(data.InstallDir, data.AppImage, digest); err != nil {
+		i.logger.Warn("Failed to record image digest: %v", err)
+	}
+}
@@ -51,6 +51,21 @@ func TestGetMainDBPath(t *testing.T) {
 	assert.Equal(t, expectedPath, dbPath)
 }
 
+func TestSetInstallPaths(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installer := NewInstaller(logger)
+	assert.Equal(t, config.DefaultInstallPaths(), installer.InstallPaths())
+
+	dir := t.TempDir()
+	installer.SetInstallPaths(config.PathsForDir(dir))
+
+	data := installer.GetConfig().GetData()
+	assert.Equal(t, dir, data.InstallDir)
+	assert.Equal(t, filepath.Join(dir, "storage", "backups"), data.BackupPath)
+	assert.Equal(t, filepath.Join(dir, ".env"), installer.InstallPaths().EnvFile)
+	assert.Equal(t, filepath.Join(dir, "storage", "fusionaly-production.db"), installer.GetMainDBPath())
+}
+
 func TestGetBackupDir(t *testing.T) {
 	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
 	installer := NewInstaller(logger)
@@ -149,3 +164,45 @@ func TestBackupValidation(t *testing.T) {
 		assert.Contains(t, err.Error(), "backup file is empty", "Error should indicate empty file")
 	})
 }
+
+func TestInstallOptionsValidate(t *testing.T) {
+	assert.NoError(t, InstallOptions{}.Validate())
+	assert.NoError(t, InstallOptions{Version: "1.2.3"}.Validate())
+	assert.NoError(t, InstallOptions{Version: "v1.2.3-rc.1"}.Validate())
+	assert.Error(t, InstallOptions{Version: "1.2.3 beta"}.Validate())
+	assert.Error(t, InstallOptions{Version: "repo:1.2.3"}.Validate())
+}
+
+func TestRunCompleteInstallationRejectsMalformedVersion(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	installer := NewInstaller(logger)
+
+	err := installer.RunCompleteInstallationWithOptions(InstallOptions{Version: "../latest"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid install options")
+}
+
+func TestInstallOptionsFromFile(t *testing.T) {
+	t.Setenv("FUSIONALY_ADMIN_PASSWORD", "s3cret-Passw0rd")
+	path := filepath.Join(t.TempDir(), "fusionaly.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`domain: analytics.example.com
+version: 1.2.3
+admin:
+  email: admin@example.com
+  password: ${FUSIONALY_ADMIN_PASSWORD}
+backup:
+  schedule: "0 2 * * *"
+`), 0o600))
+
+	opts, warnings, err := InstallOptionsFromFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, "1.2.3", opts.Version)
+	require.NotNil(t, opts.File)
+	assert.Equal(t, "admin@example.com", opts.File.Admin.Email)
+	assert.Equal(t, "s3cret-Passw0rd", opts.File.Admin.Password)
+	assert.NoError(t, opts.Validate())
+
+	opts.File.Backup.Schedule = "every night"
+	assert.Error(t, opts.Validate())
+}
@@ -0,0 +1,43 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// MetricsSink receives the timing and outcome of every install step, e.g.
+// to forward them to an operator's own monitoring. Nothing is collected
+// unless InstallOptions.Metrics is set.
+type MetricsSink interface {
+	RecordDuration(step string, d time.Duration)
+	RecordResult(step string, ok bool)
+}
+
+// NopMetrics discards every measurement.
+type NopMetrics struct{}
+
+func (NopMetrics) RecordDuration(string, time.Duration) {}
+func (NopMetrics) RecordResult(string, bool)            {}
+
+// StdoutMetrics prints one line per measurement, such as
+// "metric step=pull duration_ms=5210" and "metric step=pull ok=true".
+type StdoutMetrics struct {
+	Out io.Writer // Destination; nil means os.Stdout
+}
+
+func (m StdoutMetrics) RecordDuration(step string, d time.Duration) {
+	fmt.Fprintf(m.out(), "metric step=%s duration_ms=%d\n", step, d.Milliseconds())
+}
+
+func (m StdoutMetrics) RecordResult(step string, ok bool) {
+	fmt.Fprintf(m.out(), "metric step=%s ok=%t\n", step, ok)
+}
+
+func (m StdoutMetrics) out() io.Writer {
+	if m.Out == nil {
+		return os.Stdout
+	}
+	return m.Out
+}
@@ -0,0 +1,61 @@
+package installer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSink records every measurement it receives.
+type fakeSink struct {
+	durations []string
+	results   map[string]bool
+}
+
+func (f *fakeSink) RecordDuration(step string, d time.Duration) {
+	f.durations = append(f.durations, step)
+}
+
+func (f *fakeSink) RecordResult(step string, ok bool) {
+	if f.results == nil {
+		f.results = map[string]bool{}
+	}
+	f.results[step] = ok
+}
+
+func TestRunStepsRecordsMetrics(t *testing.T) {
+	i := quietInstaller()
+	sink := &fakeSink{}
+	i.options.Metrics = sink
+
+	pullErr := errors.New("registry unavailable")
+	err := i.runSteps(stubSteps(i.installSteps(), StepPull, pullErr), nil)
+	require.ErrorIs(t, err, pullErr)
+
+	assert.Equal(t, []string{StepPreflight, StepSQLite, StepDocker, StepConfigure, StepPull}, sink.durations)
+	assert.Equal(t, map[string]bool{
+		StepPreflight: true,
+		StepSQLite:    true,
+		StepDocker:    true,
+		StepConfigure: true,
+		StepPull:      false,
+	}, sink.results)
+}
+
+func TestRunStepsWithoutMetrics(t *testing.T) {
+	i := quietInstaller()
+	require.NoError(t, i.runSteps(stubSteps(i.installSteps(), "", nil), nil))
+}
+
+func TestStdoutMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	m := StdoutMetrics{Out: &buf}
+	m.RecordDuration(StepPull, 5210*time.Millisecond)
+	m.RecordResult(StepPull, true)
+
+	assert.Equal(t, "metric step=pull duration_ms=5210\nmetric step=pull ok=true\n", buf.String())
+}
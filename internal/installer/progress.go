@@ -0,0 +1,72 @@
+package installer
+
+import "time"
+
+// StepStatus is the state an install step reports in an Event.
+type StepStatus string
+
+const (
+	StatusStarted   StepStatus = "started"
+	StatusCompleted StepStatus = "completed"
+	StatusFailed    StepStatus = "failed"
+)
+
+// Install steps, in the order a complete installation runs them. Database
+// migrations run inside the app container when it starts, so they are not a
+// separate step. The admin account is normally created from the dashboard;
+// StepAdmin only runs when an install file declares one.
+const (
+	StepPreflight   = "preflight"
+	StepSQLite      = "sqlite"
+	StepDocker      = "docker"
+	StepConfigure   = "configure"
+	StepPull        = "pull"
+	StepUp          = "up"
+	StepMaintenance = "maintenance"
+	StepHealth      = "health"
+	StepAdmin       = "admin"
+)
+
+// Event reports progress of one install step.
+type Event struct {
+	Step    string
+	Status  StepStatus
+	Percent int   // Overall installation progress, 0-100
+	Err     error // Set when Status is StatusFailed
+}
+
+// step is one stage of an installation.
+type step struct {
+	name  string
+	title string
+	run   func() error
+}
+
+// runSteps runs steps in order, logging each and reporting it to
+// onProgress and the configured MetricsSink, and stops at the first failure.
+func (i *Installer) runSteps(steps []step, onProgress func(Event)) error {
+	emit := func(e Event) {
+		if onProgress != nil {
+			onProgress(e)
+		}
+	}
+	metrics := i.options.Metrics
+	if metrics == nil {
+		metrics = NopMetrics{}
+	}
+	total := len(steps)
+	for n, s := range steps {
+		i.logger.Info("Step %d/%d: %s", n+1, total, s.title)
+		emit(Event{Step: s.name, Status: StatusStarted, Percent: n * 100 / total})
+		start := time.Now()
+		err := s.run()
+		metrics.RecordDuration(s.name, time.Since(start))
+		metrics.RecordResult(s.name, err == nil)
+		if err != nil {
+			emit(Event{Step: s.name, Status: StatusFailed, Percent: n * 100 / total, Err: err})
+			return err
+		}
+		emit(Event{Step: s.name, Status: StatusCompleted, Percent: (n + 1) * 100 / total})
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package installer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fusionaly-installer/internal/logging"
+)
+
+func quietInstaller() *Installer {
+	return NewInstaller(logging.NewLogger(logging.Config{Level: "error", Quiet: true}))
+}
+
+// stubSteps keeps the real step names and order but replaces their work.
+func stubSteps(steps []step, failAt string, err error) []step {
+	stubbed := make([]step, len(steps))
+	for n, s := range steps {
+		s.run = func() error { return nil }
+		if s.name == failAt {
+			s.run = func() error { return err }
+		}
+		stubbed[n] = s
+	}
+	return stubbed
+}
+
+func TestInstallStepsOrder(t *testing.T) {
+	var names []string
+	for _, s := range quietInstaller().installSteps() {
+		names = append(names, s.name)
+	}
+	assert.Equal(t, []string{StepPreflight, StepSQLite, StepDocker, StepConfigure, StepPull, StepUp, StepMaintenance, StepHealth}, names)
+}
+
+func TestRunStepsEmitsEventsForSuccessfulInstall(t *testing.T) {
+	i := quietInstaller()
+	var events []Event
+	err := i.runSteps(stubSteps(i.installSteps(), "", nil), func(e Event) { events = append(events, e) })
+	require.NoError(t, err)
+
+	steps := i.installSteps()
+	require.Len(t, events, 2*len(steps))
+	for n, s := range steps {
+		assert.Equal(t, Event{Step: s.name, Status: StatusStarted, Percent: n * 100 / len(steps)}, events[2*n])
+		assert.Equal(t, Event{Step: s.name, Status: StatusCompleted, Percent: (n + 1) * 100 / len(steps)}, events[2*n+1])
+	}
+	assert.Equal(t, 100, events[len(events)-1].Percent)
+}
+
+func TestRunStepsEmitsFailedEventForFailingStep(t *testing.T) {
+	i := quietInstaller()
+	pullErr := errors.New("registry unavailable")
+	var events []Event
+	err := i.runSteps(stubSteps(i.installSteps(), StepPull, pullErr), func(e Event) { events = append(events, e) })
+	require.ErrorIs(t, err, pullErr)
+
+	last := events[len(events)-1]
+	assert.Equal(t, StepPull, last.Step)
+	assert.Equal(t, StatusFailed, last.Status)
+	assert.ErrorIs(t, last.Err, pullErr)
+	for _, e := range events {
+		assert.NotEqual(t, StepUp, e.Step, "steps after the failure must not run")
+	}
+	assert.Equal(t, Event{Step: StepConfigure, Status: StatusCompleted, Percent: 50}, events[len(events)-3])
+}
+
+func TestRunStepsWithoutHandler(t *testing.T) {
+	i := quietInstaller()
+	assert.NoError(t, i.runSteps(stubSteps(i.installSteps(), "", nil), nil))
+}
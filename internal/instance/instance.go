@@ -0,0 +1,196 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
+)
+
+const (
+	// DefaultRegistry records the named instances on this host and the
+	// ports assigned to them.
+	DefaultRegistry = "/etc/fusionaly/instances.json"
+
+	// firstHTTPPort and firstHTTPSPort are where port allocation for named
+	// instances starts; the default instance keeps 80 and 443.
+	firstHTTPPort  = 8080
+	firstHTTPSPort = 8443
+)
+
+// ErrUnknownInstance is returned by Lookup for a name never created.
+var ErrUnknownInstance = errors.New("unknown instance")
+
+// namePattern keeps instance names usable in container names and paths.
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,30}[a-z0-9]$|^[a-z0-9]$`)
+
+// reservedPrefixes start the default installation's container and network
+// names after its project. docker's name filters match substrings, so an
+// instance named e.g. "app", whose containers are fusionaly-app-app-1, would
+// be mistaken for the default installation's app containers.
+var reservedPrefixes = []string{"app", "caddy", "network"}
+
+// Instance is one Fusionaly installation on the host. The default instance
+// has an empty Name and uses the standard paths, container names and ports;
+// every named instance gets its own docker project, data directory and
+// proxy ports so it never touches another's containers or files.
+type Instance struct {
+	Name  string       `json:"name"`
+	Ports docker.Ports `json:"ports"`
+}
+
+// Default returns the standard installation.
+func Default() Instance {
+	return Instance{Ports: docker.DefaultPorts()}
+}
+
+// ValidateName rejects names that cannot prefix a container or directory.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q: use 1-32 lowercase letters, digits or dashes", name)
+	}
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("invalid instance name %q: names starting with %s would clash with the default installation's containers", name, strings.Join(reservedPrefixes, ", "))
+		}
+	}
+	return nil
+}
+
+// IsDefault reports whether i is the standard installation.
+func (i Instance) IsDefault() bool {
+	return i.Name == ""
+}
+
+// ProjectName prefixes every docker object of the instance.
+func (i Instance) ProjectName() string {
+	if i.IsDefault() {
+		return docker.DefaultProject
+	}
+	return docker.DefaultProject + "-" + i.Name
+}
+
+// DataDir is where the instance keeps its database, logs and .env file.
+func (i Instance) DataDir() string {
+	if i.IsDefault() {
+		return config.DefaultDataDir
+	}
+	return filepath.Join(filepath.Dir(config.DefaultDataDir), i.ProjectName())
+}
+
+// Paths returns the instance's file locations.
+func (i Instance) Paths() config.InstallPaths {
+	return config.PathsForDir(i.DataDir())
+}
+
+// Names returns the instance's containers and network.
+func (i Instance) Names() docker.Names {
+	return docker.NamesFor(i.ProjectName())
+}
+
+// Registry stores the named instances in a JSON file.
+type Registry struct {
+	path string
+}
+
+// NewRegistry returns the registry kept at path.
+func NewRegistry(path string) *Registry {
+	return &Registry{path: path}
+}
+
+func (r *Registry) load() ([]Instance, error) {
+	var instances []Instance
+	data, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &instances); err != nil {
+		return nil, fmt.Errorf("failed to parse instance registry %s: %w", r.path, err)
+	}
+	return instances, nil
+}
+
+func (r *Registry) save(instances []Instance) error {
+	sort.Slice(instances, func(a, b int) bool { return instances[a].Name < instances[b].Name })
+	data, err := json.MarshalIndent(instances, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode instance registry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(r.path), err)
+	}
+	if err := executor.WriteFile(executor.Default(), r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write instance registry: %w", err)
+	}
+	return nil
+}
+
+// List returns the named instances, sorted by name.
+func (r *Registry) List() ([]Instance, error) {
+	return r.load()
+}
+
+// Lookup returns the instance called name; an empty name is the default
+// instance.
+func (r *Registry) Lookup(name string) (Instance, error) {
+	if name == "" {
+		return Default(), nil
+	}
+	instances, err := r.load()
+	if err != nil {
+		return Instance{}, err
+	}
+	for _, inst := range instances {
+		if inst.Name == name {
+			return inst, nil
+		}
+	}
+	return Instance{}, fmt.Errorf("%w %q", ErrUnknownInstance, name)
+}
+
+// Create registers a new named instance with the lowest proxy ports no
+// other instance uses. Creating an existing name returns it unchanged.
+func (r *Registry) Create(name string) (Instance, error) {
+	if name == "" {
+		return Default(), nil
+	}
+	if err := ValidateName(name); err != nil {
+		return Instance{}, err
+	}
+	instances, err := r.load()
+	if err != nil {
+		return Instance{}, err
+	}
+	used := map[int]bool{}
+	for _, inst := range instances {
+		if inst.Name == name {
+			return inst, nil
+		}
+		used[inst.Ports.HTTP] = true
+		used[inst.Ports.HTTPS] = true
+	}
+
+	inst := Instance{Name: name}
+	for n := 0; ; n++ {
+		http, https := firstHTTPPort+n, firstHTTPSPort+n
+		if !used[http] && !used[https] {
+			inst.Ports = docker.Ports{HTTP: http, HTTPS: https}
+			break
+		}
+	}
+	if err := r.save(append(instances, inst)); err != nil {
+		return Instance{}, err
+	}
+	return inst, nil
+}
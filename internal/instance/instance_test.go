@@ -0,0 +1,97 @@
+package instance
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"fusionaly-installer/internal/docker"
+)
+
+func TestDefaultInstanceKeepsStandardLayout(t *testing.T) {
+	inst := Default()
+	if inst.ProjectName() != "fusionaly" || inst.DataDir() != "/opt/fusionaly" {
+		t.Errorf("default instance moved: project %q, data dir %q", inst.ProjectName(), inst.DataDir())
+	}
+	if inst.Names() != docker.DefaultNames() {
+		t.Errorf("default names = %+v", inst.Names())
+	}
+	if inst.Ports != docker.DefaultPorts() {
+		t.Errorf("default ports = %+v", inst.Ports)
+	}
+}
+
+func TestTwoInstancesDoNotCollide(t *testing.T) {
+	r := NewRegistry(filepath.Join(t.TempDir(), "instances.json"))
+	a, err := r.Create("acme")
+	if err != nil {
+		t.Fatalf("Create acme: %v", err)
+	}
+	b, err := r.Create("globex")
+	if err != nil {
+		t.Fatalf("Create globex: %v", err)
+	}
+
+	if a.ProjectName() != "fusionaly-acme" || b.ProjectName() != "fusionaly-globex" {
+		t.Errorf("project names = %q, %q", a.ProjectName(), b.ProjectName())
+	}
+	if a.DataDir() != "/opt/fusionaly-acme" || b.DataDir() != "/opt/fusionaly-globex" {
+		t.Errorf("data dirs = %q, %q", a.DataDir(), b.DataDir())
+	}
+	if a.Paths().EnvFile == b.Paths().EnvFile {
+		t.Errorf("instances share %s", a.Paths().EnvFile)
+	}
+
+	seen := map[string]bool{}
+	for _, inst := range []Instance{Default(), a, b} {
+		n := inst.Names()
+		for _, name := range []string{n.Network, n.Caddy, n.AppPrimary, n.AppSecondary} {
+			if seen[name] {
+				t.Errorf("docker object %s is shared between instances", name)
+			}
+			seen[name] = true
+		}
+	}
+
+	ports := map[int]bool{80: true, 443: true}
+	for _, p := range []int{a.Ports.HTTP, a.Ports.HTTPS, b.Ports.HTTP, b.Ports.HTTPS} {
+		if ports[p] {
+			t.Errorf("port %d assigned twice: %+v %+v", p, a.Ports, b.Ports)
+		}
+		ports[p] = true
+	}
+}
+
+func TestRegistryPersistsInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "etc", "instances.json")
+	created, err := NewRegistry(path).Create("acme")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	r := NewRegistry(path)
+	again, err := r.Create("acme")
+	if err != nil || again != created {
+		t.Errorf("recreating returned %+v, %v; want %+v", again, err, created)
+	}
+	found, err := r.Lookup("acme")
+	if err != nil || found != created {
+		t.Errorf("Lookup = %+v, %v; want %+v", found, err, created)
+	}
+	if _, err := r.Lookup("initech"); !errors.Is(err, ErrUnknownInstance) {
+		t.Errorf("expected ErrUnknownInstance, got %v", err)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"acme", "a", "client-2"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Acme", "-acme", "acme-", "acme/../etc", "a b", "app", "app-2", "caddy", "network"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) should fail", name)
+		}
+	}
+}
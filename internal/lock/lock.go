@@ -0,0 +1,71 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// FileName is the lock file created inside the install directory.
+const FileName = ".fusionaly.lock"
+
+// ErrAlreadyRunning is returned when another installer process holds the lock.
+var ErrAlreadyRunning = errors.New("another fusionaly command is already running")
+
+// AcquireLock takes an exclusive lock on dir/FileName so mutating commands
+// never overlap. The lock is an flock(2) on the open file, so the kernel drops
+// it when the process exits for any reason, including a panic, a signal or
+// os.Exit skipping deferred calls. release is safe to call more than once.
+func AcquireLock(dir string) (release func(), err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory %s: %w", dir, err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		holder := readHolder(f)
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			if holder != "" {
+				return nil, fmt.Errorf("%w (pid %s holds %s)", ErrAlreadyRunning, holder, path)
+			}
+			return nil, fmt.Errorf("%w (%s is held)", ErrAlreadyRunning, path)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// Record the holder so a blocked command can say who it is waiting on.
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.Truncate(0)
+			syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+			f.Close()
+		})
+	}, nil
+}
+
+// readHolder returns the PID written by the process holding the lock.
+func readHolder(f *os.File) string {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid := strings.TrimSpace(string(buf[:n]))
+	if _, err := strconv.Atoi(pid); err != nil {
+		return ""
+	}
+	return pid
+}
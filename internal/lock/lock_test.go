@@ -0,0 +1,50 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAcquireLockIsExclusive(t *testing.T) {
+	dir := t.TempDir()
+
+	release, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("first AcquireLock: %v", err)
+	}
+
+	_, err = AcquireLock(dir)
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second AcquireLock error = %v, want ErrAlreadyRunning", err)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(os.Getpid())) {
+		t.Errorf("error %q should name the holding pid", err)
+	}
+
+	release()
+	release() // a second release is a no-op
+
+	again, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock after release: %v", err)
+	}
+	again()
+}
+
+func TestAcquireLockCreatesDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "opt", "fusionaly")
+
+	release, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	defer release()
+
+	if _, err := os.Stat(filepath.Join(dir, FileName)); err != nil {
+		t.Errorf("lock file not created: %v", err)
+	}
+}
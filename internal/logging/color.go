@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
+)
+
+// ANSI color codes used for each level in text mode.
+const (
+	colorRed    = 31
+	colorYellow = 33
+	colorCyan   = 36
+	colorGray   = 37
+)
+
+// colorEnabled decides whether text output gets ANSI colors: Config.Color
+// forces the choice, otherwise colors are used only when out is a terminal
+// and NO_COLOR (https://no-color.org) is unset.
+func colorEnabled(config Config, out *os.File) bool {
+	if config.Color != nil {
+		return *config.Color
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(out.Fd()))
+}
+
+func levelColor(level logrus.Level) int {
+	switch level {
+	case logrus.DebugLevel, logrus.TraceLevel:
+		return colorGray
+	case logrus.WarnLevel:
+		return colorYellow
+	case logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel:
+		return colorRed
+	}
+	return colorCyan
+}
+
+// textFormatter renders console lines as "LEVEL[15:04:05] message key=value",
+// coloring the level and field names when color is set.
+type textFormatter struct {
+	color bool
+}
+
+func (f *textFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b bytes.Buffer
+	level := strings.ToUpper(entry.Level.String())
+	paint := func(s string) string {
+		if !f.color {
+			return s
+		}
+		return fmt.Sprintf("\x1b[%dm%s\x1b[0m", levelColor(entry.Level), s)
+	}
+
+	fmt.Fprintf(&b, "%s[%s] %s", paint(level), entry.Time.Format("15:04:05"), entry.Message)
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := fmt.Sprint(entry.Data[k])
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", paint(k), value)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
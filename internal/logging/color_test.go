@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func logLines(t *testing.T, config Config) string {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := NewLogger(config)
+	logger.SetOutput(&buf)
+	logger.Warn("disk almost full")
+	logger.Error("backup failed")
+	return buf.String()
+}
+
+func TestColorForcedOn(t *testing.T) {
+	on := true
+	out := logLines(t, Config{Color: &on})
+
+	if !strings.Contains(out, "\x1b[33mWARNING\x1b[0m") {
+		t.Errorf("expected yellow warning, got %q", out)
+	}
+	if !strings.Contains(out, "\x1b[31mERROR\x1b[0m") {
+		t.Errorf("expected red error, got %q", out)
+	}
+}
+
+func TestColorForcedOff(t *testing.T) {
+	off := false
+	out := logLines(t, Config{Color: &off})
+
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no escape codes, got %q", out)
+	}
+	if !strings.Contains(out, "WARNING[") || !strings.Contains(out, "disk almost full") {
+		t.Errorf("plain output lost the level or message: %q", out)
+	}
+}
+
+func TestColorAutoDisabled(t *testing.T) {
+	t.Run("NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		if colorEnabled(Config{}, os.Stdout) {
+			t.Error("NO_COLOR should disable colors")
+		}
+	})
+
+	t.Run("not a terminal", func(t *testing.T) {
+		f, err := os.CreateTemp(t.TempDir(), "out")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if colorEnabled(Config{}, f) {
+			t.Error("a regular file is not a terminal")
+		}
+	})
+
+	t.Run("forced on beats NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		on := true
+		if !colorEnabled(Config{Color: &on}, os.Stdout) {
+			t.Error("Config.Color should override NO_COLOR")
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package logging
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -15,56 +17,135 @@ type Config struct {
 	LogDir  string
 	Quiet   bool
 	LogFile string // Specify the log file name
+	Format  string // "text" (default) or "json"
+	// Color forces ANSI level colors in text mode on or off. nil colors the
+	// output only when stdout is a terminal and NO_COLOR is unset.
+	Color *bool
+
+	// Optional size-rotated log file. When FilePath is set, entries are also
+	// written there; FileOnly stops them from going to stdout as well.
+	FilePath   string
+	MaxSizeMB  int // Rotate once the file exceeds this size (default 10)
+	MaxBackups int // Number of rotated files (.1, .2, ...) to keep (default 3)
+	FileOnly   bool
 }
 
 type Logger struct {
 	*logrus.Logger
 	config      Config // Store the configuration
 	fileLogging bool
+	fields      logrus.Fields // Attached to every entry; never mutated after creation
 }
 
 func NewLogger(config Config) *Logger {
 	logger := logrus.New()
 	logger.SetOutput(os.Stdout)
-	logger.SetFormatter(&logrus.TextFormatter{
-		DisableTimestamp:       false,      // Enable timestamps for console logs
-		TimestampFormat:        "15:04:05", // Use a short time format (HH:MM:SS)
-		DisableColors:          false,      // Keep colors for console logs
-		DisableQuote:           true,
-		ForceColors:            true, // Ensure colors even if output is redirected
-		FullTimestamp:          true,
-		DisableLevelTruncation: true,
-		PadLevelText:           false,
-		FieldMap: logrus.FieldMap{
-			logrus.FieldKeyLevel: "", // Remove the level prefix
-			logrus.FieldKeyMsg:   "", // Remove the msg prefix
-			logrus.FieldKeyTime:  "", // We'll prepend the timestamp manually
-		},
-	})
+	if config.Format == "json" {
+		logger.SetFormatter(newJSONFormatter())
+	} else {
+		logger.SetFormatter(newTextFormatter(colorEnabled(config, os.Stdout)))
+	}
+
+	level, err := parseLevel(ResolveLevel(config.Level, config.Verbose, config.Quiet))
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	logger.SetLevel(level)
+
+	if config.FilePath != "" {
+		attachRotatingFile(logger, config)
+	}
+
+	return &Logger{
+		Logger:      logger,
+		config:      config,
+		fileLogging: false,
+	}
+}
+
+// attachRotatingFile mirrors every entry to config.FilePath. The file never
+// gets terminal colors, and uses JSON when that format was requested.
+func attachRotatingFile(logger *logrus.Logger, config Config) {
+	writer, err := newRotatingFile(config.FilePath, config.MaxSizeMB, config.MaxBackups)
+	if err != nil {
+		logger.Errorf("Failed to open log file %s: %v", config.FilePath, err)
+		return
+	}
+
+	var formatter logrus.Formatter = &logrus.TextFormatter{
+		DisableColors:   true,
+		FullTimestamp:   true,
+		TimestampFormat: time.RFC3339,
+	}
+	if config.Format == "json" {
+		formatter = newJSONFormatter()
+	}
+	logger.AddHook(&FileHook{Writer: writer, Formatter: formatter})
+
+	if config.FileOnly {
+		logger.SetOutput(io.Discard)
+	}
+}
 
-	switch config.Level {
+// parseLevel maps the level names accepted in Config.Level to logrus levels.
+func parseLevel(level string) (logrus.Level, error) {
+	switch level {
 	case "debug":
-		logger.SetLevel(logrus.DebugLevel)
+		return logrus.DebugLevel, nil
 	case "info":
-		logger.SetLevel(logrus.InfoLevel)
+		return logrus.InfoLevel, nil
 	case "warn":
-		logger.SetLevel(logrus.WarnLevel)
+		return logrus.WarnLevel, nil
 	case "error":
-		logger.SetLevel(logrus.ErrorLevel)
-	default:
-		logger.SetLevel(logrus.InfoLevel)
+		return logrus.ErrorLevel, nil
 	}
-	if config.Verbose {
-		logger.SetLevel(logrus.DebugLevel)
+	return logrus.InfoLevel, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", level)
+}
+
+// ResolveLevel returns the level to log at given a configured level and the
+// --verbose/--quiet switches: verbose means "debug" and quiet means "error",
+// with verbose winning when both are set. Without either, level is used,
+// defaulting to "info" when empty.
+func ResolveLevel(level string, verbose, quiet bool) string {
+	switch {
+	case verbose:
+		return "debug"
+	case quiet:
+		return "error"
+	case level == "":
+		return "info"
 	}
-	if config.Quiet {
-		logger.SetLevel(logrus.ErrorLevel)
+	return level
+}
+
+// SetLevel changes the active level at runtime. The level is stored
+// atomically, so it is safe to call while other goroutines are logging; it is
+// shared with every child created by With. An invalid level returns an error
+// and leaves the current level unchanged.
+func (l *Logger) SetLevel(level string) error {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
 	}
+	l.Logger.SetLevel(parsed)
+	return nil
+}
 
-	return &Logger{
-		Logger:      logger,
-		config:      config,
-		fileLogging: false,
+// newTextFormatter returns the console formatter used by default.
+func newTextFormatter(color bool) logrus.Formatter {
+	return &textFormatter{color: color}
+}
+
+// newJSONFormatter returns a formatter emitting one JSON object per line with
+// ts, level and msg keys plus any structured fields.
+func newJSONFormatter() logrus.Formatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyTime:  "ts",
+			logrus.FieldKeyLevel: "level",
+			logrus.FieldKeyMsg:   "msg",
+		},
 	}
 }
 
@@ -124,33 +205,54 @@ func (h *FileHook) Fire(entry *logrus.Entry) error {
 	return err
 }
 
+// With returns a child logger that adds fields to every message it logs, on
+// top of any fields already carried by l. The parent is left untouched.
+func (l *Logger) With(fields map[string]any) *Logger {
+	merged := make(logrus.Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	child := *l
+	child.fields = merged
+	return &child
+}
+
+// entry returns a logrus entry carrying the logger's structured fields.
+func (l *Logger) entry() *logrus.Entry {
+	return l.Logger.WithFields(l.fields)
+}
+
 func (l *Logger) Debug(format string, args ...interface{}) {
-	l.Logger.Debugf(format, args...)
+	l.entry().Debugf(format, args...)
 }
 
 func (l *Logger) Info(format string, args ...interface{}) {
-	l.Logger.Infof(format, args...)
+	l.entry().Infof(format, args...)
 }
 
 func (l *Logger) Warn(format string, args ...interface{}) {
-	l.Logger.Warnf(format, args...)
+	l.entry().Warnf(format, args...)
 }
 
 func (l *Logger) Error(format string, args ...interface{}) {
-	l.Logger.Errorf(format, args...)
+	l.entry().Errorf(format, args...)
 }
 
 func (l *Logger) Success(format string, args ...interface{}) {
-	l.Logger.Infof("✔ "+format, args...)
+	l.entry().Infof("✔ "+format, args...)
 	if l.fileLogging {
-		l.Logger.WithField("status", "success").Infof(format, args...)
+		l.entry().WithField("status", "success").Infof(format, args...)
 	}
 }
 
 func (l *Logger) Step(step, total int, format string, args ...interface{}) {
-	l.Logger.Infof("➜ Step %d/%d: "+format, append([]interface{}{step, total}, args...)...)
+	l.entry().Infof("➜ Step %d/%d: "+format, append([]interface{}{step, total}, args...)...)
 	if l.fileLogging {
-		l.Logger.WithFields(logrus.Fields{
+		l.entry().WithFields(logrus.Fields{
 			"step":  step,
 			"total": total,
 		}).Infof(format, args...)
@@ -159,7 +261,7 @@ func (l *Logger) Step(step, total int, format string, args ...interface{}) {
 
 func (l *Logger) InfoWithTime(format string, args ...interface{}) {
 	// For console output, Logrus's TextFormatter already includes the timestamp
-	l.Logger.Infof(format, args...)
+	l.entry().Infof(format, args...)
 }
 
 func (l *Logger) GetVerbose() bool {
@@ -177,5 +279,6 @@ func DefaultConfig() Config {
 		LogDir:  "",
 		Quiet:   false,
 		LogFile: "", // Default to empty, will use fusionaly-cli.log
+		Format:  "text",
 	}
 }
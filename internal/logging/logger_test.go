@@ -0,0 +1,260 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(Config{Level: "debug", Format: "json"})
+	logger.SetOutput(&buf)
+
+	logger.WithField("step", "install").Infof("hello %s", "world")
+
+	var entry map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("output is not a JSON object: %v\n%s", err, buf.String())
+	}
+	for _, key := range []string{"ts", "level", "msg", "step"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("missing %q in %v", key, entry)
+		}
+	}
+	if entry["msg"] != "hello world" || entry["level"] != "info" || entry["step"] != "install" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestJSONFormatOneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(Config{Format: "json"})
+	logger.SetOutput(&buf)
+
+	logger.Info("first")
+	logger.Warn("second")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Errorf("line is not JSON: %q", line)
+		}
+	}
+}
+
+func TestTextFormatIsDefault(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(Config{})
+	logger.SetOutput(&buf)
+
+	logger.Info("plain message")
+
+	out := buf.String()
+	if strings.HasPrefix(strings.TrimSpace(out), "{") {
+		t.Errorf("default format should be text, got %q", out)
+	}
+	if !strings.Contains(out, "plain message") {
+		t.Errorf("missing message in %q", out)
+	}
+}
+
+func TestRotatingFileRollsOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "install.log")
+	r, err := newRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer r.Close()
+	r.maxSize = 100
+
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 5; i++ {
+		if _, err := r.Write(line); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, found %s.3", path)
+	}
+}
+
+func TestLoggerWritesToRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "install.log")
+	logger := NewLogger(Config{FilePath: path, MaxSizeMB: 1, MaxBackups: 1, FileOnly: true})
+
+	msg := strings.Repeat("y", 1024)
+	for i := 0; i < 1100; i++ {
+		logger.Info("%s", msg)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected rotated backup: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if strings.Contains(string(data), "\x1b[") {
+		t.Error("log file should not contain terminal color codes")
+	}
+}
+
+func TestWithInheritsAndIsolatesFields(t *testing.T) {
+	var buf bytes.Buffer
+	parent := NewLogger(Config{Format: "json"})
+	parent.SetOutput(&buf)
+
+	child := parent.With(map[string]any{"step": "install"})
+	grandchild := child.With(map[string]any{"container": "fusionaly-app-1"})
+	sibling := child.With(map[string]any{"step": "update"})
+
+	decode := func() map[string]any {
+		t.Helper()
+		var entry map[string]any
+		if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+		}
+		buf.Reset()
+		return entry
+	}
+
+	grandchild.Info("pulling image")
+	entry := decode()
+	if entry["step"] != "install" || entry["container"] != "fusionaly-app-1" {
+		t.Errorf("grandchild should inherit fields, got %v", entry)
+	}
+
+	sibling.Info("override")
+	if entry := decode(); entry["step"] != "update" {
+		t.Errorf("sibling should override step, got %v", entry)
+	}
+
+	child.Info("child")
+	entry = decode()
+	if entry["step"] != "install" {
+		t.Errorf("child step changed: %v", entry)
+	}
+	if _, ok := entry["container"]; ok {
+		t.Errorf("child must not see grandchild fields: %v", entry)
+	}
+
+	parent.Info("parent")
+	entry = decode()
+	if _, ok := entry["step"]; ok {
+		t.Errorf("parent must not see child fields: %v", entry)
+	}
+}
+
+func TestWithRendersKeyValueInText(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(Config{})
+	logger.SetOutput(&buf)
+
+	logger.With(map[string]any{"request_id": "abc123"}).Info("tagged")
+
+	plain := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+	if !strings.Contains(plain, "request_id=abc123") {
+		t.Errorf("expected key=value in text output, got %q", buf.String())
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(Config{Level: "info"})
+	logger.SetOutput(&buf)
+
+	logger.Debug("hidden")
+	if err := logger.SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	logger.Debug("visible")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "visible") {
+		t.Errorf("unexpected output after level change: %q", out)
+	}
+}
+
+func TestSetLevelInvalidKeepsCurrent(t *testing.T) {
+	logger := NewLogger(Config{Level: "warn"})
+	if err := logger.SetLevel("loud"); err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+	if got := logger.GetLevel(); got != logrus.WarnLevel {
+		t.Errorf("level = %v, want warn", got)
+	}
+}
+
+func TestSetLevelConcurrent(t *testing.T) {
+	logger := NewLogger(Config{Level: "info"})
+	logger.SetOutput(io.Discard)
+	child := logger.With(map[string]any{"step": "install"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				child.Debug("debug %d", j)
+				logger.Info("info %d", j)
+			}
+		}()
+	}
+	levels := []string{"debug", "info", "warn", "error"}
+	for i := 0; i < 200; i++ {
+		if err := logger.SetLevel(levels[i%len(levels)]); err != nil {
+			t.Fatalf("SetLevel: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestResolveLevel(t *testing.T) {
+	tests := []struct {
+		level          string
+		verbose, quiet bool
+		want           string
+	}{
+		{"", false, false, "info"},
+		{"warn", false, false, "warn"},
+		{"warn", true, false, "debug"},
+		{"warn", false, true, "error"},
+		{"", true, true, "debug"}, // --verbose wins over --quiet
+	}
+	for _, tt := range tests {
+		if got := ResolveLevel(tt.level, tt.verbose, tt.quiet); got != tt.want {
+			t.Errorf("ResolveLevel(%q, verbose=%v, quiet=%v) = %q, want %q", tt.level, tt.verbose, tt.quiet, got, tt.want)
+		}
+	}
+}
+
+func TestVerboseWinsOverQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(Config{Verbose: true, Quiet: true})
+	logger.SetOutput(&buf)
+
+	logger.Debug("visible")
+	if !strings.Contains(buf.String(), "visible") {
+		t.Errorf("debug output missing with both Verbose and Quiet set: %q", buf.String())
+	}
+}
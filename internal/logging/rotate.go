@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultMaxSizeMB  = 10
+	defaultMaxBackups = 3
+)
+
+// rotatingFile is an io.Writer that appends to path and, once the file would
+// grow past maxSize bytes, shifts it to path.1 (path.1 to path.2, and so on),
+// keeping at most maxBackups old files.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSizeMB, maxBackups int) (*rotatingFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxSizeMB
+	}
+	if maxBackups <= 0 {
+		maxBackups = defaultMaxBackups
+	}
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the active log file.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	os.Remove(r.backupName(r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(r.backupName(i), r.backupName(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(r.path, r.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
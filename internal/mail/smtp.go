@@ -0,0 +1,189 @@
+// Package mail sends email through the SMTP server configured for the
+// installation.
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/validation"
+)
+
+// DefaultPort is the SMTP submission port used when SMTP_PORT is not set.
+const DefaultPort = "587"
+
+// implicitTLSPort is the SMTPS port, where TLS starts before the SMTP
+// greeting instead of through STARTTLS.
+const implicitTLSPort = "465"
+
+// SMTPConfig holds the outbound mail settings. They are read from the SMTP_*
+// keys in the .env file.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPConfigFromEnv builds an SMTPConfig from .env values looked up with get.
+func SMTPConfigFromEnv(get func(key string) string) SMTPConfig {
+	return SMTPConfig{
+		Host:     get("SMTP_HOST"),
+		Port:     get("SMTP_PORT"),
+		Username: get("SMTP_USERNAME"),
+		Password: get("SMTP_PASSWORD"),
+		From:     get("SMTP_FROM"),
+	}
+}
+
+// Validate checks that the settings are complete enough to send mail.
+func (c SMTPConfig) Validate() error {
+	if strings.TrimSpace(c.Host) == "" {
+		return fmt.Errorf("incomplete SMTP configuration, missing SMTP_HOST")
+	}
+	if err := validation.ValidatePort(c.port()); err != nil {
+		return fmt.Errorf("invalid SMTP_PORT: %w", err)
+	}
+	if err := validation.ValidateEmail(c.From); err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+	if c.Username != "" && c.Password == "" {
+		return fmt.Errorf("incomplete SMTP configuration, SMTP_USERNAME is set without SMTP_PASSWORD")
+	}
+	return nil
+}
+
+func (c SMTPConfig) port() string {
+	if c.Port == "" {
+		return DefaultPort
+	}
+	return c.Port
+}
+
+// Addr returns the host:port to dial.
+func (c SMTPConfig) Addr() string {
+	return net.JoinHostPort(c.Host, c.port())
+}
+
+// Client is the subset of *smtp.Client used to send a message.
+type Client interface {
+	Auth(a smtp.Auth) error
+	Mail(from string) error
+	Rcpt(to string) error
+	Data() (io.WriteCloser, error)
+	Quit() error
+	Close() error
+}
+
+// Dialer opens an SMTP session with the server described by config.
+type Dialer func(ctx context.Context, config SMTPConfig) (Client, error)
+
+// Mailer sends mail with the configured SMTP server.
+type Mailer struct {
+	config SMTPConfig
+	dial   Dialer
+	now    func() time.Time
+}
+
+// NewMailer creates a Mailer after validating config.
+func NewMailer(config SMTPConfig) (*Mailer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Mailer{config: config, dial: dialSMTP, now: time.Now}, nil
+}
+
+// SendTestEmail sends a short message to to, proving that the server accepts
+// the configured credentials and sender.
+func (m *Mailer) SendTestEmail(ctx context.Context, to string) error {
+	if err := validation.ValidateEmail(to); err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx, m.config)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", m.config.Addr(), err)
+	}
+	defer client.Close()
+
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return fmt.Errorf("authenticate as %s: %w", m.config.Username, err)
+		}
+	}
+	if err := client.Mail(m.config.From); err != nil {
+		return fmt.Errorf("sender %s rejected: %w", m.config.From, err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("recipient %s rejected: %w", to, err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("start message: %w", err)
+	}
+	if _, err := io.WriteString(w, m.testMessage(to)); err != nil {
+		w.Close()
+		return fmt.Errorf("write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// testMessage renders the RFC 5322 message sent by SendTestEmail.
+func (m *Mailer) testMessage(to string) string {
+	headers := []string{
+		"From: " + m.config.From,
+		"To: " + to,
+		"Subject: Fusionaly test email",
+		"Date: " + m.now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := "This is a test email from your Fusionaly installation.\r\n" +
+		"If you can read it, outbound email is configured correctly.\r\n"
+	return strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+}
+
+// dialSMTP connects to the server, using implicit TLS on port 465 and
+// upgrading with STARTTLS elsewhere when the server offers it.
+func dialSMTP(ctx context.Context, config SMTPConfig) (Client, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	tlsConfig := &tls.Config{ServerName: config.Host}
+
+	var conn net.Conn
+	var err error
+	if config.port() == implicitTLSPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", config.Addr())
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", config.Addr())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok && config.port() != implicitTLSPort {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("starttls: %w", err)
+		}
+	}
+	return client, nil
+}
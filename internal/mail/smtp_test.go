@@ -0,0 +1,210 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeClient struct {
+	calls   []string
+	auth    smtp.Auth
+	from    string
+	rcpt    []string
+	data    bytes.Buffer
+	rcptErr error
+}
+
+type fakeData struct{ c *fakeClient }
+
+func (d fakeData) Write(p []byte) (int, error) { return d.c.data.Write(p) }
+func (d fakeData) Close() error {
+	d.c.calls = append(d.c.calls, "DATA END")
+	return nil
+}
+
+func (c *fakeClient) Auth(a smtp.Auth) error {
+	c.calls = append(c.calls, "AUTH")
+	c.auth = a
+	return nil
+}
+
+func (c *fakeClient) Mail(from string) error {
+	c.calls = append(c.calls, "MAIL")
+	c.from = from
+	return nil
+}
+
+func (c *fakeClient) Rcpt(to string) error {
+	c.calls = append(c.calls, "RCPT")
+	c.rcpt = append(c.rcpt, to)
+	return c.rcptErr
+}
+
+func (c *fakeClient) Data() (io.WriteCloser, error) {
+	c.calls = append(c.calls, "DATA")
+	return fakeData{c}, nil
+}
+
+func (c *fakeClient) Quit() error {
+	c.calls = append(c.calls, "QUIT")
+	return nil
+}
+
+func (c *fakeClient) Close() error { return nil }
+
+func testConfig() SMTPConfig {
+	return SMTPConfig{
+		Host:     "smtp.example.com",
+		Port:     "587",
+		Username: "mailer",
+		Password: "s3cret",
+		From:     "analytics@example.com",
+	}
+}
+
+func newTestMailer(t *testing.T, config SMTPConfig, client *fakeClient) (*Mailer, *string) {
+	t.Helper()
+	m, err := NewMailer(config)
+	if err != nil {
+		t.Fatalf("NewMailer: %v", err)
+	}
+	dialed := new(string)
+	m.dial = func(ctx context.Context, c SMTPConfig) (Client, error) {
+		*dialed = c.Addr()
+		return client, nil
+	}
+	m.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return m, dialed
+}
+
+func TestSendTestEmailEnvelopeAndAuth(t *testing.T) {
+	client := &fakeClient{}
+	m, dialed := newTestMailer(t, testConfig(), client)
+
+	if err := m.SendTestEmail(context.Background(), "admin@example.com"); err != nil {
+		t.Fatalf("SendTestEmail: %v", err)
+	}
+
+	if *dialed != "smtp.example.com:587" {
+		t.Errorf("dialed %q, want smtp.example.com:587", *dialed)
+	}
+	want := "AUTH MAIL RCPT DATA DATA END QUIT"
+	if got := strings.Join(client.calls, " "); got != want {
+		t.Errorf("SMTP sequence = %q, want %q", got, want)
+	}
+	if client.from != "analytics@example.com" {
+		t.Errorf("MAIL FROM = %q", client.from)
+	}
+	if len(client.rcpt) != 1 || client.rcpt[0] != "admin@example.com" {
+		t.Errorf("RCPT TO = %v", client.rcpt)
+	}
+
+	mech, resp, err := client.auth.Start(&smtp.ServerInfo{Name: "smtp.example.com", TLS: true})
+	if err != nil {
+		t.Fatalf("auth start: %v", err)
+	}
+	if mech != "PLAIN" || string(resp) != "\x00mailer\x00s3cret" {
+		t.Errorf("auth = %s %q, want PLAIN with configured credentials", mech, resp)
+	}
+
+	msg := client.data.String()
+	for _, want := range []string{"From: analytics@example.com\r\n", "To: admin@example.com\r\n", "Subject: Fusionaly test email\r\n", "\r\n\r\nThis is a test email"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSendTestEmailWithoutCredentialsSkipsAuth(t *testing.T) {
+	config := testConfig()
+	config.Username, config.Password = "", ""
+	client := &fakeClient{}
+	m, _ := newTestMailer(t, config, client)
+
+	if err := m.SendTestEmail(context.Background(), "admin@example.com"); err != nil {
+		t.Fatalf("SendTestEmail: %v", err)
+	}
+	if client.calls[0] != "MAIL" {
+		t.Errorf("expected no AUTH, got %v", client.calls)
+	}
+}
+
+func TestSendTestEmailRecipientRejected(t *testing.T) {
+	client := &fakeClient{rcptErr: errors.New("550 no such user")}
+	m, _ := newTestMailer(t, testConfig(), client)
+
+	err := m.SendTestEmail(context.Background(), "admin@example.com")
+	if err == nil || !strings.Contains(err.Error(), "550 no such user") {
+		t.Fatalf("expected recipient error, got %v", err)
+	}
+	for _, call := range client.calls {
+		if call == "DATA" {
+			t.Error("message should not be sent after RCPT is rejected")
+		}
+	}
+}
+
+func TestSendTestEmailRejectsInvalidRecipient(t *testing.T) {
+	client := &fakeClient{}
+	m, _ := newTestMailer(t, testConfig(), client)
+
+	if err := m.SendTestEmail(context.Background(), "not-an-email"); err == nil {
+		t.Fatal("expected invalid recipient error")
+	}
+	if len(client.calls) != 0 {
+		t.Errorf("expected no SMTP commands, got %v", client.calls)
+	}
+}
+
+func TestSMTPConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*SMTPConfig)
+		wantErr string
+	}{
+		{"valid", func(*SMTPConfig) {}, ""},
+		{"default port", func(c *SMTPConfig) { c.Port = "" }, ""},
+		{"missing host", func(c *SMTPConfig) { c.Host = " " }, "SMTP_HOST"},
+		{"non-numeric port", func(c *SMTPConfig) { c.Port = "smtp" }, "SMTP_PORT"},
+		{"port out of range", func(c *SMTPConfig) { c.Port = "70000" }, "SMTP_PORT"},
+		{"invalid from", func(c *SMTPConfig) { c.From = "analytics" }, "SMTP_FROM"},
+		{"username without password", func(c *SMTPConfig) { c.Password = "" }, "SMTP_PASSWORD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.modify(&config)
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSMTPConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"SMTP_HOST":     "smtp.example.com",
+		"SMTP_PORT":     "465",
+		"SMTP_USERNAME": "mailer",
+		"SMTP_PASSWORD": "s3cret",
+		"SMTP_FROM":     "analytics@example.com",
+	}
+	got := SMTPConfigFromEnv(func(key string) string { return env[key] })
+	want := SMTPConfig{Host: "smtp.example.com", Port: "465", Username: "mailer", Password: "s3cret", From: "analytics@example.com"}
+	if got != want {
+		t.Errorf("SMTPConfigFromEnv() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// Package offsite copies backups off the host to object storage.
+package offsite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultPrefix is the key prefix used when none is configured.
+const DefaultPrefix = "fusionaly/backups"
+
+// Uploader stores an object under key. body is read to the end; size is its
+// length in bytes.
+type Uploader interface {
+	Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error
+}
+
+// Store uploads backup files through an Uploader, naming each object
+// <prefix>/<file name>.
+type Store struct {
+	uploader Uploader
+	prefix   string
+}
+
+// NewStore creates a Store. An empty prefix uses DefaultPrefix.
+func NewStore(uploader Uploader, prefix string) *Store {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Store{uploader: uploader, prefix: prefix}
+}
+
+// UploadBackup uploads the file at localPath and returns its remote key.
+func (s *Store) UploadBackup(ctx context.Context, localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot read backup: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("cannot read backup: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("cannot upload directory %s", localPath)
+	}
+
+	key := s.Key(localPath)
+	if err := s.uploader.Upload(ctx, key, f, info.Size()); err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return key, nil
+}
+
+// Key returns the remote key a local backup file is stored under.
+func (s *Store) Key(localPath string) string {
+	return path.Join(s.prefix, filepath.Base(localPath))
+}
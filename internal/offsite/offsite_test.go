@@ -0,0 +1,158 @@
+package offsite
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeUploader struct {
+	keys   []string
+	bodies []string
+	err    error
+}
+
+func (f *fakeUploader) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	data, _ := io.ReadAll(body)
+	f.keys = append(f.keys, key)
+	f.bodies = append(f.bodies, string(data))
+	return f.err
+}
+
+func writeBackup(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadBackup_KeyNaming(t *testing.T) {
+	path := writeBackup(t, "fusionaly-backup-20240101-120000.sql.gz", "dump")
+
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", "fusionaly/backups/fusionaly-backup-20240101-120000.sql.gz"},
+		{"/prod/db/", "prod/db/fusionaly-backup-20240101-120000.sql.gz"},
+	}
+	for _, tt := range tests {
+		fake := &fakeUploader{}
+		key, err := NewStore(fake, tt.prefix).UploadBackup(context.Background(), path)
+		if err != nil {
+			t.Fatalf("prefix %q: %v", tt.prefix, err)
+		}
+		if key != tt.want || fake.keys[0] != tt.want {
+			t.Errorf("prefix %q: got key %q (uploaded %q), want %q", tt.prefix, key, fake.keys[0], tt.want)
+		}
+		if fake.bodies[0] != "dump" {
+			t.Errorf("unexpected body %q", fake.bodies[0])
+		}
+	}
+}
+
+func TestUploadBackup_Errors(t *testing.T) {
+	fake := &fakeUploader{}
+	if _, err := NewStore(fake, "").UploadBackup(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	if len(fake.keys) != 0 {
+		t.Error("nothing should be uploaded for a missing file")
+	}
+
+	fake.err = errors.New("denied")
+	path := writeBackup(t, "backup_20240101_000000.db", "x")
+	if _, err := NewStore(fake, "").UploadBackup(context.Background(), path); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("expected upload error, got %v", err)
+	}
+}
+
+func TestS3ConfigValidate(t *testing.T) {
+	if err := (S3Config{Bucket: "b"}).Validate(); err == nil || !strings.Contains(err.Error(), "S3_ACCESS_KEY") {
+		t.Errorf("expected missing keys to be listed, got %v", err)
+	}
+	cfg := S3Config{Endpoint: "s3.example.com", Bucket: "b", AccessKey: "a", SecretKey: "s"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an endpoint without scheme to be rejected")
+	}
+	cfg.Endpoint = "https://s3.example.com"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Example key from the AWS Signature Version 4 documentation.
+func TestSigningKey(t *testing.T) {
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestS3Uploader_PutsSignedObject(t *testing.T) {
+	var gotPath, gotBody, gotAuth, gotDate, gotHash string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotAuth = r.Header.Get("Authorization")
+		gotDate = r.Header.Get("X-Amz-Date")
+		gotHash = r.Header.Get("X-Amz-Content-Sha256")
+	}))
+	defer srv.Close()
+
+	u, err := NewS3Uploader(S3Config{Endpoint: srv.URL, Bucket: "backups", AccessKey: "AKID", SecretKey: "secret"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	body := strings.NewReader("hello")
+	if err := u.Upload(context.Background(), "fusionaly/backups/a b.sql.gz", body, body.Size()); err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+
+	if gotPath != "/backups/fusionaly/backups/a%20b.sql.gz" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotBody != "hello" {
+		t.Errorf("unexpected body %q", gotBody)
+	}
+	if gotDate != "20240101T120000Z" {
+		t.Errorf("unexpected date %q", gotDate)
+	}
+	if gotHash != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected payload hash %q", gotHash)
+	}
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(gotAuth, wantPrefix) || len(gotAuth) != len(wantPrefix)+64 {
+		t.Errorf("unexpected Authorization header %q", gotAuth)
+	}
+}
+
+func TestS3Uploader_ReportsRejection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	u, err := NewS3Uploader(S3Config{Endpoint: srv.URL, Bucket: "b", AccessKey: "a", SecretKey: "s"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = u.Upload(context.Background(), "k", strings.NewReader("x"), 1)
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("expected AccessDenied error, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package offsite
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultRegion is used for signing when no region is configured; most
+// S3-compatible services accept it.
+const DefaultRegion = "us-east-1"
+
+// S3Config holds the settings for an S3-compatible bucket. They are read from
+// the S3_* keys in the .env file.
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string
+	Prefix    string
+}
+
+// S3ConfigFromEnv builds an S3Config from .env values looked up with get.
+func S3ConfigFromEnv(get func(key string) string) S3Config {
+	return S3Config{
+		Endpoint:  get("S3_ENDPOINT"),
+		Bucket:    get("S3_BUCKET"),
+		AccessKey: get("S3_ACCESS_KEY"),
+		SecretKey: get("S3_SECRET_KEY"),
+		Region:    get("S3_REGION"),
+		Prefix:    get("S3_PREFIX"),
+	}
+}
+
+// Enabled reports whether off-site upload has been configured at all.
+func (c S3Config) Enabled() bool {
+	return c.Bucket != ""
+}
+
+// Validate checks that every setting needed to upload is present.
+func (c S3Config) Validate() error {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"S3_ENDPOINT", c.Endpoint},
+		{"S3_BUCKET", c.Bucket},
+		{"S3_ACCESS_KEY", c.AccessKey},
+		{"S3_SECRET_KEY", c.SecretKey},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("incomplete S3 configuration, missing %s", strings.Join(missing, ", "))
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid S3_ENDPOINT %q", c.Endpoint)
+	}
+	return nil
+}
+
+// S3Uploader uploads objects with path-style PUT requests signed with AWS
+// Signature Version 4.
+type S3Uploader struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Uploader creates an S3Uploader. A nil client uses http.DefaultClient.
+func NewS3Uploader(config S3Config, client *http.Client) (*S3Uploader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Region == "" {
+		config.Region = DefaultRegion
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3Uploader{config: config, client: client, now: time.Now}, nil
+}
+
+// Upload implements Uploader.
+func (u *S3Uploader) Upload(ctx context.Context, key string, body io.ReadSeeker, size int64) error {
+	payloadHash, err := hashBody(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint, _ := url.Parse(u.config.Endpoint)
+	objectPath := strings.TrimRight(endpoint.Path, "/") + "/" + uriEncode(u.config.Bucket, false) + "/" + uriEncode(key, true)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.Scheme+"://"+endpoint.Host+objectPath, io.NopCloser(body))
+	if err != nil {
+		return fmt.Errorf("failed to build upload request: %w", err)
+	}
+	req.ContentLength = size
+	u.sign(req, objectPath, payloadHash)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload rejected with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign adds the SigV4 headers for a request without a query string.
+func (u *S3Uploader) sign(req *http.Request, canonicalURI, payloadHash string) {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + u.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := signingKey(u.config.SecretKey, day, u.config.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the SigV4 key for one day, region and service.
+func signingKey(secret, day, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// hashBody returns the hex SHA-256 of body and rewinds it for sending.
+func hashBody(body io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", fmt.Errorf("failed to read upload body: %w", err)
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to rewind upload body: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encodes s the way SigV4 expects: everything except
+// unreserved characters, keeping '/' when keepSlash is set.
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
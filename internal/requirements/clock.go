@@ -0,0 +1,125 @@
+package requirements
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultClockSource is the server whose HTTP Date header CheckClockSkew
+	// compares against: the Let's Encrypt API, which the proxy has to reach
+	// anyway to issue certificates.
+	DefaultClockSource = "https://acme-v02.api.letsencrypt.org/directory"
+
+	// DefaultMaxClockSkew is how far the host clock may drift before
+	// certificate issuance and token validation start failing.
+	DefaultMaxClockSkew = 2 * time.Minute
+)
+
+// ErrClockSkew is matched by the error CheckClockSkew returns when the host
+// clock is off by more than the allowed skew.
+var ErrClockSkew = errors.New("host clock is out of sync")
+
+// ClockSkewError reports how far the host clock is from the reference time.
+type ClockSkewError struct {
+	Skew   time.Duration // Host time minus reference time; positive when the host is ahead
+	Max    time.Duration
+	Source string
+}
+
+func (e *ClockSkewError) Error() string {
+	direction := "ahead of"
+	if e.Skew < 0 {
+		direction = "behind"
+	}
+	return fmt.Sprintf("%s: it is %s %s %s (at most %s allowed); enable NTP, e.g. `timedatectl set-ntp true`",
+		ErrClockSkew, absDuration(e.Skew).Round(time.Second), direction, e.Source, e.Max)
+}
+
+func (e *ClockSkewError) Is(target error) bool { return target == ErrClockSkew }
+
+// TimeSource returns a trusted current time, and the name it is reported by.
+type TimeSource interface {
+	Now(ctx context.Context) (time.Time, error)
+	String() string
+}
+
+// HTTPDateSource reads the time from the Date header of a HEAD request to
+// URL, which needs nothing beyond outbound HTTPS, unlike NTP.
+type HTTPDateSource struct {
+	URL    string
+	Client *http.Client // nil uses a client with a 10s timeout
+}
+
+// Now implements TimeSource. The Date header has second precision and is
+// set while the request is in flight, so the result is the header plus half
+// a second.
+func (s HTTPDateSource) Now(ctx context.Context) (time.Time, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.URL, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("no usable Date header from %s: %w", s.URL, err)
+	}
+	return date.Add(500 * time.Millisecond), nil
+}
+
+func (s HTTPDateSource) String() string { return s.URL }
+
+// SetTimeSource makes CheckClockSkew compare against source instead of the
+// Date header of DefaultClockSource.
+func (c *Checker) SetTimeSource(source TimeSource) {
+	c.timeSource = source
+}
+
+// CheckClockSkew compares the host clock with the time source and returns
+// the skew, host minus reference. It fails with a *ClockSkewError when the
+// skew exceeds max, and with the source's error when no reference time could
+// be read. The round trip is accounted for by comparing against the host
+// time halfway through the request.
+func (c *Checker) CheckClockSkew(ctx context.Context, max time.Duration) (time.Duration, error) {
+	source := c.timeSource
+	if source == nil {
+		source = HTTPDateSource{URL: DefaultClockSource}
+	}
+	now := c.now
+	if now == nil {
+		now = time.Now
+	}
+
+	before := now()
+	reference, err := source.Now(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the time from %s: %w", source, err)
+	}
+	after := now()
+	host := before.Add(after.Sub(before) / 2)
+
+	skew := host.Sub(reference)
+	if absDuration(skew) > max {
+		return skew, &ClockSkewError{Skew: skew, Max: max, Source: source.String()}
+	}
+	c.logger.Debug("Host clock is within %s of %s", skew.Round(time.Millisecond), source)
+	return skew, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,81 @@
+package requirements
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// fixedSource reports a fixed reference time, or fails with err.
+type fixedSource struct {
+	t   time.Time
+	err error
+}
+
+func (s fixedSource) Now(ctx context.Context) (time.Time, error) { return s.t, s.err }
+func (s fixedSource) String() string                             { return "test clock" }
+
+var referenceTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newClockChecker(hostOffset time.Duration, source TimeSource) *Checker {
+	c := NewChecker(logging.NewLogger(logging.Config{Level: "error", Quiet: true}))
+	c.now = func() time.Time { return referenceTime.Add(hostOffset) }
+	c.SetTimeSource(source)
+	return c
+}
+
+func TestCheckClockSkew_WithinRange(t *testing.T) {
+	c := newClockChecker(30*time.Second, fixedSource{t: referenceTime})
+
+	skew, err := c.CheckClockSkew(context.Background(), DefaultMaxClockSkew)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, skew)
+}
+
+func TestCheckClockSkew_OutOfRange(t *testing.T) {
+	for name, offset := range map[string]time.Duration{"ahead": 10 * time.Minute, "behind": -3 * time.Hour} {
+		c := newClockChecker(offset, fixedSource{t: referenceTime})
+
+		skew, err := c.CheckClockSkew(context.Background(), DefaultMaxClockSkew)
+		assert.ErrorIs(t, err, ErrClockSkew, name)
+		assert.Equal(t, offset, skew, name)
+		var skewErr *ClockSkewError
+		if assert.ErrorAs(t, err, &skewErr, name) {
+			assert.Equal(t, DefaultMaxClockSkew, skewErr.Max)
+		}
+		assert.Contains(t, err.Error(), name, "the message should say which way the clock is off")
+	}
+}
+
+func TestCheckClockSkew_SourceUnavailable(t *testing.T) {
+	c := newClockChecker(0, fixedSource{err: errors.New("connection refused")})
+
+	_, err := c.CheckClockSkew(context.Background(), DefaultMaxClockSkew)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrClockSkew, "an unreachable source says nothing about the clock")
+}
+
+func TestHTTPDateSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.Header().Set("Date", referenceTime.Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+
+	got, err := HTTPDateSource{URL: srv.URL}.Now(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, referenceTime.Add(500*time.Millisecond), got.UTC())
+
+	// End to end: a host an hour behind the server is out of range.
+	c := newClockChecker(-time.Hour, HTTPDateSource{URL: srv.URL})
+	_, err = c.CheckClockSkew(context.Background(), DefaultMaxClockSkew)
+	assert.ErrorIs(t, err, ErrClockSkew)
+}
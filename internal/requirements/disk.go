@@ -0,0 +1,127 @@
+package requirements
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// DefaultMinDiskGB is the free space required on the install path when no
+// other minimum is configured.
+const DefaultMinDiskGB = 2.0
+
+const bytesPerGB = 1 << 30
+
+// ErrInsufficientDisk is matched (via errors.Is) by every InsufficientDiskError.
+var ErrInsufficientDisk = errors.New("insufficient disk space")
+
+// InsufficientDiskError reports how much space was found and how much the
+// install needs, both in bytes.
+type InsufficientDiskError struct {
+	Path      string
+	Available uint64
+	Required  uint64
+}
+
+func (e *InsufficientDiskError) Error() string {
+	return fmt.Sprintf("%s on %s: %.1f GB available, %.1f GB required",
+		ErrInsufficientDisk, e.Path, float64(e.Available)/bytesPerGB, float64(e.Required)/bytesPerGB)
+}
+
+func (e *InsufficientDiskError) Is(target error) bool {
+	return target == ErrInsufficientDisk
+}
+
+// PreflightOptions configures the checks run by PreflightCheck.
+type PreflightOptions struct {
+	InstallDir  string  // Path whose filesystem must have room for images and data
+	MinDiskGB   float64 // Required free space; CI can lower it
+	MinMemoryMB int     // Required available memory; 0 skips the check
+}
+
+// DefaultPreflightOptions returns the options used by the installer. The
+// minimums can be overridden with FUSIONALY_MIN_DISK_GB and
+// FUSIONALY_MIN_MEMORY_MB.
+func DefaultPreflightOptions(installDir string) PreflightOptions {
+	opts := PreflightOptions{InstallDir: installDir, MinDiskGB: DefaultMinDiskGB, MinMemoryMB: DefaultMinMemoryMB}
+	if v := os.Getenv("FUSIONALY_MIN_DISK_GB"); v != "" {
+		if gb, err := strconv.ParseFloat(v, 64); err == nil && gb >= 0 {
+			opts.MinDiskGB = gb
+		}
+	}
+	if v := os.Getenv("FUSIONALY_MIN_MEMORY_MB"); v != "" {
+		if mb, err := strconv.Atoi(v); err == nil && mb >= 0 {
+			opts.MinMemoryMB = mb
+		}
+	}
+	return opts
+}
+
+// PreflightCheck verifies the host can hold an installation before anything
+// is downloaded.
+func (c *Checker) PreflightCheck(opts PreflightOptions) error {
+	fmt.Print("🔍 Checking available disk space... ")
+	if err := c.CheckDiskSpace(opts.InstallDir, uint64(opts.MinDiskGB*bytesPerGB)); err != nil {
+		fmt.Printf("\n❌ Error: %v\n", err)
+		return err
+	}
+	fmt.Printf("✅ At least %.1f GB free on %s\n", opts.MinDiskGB, opts.InstallDir)
+
+	if opts.MinMemoryMB > 0 {
+		fmt.Print("🔍 Checking available memory... ")
+		if err := c.CheckMemory(opts.MinMemoryMB); err != nil {
+			fmt.Printf("\n❌ Error: %v\n", err)
+			return err
+		}
+		fmt.Printf("✅ At least %d MB available\n", opts.MinMemoryMB)
+	}
+	return nil
+}
+
+// CheckDiskSpace returns an *InsufficientDiskError when the filesystem holding
+// path has fewer than required bytes available. Paths that don't exist yet are
+// checked against their nearest existing parent.
+func (c *Checker) CheckDiskSpace(path string, required uint64) error {
+	statfs := c.statfs
+	if statfs == nil {
+		statfs = availableBytes
+	}
+
+	target := existingParent(path)
+	available, err := statfs(target)
+	if err != nil {
+		return fmt.Errorf("failed to check disk space on %s: %w", target, err)
+	}
+	if available < required {
+		return &InsufficientDiskError{Path: target, Available: available, Required: required}
+	}
+	return nil
+}
+
+// availableBytes reports the space available to unprivileged users on the
+// filesystem containing path.
+func availableBytes(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// existingParent walks up from path until it finds a directory that exists.
+func existingParent(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,74 @@
+package requirements
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"fusionaly-installer/internal/logging"
+)
+
+func newDiskChecker(available uint64, err error) (*Checker, *string) {
+	var statted string
+	return &Checker{
+		logger: logging.NewLogger(logging.Config{Level: "error", Quiet: true}),
+		statfs: func(path string) (uint64, error) {
+			statted = path
+			return available, err
+		},
+	}, &statted
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	t.Run("enough space", func(t *testing.T) {
+		checker, _ := newDiskChecker(5*bytesPerGB, nil)
+		assert.NoError(t, checker.CheckDiskSpace(t.TempDir(), 2*bytesPerGB))
+	})
+
+	t.Run("insufficient space", func(t *testing.T) {
+		checker, _ := newDiskChecker(1*bytesPerGB, nil)
+		err := checker.CheckDiskSpace(t.TempDir(), 2*bytesPerGB)
+
+		assert.True(t, errors.Is(err, ErrInsufficientDisk))
+		var diskErr *InsufficientDiskError
+		if assert.True(t, errors.As(err, &diskErr)) {
+			assert.Equal(t, uint64(1*bytesPerGB), diskErr.Available)
+			assert.Equal(t, uint64(2*bytesPerGB), diskErr.Required)
+		}
+		assert.Contains(t, err.Error(), "1.0 GB available, 2.0 GB required")
+	})
+
+	t.Run("stat failure", func(t *testing.T) {
+		checker, _ := newDiskChecker(0, errors.New("permission denied"))
+		err := checker.CheckDiskSpace(t.TempDir(), 1)
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrInsufficientDisk))
+	})
+
+	t.Run("missing path uses nearest parent", func(t *testing.T) {
+		dir := t.TempDir()
+		checker, statted := newDiskChecker(5*bytesPerGB, nil)
+		assert.NoError(t, checker.CheckDiskSpace(dir+"/not/yet/created", 1))
+		assert.Equal(t, dir, *statted)
+	})
+}
+
+func TestPreflightCheckHonorsMinimum(t *testing.T) {
+	checker, _ := newDiskChecker(bytesPerGB/2, nil)
+
+	err := checker.PreflightCheck(PreflightOptions{InstallDir: t.TempDir(), MinDiskGB: DefaultMinDiskGB})
+	assert.True(t, errors.Is(err, ErrInsufficientDisk))
+
+	err = checker.PreflightCheck(PreflightOptions{InstallDir: t.TempDir(), MinDiskGB: 0.1})
+	assert.NoError(t, err)
+}
+
+func TestDefaultPreflightOptions(t *testing.T) {
+	assert.Equal(t, DefaultMinDiskGB, DefaultPreflightOptions("/opt/fusionaly").MinDiskGB)
+
+	t.Setenv("FUSIONALY_MIN_DISK_GB", "0.5")
+	opts := DefaultPreflightOptions("/opt/fusionaly")
+	assert.Equal(t, 0.5, opts.MinDiskGB)
+	assert.Equal(t, "/opt/fusionaly", opts.InstallDir)
+}
@@ -0,0 +1,90 @@
+package requirements
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultMinMemoryMB is the available memory required when no other minimum
+// is configured. Below it the app and proxy are killed by the OOM killer
+// under load.
+const DefaultMinMemoryMB = 512
+
+// meminfoPath is where Linux reports memory usage.
+const meminfoPath = "/proc/meminfo"
+
+// ErrInsufficientMemory is matched (via errors.Is) by every
+// InsufficientMemoryError.
+var ErrInsufficientMemory = errors.New("insufficient memory")
+
+// InsufficientMemoryError reports how much memory was available and how much
+// the install needs, both in MB.
+type InsufficientMemoryError struct {
+	Available int
+	Required  int
+}
+
+func (e *InsufficientMemoryError) Error() string {
+	return fmt.Sprintf("%s: %d MB available, %d MB required", ErrInsufficientMemory, e.Available, e.Required)
+}
+
+func (e *InsufficientMemoryError) Is(target error) bool {
+	return target == ErrInsufficientMemory
+}
+
+// CheckMemory returns an *InsufficientMemoryError when the host has less than
+// minMB of memory available to new processes.
+func (c *Checker) CheckMemory(minMB int) error {
+	meminfo := c.meminfo
+	if meminfo == nil {
+		meminfo = func() ([]byte, error) { return os.ReadFile(meminfoPath) }
+	}
+
+	data, err := meminfo()
+	if err != nil {
+		return fmt.Errorf("failed to check available memory: %w", err)
+	}
+	available, err := availableMB(data)
+	if err != nil {
+		return fmt.Errorf("failed to check available memory: %w", err)
+	}
+	if available < minMB {
+		return &InsufficientMemoryError{Available: available, Required: minMB}
+	}
+	return nil
+}
+
+// availableMB parses /proc/meminfo. MemAvailable is the kernel's estimate of
+// memory usable without swapping; kernels before 3.14 lack it, so free
+// memory plus reclaimable page cache stands in.
+func availableMB(meminfo []byte) (int, error) {
+	fields := map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(meminfo))
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Fields(rest)
+		if len(parts) == 0 {
+			continue
+		}
+		if kb, err := strconv.Atoi(parts[0]); err == nil {
+			fields[key] = kb
+		}
+	}
+
+	if kb, ok := fields["MemAvailable"]; ok {
+		return kb / 1024, nil
+	}
+	free, ok := fields["MemFree"]
+	if !ok {
+		return 0, fmt.Errorf("%s has no MemAvailable or MemFree", meminfoPath)
+	}
+	return (free + fields["Buffers"] + fields["Cached"]) / 1024, nil
+}
@@ -0,0 +1,77 @@
+package requirements
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"fusionaly-installer/internal/logging"
+)
+
+const sampleMeminfo = `MemTotal:        2030412 kB
+MemFree:          153212 kB
+MemAvailable:    1203844 kB
+Buffers:           48532 kB
+Cached:           964304 kB
+SwapCached:            0 kB
+`
+
+// sampleMeminfoOld is from a kernel without MemAvailable.
+const sampleMeminfoOld = `MemTotal:        1016000 kB
+MemFree:          102400 kB
+Buffers:           51200 kB
+Cached:           153600 kB
+`
+
+func newMemoryChecker(meminfo string, err error) *Checker {
+	return &Checker{
+		logger:  logging.NewLogger(logging.Config{Level: "error", Quiet: true}),
+		meminfo: func() ([]byte, error) { return []byte(meminfo), err },
+	}
+}
+
+func TestCheckMemory(t *testing.T) {
+	t.Run("above threshold", func(t *testing.T) {
+		assert.NoError(t, newMemoryChecker(sampleMeminfo, nil).CheckMemory(512))
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		err := newMemoryChecker(sampleMeminfo, nil).CheckMemory(2048)
+
+		assert.True(t, errors.Is(err, ErrInsufficientMemory))
+		var memErr *InsufficientMemoryError
+		if assert.True(t, errors.As(err, &memErr)) {
+			assert.Equal(t, 1175, memErr.Available)
+			assert.Equal(t, 2048, memErr.Required)
+		}
+		assert.Contains(t, err.Error(), "1175 MB available, 2048 MB required")
+	})
+
+	t.Run("kernel without MemAvailable", func(t *testing.T) {
+		checker := newMemoryChecker(sampleMeminfoOld, nil)
+		assert.NoError(t, checker.CheckMemory(300))
+		assert.True(t, errors.Is(checker.CheckMemory(301), ErrInsufficientMemory))
+	})
+
+	t.Run("unreadable meminfo", func(t *testing.T) {
+		err := newMemoryChecker("", errors.New("permission denied")).CheckMemory(512)
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrInsufficientMemory))
+
+		err = newMemoryChecker("garbage\n", nil).CheckMemory(512)
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrInsufficientMemory))
+	})
+}
+
+func TestPreflightCheckMemory(t *testing.T) {
+	checker := newMemoryChecker(sampleMeminfoOld, nil)
+	checker.statfs = func(string) (uint64, error) { return 5 * bytesPerGB, nil }
+
+	err := checker.PreflightCheck(PreflightOptions{InstallDir: t.TempDir(), MinDiskGB: 1, MinMemoryMB: DefaultMinMemoryMB})
+	assert.True(t, errors.Is(err, ErrInsufficientMemory))
+
+	t.Setenv("FUSIONALY_MIN_MEMORY_MB", "256")
+	assert.NoError(t, checker.PreflightCheck(DefaultPreflightOptions(t.TempDir())))
+}
@@ -0,0 +1,107 @@
+package requirements
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultRegistryHost is the registry the app and proxy images are pulled
+// from unless their names say otherwise.
+const DefaultRegistryHost = "registry-1.docker.io"
+
+// Defaults for WaitForNetwork.
+const (
+	DefaultNetworkTimeout       = 2 * time.Minute
+	DefaultNetworkRetryInterval = 3 * time.Second
+)
+
+// ErrNetworkUnavailable is returned by WaitForNetwork when the registry could
+// not be reached before the timeout.
+var ErrNetworkUnavailable = errors.New("network is not ready")
+
+// Resolver looks up the addresses of a host. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// SetRegistryHost makes WaitForNetwork probe host instead of
+// DefaultRegistryHost, e.g. the result of RegistryHost for the app image.
+func (c *Checker) SetRegistryHost(host string) {
+	c.registry = host
+}
+
+// RegistryHost returns the registry an image reference is pulled from:
+// its first path component when that names a host, Docker Hub otherwise.
+func RegistryHost(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return DefaultRegistryHost
+}
+
+// WaitForNetwork blocks until the image registry resolves in DNS and accepts
+// a TCP connection on port 443, retrying every DefaultNetworkRetryInterval.
+// Fresh cloud VMs often start the installer before DHCP or DNS is up, which
+// otherwise surfaces later as a failed image pull. It returns an error
+// wrapping ErrNetworkUnavailable and the last failure once timeout has
+// passed, or ctx's error when it is cancelled.
+func (c *Checker) WaitForNetwork(ctx context.Context, timeout time.Duration) error {
+	host := c.registry
+	if host == "" {
+		host = DefaultRegistryHost
+	}
+	interval := c.retryInterval
+	if interval <= 0 {
+		interval = DefaultNetworkRetryInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		err := c.probeRegistry(ctx, host)
+		if err == nil {
+			c.logger.Success("Network ready: %s is reachable", host)
+			return nil
+		}
+		c.logger.Debug("Network check %d for %s failed: %v", attempt, host, err)
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w after %s: %v", ErrNetworkUnavailable, timeout, err)
+			}
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// probeRegistry resolves host and opens a connection to its HTTPS port.
+func (c *Checker) probeRegistry(ctx context.Context, host string) error {
+	resolver := c.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	dial := c.dial
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 10 * time.Second}).DialContext
+	}
+
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("resolve %s: no addresses", host)
+	}
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(host, "443"))
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", host, err)
+	}
+	conn.Close()
+	return nil
+}
@@ -0,0 +1,85 @@
+package requirements
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// flakyResolver fails the first failures lookups, like DNS that is still
+// coming up.
+type flakyResolver struct {
+	failures int
+	lookups  int
+}
+
+func (r *flakyResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.lookups++
+	if r.lookups <= r.failures {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+	return []string{"203.0.113.5"}, nil
+}
+
+func newNetworkChecker(resolver *flakyResolver, dialFailures int) (*Checker, *[]string) {
+	c := NewChecker(logging.NewLogger(logging.Config{Level: "error", Quiet: true}))
+	c.resolver = resolver
+	c.retryInterval = time.Millisecond
+	var dialed []string
+	c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if len(dialed) <= dialFailures {
+			return nil, errors.New("connect: network is unreachable")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	return c, &dialed
+}
+
+func TestWaitForNetworkRetriesUntilUp(t *testing.T) {
+	resolver := &flakyResolver{failures: 2}
+	c, dialed := newNetworkChecker(resolver, 1)
+	c.SetRegistryHost("ghcr.io")
+
+	assert.NoError(t, c.WaitForNetwork(context.Background(), time.Second))
+	assert.Equal(t, 4, resolver.lookups)
+	assert.Equal(t, []string{"ghcr.io:443", "ghcr.io:443"}, *dialed)
+}
+
+func TestWaitForNetworkTimesOut(t *testing.T) {
+	c, dialed := newNetworkChecker(&flakyResolver{failures: 1 << 30}, 0)
+
+	err := c.WaitForNetwork(context.Background(), 20*time.Millisecond)
+	assert.True(t, errors.Is(err, ErrNetworkUnavailable))
+	assert.Contains(t, err.Error(), "resolve "+DefaultRegistryHost)
+	assert.Empty(t, *dialed)
+}
+
+func TestWaitForNetworkStopsWhenCancelled(t *testing.T) {
+	c, _ := newNetworkChecker(&flakyResolver{failures: 1 << 30}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.WaitForNetwork(ctx, time.Minute)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestRegistryHost(t *testing.T) {
+	for image, want := range map[string]string{
+		"karloscodes/fusionaly-beta:1.2.3": DefaultRegistryHost,
+		"caddy:2.7-alpine":                 DefaultRegistryHost,
+		"ghcr.io/karloscodes/fusionaly:1":  "ghcr.io",
+		"registry.local:5000/fusionaly":    "registry.local:5000",
+		"localhost/fusionaly:dev":          "localhost",
+	} {
+		assert.Equal(t, want, RegistryHost(image), image)
+	}
+}
@@ -0,0 +1,130 @@
+package requirements
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrPortInUse is matched (via errors.Is) by every PortInUseError.
+var ErrPortInUse = errors.New("port already in use")
+
+// PortInUseError lists the ports that could not be bound.
+type PortInUseError struct {
+	Address string // Bind address that was tried; empty means all interfaces
+	Ports   []int
+}
+
+func (e *PortInUseError) Error() string {
+	ports := make([]string, len(e.Ports))
+	for i, p := range e.Ports {
+		ports[i] = strconv.Itoa(p)
+	}
+	addr := e.Address
+	if addr == "" {
+		addr = "all interfaces"
+	}
+	return fmt.Sprintf("%s on %s: %s", ErrPortInUse, addr, strings.Join(ports, ", "))
+}
+
+func (e *PortInUseError) Is(target error) bool {
+	return target == ErrPortInUse
+}
+
+// IPFamily selects the IP versions the port checks bind on.
+type IPFamily int
+
+const (
+	DualStack IPFamily = iota // IPv4 and IPv6; a port busy on either is in use
+	IPv4Only
+	IPv6Only
+)
+
+// SetIPFamily restricts the port checks to one IP version. The default,
+// DualStack, matches docker, which publishes ports on both.
+func (c *Checker) SetIPFamily(f IPFamily) {
+	c.family = f
+}
+
+// networks returns the listen networks probed for address. An IP address
+// is only probed in its own family, whatever the Checker's setting.
+func (c *Checker) networks(address string) []string {
+	if ip := net.ParseIP(address); ip != nil {
+		if ip.To4() != nil {
+			return []string{"tcp4"}
+		}
+		return []string{"tcp6"}
+	}
+	switch c.family {
+	case IPv4Only:
+		return []string{"tcp4"}
+	case IPv6Only:
+		return []string{"tcp6"}
+	}
+	return []string{"tcp4", "tcp6"}
+}
+
+// CheckPortsFree verifies every TCP port can be bound on all interfaces,
+// which is how docker publishes the proxy ports.
+func (c *Checker) CheckPortsFree(ports ...int) error {
+	return c.CheckPortsFreeOn("", ports...)
+}
+
+// CheckPortsFreeOn is like CheckPortsFree but binds on the given address
+// (e.g. "127.0.0.1"). Without an IP address each port is probed on IPv4
+// and IPv6 separately, as a "tcp" listener would accept a port that is only
+// taken on one of them. It returns a *PortInUseError naming every conflict.
+func (c *Checker) CheckPortsFreeOn(address string, ports ...int) error {
+	var busy []int
+	for _, port := range ports {
+		if !c.portFree(address, port) {
+			busy = append(busy, port)
+		}
+	}
+	if len(busy) > 0 {
+		return &PortInUseError{Address: address, Ports: busy}
+	}
+	return nil
+}
+
+// wildcards are the all-interfaces addresses of each listen network.
+var wildcards = map[string]string{"tcp4": "0.0.0.0", "tcp6": "::"}
+
+// portFree binds port on address in every probed family. A family the host
+// or address has no support for is skipped, but a port that could not be
+// probed in any family is not free.
+func (c *Checker) portFree(address string, port int) bool {
+	probed := false
+	for _, network := range c.networks(address) {
+		host := address
+		if host == "" {
+			host = wildcards[network]
+		}
+		ln, err := net.Listen(network, net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			if familyUnavailable(err) {
+				c.logger.Debug("Skipping %s check of port %d on %q: %v", network, port, address, err)
+				continue
+			}
+			c.logger.Debug("Port %d on %q is not available over %s: %v", port, address, network, err)
+			return false
+		}
+		ln.Close()
+		probed = true
+	}
+	return probed
+}
+
+// familyUnavailable reports whether a listen error means the IP version
+// cannot be used at all, e.g. IPv6 disabled or a host without an IPv6
+// address, rather than that the port is taken.
+func familyUnavailable(err error) bool {
+	var addrErr *net.AddrError
+	if errors.As(err, &addrErr) && addrErr.Err == "no suitable address found" {
+		return true
+	}
+	return errors.Is(err, syscall.EAFNOSUPPORT) || errors.Is(err, syscall.EADDRNOTAVAIL)
+}
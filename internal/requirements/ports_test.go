@@ -0,0 +1,87 @@
+package requirements
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"fusionaly-installer/internal/logging"
+)
+
+func TestCheckPortsFree(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	checker := NewChecker(logger)
+
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer occupied.Close()
+	busyPort := occupied.Addr().(*net.TCPAddr).Port
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find available port: %v", err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	t.Run("free port", func(t *testing.T) {
+		assert.NoError(t, checker.CheckPortsFreeOn("127.0.0.1", freePort))
+	})
+
+	t.Run("occupied port is reported", func(t *testing.T) {
+		err := checker.CheckPortsFreeOn("127.0.0.1", freePort, busyPort)
+		assert.True(t, errors.Is(err, ErrPortInUse))
+
+		var portErr *PortInUseError
+		if assert.True(t, errors.As(err, &portErr)) {
+			assert.Equal(t, []int{busyPort}, portErr.Ports)
+			assert.Equal(t, "127.0.0.1", portErr.Address)
+		}
+	})
+
+	t.Run("all interfaces", func(t *testing.T) {
+		err := checker.CheckPortsFree(busyPort)
+		assert.True(t, errors.Is(err, ErrPortInUse))
+	})
+}
+
+func TestCheckPortsFreeDetectsIPv6OnlyListener(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+
+	// A tcp6 listener on [::] sets IPV6_V6ONLY, so the port stays free on IPv4.
+	occupied, err := net.Listen("tcp6", "[::]:0")
+	if err != nil {
+		t.Skipf("IPv6 is not available: %v", err)
+	}
+	defer occupied.Close()
+	port := occupied.Addr().(*net.TCPAddr).Port
+
+	t.Run("dual stack", func(t *testing.T) {
+		err := NewChecker(logger).CheckPortsFree(port)
+		var portErr *PortInUseError
+		if assert.True(t, errors.As(err, &portErr)) {
+			assert.Equal(t, []int{port}, portErr.Ports)
+		}
+	})
+
+	t.Run("IPv6 only", func(t *testing.T) {
+		checker := NewChecker(logger)
+		checker.SetIPFamily(IPv6Only)
+		assert.True(t, errors.Is(checker.CheckPortsFree(port), ErrPortInUse))
+	})
+
+	t.Run("IPv4 only", func(t *testing.T) {
+		checker := NewChecker(logger)
+		checker.SetIPFamily(IPv4Only)
+		assert.NoError(t, checker.CheckPortsFree(port))
+	})
+
+	t.Run("IPv6 address", func(t *testing.T) {
+		err := NewChecker(logger).CheckPortsFreeOn("::1", port)
+		assert.True(t, errors.Is(err, ErrPortInUse))
+	})
+}
@@ -1,15 +1,30 @@
 package requirements
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"fusionaly-installer/internal/logging"
 )
 
 type Checker struct {
-	logger *logging.Logger
+	logger  *logging.Logger
+	statfs  func(path string) (uint64, error) // Free-space lookup; nil uses syscall.Statfs
+	meminfo func() ([]byte, error)            // /proc/meminfo contents; nil reads the real file
+	family  IPFamily                          // IP versions the port checks bind on
+
+	registry      string                                                            // Host WaitForNetwork probes; empty means DefaultRegistryHost
+	resolver      Resolver                                                          // DNS lookups for WaitForNetwork; nil means net.DefaultResolver
+	dial          func(ctx context.Context, network, addr string) (net.Conn, error) // Connects to the registry; nil uses a net.Dialer
+	retryInterval time.Duration                                                     // Pause between network checks; zero uses DefaultNetworkRetryInterval
+
+	timeSource TimeSource       // Reference for CheckClockSkew; nil reads DefaultClockSource's Date header
+	now        func() time.Time // Host clock; nil uses time.Now
+
+	httpPort, httpsPort int // Proxy ports CheckSystemRequirements needs free; zero means 80 and 443
 }
 
 func NewChecker(logger *logging.Logger) *Checker {
@@ -18,6 +33,12 @@ func NewChecker(logger *logging.Logger) *Checker {
 	}
 }
 
+// SetPorts sets the HTTP and HTTPS ports CheckSystemRequirements needs free,
+// for an instance whose proxy does not publish on 80 and 443.
+func (c *Checker) SetPorts(http, https int) {
+	c.httpPort, c.httpsPort = http, https
+}
+
 // CheckSystemRequirements performs all system requirement checks
 func (c *Checker) CheckSystemRequirements() error {
 	fmt.Println("🔍 Performing system checks...")
@@ -58,27 +79,23 @@ func (c *Checker) checkPortAvailability() error {
 
 	fmt.Print("🔍 Checking port availability... ")
 
-	if !c.checkPort(80) {
-		fmt.Printf("\n❌ Error: Port 80 is not available - required for HTTP access and SSL certificate generation\n")
-		return fmt.Errorf("port 80 is not available")
+	http, https := c.httpPort, c.httpsPort
+	if http == 0 {
+		http = 80
 	}
-
-	if !c.checkPort(443) {
-		fmt.Printf("\n❌ Error: Port 443 is not available - required for HTTPS access and SSL certificate generation\n")
-		return fmt.Errorf("port 443 is not available")
+	if https == 0 {
+		https = 443
+	}
+	if err := c.CheckPortsFree(http, https); err != nil {
+		fmt.Printf("\n❌ Error: %v - ports %d and %d are required for HTTP(S) access and SSL certificate generation\n", err, http, https)
+		return err
 	}
 
-	fmt.Println("✅ Ports 80 and 443 are available")
+	fmt.Printf("✅ Ports %d and %d are available\n", http, https)
 	return nil
 }
 
 // checkPort checks if a specific port is available
 func (c *Checker) checkPort(port int) bool {
-	address := fmt.Sprintf("localhost:%d", port)
-	listener, err := net.Listen("tcp", address)
-	if err != nil {
-		return false
-	}
-	listener.Close()
-	return true
+	return c.portFree("localhost", port)
 }
@@ -147,6 +147,36 @@ func TestCheckPortAvailability(t *testing.T) {
 	}
 }
 
+func TestCheckPortAvailability_InstancePorts(t *testing.T) {
+	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
+	checker := NewChecker(logger)
+
+	originalSkip := os.Getenv("SKIP_PORT_CHECKING")
+	defer os.Setenv("SKIP_PORT_CHECKING", originalSkip)
+	os.Setenv("SKIP_PORT_CHECKING", "")
+
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer occupied.Close()
+	busyPort := occupied.Addr().(*net.TCPAddr).Port
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find available port: %v", err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	// The instance's own ports are checked, not 80 and 443
+	checker.SetPorts(freePort, busyPort)
+	assert.ErrorIs(t, checker.checkPortAvailability(), ErrPortInUse)
+
+	checker.SetPorts(freePort, freePort)
+	assert.NoError(t, checker.checkPortAvailability())
+}
+
 func TestCheckSystemRequirements(t *testing.T) {
 	logger := logging.NewLogger(logging.Config{Level: "error", Quiet: true})
 	checker := NewChecker(logger)
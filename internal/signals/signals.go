@@ -0,0 +1,60 @@
+package signals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ErrInterrupted is the cancellation cause of a context ended by a signal.
+var ErrInterrupted = errors.New("interrupted")
+
+// exit is replaced in tests.
+var exit = os.Exit
+
+// NotifyContext returns a copy of parent that is cancelled on the first
+// SIGINT or SIGTERM, with ErrInterrupted as its cause. onSignal, if set, is
+// called once with the signal so the caller can warn or clean up while
+// cancelled commands unwind. A second signal exits immediately with status
+// 130 for when cleanup itself hangs. stop releases the signal handlers.
+func NotifyContext(parent context.Context, onSignal func(os.Signal)) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-ch:
+			cancel(fmt.Errorf("%w by %s", ErrInterrupted, sig))
+			if onSignal != nil {
+				onSignal(sig)
+			}
+		case <-done:
+			return
+		}
+		select {
+		case <-ch:
+			exit(130)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			cancel(nil)
+		})
+	}
+}
+
+// Interrupted reports whether ctx was cancelled by a signal.
+func Interrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrInterrupted)
+}
@@ -0,0 +1,107 @@
+package signals
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+)
+
+func TestNotifyContextCancelsRunningCommand(t *testing.T) {
+	received := make(chan os.Signal, 1)
+	ctx, stop := NotifyContext(context.Background(), func(sig os.Signal) { received <- sig })
+	defer stop()
+
+	time.AfterFunc(20*time.Millisecond, func() { syscall.Kill(os.Getpid(), syscall.SIGINT) })
+	_, err := executor.NewCommandExecutor().Run(ctx, "sleep", "5")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if !Interrupted(ctx) {
+		t.Errorf("Interrupted = false, cause %v", context.Cause(ctx))
+	}
+
+	select {
+	case sig := <-received:
+		if sig != os.Interrupt {
+			t.Errorf("onSignal got %v, want interrupt", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("onSignal was not called")
+	}
+}
+
+func TestNotifyContextSecondSignalExits(t *testing.T) {
+	exited := make(chan int, 1)
+	exit = func(code int) { exited <- code }
+	defer func() { exit = os.Exit }()
+
+	ctx, stop := NotifyContext(context.Background(), nil)
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	<-ctx.Done()
+	syscall.Kill(os.Getpid(), syscall.SIGTERM)
+
+	select {
+	case code := <-exited:
+		if code != 130 {
+			t.Errorf("exit code %d, want 130", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second signal did not exit")
+	}
+}
+
+func TestStopIsNotAnInterrupt(t *testing.T) {
+	ctx, stop := NotifyContext(context.Background(), nil)
+	stop()
+	stop()
+	if ctx.Err() == nil {
+		t.Fatal("stop should cancel the context")
+	}
+	if Interrupted(ctx) {
+		t.Error("a context ended by stop was reported as interrupted")
+	}
+}
+
+func TestWithTimeoutStopsRunningCommand(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	e := executor.WithBaseContext(executor.NewCommandExecutor(), ctx)
+
+	start := time.Now()
+	_, err := e.Run(context.Background(), "sleep", "5")
+	if !errors.Is(err, ErrTimedOut) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a timeout matching context.DeadlineExceeded, got %v", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("a timeout should not look like an interruption: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command was not stopped promptly (%s)", elapsed)
+	}
+	if !TimedOut(ctx) || Interrupted(ctx) {
+		t.Errorf("TimedOut = %v, Interrupted = %v, cause %v", TimedOut(ctx), Interrupted(ctx), context.Cause(ctx))
+	}
+
+	// Commands started after the limit are refused with the same error.
+	if _, err := e.Run(context.Background(), "true"); !errors.Is(err, ErrTimedOut) {
+		t.Errorf("expected ErrTimedOut for a later command, got %v", err)
+	}
+}
+
+func TestWithTimeoutZeroMeansNoLimit(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("a zero limit should not set a deadline")
+	}
+	cancel()
+	if TimedOut(ctx) {
+		t.Error("cancelling is not a timeout")
+	}
+}
@@ -0,0 +1,36 @@
+package signals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimedOut is the cancellation cause of a context ended by WithTimeout.
+var ErrTimedOut = errors.New("timed out")
+
+// timeoutError is the cause WithTimeout sets. It also matches
+// context.DeadlineExceeded, so code checking for a deadline still sees one.
+type timeoutError struct{ limit time.Duration }
+
+func (e timeoutError) Error() string { return fmt.Sprintf("timed out after %s", e.limit) }
+
+func (e timeoutError) Is(target error) bool {
+	return target == ErrTimedOut || target == context.DeadlineExceeded
+}
+
+// WithTimeout returns a copy of parent that ends once limit has elapsed,
+// with a cause matching ErrTimedOut; it bounds a whole command. A zero or
+// negative limit means no limit: only parent ends the context.
+func WithTimeout(parent context.Context, limit time.Duration) (context.Context, context.CancelFunc) {
+	if limit <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, limit, timeoutError{limit})
+}
+
+// TimedOut reports whether ctx ended because its WithTimeout limit passed.
+func TimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrTimedOut)
+}
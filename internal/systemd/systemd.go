@@ -0,0 +1,175 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+const (
+	// DefaultUnitName is the name of the unit that starts the stack on boot
+	DefaultUnitName = "fusionaly.service"
+	// DefaultUnitDir is where administrator-installed units live
+	DefaultUnitDir = "/etc/systemd/system"
+	// DefaultInstallDir is the default installation directory
+	DefaultInstallDir = "/opt/fusionaly"
+	// DefaultBinaryPath is the path to the fusionaly binary
+	DefaultBinaryPath = "/usr/local/bin/fusionaly"
+)
+
+// UnitOptions describes the generated unit.
+type UnitOptions struct {
+	BinaryPath string // Installer binary whose start/stop commands drive the stack
+	InstallDir string // Working directory for the commands
+	Instance   string // Named instance the commands manage; empty for the default installation
+}
+
+// UnitName returns the unit that starts instance on boot: DefaultUnitName
+// for the default installation, fusionaly-<instance>.service otherwise, so
+// each instance's unit is installed and removed on its own.
+func UnitName(instance string) string {
+	if instance == "" {
+		return DefaultUnitName
+	}
+	return strings.TrimSuffix(DefaultUnitName, ".service") + "-" + instance + ".service"
+}
+
+// DefaultUnitOptions returns the options for a standard installation.
+func DefaultUnitOptions() UnitOptions {
+	return UnitOptions{
+		BinaryPath: DefaultBinaryPath,
+		InstallDir: DefaultInstallDir,
+	}
+}
+
+// GenerateSystemdUnit renders a oneshot unit that brings the containers up
+// once docker.service is running and stops them on shutdown.
+func GenerateSystemdUnit(opts UnitOptions) (string, error) {
+	for _, p := range []struct{ name, value string }{
+		{"binary path", opts.BinaryPath},
+		{"install dir", opts.InstallDir},
+	} {
+		if !filepath.IsAbs(p.value) {
+			return "", fmt.Errorf("%s must be an absolute path, got %q", p.name, p.value)
+		}
+		if strings.ContainsAny(p.value, " \t\n\"'\\") {
+			return "", fmt.Errorf("%s %q contains characters systemd would need quoted", p.name, p.value)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Managed by the Fusionaly installer\n")
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Fusionaly analytics stack\n")
+	b.WriteString("Requires=docker.service\n")
+	b.WriteString("After=docker.service network-online.target\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	b.WriteString("RemainAfterExit=yes\n")
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", opts.InstallDir)
+	instance := ""
+	if opts.Instance != "" {
+		instance = " --instance " + opts.Instance
+	}
+	fmt.Fprintf(&b, "ExecStart=%s start%s\n", opts.BinaryPath, instance)
+	fmt.Fprintf(&b, "ExecStop=%s stop%s\n", opts.BinaryPath, instance)
+	b.WriteString("TimeoutStartSec=300\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String(), nil
+}
+
+// Manager installs the unit
+type Manager struct {
+	logger     *logging.Logger
+	runner     executor.Executor
+	unitDir    string
+	unitName   string
+	instance   string
+	writeFile  func(name string, data []byte, perm os.FileMode) error
+	removeFile func(name string) error
+}
+
+// NewManager creates a Manager that writes to DefaultUnitDir and runs
+// systemctl through runner; a dry run of runner leaves the unit file alone
+func NewManager(logger *logging.Logger, runner executor.Executor) *Manager {
+	return &Manager{
+		logger:   logger,
+		runner:   runner,
+		unitDir:  DefaultUnitDir,
+		unitName: DefaultUnitName,
+		writeFile: func(name string, data []byte, perm os.FileMode) error {
+			return executor.WriteFile(runner, name, data, perm)
+		},
+		removeFile: func(name string) error {
+			return executor.Remove(runner, name)
+		},
+	}
+}
+
+// SetInstance makes m install and remove the boot unit of the named
+// instance instead of the default installation's.
+func (m *Manager) SetInstance(name string) {
+	m.instance = name
+	m.unitName = UnitName(name)
+}
+
+// InstallSystemdUnit writes the unit file, reloads systemd and enables the
+// unit so the stack starts on boot.
+func (m *Manager) InstallSystemdUnit(ctx context.Context, opts UnitOptions) error {
+	if os.Getenv("ENV") == "test" {
+		m.logger.InfoWithTime("Skipping systemd unit setup in test environment")
+		return nil
+	}
+
+	// The unit's commands always manage the instance it is named after.
+	opts.Instance = m.instance
+	unit, err := GenerateSystemdUnit(opts)
+	if err != nil {
+		return err
+	}
+
+	unitFile := filepath.Join(m.unitDir, m.unitName)
+	m.logger.Info("Installing systemd unit %s...", unitFile)
+	if err := m.writeFile(unitFile, []byte(unit), 0o644); err != nil {
+		return fmt.Errorf("failed to write unit file %s: %w", unitFile, err)
+	}
+
+	for _, args := range [][]string{
+		{"daemon-reload"},
+		{"enable", m.unitName},
+	} {
+		if res, err := m.runner.Run(ctx, "systemctl", args...); err != nil {
+			return fmt.Errorf("systemctl %s failed: %w - %s", strings.Join(args, " "), err, strings.TrimSpace(res.Stderr))
+		}
+	}
+
+	m.logger.Success("Fusionaly will start automatically on boot")
+	return nil
+}
+
+// RemoveSystemdUnit disables and deletes the unit. A unit that was never
+// installed is not an error.
+func (m *Manager) RemoveSystemdUnit(ctx context.Context) error {
+	unitFile := filepath.Join(m.unitDir, m.unitName)
+	if _, err := m.runner.Run(ctx, "systemctl", "disable", m.unitName); err != nil {
+		m.logger.Warn("Failed to disable %s: %v", m.unitName, err)
+	}
+	if err := m.removeFile(unitFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove unit file %s: %w", unitFile, err)
+	}
+	if res, err := m.runner.Run(ctx, "systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("systemctl daemon-reload failed: %w - %s", err, strings.TrimSpace(res.Stderr))
+	}
+	m.logger.Success("Removed systemd unit %s", unitFile)
+	return nil
+}
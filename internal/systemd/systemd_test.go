@@ -0,0 +1,194 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+func testLogger(t *testing.T) *logging.Logger {
+	dir := t.TempDir()
+	return logging.NewLogger(logging.Config{LogDir: dir})
+}
+
+type fakeRunner struct {
+	calls [][]string
+	errs  []error
+}
+
+func (f *fakeRunner) Run(ctx context.Context, name string, args ...string) (executor.Result, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	if i := len(f.calls) - 1; i < len(f.errs) {
+		return executor.Result{ExitCode: 1, Stderr: "failed"}, f.errs[i]
+	}
+	return executor.Result{}, nil
+}
+
+type fakeFS struct {
+	files map[string]string
+	perm  os.FileMode
+	err   error
+}
+
+func (f *fakeFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.files == nil {
+		f.files = map[string]string{}
+	}
+	f.files[name] = string(data)
+	f.perm = perm
+	return nil
+}
+
+func newTestManager(t *testing.T, fr *fakeRunner, fs *fakeFS) *Manager {
+	t.Setenv("ENV", "")
+	m := NewManager(testLogger(t), fr)
+	m.writeFile = fs.WriteFile
+	return m
+}
+
+func TestGenerateSystemdUnit(t *testing.T) {
+	unit, err := GenerateSystemdUnit(DefaultUnitOptions())
+	if err != nil {
+		t.Fatalf("GenerateSystemdUnit: %v", err)
+	}
+	for _, want := range []string{
+		"Requires=docker.service\n",
+		"After=docker.service network-online.target\n",
+		"Type=oneshot\n",
+		"RemainAfterExit=yes\n",
+		"WorkingDirectory=/opt/fusionaly\n",
+		"ExecStart=/usr/local/bin/fusionaly start\n",
+		"ExecStop=/usr/local/bin/fusionaly stop\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestGenerateSystemdUnitRejectsBadPaths(t *testing.T) {
+	for _, opts := range []UnitOptions{
+		{BinaryPath: "fusionaly", InstallDir: DefaultInstallDir},
+		{BinaryPath: DefaultBinaryPath, InstallDir: ""},
+		{BinaryPath: "/opt/my tools/fusionaly", InstallDir: DefaultInstallDir},
+	} {
+		if _, err := GenerateSystemdUnit(opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+}
+
+func TestInstallSystemdUnit(t *testing.T) {
+	fr := &fakeRunner{}
+	fs := &fakeFS{}
+	m := newTestManager(t, fr, fs)
+
+	if err := m.InstallSystemdUnit(context.Background(), DefaultUnitOptions()); err != nil {
+		t.Fatalf("InstallSystemdUnit: %v", err)
+	}
+
+	unit, ok := fs.files["/etc/systemd/system/fusionaly.service"]
+	if !ok {
+		t.Fatalf("unit file not written, got %v", fs.files)
+	}
+	want, _ := GenerateSystemdUnit(DefaultUnitOptions())
+	if unit != want {
+		t.Errorf("unit content mismatch:\n%s", unit)
+	}
+	if fs.perm != 0o644 {
+		t.Errorf("perm = %o, want 644", fs.perm)
+	}
+
+	wantCalls := [][]string{
+		{"systemctl", "daemon-reload"},
+		{"systemctl", "enable", "fusionaly.service"},
+	}
+	if !reflect.DeepEqual(fr.calls, wantCalls) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", wantCalls, fr.calls)
+	}
+}
+
+func TestInstallSystemdUnitWriteFailure(t *testing.T) {
+	fr := &fakeRunner{}
+	m := newTestManager(t, fr, &fakeFS{err: errors.New("read-only file system")})
+
+	if err := m.InstallSystemdUnit(context.Background(), DefaultUnitOptions()); err == nil {
+		t.Fatal("expected write error")
+	}
+	if len(fr.calls) != 0 {
+		t.Errorf("systemctl should not run when the unit is not written, got %v", fr.calls)
+	}
+}
+
+func TestInstallSystemdUnitStopsOnReloadFailure(t *testing.T) {
+	fr := &fakeRunner{errs: []error{errors.New("exit status 1")}}
+	m := newTestManager(t, fr, &fakeFS{})
+
+	err := m.InstallSystemdUnit(context.Background(), DefaultUnitOptions())
+	if err == nil || !strings.Contains(err.Error(), "daemon-reload") {
+		t.Fatalf("expected daemon-reload error, got %v", err)
+	}
+	if len(fr.calls) != 1 {
+		t.Errorf("enable should not run after daemon-reload fails, got %v", fr.calls)
+	}
+}
+
+func TestRemoveSystemdUnit(t *testing.T) {
+	fr := &fakeRunner{}
+	m := newTestManager(t, fr, &fakeFS{})
+	var removed string
+	m.removeFile = func(name string) error { removed = name; return nil }
+
+	if err := m.RemoveSystemdUnit(context.Background()); err != nil {
+		t.Fatalf("RemoveSystemdUnit: %v", err)
+	}
+	if removed != "/etc/systemd/system/fusionaly.service" {
+		t.Errorf("removed %q", removed)
+	}
+	wantCalls := [][]string{
+		{"systemctl", "disable", "fusionaly.service"},
+		{"systemctl", "daemon-reload"},
+	}
+	if !reflect.DeepEqual(fr.calls, wantCalls) {
+		t.Errorf("commands mismatch\nwant %v\ngot  %v", wantCalls, fr.calls)
+	}
+}
+
+func TestInstallSystemdUnitForInstance(t *testing.T) {
+	fr := &fakeRunner{}
+	fs := &fakeFS{}
+	m := newTestManager(t, fr, fs)
+	m.SetInstance("staging")
+
+	opts := DefaultUnitOptions()
+	opts.InstallDir = "/opt/fusionaly-staging"
+	if err := m.InstallSystemdUnit(context.Background(), opts); err != nil {
+		t.Fatalf("InstallSystemdUnit: %v", err)
+	}
+
+	if _, ok := fs.files["/etc/systemd/system/fusionaly.service"]; ok {
+		t.Fatal("a named instance must not overwrite the default instance's unit")
+	}
+	unit := fs.files["/etc/systemd/system/fusionaly-staging.service"]
+	for _, want := range []string{
+		"ExecStart=/usr/local/bin/fusionaly start --instance staging\n",
+		"ExecStop=/usr/local/bin/fusionaly stop --instance staging\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+	if got := fr.calls[len(fr.calls)-1]; !reflect.DeepEqual(got, []string{"systemctl", "enable", "fusionaly-staging.service"}) {
+		t.Errorf("enabled %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+)
+
+// Steps of ApplyConfig, as reported by ApplyError.
+const (
+	ApplyStepSnapshot = "snapshot" // Reading the current .env file
+	ApplyStepValidate = "validate" // Checking the new configuration
+	ApplyStepApply    = "apply"    // Writing the .env file and redeploying the app
+	ApplyStepHealth   = "health"   // Waiting for the redeployed app to be healthy
+)
+
+// ErrConfigReverted is matched (via errors.Is) by an ApplyError whose
+// change was undone by restoring the snapshot.
+var ErrConfigReverted = errors.New("config change reverted")
+
+// ApplyError reports the ApplyConfig step that failed and whether the
+// previous configuration was restored.
+type ApplyError struct {
+	Step      string
+	Reverted  bool
+	RevertErr error // Why restoring the snapshot failed; nil when it was not needed or worked
+	Err       error
+}
+
+func (e *ApplyError) Error() string {
+	msg := fmt.Sprintf("config apply failed at %s: %v", e.Step, e.Err)
+	switch {
+	case e.RevertErr != nil:
+		msg += fmt.Sprintf("; reverting to the previous configuration also failed: %v", e.RevertErr)
+	case e.Reverted:
+		msg += "; reverted to the previous configuration"
+	}
+	return msg
+}
+
+func (e *ApplyError) Is(target error) bool {
+	return target == ErrConfigReverted && e.Reverted
+}
+
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyConfig replaces the installation's configuration with newConfig as a
+// unit: it snapshots the .env file, validates and writes the new settings,
+// redeploys the app next to the running one and waits up to
+// PostUpdateHealthTimeout for it to be healthy. If the redeploy or the health
+// check fails, the snapshot is written back and, when the new app was
+// deployed, the previous configuration is redeployed. An empty PrivateKey
+// keeps the current one. Failures are *ApplyError values naming the step.
+func (u *Updater) ApplyConfig(ctx context.Context, newConfig config.ConfigData) error {
+	envFile := filepath.Join(u.config.GetData().InstallDir, ".env")
+	// Loading may add a missing private key to the file, so snapshot after.
+	if err := u.config.LoadFromFile(envFile); err != nil {
+		return &ApplyError{Step: ApplyStepSnapshot, Err: err}
+	}
+	snapshot, err := os.ReadFile(envFile)
+	if err != nil {
+		return &ApplyError{Step: ApplyStepSnapshot, Err: err}
+	}
+	previous := u.config.GetData()
+
+	if newConfig.PrivateKey == "" {
+		newConfig.PrivateKey = previous.PrivateKey
+	}
+	candidate := config.NewConfig(u.logger)
+	candidate.SetData(newConfig)
+	if err := candidate.Validate(); err != nil {
+		return &ApplyError{Step: ApplyStepValidate, Err: err}
+	}
+	if newConfig.InstallDir != previous.InstallDir {
+		return &ApplyError{Step: ApplyStepValidate, Err: fmt.Errorf("INSTALL_DIR cannot be changed from %s", previous.InstallDir)}
+	}
+
+	u.config.SetData(newConfig)
+	if err := u.config.SaveToFile(envFile); err != nil {
+		return u.revert(ApplyStepApply, err, envFile, snapshot, previous, false)
+	}
+	// Docker.Update starts the new app next to the running one, so a failure
+	// here leaves the previous configuration serving.
+	if err := u.docker.Update(u.config); err != nil {
+		return u.revert(ApplyStepApply, err, envFile, snapshot, previous, false)
+	}
+	if err := u.verifyHealthy(ctx); err != nil {
+		u.logger.Error("The app is not healthy with the new configuration: %v", err)
+		return u.revert(ApplyStepHealth, err, envFile, snapshot, previous, true)
+	}
+
+	u.logger.Success("Configuration applied")
+	return nil
+}
+
+// revert writes snapshot back to envFile and, when redeploy is set,
+// redeploys the app with previous, returning the ApplyError for step.
+func (u *Updater) revert(step string, cause error, envFile string, snapshot []byte, previous config.ConfigData, redeploy bool) error {
+	u.logger.Warn("Reverting to the previous configuration")
+	applyErr := &ApplyError{Step: step, Err: cause}
+	if err := executor.WriteFile(executor.Default(), envFile, snapshot, 0o600); err != nil {
+		applyErr.RevertErr = fmt.Errorf("restore %s: %w", envFile, err)
+		return applyErr
+	}
+	u.config.SetData(previous)
+	if redeploy {
+		if err := u.docker.Update(u.config); err != nil {
+			applyErr.RevertErr = fmt.Errorf("redeploy: %w", err)
+			return applyErr
+		}
+	}
+	applyErr.Reverted = true
+	u.logger.Success("Previous configuration restored")
+	return applyErr
+}
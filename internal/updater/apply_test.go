@@ -0,0 +1,132 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadedData loads the installation's current settings, as ApplyConfig's
+// caller would start from them.
+func loadedData(t *testing.T, u *Updater, envFile string) {
+	t.Helper()
+	if err := u.config.LoadFromFile(envFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyConfig_HappyPath(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	loadedData(t, u, envFile)
+	data := u.config.GetData()
+	data.AppMemory = "1g"
+
+	if err := u.ApplyConfig(context.Background(), data); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+	want := []string{"deploy karloscodes/fusionaly-beta:1.2.0", "health fusionaly-app-2"}
+	if !reflect.DeepEqual(fd.calls, want) {
+		t.Errorf("calls mismatch\nwant %v\ngot  %v", want, fd.calls)
+	}
+	content, _ := os.ReadFile(envFile)
+	if !strings.Contains(string(content), "APP_MEMORY_LIMIT=1g\n") {
+		t.Errorf("env file should record the new setting:\n%s", content)
+	}
+}
+
+func TestApplyConfig_RevertsOnFailedHealthCheck(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	loadedData(t, u, envFile)
+	before, _ := os.ReadFile(envFile)
+	data := u.config.GetData()
+	data.AppImage = "karloscodes/fusionaly-beta:1.3.0"
+	fd.healthErrs = []error{errors.New("container reported unhealthy")}
+
+	err := u.ApplyConfig(context.Background(), data)
+	if !errors.Is(err, ErrConfigReverted) {
+		t.Fatalf("expected ErrConfigReverted, got %v", err)
+	}
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || applyErr.Step != ApplyStepHealth {
+		t.Errorf("expected failure at %s, got %v", ApplyStepHealth, err)
+	}
+	want := []string{
+		"deploy karloscodes/fusionaly-beta:1.3.0",
+		"health fusionaly-app-2",
+		"deploy karloscodes/fusionaly-beta:1.2.0",
+	}
+	if !reflect.DeepEqual(fd.calls, want) {
+		t.Errorf("calls mismatch\nwant %v\ngot  %v", want, fd.calls)
+	}
+	after, _ := os.ReadFile(envFile)
+	if string(after) != string(before) {
+		t.Errorf("env file should be restored\nwant %s\ngot  %s", before, after)
+	}
+	if got := u.config.GetData().AppImage; got != "karloscodes/fusionaly-beta:1.2.0" {
+		t.Errorf("config should hold the previous image, got %s", got)
+	}
+}
+
+func TestApplyConfig_ReportsFailedRevert(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	loadedData(t, u, envFile)
+	data := u.config.GetData()
+	data.AppImage = "karloscodes/fusionaly-beta:1.3.0"
+	fd.healthErrs = []error{errors.New("container reported unhealthy")}
+	fd.deployErrs = []error{nil, errors.New("port already allocated")}
+
+	err := u.ApplyConfig(context.Background(), data)
+	if errors.Is(err, ErrConfigReverted) {
+		t.Fatalf("a failed redeploy should not count as reverted: %v", err)
+	}
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || applyErr.RevertErr == nil {
+		t.Errorf("expected the revert failure to be reported, got %v", err)
+	}
+}
+
+func TestApplyConfig_DeployFailureRestoresFile(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	loadedData(t, u, envFile)
+	before, _ := os.ReadFile(envFile)
+	data := u.config.GetData()
+	data.AppImage = "karloscodes/fusionaly-beta:1.3.0"
+	fd.deployErrs = []error{errors.New("pull failed")}
+
+	err := u.ApplyConfig(context.Background(), data)
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || applyErr.Step != ApplyStepApply || !applyErr.Reverted {
+		t.Fatalf("expected a reverted failure at %s, got %v", ApplyStepApply, err)
+	}
+	if want := []string{"deploy karloscodes/fusionaly-beta:1.3.0"}; !reflect.DeepEqual(fd.calls, want) {
+		t.Errorf("the previous app is still serving and should not be redeployed, got %v", fd.calls)
+	}
+	after, _ := os.ReadFile(envFile)
+	if string(after) != string(before) {
+		t.Errorf("env file should be restored\nwant %s\ngot  %s", before, after)
+	}
+}
+
+func TestApplyConfig_RejectsInvalidConfig(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	loadedData(t, u, envFile)
+	before, _ := os.ReadFile(envFile)
+	data := u.config.GetData()
+	data.Domain = "not a domain"
+
+	err := u.ApplyConfig(context.Background(), data)
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || applyErr.Step != ApplyStepValidate {
+		t.Fatalf("expected failure at %s, got %v", ApplyStepValidate, err)
+	}
+	if len(fd.calls) != 0 {
+		t.Errorf("nothing should be deployed, got %v", fd.calls)
+	}
+	after, _ := os.ReadFile(envFile)
+	if string(after) != string(before) {
+		t.Errorf("env file should be untouched\nwant %s\ngot  %s", before, after)
+	}
+}
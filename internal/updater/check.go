@@ -0,0 +1,111 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/docker"
+	apperrors "fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+// ReleaseConfigAsset is the release asset naming the app image each
+// installer release deploys.
+const ReleaseConfigAsset = "config.json"
+
+// semverPattern matches the tags CheckForUpdate can compare, e.g. "v1.4.2"
+// or "1.4".
+var semverPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,2}$`)
+
+// UpdateInfo is the result of CheckForUpdate.
+type UpdateInfo struct {
+	Current   string // App version running now
+	Latest    string // App version of the newest release
+	Available bool   // Latest is newer than Current
+}
+
+// UpdateChecker compares the deployed app version with the newest release.
+type UpdateChecker struct {
+	logger     *logging.Logger
+	client     *http.Client
+	releaseURL string
+	installed  func(ctx context.Context) (string, error)
+}
+
+// NewUpdateChecker creates an UpdateChecker that reads the latest release
+// from GitHub and the installed version from the running containers.
+func NewUpdateChecker(logger *logging.Logger) *UpdateChecker {
+	return &UpdateChecker{
+		logger:     logger,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		releaseURL: GitHubAPIURL,
+		installed:  docker.NewStack(logger, executor.Default()).InstalledVersion,
+	}
+}
+
+// CheckForUpdate reports whether a newer app version than the installed one
+// has been released. Failing to reach the release source returns an
+// *errors.NetworkError; nothing is changed on the host either way.
+func (c *UpdateChecker) CheckForUpdate(ctx context.Context) (UpdateInfo, error) {
+	current, err := c.installed(ctx)
+	if err != nil {
+		return UpdateInfo{}, err
+	}
+	latest, err := c.latestAppVersion(ctx)
+	if err != nil {
+		return UpdateInfo{Current: current}, err
+	}
+
+	info := UpdateInfo{Current: current, Latest: latest}
+	for _, v := range []string{current, latest} {
+		if !semverPattern.MatchString(v) {
+			return info, fmt.Errorf("cannot compare versions %q and %q: %q is not a release version", current, latest, v)
+		}
+	}
+	info.Available = compareVersions(current, latest) < 0
+	return info, nil
+}
+
+// latestAppVersion reads the app image tag from the latest release's
+// config.json.
+func (c *UpdateChecker) latestAppVersion(ctx context.Context) (string, error) {
+	resp, err := fetch(ctx, c.client, c.releaseURL)
+	if err != nil {
+		return "", apperrors.NewNetworkError("fetch latest release", c.releaseURL, err)
+	}
+	var rel release
+	err = json.NewDecoder(resp.Body).Decode(&rel)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse release JSON: %w", err)
+	}
+
+	configURL := rel.assetURL(ReleaseConfigAsset)
+	if configURL == "" {
+		return "", fmt.Errorf("release %s has no %s", rel.TagName, ReleaseConfigAsset)
+	}
+	resp, err = fetch(ctx, c.client, configURL)
+	if err != nil {
+		return "", apperrors.NewNetworkError("fetch release config", configURL, err)
+	}
+	defer resp.Body.Close()
+
+	var releaseConfig struct {
+		AppImage string `json:"app_image"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releaseConfig); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", ReleaseConfigAsset, err)
+	}
+	image, _, _ := strings.Cut(releaseConfig.AppImage, "@")
+	i := strings.LastIndex(image, ":")
+	if i < 0 || i < strings.LastIndex(image, "/") {
+		return "", fmt.Errorf("release app image %q has no version tag", releaseConfig.AppImage)
+	}
+	return image[i+1:], nil
+}
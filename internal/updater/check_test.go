@@ -0,0 +1,81 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	apperrors "fusionaly-installer/internal/errors"
+	"fusionaly-installer/internal/logging"
+)
+
+const testConfigURL = "https://dl.example.com/config.json"
+
+func newTestUpdateChecker(installed string, transport http.RoundTripper) *UpdateChecker {
+	return &UpdateChecker{
+		logger:     logging.NewLogger(logging.Config{Level: "error"}),
+		client:     &http.Client{Transport: transport},
+		releaseURL: testReleaseURL,
+		installed:  func(context.Context) (string, error) { return installed, nil },
+	}
+}
+
+func releaseStub(appImage string) *stubTransport {
+	return &stubTransport{bodies: map[string]string{
+		testReleaseURL: `{"tag_name":"v0.9.0","assets":[{"name":"config.json","browser_download_url":"` + testConfigURL + `"}]}`,
+		testConfigURL:  `{"app_image":"` + appImage + `","caddy_image":"caddy:2.7-alpine"}`,
+	}}
+}
+
+func TestCheckForUpdate_Available(t *testing.T) {
+	c := newTestUpdateChecker("v1.4.2", releaseStub("karloscodes/fusionaly-beta:v1.10.0"))
+
+	info, err := c.CheckForUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdate returned error: %v", err)
+	}
+	want := UpdateInfo{Current: "v1.4.2", Latest: "v1.10.0", Available: true}
+	if info != want {
+		t.Errorf("info = %+v, want %+v", info, want)
+	}
+}
+
+func TestCheckForUpdate_UpToDate(t *testing.T) {
+	c := newTestUpdateChecker("1.10.0", releaseStub("karloscodes/fusionaly-beta:v1.10.0"))
+
+	info, err := c.CheckForUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdate returned error: %v", err)
+	}
+	if info.Available {
+		t.Errorf("expected no update, got %+v", info)
+	}
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("dial tcp: no route to host")
+}
+
+func TestCheckForUpdate_NetworkFailure(t *testing.T) {
+	c := newTestUpdateChecker("v1.4.2", failingTransport{})
+
+	info, err := c.CheckForUpdate(context.Background())
+	var netErr *apperrors.NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("expected *errors.NetworkError, got %v", err)
+	}
+	if info.Current != "v1.4.2" || info.Available {
+		t.Errorf("unexpected info on failure: %+v", info)
+	}
+}
+
+func TestCheckForUpdate_UncomparableVersion(t *testing.T) {
+	c := newTestUpdateChecker("latest", releaseStub("karloscodes/fusionaly-beta:v1.10.0"))
+
+	if _, err := c.CheckForUpdate(context.Background()); err == nil {
+		t.Fatal("expected an error for a non-release installed tag")
+	}
+}
@@ -0,0 +1,108 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// ErrDigestMismatch is matched (via errors.Is) by every DigestMismatchError.
+var ErrDigestMismatch = errors.New("running image digest does not match the deployed one")
+
+// DigestMismatchError reports an app container whose image is not the one
+// recorded when it was deployed, e.g. because its tag was re-pushed and the
+// container was recreated from the new image.
+type DigestMismatchError struct {
+	Image    string
+	Recorded string
+	Running  []string
+}
+
+func (e *DigestMismatchError) Error() string {
+	running := "none"
+	if len(e.Running) > 0 {
+		running = strings.Join(e.Running, ", ")
+	}
+	return fmt.Sprintf("%s: %s was deployed as %s but the container runs %s", ErrDigestMismatch, e.Image, e.Recorded, running)
+}
+
+func (e *DigestMismatchError) Is(target error) bool {
+	return target == ErrDigestMismatch
+}
+
+// digestSource is the part of *docker.Docker used to read running digests.
+type digestSource interface {
+	ContainerImageDigests(container string) ([]string, error)
+}
+
+// RecordDigest stores the registry digest image resolved to on its most
+// recent version history entry, adding an entry if the image has none.
+func RecordDigest(installDir, image, digest string) error {
+	h, err := loadHistory(installDir)
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := len(h.Deployments) - 1; i >= 0; i-- {
+		if h.Deployments[i].Image == image {
+			h.Deployments[i].Digest = digest
+			found = true
+			break
+		}
+	}
+	if !found {
+		h.push(image, time.Now().UTC())
+		h.Deployments[len(h.Deployments)-1].Digest = digest
+	}
+	return h.save(installDir)
+}
+
+// recordDigest pins the digest image was pulled as on its history entry.
+// Failures only weaken later verification, so they are logged, not returned.
+func (u *Updater) recordDigest(installDir, image string) {
+	digest, err := u.docker.ImageDigest(image)
+	if err != nil {
+		u.logger.Warn("Failed to resolve image digest for %s: %v", image, err)
+		return
+	}
+	if err := RecordDigest(installDir, image, digest); err != nil {
+		u.logger.Warn("Failed to record image digest: %v", err)
+	}
+}
+
+// VerifyImageDigest compares the image container runs with the digest
+// recorded for the latest deployment. A mismatch is logged as a warning, or
+// returned as a *DigestMismatchError when strict is set. Nothing is checked
+// when no digest was recorded, as for installs that predate digest pinning.
+func VerifyImageDigest(logger *logging.Logger, installDir, container string, src digestSource, strict bool) error {
+	h, err := loadHistory(installDir)
+	if err != nil {
+		return err
+	}
+	if len(h.Deployments) == 0 || h.Deployments[len(h.Deployments)-1].Digest == "" {
+		logger.Debug("No image digest recorded, skipping verification")
+		return nil
+	}
+	latest := h.Deployments[len(h.Deployments)-1]
+
+	running, err := src.ContainerImageDigests(container)
+	if err != nil {
+		return fmt.Errorf("failed to read image digest of %s: %w", container, err)
+	}
+	for _, digest := range running {
+		if digest == latest.Digest {
+			logger.Debug("Image digest of %s matches %s", container, latest.Digest)
+			return nil
+		}
+	}
+
+	mismatch := &DigestMismatchError{Image: latest.Image, Recorded: latest.Digest, Running: running}
+	if strict {
+		return mismatch
+	}
+	logger.Warn("%v", mismatch)
+	return nil
+}
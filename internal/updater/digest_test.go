@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/logging"
+)
+
+type fakeDigests struct {
+	digests map[string][]string
+}
+
+func (f *fakeDigests) ContainerImageDigests(container string) ([]string, error) {
+	digests, ok := f.digests[container]
+	if !ok {
+		return nil, errors.New("no such container")
+	}
+	return digests, nil
+}
+
+func TestRecordDigest_SetsLatestMatchingEntry(t *testing.T) {
+	dir := t.TempDir()
+	if err := RecordDeployment(dir, "app:1.0.0", "app:1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordDigest(dir, "app:1.1.0", "sha256:aaa"); err != nil {
+		t.Fatalf("RecordDigest: %v", err)
+	}
+	h, _ := loadHistory(dir)
+	if len(h.Deployments) != 2 || h.Deployments[1].Digest != "sha256:aaa" || h.Deployments[0].Digest != "" {
+		t.Errorf("unexpected history: %+v", h.Deployments)
+	}
+}
+
+func TestVerifyImageDigest(t *testing.T) {
+	dir := t.TempDir()
+	if err := RecordDeployment(dir, "app:1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordDigest(dir, "app:1.1.0", "sha256:aaa"); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(t.TempDir(), "install.log")
+	logger := logging.NewLogger(logging.Config{FilePath: logPath, FileOnly: true})
+
+	src := &fakeDigests{digests: map[string][]string{
+		"fusionaly-app-1": {"sha256:aaa"},
+		"fusionaly-app-2": {"sha256:bbb"},
+	}}
+
+	if err := VerifyImageDigest(logger, dir, "fusionaly-app-1", src, true); err != nil {
+		t.Errorf("matching digest should verify, got %v", err)
+	}
+
+	err := VerifyImageDigest(logger, dir, "fusionaly-app-2", src, true)
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("expected ErrDigestMismatch in strict mode, got %v", err)
+	}
+	var mismatch *DigestMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Recorded != "sha256:aaa" || mismatch.Running[0] != "sha256:bbb" {
+		t.Errorf("unexpected mismatch details: %+v", mismatch)
+	}
+
+	if err := VerifyImageDigest(logger, dir, "fusionaly-app-2", src, false); err != nil {
+		t.Fatalf("non-strict mismatch should only warn, got %v", err)
+	}
+	content, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(content), "level=warning") || !strings.Contains(string(content), "sha256:bbb") {
+		t.Errorf("expected a mismatch warning in the log:\n%s", content)
+	}
+}
+
+func TestVerifyImageDigest_NothingRecorded(t *testing.T) {
+	dir := t.TempDir()
+	if err := RecordDeployment(dir, "app:1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+	if err := VerifyImageDigest(logger, dir, "missing", &fakeDigests{}, true); err != nil {
+		t.Errorf("installs without a recorded digest should not be checked, got %v", err)
+	}
+}
+
+func TestUpdate_RecordsDigest(t *testing.T) {
+	u, _, _, _ := newTestUpdater(t)
+	if err := u.Update(t.Context(), "1.3.0"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	h, _ := loadHistory(u.config.GetData().InstallDir)
+	last := h.Deployments[len(h.Deployments)-1]
+	if last.Digest != "sha256:"+last.Image {
+		t.Errorf("digest not recorded: %+v", last)
+	}
+}
@@ -0,0 +1,133 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fusionaly-installer/internal/executor"
+)
+
+// HistoryFileName is the JSON file in the install dir that records which app
+// images were deployed successfully, oldest first.
+const HistoryFileName = "version-history.json"
+
+// maxHistoryEntries caps how many deployments are remembered.
+const maxHistoryEntries = 20
+
+// ErrNoPreviousVersion is returned by Rollback when no earlier successful
+// deployment has been recorded.
+var ErrNoPreviousVersion = errors.New("no previous version recorded")
+
+// Deployment is one successful install, update or rollback.
+type Deployment struct {
+	Image      string    `json:"image"`
+	Digest     string    `json:"digest,omitempty"` // Registry digest the image resolved to when deployed
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+type versionHistory struct {
+	Deployments []Deployment `json:"deployments"`
+}
+
+func historyPath(installDir string) string {
+	return filepath.Join(installDir, HistoryFileName)
+}
+
+func loadHistory(installDir string) (*versionHistory, error) {
+	h := &versionHistory{}
+	data, err := os.ReadFile(historyPath(installDir))
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version history: %w", err)
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("failed to parse version history: %w", err)
+	}
+	return h, nil
+}
+
+func (h *versionHistory) save(installDir string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode version history: %w", err)
+	}
+	if err := executor.WriteFile(executor.Default(), historyPath(installDir), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write version history: %w", err)
+	}
+	return nil
+}
+
+// push appends image unless it is already the latest entry.
+func (h *versionHistory) push(image string, at time.Time) {
+	if image == "" {
+		return
+	}
+	if n := len(h.Deployments); n > 0 && h.Deployments[n-1].Image == image {
+		return
+	}
+	h.Deployments = append(h.Deployments, Deployment{Image: image, DeployedAt: at})
+	if len(h.Deployments) > maxHistoryEntries {
+		h.Deployments = h.Deployments[len(h.Deployments)-maxHistoryEntries:]
+	}
+}
+
+// RecordDeployment appends the given images, in order, to the version
+// history in installDir. Consecutive duplicates are skipped, so callers can
+// pass the image that was running before an update followed by the new one.
+func RecordDeployment(installDir string, images ...string) error {
+	h, err := loadHistory(installDir)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, image := range images {
+		h.push(image, now)
+	}
+	return h.save(installDir)
+}
+
+// Rollback redeploys the image that was running before the current one and
+// drops the current entry from the history, so repeated rollbacks walk
+// further back. It returns ErrNoPreviousVersion when there is nothing to roll
+// back to.
+func (u *Updater) Rollback(ctx context.Context) error {
+	envFile := filepath.Join(u.config.GetData().InstallDir, ".env")
+	if err := u.config.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	installDir := u.config.GetData().InstallDir
+
+	h, err := loadHistory(installDir)
+	if err != nil {
+		return err
+	}
+	if len(h.Deployments) < 2 {
+		return ErrNoPreviousVersion
+	}
+	current := u.config.GetData().AppImage
+	previous := h.Deployments[len(h.Deployments)-2].Image
+	u.logger.Info("Rolling back app image %s -> %s", current, previous)
+
+	if err := u.redeploy(previous); err != nil {
+		return fmt.Errorf("rollback to %s failed: %w", previous, err)
+	}
+	if err := u.verifyHealthy(ctx); err != nil {
+		return fmt.Errorf("rolled back to %s but it is not healthy: %w", previous, err)
+	}
+
+	if err := u.config.SaveToFile(envFile); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	h.Deployments = h.Deployments[:len(h.Deployments)-1]
+	if err := h.save(installDir); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRollback_RedeploysPreviousVersion(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	if err := u.Update(context.Background(), "1.3.0"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	fd.calls = nil
+
+	if err := u.Rollback(context.Background()); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	want := []string{"deploy karloscodes/fusionaly-beta:1.2.0", "health fusionaly-app-2"}
+	if !reflect.DeepEqual(fd.calls, want) {
+		t.Errorf("calls mismatch\nwant %v\ngot  %v", want, fd.calls)
+	}
+	content, _ := os.ReadFile(envFile)
+	if !strings.Contains(string(content), "APP_IMAGE=karloscodes/fusionaly-beta:1.2.0\n") {
+		t.Errorf("env file should record the restored image:\n%s", content)
+	}
+
+	// The rolled-back entry is dropped, so there is nothing further to return to.
+	if err := u.Rollback(context.Background()); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Errorf("expected ErrNoPreviousVersion after rolling back the only update, got %v", err)
+	}
+}
+
+func TestRollback_NoHistory(t *testing.T) {
+	u, fd, _, _ := newTestUpdater(t)
+
+	if err := u.Rollback(context.Background()); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("expected ErrNoPreviousVersion, got %v", err)
+	}
+	if len(fd.calls) != 0 {
+		t.Errorf("nothing should be deployed without history, got %v", fd.calls)
+	}
+}
+
+func TestRollback_KeepsHistoryWhenUnhealthy(t *testing.T) {
+	u, fd, _, _ := newTestUpdater(t)
+	if err := u.Update(context.Background(), "1.3.0"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	fd.healthErrs = []error{errors.New("unhealthy")}
+
+	if err := u.Rollback(context.Background()); err == nil {
+		t.Fatal("expected error when the restored version is unhealthy")
+	}
+	h, err := loadHistory(u.config.GetData().InstallDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Deployments) != 2 {
+		t.Errorf("history should be untouched after a failed rollback, got %v", h.Deployments)
+	}
+}
+
+func TestRecordDeployment_SkipsConsecutiveDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	if err := RecordDeployment(dir, "app:1.0.0", "app:1.0.0", "app:1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordDeployment(dir, "app:1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	h, err := loadHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var images []string
+	for _, d := range h.Deployments {
+		images = append(images, d.Image)
+	}
+	if want := []string{"app:1.0.0", "app:1.1.0"}; !reflect.DeepEqual(images, want) {
+		t.Errorf("want %v, got %v", want, images)
+	}
+}
@@ -0,0 +1,123 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/executor"
+	"fusionaly-installer/internal/logging"
+)
+
+// SecretHistoryFileName is the file in the install dir that keeps every
+// replaced FUSIONALY_PRIVATE_KEY, oldest first, readable by root only.
+const SecretHistoryFileName = "secret-history.json"
+
+// secretKey is the .env key holding the app's secret.
+const secretKey = "FUSIONALY_PRIVATE_KEY"
+
+// RetiredSecret is a secret replaced by RotateSecret.
+type RetiredSecret struct {
+	Secret    string    `json:"secret"`
+	RetiredAt time.Time `json:"retired_at"`
+}
+
+// containerReloader is the part of *docker.Docker that recreates the
+// containers with a new configuration.
+type containerReloader interface {
+	Reload(conf *config.Config) error
+}
+
+// SecretRotator replaces the app's secret key.
+type SecretRotator struct {
+	logger   *logging.Logger
+	envFile  string
+	docker   containerReloader
+	generate func() (string, error)
+	now      func() time.Time
+}
+
+// NewSecretRotator creates a SecretRotator for the installation whose .env
+// file is envFile, recreating containers through d.
+func NewSecretRotator(logger *logging.Logger, envFile string, d containerReloader) *SecretRotator {
+	return &SecretRotator{
+		logger:   logger,
+		envFile:  envFile,
+		docker:   d,
+		generate: func() (string, error) { return config.GenerateSecret(config.PrivateKeyLength) },
+		now:      time.Now,
+	}
+}
+
+// RotateSecret generates a new FUSIONALY_PRIVATE_KEY, records the old one in
+// SecretHistoryFileName, writes the new one into the .env file in place and
+// recreates the containers so the app picks it up. Every session signed
+// with the old key stops being valid.
+func (r *SecretRotator) RotateSecret(ctx context.Context) error {
+	env, err := config.LoadEnvFile(r.envFile)
+	if err != nil {
+		return err
+	}
+	previous, ok := env.Get(secretKey)
+	if !ok || previous == "" {
+		return fmt.Errorf("%s has no %s; run install first", r.envFile, secretKey)
+	}
+
+	secret, err := r.generate()
+	if err != nil {
+		return err
+	}
+	if secret == previous {
+		return fmt.Errorf("generated secret matches the current one")
+	}
+
+	if err := r.retire(previous); err != nil {
+		return err
+	}
+	env.Set(secretKey, secret)
+	if err := env.Save(); err != nil {
+		return err
+	}
+	r.logger.Success("Wrote a new %s to %s", secretKey, r.envFile)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.logger.Warn("Restarting Fusionaly with the new secret; every user will have to sign in again")
+	conf := config.NewConfig(r.logger)
+	if err := conf.LoadFromFile(r.envFile); err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", r.envFile, err)
+	}
+	if err := r.docker.Reload(conf); err != nil {
+		return fmt.Errorf("secret rotated but containers failed to restart: %w", err)
+	}
+	r.logger.Success("Secret rotated")
+	return nil
+}
+
+// retire appends previous to the secret history next to the .env file.
+func (r *SecretRotator) retire(previous string) error {
+	path := filepath.Join(filepath.Dir(r.envFile), SecretHistoryFileName)
+	var history []RetiredSecret
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &history); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	history = append(history, RetiredSecret{Secret: previous, RetiredAt: r.now().UTC()})
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode secret history: %w", err)
+	}
+	if err := executor.WriteFile(executor.Default(), path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
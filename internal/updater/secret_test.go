@@ -0,0 +1,91 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/logging"
+)
+
+type fakeReloader struct {
+	secrets []string
+	err     error
+}
+
+func (f *fakeReloader) Reload(conf *config.Config) error {
+	f.secrets = append(f.secrets, conf.GetData().PrivateKey)
+	return f.err
+}
+
+const oldSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestRotator(t *testing.T) (*SecretRotator, *fakeReloader, string) {
+	t.Helper()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := "# managed by fusionaly\nFUSIONALY_DOMAIN=analytics.example.com\nFUSIONALY_PRIVATE_KEY=" + oldSecret + "\nAPP_IMAGE=karloscodes/fusionaly-beta:1.2.0\n"
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeReloader{}
+	return NewSecretRotator(logging.NewLogger(logging.Config{Level: "error"}), envFile, fr), fr, envFile
+}
+
+func TestRotateSecret(t *testing.T) {
+	r, fr, envFile := newTestRotator(t)
+
+	if err := r.RotateSecret(context.Background()); err != nil {
+		t.Fatalf("RotateSecret: %v", err)
+	}
+
+	env, _ := config.LoadEnvFile(envFile)
+	secret, _ := env.Get("FUSIONALY_PRIVATE_KEY")
+	if secret == oldSecret || len(secret) != config.PrivateKeyLength {
+		t.Errorf("secret not replaced: %q", secret)
+	}
+	content, _ := os.ReadFile(envFile)
+	if strings.Count(string(content), "FUSIONALY_PRIVATE_KEY=") != 1 || !strings.HasPrefix(string(content), "# managed by fusionaly\n") {
+		t.Errorf("env file not edited in place:\n%s", content)
+	}
+
+	if len(fr.secrets) != 1 || fr.secrets[0] != secret {
+		t.Errorf("containers should be reloaded once with the new secret, got %v", fr.secrets)
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(envFile), SecretHistoryFileName))
+	if err != nil {
+		t.Fatalf("secret history not written: %v", err)
+	}
+	var history []RetiredSecret
+	if err := json.Unmarshal(data, &history); err != nil || len(history) != 1 || history[0].Secret != oldSecret {
+		t.Errorf("unexpected history %s (%v)", data, err)
+	}
+}
+
+func TestRotateSecret_TwiceKeepsBothOldSecrets(t *testing.T) {
+	r, fr, envFile := newTestRotator(t)
+	for i := 0; i < 2; i++ {
+		if err := r.RotateSecret(context.Background()); err != nil {
+			t.Fatalf("RotateSecret #%d: %v", i+1, err)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(envFile), SecretHistoryFileName))
+	var history []RetiredSecret
+	json.Unmarshal(data, &history)
+	if len(history) != 2 || history[0].Secret != oldSecret || history[1].Secret != fr.secrets[0] {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestRotateSecret_ReloadFailure(t *testing.T) {
+	r, fr, _ := newTestRotator(t)
+	fr.err = errors.New("docker unavailable")
+	if err := r.RotateSecret(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to restart") {
+		t.Errorf("expected a restart error, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package updater
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// ChecksumsAsset is the release asset listing the SHA-256 of every binary in
+// sha256sum format.
+const ChecksumsAsset = "checksums.txt"
+
+// ErrChecksumMismatch is returned when a downloaded binary does not match
+// the checksum published with the release.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// release is the subset of the GitHub release API response we use.
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name       string `json:"name"`
+		BrowserURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (r *release) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.BrowserURL
+		}
+	}
+	return ""
+}
+
+// SelfUpdater replaces the running installer binary with the latest release.
+type SelfUpdater struct {
+	logger         *logging.Logger
+	client         *http.Client
+	releaseURL     string
+	currentVersion string
+	arch           string
+	executable     func() (string, error)
+}
+
+// NewSelfUpdater creates a SelfUpdater for the binary at currentVersion.
+func NewSelfUpdater(logger *logging.Logger, currentVersion string) *SelfUpdater {
+	return &SelfUpdater{
+		logger:         logger,
+		client:         &http.Client{Timeout: 60 * time.Second},
+		releaseURL:     GitHubAPIURL,
+		currentVersion: currentVersion,
+		arch:           runtime.GOARCH,
+		executable:     os.Executable,
+	}
+}
+
+// SelfUpdate downloads the newest release binary for this platform, checks
+// it against the release checksums and atomically replaces the running
+// executable. It does nothing when the current version is already the latest.
+func (s *SelfUpdater) SelfUpdate(ctx context.Context) error {
+	rel, err := s.latestRelease(ctx)
+	if err != nil {
+		return err
+	}
+	latest := strings.TrimPrefix(rel.TagName, "v")
+	if latest == "" {
+		return fmt.Errorf("invalid version in release tag: %q", rel.TagName)
+	}
+	if compareVersions(s.currentVersion, latest) >= 0 {
+		s.logger.Info("Installer %s is already up to date (latest release is %s)", s.currentVersion, latest)
+		return nil
+	}
+
+	name, binaryURL := s.binaryAsset(rel, latest)
+	if binaryURL == "" {
+		return fmt.Errorf("no installer binary for %s in release %s", s.arch, rel.TagName)
+	}
+	checksumsURL := rel.assetURL(ChecksumsAsset)
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no %s, refusing to install an unverified binary", rel.TagName, ChecksumsAsset)
+	}
+	want, err := s.expectedChecksum(ctx, checksumsURL, name)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate running executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	s.logger.InfoWithTime("Updating installer %s -> %s", s.currentVersion, latest)
+	if err := s.install(ctx, binaryURL, want, target); err != nil {
+		return err
+	}
+	s.logger.Success("Installer updated to %s", latest)
+	return nil
+}
+
+// binaryAsset picks the release asset for this architecture, preferring the
+// current naming pattern over the old one.
+func (s *SelfUpdater) binaryAsset(rel *release, version string) (string, string) {
+	for _, name := range []string{
+		fmt.Sprintf("fusionaly-installer-v%s-%s", version, s.arch),
+		fmt.Sprintf("fusionaly-v%s-%s", version, s.arch),
+	} {
+		if u := rel.assetURL(name); u != "" {
+			return name, u
+		}
+	}
+	return "", ""
+}
+
+func (s *SelfUpdater) latestRelease(ctx context.Context) (*release, error) {
+	resp, err := s.get(ctx, s.releaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var rel release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, fmt.Errorf("failed to parse release JSON: %w", err)
+	}
+	return &rel, nil
+}
+
+func (s *SelfUpdater) expectedChecksum(ctx context.Context, url, name string) (string, error) {
+	resp, err := s.get(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	defer resp.Body.Close()
+	return parseChecksum(resp.Body, name)
+}
+
+// parseChecksum finds the hex SHA-256 for name in sha256sum output.
+func parseChecksum(r io.Reader, name string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary mode with a leading '*'.
+		if strings.TrimPrefix(fields[1], "*") == name {
+			sum := strings.ToLower(fields[0])
+			if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+				return "", fmt.Errorf("invalid checksum for %s: %q", name, fields[0])
+			}
+			return sum, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksums: %w", err)
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// install downloads url next to target, verifies it and renames it over
+// target, so the running binary is never left half-written.
+func (s *SelfUpdater) install(ctx context.Context, url, wantSum, target string) error {
+	resp, err := s.get(ctx, url)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".new-*")
+	if err != nil {
+		return fmt.Errorf("create new binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := verifyCopy(tmp, resp.Body, wantSum); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o755); err != nil {
+		tmp.Close()
+		return fmt.Errorf("chmod new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write new binary: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("replace binary: %w", err)
+	}
+	return nil
+}
+
+// verifyCopy copies r to w and checks the SHA-256 of what was copied.
+func verifyCopy(w io.Writer, r io.Reader, wantSum string) error {
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), r); err != nil {
+		return fmt.Errorf("write new binary: %w", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(wantSum) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, wantSum, got)
+	}
+	return nil
+}
+
+func (s *SelfUpdater) get(ctx context.Context, url string) (*http.Response, error) {
+	return fetch(ctx, s.client, url)
+}
+
+// fetch GETs url with client and returns the response when the status is
+// 200; the caller closes the body.
+func fetch(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
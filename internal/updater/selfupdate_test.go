@@ -0,0 +1,152 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/logging"
+)
+
+// stubTransport serves canned bodies by URL and records what was fetched.
+type stubTransport struct {
+	bodies  map[string]string
+	fetched []string
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	s.fetched = append(s.fetched, url)
+	body, ok := s.bodies[url]
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusNotFound
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+const (
+	testReleaseURL   = "https://api.example.com/releases/latest"
+	testBinaryURL    = "https://dl.example.com/fusionaly-installer-v1.5.0-amd64"
+	testChecksumsURL = "https://dl.example.com/checksums.txt"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTestSelfUpdater(t *testing.T, current, checksum string) (*SelfUpdater, *stubTransport, string) {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "fusionaly")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &stubTransport{bodies: map[string]string{
+		testReleaseURL: fmt.Sprintf(`{"tag_name":"v1.5.0","assets":[
+			{"name":"fusionaly-installer-v1.5.0-amd64","browser_download_url":%q},
+			{"name":"checksums.txt","browser_download_url":%q}]}`, testBinaryURL, testChecksumsURL),
+		testBinaryURL:    "new binary",
+		testChecksumsURL: checksum + "  fusionaly-installer-v1.5.0-amd64\n" + sha256Hex("other") + "  fusionaly-installer-v1.5.0-arm64\n",
+	}}
+
+	s := NewSelfUpdater(logging.NewLogger(logging.Config{Level: "error"}), current)
+	s.client = &http.Client{Transport: stub}
+	s.releaseURL = testReleaseURL
+	s.arch = "amd64"
+	s.executable = func() (string, error) { return exe, nil }
+	return s, stub, exe
+}
+
+func TestSelfUpdate_ReplacesExecutable(t *testing.T) {
+	s, _, exe := newTestSelfUpdater(t, "1.4.2", sha256Hex("new binary"))
+
+	if err := s.SelfUpdate(context.Background()); err != nil {
+		t.Fatalf("SelfUpdate returned error: %v", err)
+	}
+	content, _ := os.ReadFile(exe)
+	if string(content) != "new binary" {
+		t.Errorf("executable not replaced, got %q", content)
+	}
+	info, _ := os.Stat(exe)
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("expected mode 0755, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestSelfUpdate_NoopWhenCurrent(t *testing.T) {
+	for _, current := range []string{"1.5.0", "v1.5.0", "1.6.0"} {
+		s, stub, exe := newTestSelfUpdater(t, current, sha256Hex("new binary"))
+
+		if err := s.SelfUpdate(context.Background()); err != nil {
+			t.Fatalf("%s: SelfUpdate returned error: %v", current, err)
+		}
+		if len(stub.fetched) != 1 {
+			t.Errorf("%s: only the release should be fetched, got %v", current, stub.fetched)
+		}
+		content, _ := os.ReadFile(exe)
+		if string(content) != "old binary" {
+			t.Errorf("%s: executable should be untouched", current)
+		}
+	}
+}
+
+func TestSelfUpdate_ChecksumMismatchKeepsOldBinary(t *testing.T) {
+	s, _, exe := newTestSelfUpdater(t, "1.4.2", sha256Hex("tampered"))
+
+	err := s.SelfUpdate(context.Background())
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	content, _ := os.ReadFile(exe)
+	if string(content) != "old binary" {
+		t.Errorf("executable should be untouched, got %q", content)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestSelfUpdate_RequiresChecksums(t *testing.T) {
+	s, stub, _ := newTestSelfUpdater(t, "1.4.2", sha256Hex("new binary"))
+	stub.bodies[testReleaseURL] = fmt.Sprintf(`{"tag_name":"v1.5.0","assets":[{"name":"fusionaly-installer-v1.5.0-amd64","browser_download_url":%q}]}`, testBinaryURL)
+
+	if err := s.SelfUpdate(context.Background()); err == nil || !strings.Contains(err.Error(), "unverified") {
+		t.Fatalf("expected refusal without checksums, got %v", err)
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := sha256Hex("x")
+	input := "# comment\n" + sum + " *fusionaly-v1.0.0-arm64\nnot-hex  fusionaly-v1.0.0-amd64\n"
+
+	got, err := parseChecksum(strings.NewReader(input), "fusionaly-v1.0.0-arm64")
+	if err != nil || got != sum {
+		t.Errorf("got %q, %v; want %q", got, err, sum)
+	}
+	if _, err := parseChecksum(strings.NewReader(input), "fusionaly-v1.0.0-amd64"); err == nil {
+		t.Error("expected an error for a malformed checksum")
+	}
+	if _, err := parseChecksum(strings.NewReader(input), "missing"); err == nil {
+		t.Error("expected an error for an unlisted file")
+	}
+}
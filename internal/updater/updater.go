@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,6 +18,7 @@ import (
 	"fusionaly-installer/internal/cron"
 	"fusionaly-installer/internal/database"
 	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/executor"
 	"fusionaly-installer/internal/logging"
 )
 
@@ -29,8 +31,30 @@ const (
 type Updater struct {
 	logger   *logging.Logger
 	config   *config.Config
-	docker   *docker.Docker
-	database *database.Database
+	docker   deployer
+	database backupStore
+	proxy    maintenanceSwitch // Shows the maintenance page during updates; nil skips it
+}
+
+// deployer is the part of *docker.Docker the updater drives.
+type deployer interface {
+	Update(conf *config.Config) error
+	RunningAppContainer() (string, error)
+	AwaitHealthy(ctx context.Context, opts docker.HealthOptions) error
+	ImageDigest(image string) (string, error)
+}
+
+// maintenanceSwitch is the part of *docker.Stack that toggles the proxy's
+// maintenance page.
+type maintenanceSwitch interface {
+	MaintenanceMode() (bool, error)
+	SetMaintenanceMode(ctx context.Context, on bool) error
+}
+
+// backupStore is the part of *database.Database the updater drives.
+type backupStore interface {
+	BackupDatabase(dbPath, backupDir string) (string, error)
+	GetAdminUser(dbPath string) (string, error)
 }
 
 func NewUpdater(logger *logging.Logger) *Updater {
@@ -48,10 +72,13 @@ func NewUpdater(logger *logging.Logger) *Updater {
 		config:   config.NewConfig(fileLogger),
 		docker:   docker.NewDocker(fileLogger, db),
 		database: db,
+		proxy:    docker.NewStack(fileLogger, executor.Default()),
 	}
 }
 
-func (u *Updater) Run(currentVersion string) error {
+// Run updates the installer binary when a newer release exists and then the
+// running app; ctx bounds the maintenance window and its commands.
+func (u *Updater) Run(ctx context.Context, currentVersion string) error {
 	data := u.config.GetData()
 	envFile := filepath.Join(data.InstallDir, ".env")
 
@@ -61,12 +88,12 @@ func (u *Updater) Run(currentVersion string) error {
 	}
 
 	u.logger.Info("Checking for updates from server")
-	if err := u.config.FetchFromServer(""); err != nil {
+	if err := u.config.FetchFromServer(ctx, ""); err != nil {
 		u.logger.Warn("Server config fetch failed, using local: %v", err)
 	}
 
 	// Fetch the latest version from GitHub
-	latestVersion, binaryURL, err := u.getLatestVersionAndBinaryURL()
+	latestVersion, binaryURL, err := u.getLatestVersionAndBinaryURL(ctx)
 	if err != nil {
 		u.logger.Warn("Failed to fetch latest version from GitHub: %v", err)
 		latestVersion = extractVersionFromURL(u.config.GetData().InstallerURL)
@@ -100,7 +127,11 @@ func (u *Updater) Run(currentVersion string) error {
 
 					// Test if the new pattern URL is accessible
 					client := &http.Client{Timeout: 10 * time.Second}
-					resp, err := client.Head(downloadURL)
+					var resp *http.Response
+					req, err := http.NewRequestWithContext(ctx, http.MethodHead, downloadURL, nil)
+					if err == nil {
+						resp, err = client.Do(req)
+					}
 					if err != nil || resp.StatusCode != http.StatusOK {
 						// Fall back to old naming pattern
 						downloadURL = fmt.Sprintf("https://github.com/%s/releases/download/v%s/fusionaly-v%s-%s", GitHubRepo, latestVersion, latestVersion, arch)
@@ -114,7 +145,7 @@ func (u *Updater) Run(currentVersion string) error {
 				}
 			}
 
-			if err := u.updateBinary(downloadURL, BinaryInstallPath); err != nil {
+			if err := u.updateBinary(ctx, downloadURL, BinaryInstallPath); err != nil {
 				u.logger.Warn("Failed to update binary: %v", err)
 			} else {
 				u.logger.Success("Binary updated to version %s", latestVersion)
@@ -131,7 +162,7 @@ func (u *Updater) Run(currentVersion string) error {
 		}
 	}
 
-	if err := u.update(); err != nil {
+	if err := u.update(ctx); err != nil {
 		return fmt.Errorf("update failed: %w", err)
 	}
 	if err := u.config.SaveToFile(envFile); err != nil {
@@ -142,14 +173,18 @@ func (u *Updater) Run(currentVersion string) error {
 	return nil
 }
 
-func (u *Updater) getLatestVersionAndBinaryURL() (string, string, error) {
+func (u *Updater) getLatestVersionAndBinaryURL(ctx context.Context) (string, string, error) {
 	u.logger.Info("Fetching latest release from GitHub: %s", GitHubAPIURL)
 
 	client := &http.Client{
 		Timeout: 60 * time.Second,
 	}
 
-	resp, err := client.Get(GitHubAPIURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, GitHubAPIURL, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to fetch latest release: %w", err)
 	}
@@ -212,7 +247,7 @@ func (u *Updater) getLatestVersionAndBinaryURL() (string, string, error) {
 	return latestVersion, binaryURL, nil
 }
 
-func (u *Updater) update() error {
+func (u *Updater) update(ctx context.Context) error {
 	totalSteps := 4
 
 	u.logger.Info("Step 1/%d: Loading configuration", totalSteps)
@@ -221,9 +256,11 @@ func (u *Updater) update() error {
 	if err := u.config.LoadFromFile(envFile); err != nil {
 		return fmt.Errorf("failed to load config from %s: %w", envFile, err)
 	}
+	previousImage := u.config.GetData().AppImage
+	defer u.enterMaintenance(ctx)()
 
 	u.logger.Info("Step 2/%d: Checking for updates from server", totalSteps)
-	if err := u.config.FetchFromServer(""); err != nil {
+	if err := u.config.FetchFromServer(ctx, ""); err != nil {
 		u.logger.Warn("Server config fetch failed, using local config: %v", err)
 	}
 
@@ -252,6 +289,10 @@ func (u *Updater) update() error {
 	if err := u.docker.Update(u.config); err != nil {
 		return fmt.Errorf("failed to update Docker containers: %w", err)
 	}
+	if err := RecordDeployment(data.InstallDir, previousImage, u.config.GetData().AppImage); err != nil {
+		u.logger.Warn("Failed to record version history: %v", err)
+	}
+	u.recordDigest(data.InstallDir, u.config.GetData().AppImage)
 
 	u.logger.Info("Step 4/%d: Updating cron job", totalSteps)
 	cronManager := cron.NewManager(u.logger)
@@ -269,7 +310,7 @@ func (u *Updater) update() error {
 	return nil
 }
 
-func (u *Updater) updateBinary(url, binaryPath string) error {
+func (u *Updater) updateBinary(ctx context.Context, url, binaryPath string) error {
 	u.logger.InfoWithTime("Downloading new installer binary from %s", url)
 
 	// Add diagnostic logging
@@ -298,7 +339,11 @@ func (u *Updater) updateBinary(url, binaryPath string) error {
 	}
 
 	u.logger.Info("Starting HTTP request to download binary")
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("download failed: %w", err)
 	}
@@ -0,0 +1,131 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/validation"
+)
+
+// PostUpdateHealthTimeout bounds how long Update waits for the new app
+// container to report healthy before rolling back.
+const PostUpdateHealthTimeout = 2 * time.Minute
+
+// ErrUpdateRolledBack is returned when the new version failed its health
+// check and the previous image was redeployed.
+var ErrUpdateRolledBack = errors.New("update rolled back")
+
+// Update moves the app to targetVersion (or the latest release when empty):
+// it backs up the database, deploys the new image, verifies the app is
+// healthy, and redeploys the previous image if it is not. The .env file only
+// records the new image once the update is known to be good.
+func (u *Updater) Update(ctx context.Context, targetVersion string) error {
+	if targetVersion != "" {
+		if err := validation.ValidateImageTag(targetVersion); err != nil {
+			return fmt.Errorf("invalid target version: %w", err)
+		}
+	}
+
+	envFile := filepath.Join(u.config.GetData().InstallDir, ".env")
+	if err := u.config.LoadFromFile(envFile); err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	previous := u.config.GetData().AppImage
+
+	if targetVersion != "" {
+		if err := u.config.PinAppVersion(targetVersion); err != nil {
+			return err
+		}
+	} else if err := u.config.FetchFromServer(ctx, ""); err != nil {
+		u.logger.Warn("Server config fetch failed, using local config: %v", err)
+	}
+	target := u.config.GetData().AppImage
+	u.logger.Info("Updating app image %s -> %s", previous, target)
+	defer u.enterMaintenance(ctx)()
+
+	backup, err := u.database.BackupDatabase(u.config.GetMainDBPath(), u.config.GetData().BackupPath)
+	if err != nil {
+		return fmt.Errorf("database backup failed, update aborted: %w", err)
+	}
+	u.logger.Success("Database backed up to %s", backup)
+
+	// Docker.Update pulls the image and starts it next to the running
+	// container, so a failure here leaves the previous version serving.
+	if err := u.docker.Update(u.config); err != nil {
+		return fmt.Errorf("deploy %s failed, previous version is still running: %w", target, err)
+	}
+
+	if err := u.verifyHealthy(ctx); err != nil {
+		u.logger.Error("%s is not healthy after the update: %v", target, err)
+		if rbErr := u.redeploy(previous); rbErr != nil {
+			return fmt.Errorf("update to %s failed (%v) and rollback to %s also failed: %w", target, err, previous, rbErr)
+		}
+		return fmt.Errorf("%w: %s failed its health check, restored %s: %v", ErrUpdateRolledBack, target, previous, err)
+	}
+
+	if err := u.config.SaveToFile(envFile); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	if err := RecordDeployment(u.config.GetData().InstallDir, previous, target); err != nil {
+		u.logger.Warn("Failed to record version history: %v", err)
+	}
+	u.recordDigest(u.config.GetData().InstallDir, target)
+	u.logger.Success("Updated to %s", target)
+	return nil
+}
+
+// verifyHealthy waits for the app container that is now serving traffic.
+func (u *Updater) verifyHealthy(ctx context.Context) error {
+	container, err := u.docker.RunningAppContainer()
+	if err != nil {
+		return err
+	}
+	opts := docker.DeployHealthOptions(container)
+	opts.Timeout = PostUpdateHealthTimeout
+	return u.docker.AwaitHealthy(ctx, opts)
+}
+
+// redeploy switches the app back to image.
+func (u *Updater) redeploy(image string) error {
+	u.logger.Warn("Rolling back to %s", image)
+	data := u.config.GetData()
+	data.AppImage = image
+	u.config.SetData(data)
+	if err := u.docker.Update(u.config); err != nil {
+		return err
+	}
+	u.logger.Success("Rolled back to %s", image)
+	return nil
+}
+
+// enterMaintenance shows the maintenance page while an update runs and
+// returns the func that restores the mode the proxy was in, which stays on if
+// an operator had already enabled it. A proxy that cannot be switched only
+// costs the page, so the update goes ahead with a warning.
+func (u *Updater) enterMaintenance(ctx context.Context) func() {
+	if u.proxy == nil {
+		return func() {}
+	}
+	was, err := u.proxy.MaintenanceMode()
+	if err != nil {
+		u.logger.Warn("Failed to read maintenance mode: %v", err)
+		return func() {}
+	}
+	if was {
+		return func() {}
+	}
+	if err := u.proxy.SetMaintenanceMode(ctx, true); err != nil {
+		u.logger.Warn("Failed to enable maintenance mode: %v", err)
+		return func() {}
+	}
+	return func() {
+		// Restore even when ctx was cancelled by a failed update.
+		if err := u.proxy.SetMaintenanceMode(context.WithoutCancel(ctx), false); err != nil {
+			u.logger.Error("Failed to disable maintenance mode: %v", err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"fusionaly-installer/internal/config"
+	"fusionaly-installer/internal/docker"
+	"fusionaly-installer/internal/logging"
+)
+
+// fakeDeployer records the deploy/health sequence driven by the updater.
+type fakeDeployer struct {
+	calls      []string
+	deployErrs []error // per Update call
+	healthErrs []error // per AwaitHealthy call
+}
+
+func (f *fakeDeployer) Update(conf *config.Config) error {
+	f.calls = append(f.calls, "deploy "+conf.GetData().AppImage)
+	return popErr(&f.deployErrs)
+}
+
+func (f *fakeDeployer) ImageDigest(image string) (string, error) {
+	return "sha256:" + image, nil
+}
+
+func (f *fakeDeployer) RunningAppContainer() (string, error) {
+	return "fusionaly-app-2", nil
+}
+
+func (f *fakeDeployer) AwaitHealthy(ctx context.Context, opts docker.HealthOptions) error {
+	f.calls = append(f.calls, "health "+opts.Container)
+	return popErr(&f.healthErrs)
+}
+
+// fakeProxy records maintenance mode switches.
+type fakeProxy struct {
+	on    bool
+	calls []bool
+}
+
+func (f *fakeProxy) MaintenanceMode() (bool, error) {
+	return f.on, nil
+}
+
+func (f *fakeProxy) SetMaintenanceMode(ctx context.Context, on bool) error {
+	f.calls = append(f.calls, on)
+	f.on = on
+	return nil
+}
+
+type fakeBackups struct {
+	calls int
+	err   error
+}
+
+func (f *fakeBackups) BackupDatabase(dbPath, backupDir string) (string, error) {
+	f.calls++
+	return filepath.Join(backupDir, "backup_20240101_000000.db"), f.err
+}
+
+func (f *fakeBackups) GetAdminUser(dbPath string) (string, error) {
+	return "", nil
+}
+
+func popErr(errs *[]error) error {
+	if len(*errs) == 0 {
+		return nil
+	}
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
+}
+
+func newTestUpdater(t *testing.T) (*Updater, *fakeDeployer, *fakeBackups, string) {
+	t.Helper()
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	content := fmt.Sprintf("FUSIONALY_DOMAIN=example.com\nAPP_IMAGE=karloscodes/fusionaly-beta:1.2.0\nINSTALL_DIR=%s\nBACKUP_PATH=%s\n", dir, filepath.Join(dir, "backups"))
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.NewLogger(logging.Config{Level: "error"})
+	cfg := config.NewConfig(logger)
+	cfg.SetInstallDir(dir)
+	fd, fb := &fakeDeployer{}, &fakeBackups{}
+	return &Updater{logger: logger, config: cfg, docker: fd, database: fb}, fd, fb, envFile
+}
+
+func TestUpdate_HappyPath(t *testing.T) {
+	u, fd, fb, envFile := newTestUpdater(t)
+
+	if err := u.Update(context.Background(), "1.3.0"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if fb.calls != 1 {
+		t.Errorf("expected one backup, got %d", fb.calls)
+	}
+	want := []string{"deploy karloscodes/fusionaly-beta:1.3.0", "health fusionaly-app-2"}
+	if !reflect.DeepEqual(fd.calls, want) {
+		t.Errorf("calls mismatch\nwant %v\ngot  %v", want, fd.calls)
+	}
+	content, _ := os.ReadFile(envFile)
+	if !strings.Contains(string(content), "APP_IMAGE=karloscodes/fusionaly-beta:1.3.0\n") {
+		t.Errorf("env file should record the new image:\n%s", content)
+	}
+}
+
+func TestUpdate_RollsBackOnFailedHealthCheck(t *testing.T) {
+	u, fd, _, envFile := newTestUpdater(t)
+	fd.healthErrs = []error{errors.New("container reported unhealthy")}
+
+	err := u.Update(context.Background(), "1.3.0")
+	if !errors.Is(err, ErrUpdateRolledBack) {
+		t.Fatalf("expected ErrUpdateRolledBack, got %v", err)
+	}
+	want := []string{
+		"deploy karloscodes/fusionaly-beta:1.3.0",
+		"health fusionaly-app-2",
+		"deploy karloscodes/fusionaly-beta:1.2.0",
+	}
+	if !reflect.DeepEqual(fd.calls, want) {
+		t.Errorf("calls mismatch\nwant %v\ngot  %v", want, fd.calls)
+	}
+	content, _ := os.ReadFile(envFile)
+	if !strings.Contains(string(content), "APP_IMAGE=karloscodes/fusionaly-beta:1.2.0\n") {
+		t.Errorf("env file should keep the previous image:\n%s", content)
+	}
+}
+
+func TestUpdate_ShowsMaintenancePage(t *testing.T) {
+	u, _, _, _ := newTestUpdater(t)
+	fp := &fakeProxy{}
+	u.proxy = fp
+
+	if err := u.Update(context.Background(), "1.3.0"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(fp.calls, want) {
+		t.Errorf("maintenance switches = %v, want %v", fp.calls, want)
+	}
+}
+
+func TestUpdate_RestoresMaintenanceModeAfterFailure(t *testing.T) {
+	u, fd, _, _ := newTestUpdater(t)
+	fd.deployErrs = []error{errors.New("pull failed")}
+	fp := &fakeProxy{}
+	u.proxy = fp
+
+	if err := u.Update(context.Background(), "1.3.0"); err == nil {
+		t.Fatal("expected the deploy failure")
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(fp.calls, want) {
+		t.Errorf("maintenance switches = %v, want %v", fp.calls, want)
+	}
+	if fp.on {
+		t.Error("maintenance page should be switched off after a failed update")
+	}
+}
+
+func TestUpdate_KeepsMaintenanceModeEnabledByOperator(t *testing.T) {
+	u, _, _, _ := newTestUpdater(t)
+	fp := &fakeProxy{on: true}
+	u.proxy = fp
+
+	if err := u.Update(context.Background(), "1.3.0"); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if len(fp.calls) != 0 || !fp.on {
+		t.Errorf("maintenance mode should be left on untouched, switches %v", fp.calls)
+	}
+}
+
+func TestUpdate_ReportsFailedRollback(t *testing.T) {
+	u, fd, _, _ := newTestUpdater(t)
+	fd.healthErrs = []error{errors.New("unhealthy")}
+	fd.deployErrs = []error{nil, errors.New("pull failed")}
+
+	err := u.Update(context.Background(), "1.3.0")
+	if err == nil || errors.Is(err, ErrUpdateRolledBack) {
+		t.Fatalf("expected a rollback failure, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "rollback to karloscodes/fusionaly-beta:1.2.0 also failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdate_AbortsWhenBackupFails(t *testing.T) {
+	u, fd, fb, _ := newTestUpdater(t)
+	fb.err = errors.New("disk full")
+
+	if err := u.Update(context.Background(), "1.3.0"); err == nil {
+		t.Fatal("expected error when backup fails")
+	}
+	if len(fd.calls) != 0 {
+		t.Errorf("nothing should be deployed without a backup, got %v", fd.calls)
+	}
+}
+
+func TestUpdate_DeployFailureLeavesPreviousRunning(t *testing.T) {
+	u, fd, _, _ := newTestUpdater(t)
+	fd.deployErrs = []error{errors.New("health_check failed")}
+
+	err := u.Update(context.Background(), "1.3.0")
+	if err == nil || !strings.Contains(err.Error(), "previous version is still running") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fd.calls) != 1 {
+		t.Errorf("no rollback deploy expected, got %v", fd.calls)
+	}
+}
+
+func TestUpdate_RejectsMalformedVersion(t *testing.T) {
+	u, fd, fb, _ := newTestUpdater(t)
+	if err := u.Update(context.Background(), "not a tag"); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if len(fd.calls) != 0 || fb.calls != 0 {
+		t.Error("nothing should run for an invalid version")
+	}
+}
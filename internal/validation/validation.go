@@ -2,9 +2,11 @@ package validation
 
 import (
 	"fmt"
+	"math"
 	"net"
 	"net/url"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -218,6 +220,23 @@ func ValidateVersion(version string) error {
 	return nil
 }
 
+// ValidateImageTag validates a Docker image tag such as "1.2.3", "v1.2.3"
+// or "latest"
+func ValidateImageTag(tag string) error {
+	if tag == "" {
+		return errors.NewValidationError("image_tag", tag, "image tag cannot be empty")
+	}
+
+	// Docker tag grammar: up to 128 word characters, periods and hyphens,
+	// not starting with a period or hyphen
+	validTag := regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	if !validTag.MatchString(tag) {
+		return errors.NewValidationError("image_tag", tag, "image tag must start with alphanumeric or underscore and contain only alphanumeric, underscore, period, or hyphen (max 128 characters)")
+	}
+
+	return nil
+}
+
 // ValidateFilePath validates file path format
 func ValidateFilePath(path string) error {
 	if path == "" {
@@ -247,3 +266,70 @@ func ValidateFilePath(path string) error {
 
 	return nil
 }
+
+// RestartPolicies are the docker restart policies the installer accepts.
+var RestartPolicies = []string{"no", "on-failure", "unless-stopped", "always"}
+
+// ValidateRestartPolicy checks a container restart policy against
+// RestartPolicies.
+func ValidateRestartPolicy(policy string) error {
+	for _, allowed := range RestartPolicies {
+		if policy == allowed {
+			return nil
+		}
+	}
+	return errors.NewValidationError("restart_policy", policy, "restart policy must be one of "+strings.Join(RestartPolicies, ", "))
+}
+
+// MinMemoryLimit is the smallest memory limit docker accepts for a
+// container, 6MiB.
+const MinMemoryLimit = 6 << 20
+
+var memoryLimitRegex = regexp.MustCompile(`^([0-9]+)([bkmgBKMG]?)$`)
+
+// ParseMemoryLimit returns the bytes of a docker memory limit such as
+// "512m" or "2g"; a bare number is bytes.
+func ParseMemoryLimit(limit string) (int64, error) {
+	m := memoryLimitRegex.FindStringSubmatch(limit)
+	if m == nil {
+		return 0, errors.NewValidationError("memory_limit", limit, "memory limit must be a whole number with an optional b, k, m or g unit, e.g. 512m")
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, errors.NewValidationError("memory_limit", limit, "memory limit is too large")
+	}
+	shift := map[string]uint{"": 0, "b": 0, "k": 10, "m": 20, "g": 30}[strings.ToLower(m[2])]
+	if n > math.MaxInt64>>shift {
+		return 0, errors.NewValidationError("memory_limit", limit, "memory limit is too large")
+	}
+	return n << shift, nil
+}
+
+// ValidateMemoryLimit checks a docker memory limit is well formed and at
+// least MinMemoryLimit.
+func ValidateMemoryLimit(limit string) error {
+	bytes, err := ParseMemoryLimit(limit)
+	if err != nil {
+		return err
+	}
+	if bytes < MinMemoryLimit {
+		return errors.NewValidationError("memory_limit", limit, "memory limit must be at least 6m")
+	}
+	return nil
+}
+
+// ValidateCPULimit checks a docker --cpus value: a positive number of CPUs,
+// possibly fractional, no more than the host has.
+func ValidateCPULimit(limit string) error {
+	cpus, err := strconv.ParseFloat(limit, 64)
+	if err != nil || math.IsNaN(cpus) || math.IsInf(cpus, 0) {
+		return errors.NewValidationError("cpu_limit", limit, "CPU limit must be a number, e.g. 1.5")
+	}
+	if cpus <= 0 {
+		return errors.NewValidationError("cpu_limit", limit, "CPU limit must be positive")
+	}
+	if max := runtime.NumCPU(); cpus > float64(max) {
+		return errors.NewValidationError("cpu_limit", limit, fmt.Sprintf("CPU limit cannot exceed the %d CPUs of this host", max))
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package validation
 
 import (
 	"errors"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 
 	customerrors "fusionaly-installer/internal/errors"
@@ -280,6 +283,35 @@ func TestValidateVersion(t *testing.T) {
 	}
 }
 
+func TestValidateImageTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr bool
+	}{
+		{"semantic version", "1.2.3", false},
+		{"v prefix", "v1.2.3", false},
+		{"prerelease", "1.2.3-rc.1", false},
+		{"latest", "latest", false},
+		{"empty", "", true},
+		{"contains colon", "1.2:3", true},
+		{"contains slash", "beta/1.2.3", true},
+		{"contains space", "1.2 3", true},
+		{"leading hyphen", "-1.2.3", true},
+		{"leading period", ".1.2.3", true},
+		{"too long", strings.Repeat("a", 129), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateImageTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateImageTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateFilePath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -413,4 +445,47 @@ func TestPasswordValidationFlow(t *testing.T) {
 			t.Error("Expected empty password to be rejected as required")
 		}
 	})
-}
\ No newline at end of file
+}
+func TestValidateRestartPolicy(t *testing.T) {
+	for _, policy := range RestartPolicies {
+		if err := ValidateRestartPolicy(policy); err != nil {
+			t.Errorf("ValidateRestartPolicy(%q) error = %v", policy, err)
+		}
+	}
+	for _, policy := range []string{"", "sometimes", "Always", "on-failure:3"} {
+		if err := ValidateRestartPolicy(policy); err == nil {
+			t.Errorf("ValidateRestartPolicy(%q) should fail", policy)
+		}
+	}
+}
+
+func TestValidateMemoryLimit(t *testing.T) {
+	for limit, want := range map[string]int64{"512m": 512 << 20, "2g": 2 << 30, "2G": 2 << 30, "10000k": 10000 << 10, "8388608": 8 << 20} {
+		got, err := ParseMemoryLimit(limit)
+		if err != nil || got != want {
+			t.Errorf("ParseMemoryLimit(%q) = %d, %v; want %d", limit, got, err, want)
+		}
+		if err := ValidateMemoryLimit(limit); err != nil {
+			t.Errorf("ValidateMemoryLimit(%q) error = %v", limit, err)
+		}
+	}
+	for _, limit := range []string{"", "0", "5m", "-1g", "1.5g", "512mb", "lots", "99999999999g"} {
+		if err := ValidateMemoryLimit(limit); err == nil {
+			t.Errorf("ValidateMemoryLimit(%q) should fail", limit)
+		}
+	}
+}
+
+func TestValidateCPULimit(t *testing.T) {
+	for _, limit := range []string{"0.5", "1", "0.01"} {
+		if err := ValidateCPULimit(limit); err != nil {
+			t.Errorf("ValidateCPULimit(%q) error = %v", limit, err)
+		}
+	}
+	tooMany := strconv.Itoa(runtime.NumCPU() + 1)
+	for _, limit := range []string{"", "0", "-1", "two", "NaN", "Inf", tooMany} {
+		if err := ValidateCPULimit(limit); err == nil {
+			t.Errorf("ValidateCPULimit(%q) should fail", limit)
+		}
+	}
+}